@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+)
+
+// API key token format: scitt_<key id>_<secret>
+const (
+	apiKeyPrefix     = "scitt_"
+	apiKeyIDBytes    = 8
+	apiKeySecretSize = 32
+	apiKeySaltSize   = 16
+)
+
+// Errors returned when an API key cannot be authenticated
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrAPIKeyRevoked = errors.New("API key has been revoked")
+	ErrAPIKeyExpired = errors.New("API key has expired")
+)
+
+// CreateAPIKeyOptions holds the properties of a new API key
+type CreateAPIKeyOptions struct {
+	Name           string
+	Scopes         []Scope
+	AllowedIssuers []string
+	ExpiresAt      *time.Time
+}
+
+// CreateAPIKey generates a new API key and stores its salted hash
+// The returned token is the only copy of the secret and must be given to the client
+func CreateAPIKey(db *sql.DB, opts CreateAPIKeyOptions) (string, *database.APIKey, error) {
+	if opts.Name == "" {
+		return "", nil, fmt.Errorf("api key name is required")
+	}
+	if len(opts.Scopes) == 0 {
+		return "", nil, fmt.Errorf("at least one scope is required")
+	}
+
+	keyID, err := randomHex(apiKeyIDBytes)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomHex(apiKeySecretSize)
+	if err != nil {
+		return "", nil, err
+	}
+	salt, err := randomHex(apiKeySaltSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	scopes := make([]string, len(opts.Scopes))
+	for i, scope := range opts.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	key := database.APIKey{
+		KeyID:          keyID,
+		Name:           opts.Name,
+		Salt:           salt,
+		KeyHash:        hashSecret(salt, secret),
+		Scopes:         scopes,
+		AllowedIssuers: opts.AllowedIssuers,
+		ExpiresAt:      opts.ExpiresAt,
+	}
+
+	if err := database.InsertAPIKey(db, key); err != nil {
+		return "", nil, err
+	}
+
+	return apiKeyPrefix + keyID + "_" + secret, &key, nil
+}
+
+// AuthenticateAPIKey verifies an API key token against the stored keys
+func AuthenticateAPIKey(db *sql.DB, token string) (*Principal, error) {
+	keyID, secret, ok := parseAPIKeyToken(token)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := database.GetAPIKey(db, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSecret(key.Salt, secret)), []byte(key.KeyHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	scopes := make([]Scope, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = Scope(scope)
+	}
+
+	return &Principal{
+		CredentialID:   "apikey:" + key.KeyID,
+		Name:           key.Name,
+		Scopes:         scopes,
+		AllowedIssuers: key.AllowedIssuers,
+	}, nil
+}
+
+// AuthenticateStaticAPIKey verifies the service-wide API key from the configuration
+// The static key is granted every scope
+func AuthenticateStaticAPIKey(expected, token string) (*Principal, bool) {
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(token)) != 1 {
+		return nil, false
+	}
+
+	return &Principal{
+		CredentialID: "config",
+		Name:         "service API key",
+		Scopes:       []Scope{ScopeAdmin},
+	}, true
+}
+
+// parseAPIKeyToken splits a scitt_<key id>_<secret> token
+func parseAPIKeyToken(token string) (string, string, bool) {
+	if !strings.HasPrefix(token, apiKeyPrefix) {
+		return "", "", false
+	}
+
+	keyID, secret, ok := strings.Cut(strings.TrimPrefix(token, apiKeyPrefix), "_")
+	if !ok || keyID == "" || secret == "" {
+		return "", "", false
+	}
+
+	return keyID, secret, true
+}
+
+// hashSecret computes the stored hash of an API key secret
+func hashSecret(salt, secret string) string {
+	digest := sha256.Sum256([]byte(salt + secret))
+	return hex.EncodeToString(digest[:])
+}
+
+// randomHex returns n cryptographically secure random bytes as hex
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
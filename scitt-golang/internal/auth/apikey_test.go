@@ -0,0 +1,146 @@
+package auth_test
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/auth"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+)
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	t.Run("authenticates a created key", func(t *testing.T) {
+		db := openTestDatabase(t)
+
+		token, key, err := auth.CreateAPIKey(db, auth.CreateAPIKeyOptions{
+			Name:           "Acme CI",
+			Scopes:         []auth.Scope{auth.ScopeRegister},
+			AllowedIssuers: []string{"https://acme.example"},
+		})
+		if err != nil {
+			t.Fatalf("failed to create api key: %v", err)
+		}
+
+		if !strings.HasPrefix(token, "scitt_"+key.KeyID+"_") {
+			t.Errorf("unexpected token format: %s", token)
+		}
+		if strings.Contains(key.KeyHash, strings.TrimPrefix(token, "scitt_"+key.KeyID+"_")) {
+			t.Error("secret must not be stored in plaintext")
+		}
+
+		principal, err := auth.AuthenticateAPIKey(db, token)
+		if err != nil {
+			t.Fatalf("failed to authenticate: %v", err)
+		}
+
+		if principal.CredentialID != "apikey:"+key.KeyID {
+			t.Errorf("unexpected credential ID: %s", principal.CredentialID)
+		}
+		if !principal.HasScope(auth.ScopeRegister) || principal.HasScope(auth.ScopeRead) {
+			t.Errorf("unexpected scopes: %v", principal.Scopes)
+		}
+		if !principal.AllowsIssuer("https://acme.example") || principal.AllowsIssuer("https://other.example") {
+			t.Error("unexpected issuer binding")
+		}
+	})
+
+	t.Run("rejects wrong secret and unknown keys", func(t *testing.T) {
+		db := openTestDatabase(t)
+
+		token, _, err := auth.CreateAPIKey(db, auth.CreateAPIKeyOptions{
+			Name:   "Acme CI",
+			Scopes: []auth.Scope{auth.ScopeRegister},
+		})
+		if err != nil {
+			t.Fatalf("failed to create api key: %v", err)
+		}
+
+		for _, candidate := range []string{token + "00", "scitt_unknown_secret", "not-a-key", ""} {
+			if _, err := auth.AuthenticateAPIKey(db, candidate); !errors.Is(err, auth.ErrInvalidAPIKey) {
+				t.Errorf("%q: expected ErrInvalidAPIKey, got %v", candidate, err)
+			}
+		}
+	})
+
+	t.Run("rejects revoked and expired keys", func(t *testing.T) {
+		db := openTestDatabase(t)
+
+		revokedToken, revokedKey, err := auth.CreateAPIKey(db, auth.CreateAPIKeyOptions{
+			Name:   "revoked",
+			Scopes: []auth.Scope{auth.ScopeRegister},
+		})
+		if err != nil {
+			t.Fatalf("failed to create api key: %v", err)
+		}
+		if err := database.RevokeAPIKey(db, revokedKey.KeyID); err != nil {
+			t.Fatalf("failed to revoke api key: %v", err)
+		}
+		if _, err := auth.AuthenticateAPIKey(db, revokedToken); !errors.Is(err, auth.ErrAPIKeyRevoked) {
+			t.Errorf("expected ErrAPIKeyRevoked, got %v", err)
+		}
+
+		expired := time.Now().Add(-time.Minute)
+		expiredToken, _, err := auth.CreateAPIKey(db, auth.CreateAPIKeyOptions{
+			Name:      "expired",
+			Scopes:    []auth.Scope{auth.ScopeRegister},
+			ExpiresAt: &expired,
+		})
+		if err != nil {
+			t.Fatalf("failed to create api key: %v", err)
+		}
+		if _, err := auth.AuthenticateAPIKey(db, expiredToken); !errors.Is(err, auth.ErrAPIKeyExpired) {
+			t.Errorf("expected ErrAPIKeyExpired, got %v", err)
+		}
+	})
+
+	t.Run("static key has every scope", func(t *testing.T) {
+		principal, ok := auth.AuthenticateStaticAPIKey("secret", "secret")
+		if !ok {
+			t.Fatal("expected static key to authenticate")
+		}
+		if !principal.HasScope(auth.ScopeRegister) || !principal.HasScope(auth.ScopeRead) {
+			t.Error("expected admin principal to have every scope")
+		}
+
+		if _, ok := auth.AuthenticateStaticAPIKey("secret", "wrong"); ok {
+			t.Error("expected wrong static key to be rejected")
+		}
+		if _, ok := auth.AuthenticateStaticAPIKey("", ""); ok {
+			t.Error("expected empty static key to be rejected")
+		}
+	})
+}
+
+func TestParseScope(t *testing.T) {
+	t.Run("parses known scopes", func(t *testing.T) {
+		for _, name := range []string{"register", "READ", " admin "} {
+			if _, err := auth.ParseScope(name); err != nil {
+				t.Errorf("%q: unexpected error: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("rejects unknown scopes", func(t *testing.T) {
+		if _, err := auth.ParseScope("write"); err == nil {
+			t.Error("expected error for unknown scope")
+		}
+	})
+}
+
+func openTestDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := database.OpenDatabase(database.DatabaseOptions{
+		Path: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.CloseDatabase(db) })
+
+	return db
+}
@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwksMinRefetchInterval is the minimum time between fetch attempts, including failed ones
+const jwksMinRefetchInterval = 10 * time.Second
+
+// jsonWebKey is a single JWK (RFC 7517) with RSA or EC public parameters
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS parses a JSON Web Key Set into public keys indexed by kid
+// Keys that are not signature keys or use unsupported types are skipped
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK %q: %w", jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no usable signature keys")
+	}
+
+	return keys, nil
+}
+
+// publicKey converts the JWK to a Go public key (nil for unsupported key types)
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		key := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return key, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// KeySet resolves JWT verification keys from a JWKS file or URL
+// Keys are cached and reloaded after the refresh interval or when an unknown kid is seen
+type KeySet struct {
+	file    string
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	// fetchMu serializes fetches, which run without holding mu
+	fetchMu sync.Mutex
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time // Last successful fetch
+	attemptedAt time.Time // Last fetch, successful or not
+}
+
+// NewKeySet creates a key set backed by a JWKS file or URL and loads it
+func NewKeySet(file, url string, refresh time.Duration) (*KeySet, error) {
+	if refresh <= 0 {
+		refresh = time.Hour
+	}
+
+	ks := &KeySet{
+		file:    file,
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if err := ks.reload(); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Key returns the verification key for kid
+// An empty kid selects the only key of a single-key set
+// One request refreshes a stale set while the others keep using the cached keys;
+// an unknown kid waits for any refetch in flight, and a failed fetch keeps the cached keys
+func (ks *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	key, found := ks.lookupLocked(kid)
+	stale, unknown := ks.reloadDueLocked(kid, found)
+	ks.mu.Unlock()
+
+	if unknown {
+		ks.fetchMu.Lock()
+		ks.reloadIfDue(kid)
+		ks.fetchMu.Unlock()
+	} else if stale && ks.fetchMu.TryLock() {
+		ks.reloadIfDue(kid)
+		ks.fetchMu.Unlock()
+	}
+
+	if stale || unknown {
+		ks.mu.Lock()
+		key, found = ks.lookupLocked(kid)
+		ks.mu.Unlock()
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	return key, nil
+}
+
+// lookupLocked returns the cached key for kid; the caller must hold ks.mu
+func (ks *KeySet) lookupLocked(kid string) (crypto.PublicKey, bool) {
+	if key, ok := ks.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// reloadDueLocked reports whether the set is due a refresh because it is stale,
+// or because kid is unknown; a failed fetch is not retried within
+// jwksMinRefetchInterval, so an unavailable source is not hit by every request
+// The caller must hold ks.mu
+func (ks *KeySet) reloadDueLocked(kid string, found bool) (stale, unknown bool) {
+	if time.Since(ks.attemptedAt) <= jwksMinRefetchInterval {
+		return false, false
+	}
+	stale = time.Since(ks.fetchedAt) > ks.refresh
+	unknown = !found && kid != ""
+	return stale, unknown
+}
+
+// reloadIfDue fetches the key set unless another request just did; the caller
+// must hold ks.fetchMu
+func (ks *KeySet) reloadIfDue(kid string) {
+	ks.mu.Lock()
+	_, found := ks.lookupLocked(kid)
+	stale, unknown := ks.reloadDueLocked(kid, found)
+	ks.mu.Unlock()
+
+	if stale || unknown {
+		ks.reload()
+	}
+}
+
+// reload fetches the key set from its source and swaps it in
+func (ks *KeySet) reload() error {
+	keys, err := ks.load()
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.attemptedAt = time.Now()
+	if err != nil {
+		return err
+	}
+	ks.keys = keys
+	ks.fetchedAt = ks.attemptedAt
+	return nil
+}
+
+// load fetches and parses the key set
+func (ks *KeySet) load() (map[string]crypto.PublicKey, error) {
+	data, err := ks.fetch()
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// fetch reads the raw JWKS document
+func (ks *KeySet) fetch() ([]byte, error) {
+	if ks.file != "" {
+		data, err := os.ReadFile(ks.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		return data, nil
+	}
+
+	resp, err := ks.client.Get(ks.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS response: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+)
+
+// jwtClockSkew is the leeway applied to exp and nbf checks
+const jwtClockSkew = 60 * time.Second
+
+// ErrInvalidToken is returned for any JWT that fails validation
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// OIDCVerifier validates JWT bearer tokens issued by an OpenID Connect provider
+type OIDCVerifier struct {
+	issuer   string
+	audience string
+	keys     *KeySet
+	scopes   []Scope
+	mapping  config.ClaimMappingConfig
+	now      func() time.Time
+}
+
+// NewOIDCVerifier creates a verifier from configuration and loads its JWKS
+func NewOIDCVerifier(cfg *config.OIDCConfig) (*OIDCVerifier, error) {
+	keys, err := NewKeySet(cfg.JWKSFile, cfg.JWKSURL, cfg.JWKSRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []Scope{ScopeRegister}
+	if len(cfg.Scopes) > 0 {
+		scopes = scopes[:0]
+		for _, name := range cfg.Scopes {
+			scope, err := ParseScope(name)
+			if err != nil {
+				return nil, err
+			}
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &OIDCVerifier{
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		keys:     keys,
+		scopes:   scopes,
+		mapping:  cfg.ClaimMapping,
+		now:      time.Now,
+	}, nil
+}
+
+// LooksLikeJWT reports whether a bearer token has the compact JWS shape
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify validates a JWT and returns the principal it authenticates
+func (v *OIDCVerifier) Verify(token string) (*Principal, error) {
+	claims, err := v.verifyToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+
+	principal := &Principal{
+		CredentialID: "oidc:" + sub,
+		Name:         sub,
+		Scopes:       v.scopes,
+	}
+
+	if v.mapping.StatementIssuer != "" {
+		principal.IssuerPattern, err = compileClaimPattern(v.mapping.StatementIssuer, claims)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+	}
+	if v.mapping.StatementSubject != "" {
+		principal.SubjectPattern, err = compileClaimPattern(v.mapping.StatementSubject, claims)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+	}
+
+	return principal, nil
+}
+
+// verifyToken checks the signature and registered claims of a compact JWS
+func (v *OIDCVerifier) verifyToken(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed header")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("malformed header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature")
+	}
+
+	key, err := v.keys.Key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed claims")
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(claimsJSON)))
+	decoder.UseNumber()
+	var claims map[string]interface{}
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("malformed claims")
+	}
+
+	if iss, _ := claims["iss"].(string); iss != v.issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if !audienceContains(claims["aud"], v.audience) {
+		return nil, fmt.Errorf("token not issued for audience %q", v.audience)
+	}
+
+	now := v.now()
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return nil, fmt.Errorf("missing exp claim")
+	}
+	if now.After(exp.Add(jwtClockSkew)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(jwtClockSkew).Before(nbf) {
+		return nil, fmt.Errorf("token not yet valid")
+	}
+
+	return claims, nil
+}
+
+// verifySignature verifies a JWS signature for the supported asymmetric algorithms
+func verifySignature(alg string, key crypto.PublicKey, signingInput, signature []byte) error {
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key type does not match alg %s", alg)
+		}
+		digest := sha256.Sum256(signingInput)
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("signature verification failed")
+		}
+		return nil
+
+	case "ES256", "ES384":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key type does not match alg %s", alg)
+		}
+		var digest []byte
+		if alg == "ES256" {
+			sum := sha256.Sum256(signingInput)
+			digest = sum[:]
+		} else {
+			sum := sha512.Sum384(signingInput)
+			digest = sum[:]
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return fmt.Errorf("signature verification failed")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported alg %q", alg)
+	}
+}
+
+// audienceContains checks the aud claim, which may be a string or an array
+func audienceContains(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// numericDate converts a JWT NumericDate claim to a time
+func numericDate(value interface{}) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// claimPlaceholder matches {claim} references in claim mapping patterns
+var claimPlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_.:-]+)\}`)
+
+// compileClaimPattern expands {claim} references with token claims and turns * into
+// a wildcard. Claim values are matched literally, so they cannot inject wildcards.
+func compileClaimPattern(pattern string, claims map[string]interface{}) (*regexp.Regexp, error) {
+	segments := strings.Split(pattern, "*")
+	for i, segment := range segments {
+		var expr strings.Builder
+		last := 0
+		for _, match := range claimPlaceholder.FindAllStringSubmatchIndex(segment, -1) {
+			expr.WriteString(regexp.QuoteMeta(segment[last:match[0]]))
+
+			name := segment[match[2]:match[3]]
+			value, ok := claims[name].(string)
+			if !ok || value == "" {
+				return nil, fmt.Errorf("claim %q required by claim mapping is missing", name)
+			}
+			expr.WriteString(regexp.QuoteMeta(value))
+			last = match[1]
+		}
+		expr.WriteString(regexp.QuoteMeta(segment[last:]))
+		segments[i] = expr.String()
+	}
+
+	return regexp.Compile("^" + strings.Join(segments, ".*") + "$")
+}
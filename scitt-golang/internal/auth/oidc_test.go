@@ -0,0 +1,331 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/auth"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+)
+
+const (
+	testOIDCIssuer   = "https://idp.example.com"
+	testOIDCAudience = "scitt"
+)
+
+func TestOIDCVerifier(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	jwks := testJWKS(map[string]crypto.PublicKey{"ec-1": &ecKey.PublicKey, "rsa-1": &rsaKey.PublicKey})
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks, 0644); err != nil {
+		t.Fatalf("failed to write JWKS: %v", err)
+	}
+
+	newVerifier := func(t *testing.T, mapping config.ClaimMappingConfig) *auth.OIDCVerifier {
+		t.Helper()
+		verifier, err := auth.NewOIDCVerifier(&config.OIDCConfig{
+			Issuer:       testOIDCIssuer,
+			Audience:     testOIDCAudience,
+			JWKSFile:     jwksFile,
+			ClaimMapping: mapping,
+		})
+		if err != nil {
+			t.Fatalf("failed to create verifier: %v", err)
+		}
+		return verifier
+	}
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss": testOIDCIssuer,
+			"aud": testOIDCAudience,
+			"sub": "supplier-ci",
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"org": "acme",
+		}
+	}
+
+	t.Run("accepts ES256 and RS256 tokens", func(t *testing.T) {
+		verifier := newVerifier(t, config.ClaimMappingConfig{})
+
+		for _, token := range []string{
+			signTestJWT(t, "ES256", "ec-1", ecKey, validClaims()),
+			signTestJWT(t, "RS256", "rsa-1", rsaKey, validClaims()),
+		} {
+			principal, err := verifier.Verify(token)
+			if err != nil {
+				t.Fatalf("failed to verify token: %v", err)
+			}
+			if principal.CredentialID != "oidc:supplier-ci" {
+				t.Errorf("unexpected credential ID: %s", principal.CredentialID)
+			}
+			if !principal.HasScope(auth.ScopeRegister) || principal.HasScope(auth.ScopeAdmin) {
+				t.Errorf("unexpected scopes: %v", principal.Scopes)
+			}
+		}
+	})
+
+	t.Run("accepts audience arrays", func(t *testing.T) {
+		verifier := newVerifier(t, config.ClaimMappingConfig{})
+		claims := validClaims()
+		claims["aud"] = []string{"other", testOIDCAudience}
+
+		if _, err := verifier.Verify(signTestJWT(t, "ES256", "ec-1", ecKey, claims)); err != nil {
+			t.Errorf("failed to verify token: %v", err)
+		}
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		verifier := newVerifier(t, config.ClaimMappingConfig{})
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate EC key: %v", err)
+		}
+
+		wrongAudience := validClaims()
+		wrongAudience["aud"] = "other"
+		wrongIssuer := validClaims()
+		wrongIssuer["iss"] = "https://evil.example.com"
+		expired := validClaims()
+		expired["exp"] = time.Now().Add(-time.Hour).Unix()
+		notYetValid := validClaims()
+		notYetValid["nbf"] = time.Now().Add(time.Hour).Unix()
+		noExpiry := validClaims()
+		delete(noExpiry, "exp")
+
+		cases := map[string]string{
+			"wrong audience": signTestJWT(t, "ES256", "ec-1", ecKey, wrongAudience),
+			"wrong issuer":   signTestJWT(t, "ES256", "ec-1", ecKey, wrongIssuer),
+			"expired":        signTestJWT(t, "ES256", "ec-1", ecKey, expired),
+			"not yet valid":  signTestJWT(t, "ES256", "ec-1", ecKey, notYetValid),
+			"missing exp":    signTestJWT(t, "ES256", "ec-1", ecKey, noExpiry),
+			"bad signature":  signTestJWT(t, "ES256", "ec-1", otherKey, validClaims()),
+			"unknown kid":    signTestJWT(t, "ES256", "ec-9", ecKey, validClaims()),
+			"alg confusion":  signTestJWT(t, "RS256", "ec-1", rsaKey, validClaims()),
+			"unsigned":       unsignedTestJWT(t, validClaims()),
+			"malformed":      "not.a.jwt",
+		}
+
+		for name, token := range cases {
+			if _, err := verifier.Verify(token); !errors.Is(err, auth.ErrInvalidToken) {
+				t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("maps claims to statement issuer and subject", func(t *testing.T) {
+		verifier := newVerifier(t, config.ClaimMappingConfig{
+			StatementIssuer:  "https://{org}.example.com",
+			StatementSubject: "pkg:{org}/*",
+		})
+
+		principal, err := verifier.Verify(signTestJWT(t, "ES256", "ec-1", ecKey, validClaims()))
+		if err != nil {
+			t.Fatalf("failed to verify token: %v", err)
+		}
+
+		if !principal.AllowsIssuer("https://acme.example.com") || principal.AllowsIssuer("https://other.example.com") {
+			t.Error("unexpected issuer mapping")
+		}
+		if !principal.AllowsSubject("pkg:acme/widget@1.0") || principal.AllowsSubject("pkg:other/widget") {
+			t.Error("unexpected subject mapping")
+		}
+	})
+
+	t.Run("treats claim values literally", func(t *testing.T) {
+		verifier := newVerifier(t, config.ClaimMappingConfig{StatementSubject: "pkg:{org}/*"})
+		claims := validClaims()
+		claims["org"] = ".*"
+
+		principal, err := verifier.Verify(signTestJWT(t, "ES256", "ec-1", ecKey, claims))
+		if err != nil {
+			t.Fatalf("failed to verify token: %v", err)
+		}
+		if principal.AllowsSubject("pkg:acme/widget") {
+			t.Error("claim value must not act as a wildcard")
+		}
+	})
+
+	t.Run("rejects tokens missing mapped claims", func(t *testing.T) {
+		verifier := newVerifier(t, config.ClaimMappingConfig{StatementIssuer: "https://{org}.example.com"})
+		claims := validClaims()
+		delete(claims, "org")
+
+		if _, err := verifier.Verify(signTestJWT(t, "ES256", "ec-1", ecKey, claims)); !errors.Is(err, auth.ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("fetches JWKS from URL", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(jwks)
+		}))
+		defer stub.Close()
+
+		verifier, err := auth.NewOIDCVerifier(&config.OIDCConfig{
+			Issuer:   testOIDCIssuer,
+			Audience: testOIDCAudience,
+			JWKSURL:  stub.URL,
+		})
+		if err != nil {
+			t.Fatalf("failed to create verifier: %v", err)
+		}
+
+		if _, err := verifier.Verify(signTestJWT(t, "RS256", "rsa-1", rsaKey, validClaims())); err != nil {
+			t.Errorf("failed to verify token: %v", err)
+		}
+	})
+}
+
+func TestKeySet(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	jwks := testJWKS(map[string]crypto.PublicKey{"ec-1": &ecKey.PublicKey})
+
+	t.Run("backs off while the JWKS URL is failing", func(t *testing.T) {
+		var hits atomic.Int32
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hits.Add(1) > 1 {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(jwks)
+		}))
+		defer stub.Close()
+
+		ks, err := auth.NewKeySet("", stub.URL, time.Nanosecond)
+		if err != nil {
+			t.Fatalf("failed to create key set: %v", err)
+		}
+
+		for i := 0; i < 5; i++ {
+			if _, err := ks.Key("ec-1"); err != nil {
+				t.Errorf("should serve the cached key: %v", err)
+			}
+			if _, err := ks.Key("unknown"); err == nil {
+				t.Error("should reject an unknown kid")
+			}
+		}
+		if got := hits.Load(); got != 1 {
+			t.Errorf("JWKS URL fetched %d times, want 1", got)
+		}
+	})
+}
+
+func TestParseJWKS(t *testing.T) {
+	t.Run("skips encryption keys", func(t *testing.T) {
+		data := []byte(`{"keys":[{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"}]}`)
+		if _, err := auth.ParseJWKS(data); err == nil {
+			t.Error("should reject a JWKS without signature keys")
+		}
+	})
+
+	t.Run("rejects points off the curve", func(t *testing.T) {
+		data := []byte(`{"keys":[{"kty":"EC","kid":"bad","crv":"P-256","x":"AQ","y":"AQ"}]}`)
+		if _, err := auth.ParseJWKS(data); err == nil {
+			t.Error("should reject an invalid EC point")
+		}
+	})
+}
+
+// testJWKS encodes public keys as a JWKS document
+func testJWKS(keys map[string]crypto.PublicKey) []byte {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "EC", "kid": kid, "use": "sig", "crv": "P-256",
+				"x": encode(k.X.FillBytes(make([]byte, 32))),
+				"y": encode(k.Y.FillBytes(make([]byte, 32))),
+			})
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": encode(k.N.Bytes()),
+				"e": encode(big.NewInt(int64(k.E)).Bytes()),
+			})
+		}
+	}
+
+	data, _ := json.Marshal(set)
+	return data
+}
+
+// signTestJWT creates a compact JWS signed with key
+func signTestJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+
+	signingInput := encodeTestJWTParts(t, map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// unsignedTestJWT creates an alg "none" token
+func unsignedTestJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	return encodeTestJWTParts(t, map[string]string{"alg": "none"}, claims) + "."
+}
+
+func encodeTestJWTParts(t *testing.T, header map[string]string, claims map[string]interface{}) string {
+	t.Helper()
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		t.Fatalf("failed to encode header: %v", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("failed to encode claims: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+}
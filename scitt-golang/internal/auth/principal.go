@@ -0,0 +1,80 @@
+// Package auth authenticates clients of the transparency service
+package auth
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Scope is a permission granted to a credential
+type Scope string
+
+const (
+	// ScopeRegister allows registering signed statements
+	ScopeRegister Scope = "register"
+
+	// ScopeRead allows reading receipts and entries when read authentication is required
+	ScopeRead Scope = "read"
+
+	// ScopeAdmin grants every scope
+	ScopeAdmin Scope = "admin"
+)
+
+// ParseScope parses a scope name
+func ParseScope(name string) (Scope, error) {
+	switch scope := Scope(strings.ToLower(strings.TrimSpace(name))); scope {
+	case ScopeRegister, ScopeRead, ScopeAdmin:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("unknown scope: %s (expected register, read or admin)", name)
+	}
+}
+
+// Principal is an authenticated client
+type Principal struct {
+	// CredentialID identifies the credential and is recorded with each registered entry
+	CredentialID string
+	Name         string
+	Scopes       []Scope
+
+	// AllowedIssuers restricts the statement issuers (iss) this client may register
+	// Empty allows any issuer
+	AllowedIssuers []string
+
+	// IssuerPattern and SubjectPattern further restrict statement iss and sub
+	// (derived from OIDC claim mapping; nil allows any value)
+	IssuerPattern  *regexp.Regexp
+	SubjectPattern *regexp.Regexp
+}
+
+// HasScope reports whether the principal was granted scope (admin grants every scope)
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIssuer reports whether the principal may register statements from iss
+func (p *Principal) AllowsIssuer(iss string) bool {
+	if p.IssuerPattern != nil && !p.IssuerPattern.MatchString(iss) {
+		return false
+	}
+	if len(p.AllowedIssuers) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedIssuers {
+		if allowed == iss {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsSubject reports whether the principal may register statements about sub
+func (p *Principal) AllowsSubject(sub string) bool {
+	return p.SubjectPattern == nil || p.SubjectPattern.MatchString(sub)
+}
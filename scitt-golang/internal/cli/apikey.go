@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/auth"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+)
+
+// NewServiceAPIKeyCommand creates the service apikey command
+func NewServiceAPIKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apikey",
+		Short: "Manage client API keys",
+		Long: `Manage per-client API keys stored (hashed) in the service database.
+
+Each key has scopes (register, read, admin), an optional list of statement
+issuers it may register for, and an optional expiry. The service-wide
+api_key in the definition file keeps working and has every scope.
+
+Subcommands:
+  create - Create a new API key
+  list   - List API keys
+  revoke - Revoke an API key`,
+	}
+
+	cmd.AddCommand(NewServiceAPIKeyCreateCommand())
+	cmd.AddCommand(NewServiceAPIKeyListCommand())
+	cmd.AddCommand(NewServiceAPIKeyRevokeCommand())
+
+	return cmd
+}
+
+type serviceAPIKeyCreateOptions struct {
+	definition string
+	log        string
+	name       string
+	scopes     []string
+	issuers    []string
+	expiresIn  time.Duration
+}
+
+// NewServiceAPIKeyCreateCommand creates the service apikey create command
+func NewServiceAPIKeyCreateCommand() *cobra.Command {
+	opts := &serviceAPIKeyCreateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a new API key",
+		Long: `Create a new client API key.
+
+The key is printed once and cannot be recovered; only a salted hash is stored.
+
+Example:
+  scitt service apikey create \
+    --definition ./demo/scitt.yaml \
+    --name "Acme CI" \
+    --scope register \
+    --issuer https://acme.example \
+    --expires-in 2160h`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServiceAPIKeyCreate(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.definition, "definition", "", "path to service definition file (YAML)")
+	cmd.Flags().StringVar(&opts.log, "log", "", "name of the log to use from a multi-log definition")
+	cmd.Flags().StringVar(&opts.name, "name", "", "name of the client (required)")
+	cmd.Flags().StringSliceVar(&opts.scopes, "scope", []string{string(auth.ScopeRegister)}, "scopes to grant (register, read, admin)")
+	cmd.Flags().StringSliceVar(&opts.issuers, "issuer", nil, "statement issuers (iss) the key may register for (default any)")
+	cmd.Flags().DurationVar(&opts.expiresIn, "expires-in", 0, "expire the key after this duration (default never)")
+
+	cmd.MarkFlagRequired("definition")
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func runServiceAPIKeyCreate(opts *serviceAPIKeyCreateOptions) error {
+	scopes := make([]auth.Scope, 0, len(opts.scopes))
+	for _, name := range opts.scopes {
+		scope, err := auth.ParseScope(name)
+		if err != nil {
+			return err
+		}
+		scopes = append(scopes, scope)
+	}
+
+	var expiresAt *time.Time
+	if opts.expiresIn > 0 {
+		expiry := time.Now().Add(opts.expiresIn).UTC()
+		expiresAt = &expiry
+	}
+
+	db, err := openServiceDatabase(opts.definition, opts.log)
+	if err != nil {
+		return err
+	}
+	defer database.CloseDatabase(db)
+
+	token, key, err := auth.CreateAPIKey(db, auth.CreateAPIKeyOptions{
+		Name:           opts.name,
+		Scopes:         scopes,
+		AllowedIssuers: opts.issuers,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	fmt.Printf("✓ API key created\n")
+	fmt.Printf("  Key ID:   %s\n", key.KeyID)
+	fmt.Printf("  Name:     %s\n", key.Name)
+	fmt.Printf("  Scopes:   %s\n", strings.Join(key.Scopes, ", "))
+	if len(key.AllowedIssuers) > 0 {
+		fmt.Printf("  Issuers:  %s\n", strings.Join(key.AllowedIssuers, ", "))
+	}
+	if key.ExpiresAt != nil {
+		fmt.Printf("  Expires:  %s\n", key.ExpiresAt.Format(time.RFC3339))
+	}
+	fmt.Printf("  API Key:  %s\n", token)
+	fmt.Printf("\nStore the API key securely; it cannot be shown again.\n")
+
+	return nil
+}
+
+type serviceAPIKeyListOptions struct {
+	definition string
+	log        string
+}
+
+// NewServiceAPIKeyListCommand creates the service apikey list command
+func NewServiceAPIKeyListCommand() *cobra.Command {
+	opts := &serviceAPIKeyListOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List API keys",
+		Long: `List client API keys with their scopes and status.
+
+Example:
+  scitt service apikey list --definition ./demo/scitt.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServiceAPIKeyList(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.definition, "definition", "", "path to service definition file (YAML)")
+	cmd.Flags().StringVar(&opts.log, "log", "", "name of the log to use from a multi-log definition")
+
+	cmd.MarkFlagRequired("definition")
+
+	return cmd
+}
+
+func runServiceAPIKeyList(opts *serviceAPIKeyListOptions) error {
+	db, err := openServiceDatabase(opts.definition, opts.log)
+	if err != nil {
+		return err
+	}
+	defer database.CloseDatabase(db)
+
+	keys, err := database.ListAPIKeys(db)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		fmt.Println("No API keys")
+		return nil
+	}
+
+	fmt.Printf("%-16s  %-8s  %-20s  %-24s  %s\n", "KEY ID", "STATUS", "SCOPES", "NAME", "ISSUERS")
+	for _, key := range keys {
+		issuers := "*"
+		if len(key.AllowedIssuers) > 0 {
+			issuers = strings.Join(key.AllowedIssuers, ",")
+		}
+		fmt.Printf("%-16s  %-8s  %-20s  %-24s  %s\n", key.KeyID, apiKeyStatus(key), strings.Join(key.Scopes, ","), key.Name, issuers)
+	}
+
+	return nil
+}
+
+type serviceAPIKeyRevokeOptions struct {
+	definition string
+	log        string
+	keyID      string
+}
+
+// NewServiceAPIKeyRevokeCommand creates the service apikey revoke command
+func NewServiceAPIKeyRevokeCommand() *cobra.Command {
+	opts := &serviceAPIKeyRevokeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke an API key",
+		Long: `Revoke a client API key. Revoked keys are rejected immediately.
+
+Example:
+  scitt service apikey revoke --definition ./demo/scitt.yaml --key-id 3f9a1c0d2b7e4a65`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServiceAPIKeyRevoke(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.definition, "definition", "", "path to service definition file (YAML)")
+	cmd.Flags().StringVar(&opts.log, "log", "", "name of the log to use from a multi-log definition")
+	cmd.Flags().StringVar(&opts.keyID, "key-id", "", "ID of the key to revoke (required)")
+
+	cmd.MarkFlagRequired("definition")
+	cmd.MarkFlagRequired("key-id")
+
+	return cmd
+}
+
+func runServiceAPIKeyRevoke(opts *serviceAPIKeyRevokeOptions) error {
+	db, err := openServiceDatabase(opts.definition, opts.log)
+	if err != nil {
+		return err
+	}
+	defer database.CloseDatabase(db)
+
+	if err := database.RevokeAPIKey(db, opts.keyID); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ API key revoked\n")
+	fmt.Printf("  Key ID:   %s\n", opts.keyID)
+
+	return nil
+}
+
+// openServiceDatabase opens the metadata database of a service definition
+func openServiceDatabase(definition, log string) (*sql.DB, error) {
+	cfg, err := loadLogDefinition(definition, log)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.OpenDatabase(database.DatabaseOptions{
+		Path:      cfg.Database.Path,
+		EnableWAL: cfg.Database.EnableWAL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return db, nil
+}
+
+// apiKeyStatus describes whether a key is usable
+func apiKeyStatus(key database.APIKey) string {
+	switch {
+	case key.RevokedAt != nil:
+		return "revoked"
+	case key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt):
+		return "expired"
+	default:
+		return "active"
+	}
+}
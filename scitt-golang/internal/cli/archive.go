@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/service"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+)
+
+type serviceExportOptions struct {
+	definition string
+	log        string
+	output     string
+}
+
+// NewServiceExportCommand creates the service export command
+func NewServiceExportCommand() *cobra.Command {
+	opts := &serviceExportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the log as a portable archive",
+		Long: `Export a stopped transparency service as a single tar archive.
+
+The archive holds a manifest with the log's latest signed checkpoint and root
+hash, the service's public key set, the hash tiles up to that checkpoint, the
+statement committed to by every leaf and every recorded checkpoint. A checkpoint
+is signed for the current tree size if none has been published yet.
+
+Example:
+  scitt service export --definition ./demo/scitt.yaml --output ./backup/log.tar`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServiceExport(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.definition, "definition", "", "path to service definition file (YAML)")
+	cmd.Flags().StringVar(&opts.log, "log", "", "name of the log to use from a multi-log definition")
+	cmd.Flags().StringVar(&opts.output, "output", "", "path to write the archive to")
+
+	cmd.MarkFlagRequired("definition")
+	cmd.MarkFlagRequired("output")
+
+	return cmd
+}
+
+func runServiceExport(opts *serviceExportOptions) error {
+	cfg, err := loadLogDefinition(opts.definition, opts.log)
+	if err != nil {
+		return err
+	}
+
+	svc, err := service.NewTransparencyService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open service: %w", err)
+	}
+	defer svc.Close()
+
+	file, err := os.Create(opts.output)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer file.Close()
+
+	manifest, err := svc.ExportArchive(context.Background(), file)
+	if err != nil {
+		os.Remove(opts.output)
+		return fmt.Errorf("export failed: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	fmt.Printf("✓ Log exported\n")
+	fmt.Printf("  Archive:     %s\n", opts.output)
+	fmt.Printf("  Tree size:   %d\n", manifest.TreeSize)
+	fmt.Printf("  Root hash:   %s\n", manifest.RootHash)
+	fmt.Printf("  Statements:  %d\n", manifest.Statements)
+	fmt.Printf("  Checkpoints: %d\n", manifest.Checkpoints)
+
+	return nil
+}
+
+type serviceImportOptions struct {
+	definition string
+	log        string
+	input      string
+}
+
+// NewServiceImportCommand creates the service import command
+func NewServiceImportCommand() *cobra.Command {
+	opts := &serviceImportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Restore the log from a portable archive",
+		Long: `Restore an archive written by 'scitt service export' into the empty storage and
+database of a service definition that uses the same keys.
+
+The root hash is recomputed from the archived hash tiles and must match the
+manifest's checkpoint, verified with keys.public, as must every archived
+checkpoint. Only then is the statements table rebuilt, the entry bundles written
+and the checkpoints recorded; a rejected archive is removed from storage again.
+
+Example:
+  scitt service import --definition ./restore/scitt.yaml --input ./backup/log.tar`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServiceImport(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.definition, "definition", "", "path to service definition file (YAML)")
+	cmd.Flags().StringVar(&opts.log, "log", "", "name of the log to use from a multi-log definition")
+	cmd.Flags().StringVar(&opts.input, "input", "", "path to the archive to restore")
+
+	cmd.MarkFlagRequired("definition")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
+
+func runServiceImport(opts *serviceImportOptions) error {
+	cfg, err := loadLogDefinition(opts.definition, opts.log)
+	if err != nil {
+		return err
+	}
+	if cfg.Mirror != nil {
+		return fmt.Errorf("archives cannot be imported into a mirror")
+	}
+	if cfg.Sharding != nil {
+		return fmt.Errorf("archives cannot be imported into a sharded log")
+	}
+
+	publicKey, err := loadLogPublicKey(cfg.Keys.Public)
+	if err != nil {
+		return err
+	}
+	store, err := service.OpenStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(opts.input)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	db, err := database.OpenDatabase(database.DatabaseOptions{
+		Path:      cfg.Database.Path,
+		EnableWAL: cfg.Database.EnableWAL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.CloseDatabase(db)
+
+	manifest, err := service.ImportArchive(db, store, publicKey, file)
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	fmt.Printf("✓ Log imported and verified\n")
+	fmt.Printf("  Issuer:      %s\n", manifest.Issuer)
+	fmt.Printf("  Tree size:   %d\n", manifest.TreeSize)
+	fmt.Printf("  Root hash:   %s\n", manifest.RootHash)
+	fmt.Printf("  Statements:  %d\n", manifest.Statements)
+	fmt.Printf("  Checkpoints: %d\n", manifest.Checkpoints)
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+)
+
+// NewArtifactCommand creates the artifact command
+func NewArtifactCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "artifact",
+		Short: "Find transparent statements about artifacts",
+		Long: `Find transparent statements about artifacts by their digest.
+
+Subcommands:
+  lookup  - List statements and receipts registered for an artifact`,
+	}
+
+	cmd.AddCommand(NewArtifactLookupCommand())
+
+	return cmd
+}
+
+type artifactLookupOptions struct {
+	service       string
+	file          string
+	digest        string
+	hashAlgorithm string
+	bundle        string
+}
+
+// artifactLookupResponse mirrors the JSON returned by GET /artifacts/{alg}/{digest}/statements
+type artifactLookupResponse struct {
+	HashAlgorithm string `json:"hash_algorithm"`
+	Digest        string `json:"digest"`
+	Entries       []struct {
+		EntryID       int64   `json:"entry_id"`
+		StatementHash string  `json:"statement_hash"`
+		Iss           string  `json:"iss"`
+		Sub           *string `json:"sub"`
+		Cty           *string `json:"cty"`
+		RegisteredAt  string  `json:"registered_at"`
+		Receipt       string  `json:"receipt"`
+	} `json:"entries"`
+}
+
+// NewArtifactLookupCommand creates the artifact lookup command
+func NewArtifactLookupCommand() *cobra.Command {
+	opts := &artifactLookupOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "lookup",
+		Short: "List statements registered for an artifact",
+		Long: `List every statement registered for an artifact, with its receipt.
+
+The artifact digest is computed from --file using streaming I/O, or given
+directly with --digest. Statements match when their payload hash (for hash
+envelope statements) or attached payload hash equals the artifact digest.
+
+With --bundle, the matching receipts are saved as a single CBOR bundle
+(an array of {entry_id, statement_hash, receipt} maps).
+
+Example:
+  scitt artifact lookup \
+    --service http://localhost:56177 \
+    --file ./demo/test.parquet \
+    --bundle ./demo/test.parquet.receipts.cbor`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runArtifactLookup(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.service, "service", "", "transparency service URL (required)")
+	cmd.Flags().StringVar(&opts.file, "file", "", "artifact file to hash")
+	cmd.Flags().StringVar(&opts.digest, "digest", "", "hex encoded artifact digest (instead of --file)")
+	cmd.Flags().StringVar(&opts.hashAlgorithm, "hash-algorithm", "sha-256", "hash algorithm (sha-256, sha-384, sha-512)")
+	cmd.Flags().StringVar(&opts.bundle, "bundle", "", "output CBOR bundle file")
+
+	cmd.MarkFlagRequired("service")
+	cmd.MarkFlagsMutuallyExclusive("file", "digest")
+	cmd.MarkFlagsOneRequired("file", "digest")
+
+	return cmd
+}
+
+func runArtifactLookup(opts *artifactLookupOptions) error {
+	hashAlg, err := cose.ParseHashAlgorithm(opts.hashAlgorithm)
+	if err != nil {
+		return err
+	}
+	algName := cose.HashAlgorithmName(hashAlg)
+
+	// Compute or decode artifact digest
+	var digest []byte
+	if opts.file != "" {
+		digest, err = cose.StreamHashFromFile(opts.file, hashAlg)
+		if err != nil {
+			return fmt.Errorf("failed to hash artifact: %w", err)
+		}
+	} else {
+		digest, err = hex.DecodeString(opts.digest)
+		if err != nil {
+			return fmt.Errorf("invalid digest: %w", err)
+		}
+	}
+	digestHex := hex.EncodeToString(digest)
+
+	if verbose {
+		fmt.Printf("Looking up artifact %s:%s...\n", algName, digestHex)
+	}
+
+	url := fmt.Sprintf("%s/artifacts/%s/%s/statements", opts.service, algName, digestHex)
+	if opts.bundle != "" {
+		url += "?format=bundle"
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printErrorResponse("Lookup", resp, body)
+		return fmt.Errorf("lookup failed with status %d: %s", resp.StatusCode, describeErrorResponse(resp, body))
+	}
+
+	if opts.bundle != "" {
+		if err := os.WriteFile(opts.bundle, body, 0644); err != nil {
+			return fmt.Errorf("failed to write bundle file: %w", err)
+		}
+
+		fmt.Printf("✓ Receipt bundle saved\n")
+		fmt.Printf("  Artifact:   %s:%s\n", algName, digestHex)
+		fmt.Printf("  Bundle:     %s (%d bytes)\n", opts.bundle, len(body))
+		return nil
+	}
+
+	var result artifactLookupResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	fmt.Printf("✓ Found %d statement(s)\n", len(result.Entries))
+	fmt.Printf("  Artifact:   %s:%s\n", algName, digestHex)
+	for _, entry := range result.Entries {
+		fmt.Printf("\n  Entry %d\n", entry.EntryID)
+		fmt.Printf("    Statement Hash: %s\n", entry.StatementHash)
+		fmt.Printf("    Issuer:         %s\n", entry.Iss)
+		if entry.Sub != nil {
+			fmt.Printf("    Subject:        %s\n", *entry.Sub)
+		}
+		if entry.Cty != nil {
+			fmt.Printf("    Content Type:   %s\n", *entry.Cty)
+		}
+		fmt.Printf("    Registered At:  %s\n", entry.RegisteredAt)
+		fmt.Printf("    Receipt:        /entries/%d\n", entry.EntryID)
+	}
+
+	return nil
+}
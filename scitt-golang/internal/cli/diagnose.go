@@ -7,7 +7,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/fxamacker/cbor/v2"
 	"github.com/spf13/cobra"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
 )
@@ -52,7 +51,7 @@ func runDiagnose(inputFile, outputFile string) error {
 
 	// Decode CBOR
 	var data interface{}
-	if err := cbor.Unmarshal(rawBytes, &data); err != nil {
+	if err := cose.Unmarshal(rawBytes, &data); err != nil {
 		return fmt.Errorf("failed to parse CBOR: %w", err)
 	}
 
@@ -127,22 +126,26 @@ func isCoseKeySet(data interface{}) bool {
 
 // isCoseKey checks if data is a COSE Key structure
 func isCoseKey(data interface{}) bool {
-	m, ok := data.(map[interface{}]interface{})
+	key, ok := coseLabels(data)
 	if !ok {
 		return false
 	}
 	// COSE Keys must have kty (label 1)
-	// Try both int64 and int
-	if _, hasKty := m[int64(1)]; hasKty {
-		return true
-	}
-	if _, hasKty := m[int(1)]; hasKty {
-		return true
+	_, hasKty := key.Get(1)
+	return hasKty
+}
+
+// coseLabels normalizes the labels of a decoded COSE map (a header map or COSE Key)
+func coseLabels(data interface{}) (cose.Headers, bool) {
+	m, ok := data.(map[interface{}]interface{})
+	if !ok {
+		return nil, false
 	}
-	if _, hasKty := m[uint64(1)]; hasKty {
-		return true
+	labels, err := cose.NewHeaders(m)
+	if err != nil {
+		return nil, false
 	}
-	return false
+	return labels, true
 }
 
 // isCoseSign1 checks if data is a COSE Sign1 structure
@@ -198,86 +201,43 @@ func generateCoseKeySetEDN(data interface{}) string {
 
 // generateCoseKeyEDN generates commented EDN for COSE Key
 func generateCoseKeyEDN(data interface{}) string {
-	m := data.(map[interface{}]interface{})
+	key, _ := coseLabels(data)
 	var buf bytes.Buffer
 
 	buf.WriteString("/ COSE Key /\n{\n")
 
-	// Helper to get integer value from map with flexible key types
-	getInt := func(label int) (int, bool) {
-		if v, ok := m[int64(label)]; ok {
-			if i, ok := v.(int64); ok {
-				return int(i), true
-			}
-			if i, ok := v.(uint64); ok {
-				return int(i), true
-			}
-		}
-		if v, ok := m[label]; ok {
-			if i, ok := v.(int64); ok {
-				return int(i), true
-			}
-			if i, ok := v.(uint64); ok {
-				return int(i), true
-			}
-		}
-		if v, ok := m[uint64(label)]; ok {
-			if i, ok := v.(int64); ok {
-				return int(i), true
-			}
-			if i, ok := v.(uint64); ok {
-				return int(i), true
-			}
-		}
-		return 0, false
-	}
-
-	// Helper to get bytes value from map
-	getBytes := func(label int) ([]byte, bool) {
-		if v, ok := m[int64(label)].([]byte); ok {
-			return v, true
-		}
-		if v, ok := m[label].([]byte); ok {
-			return v, true
-		}
-		if v, ok := m[uint64(label)].([]byte); ok {
-			return v, true
-		}
-		return nil, false
-	}
-
 	// kty (1)
-	if kty, ok := getInt(1); ok {
-		buf.WriteString(fmt.Sprintf("  1: %d, / kty: %s /\n", kty, getKeyTypeName(kty)))
+	if kty, ok := key.Int(1); ok {
+		buf.WriteString(fmt.Sprintf("  1: %d, / kty: %s /\n", kty, getKeyTypeName(int(kty))))
 	}
 
 	// kid (2)
-	if kid, ok := getBytes(2); ok {
+	if kid, ok := key.Bytes(2); ok {
 		buf.WriteString(fmt.Sprintf("  2: h'%s', / kid /\n", hex.EncodeToString(kid)))
 	}
 
 	// alg (3)
-	if alg, ok := getInt(3); ok {
-		buf.WriteString(fmt.Sprintf("  3: %d, / alg: %s /\n", alg, getAlgorithmName(alg)))
+	if alg, ok := key.Int(3); ok {
+		buf.WriteString(fmt.Sprintf("  3: %d, / alg: %s /\n", alg, getAlgorithmName(int(alg))))
 	}
 
 	// crv (-1)
-	if crv, ok := getInt(-1); ok {
-		buf.WriteString(fmt.Sprintf("  -1: %d, / crv: %s /\n", crv, getCurveName(crv)))
+	if crv, ok := key.Int(-1); ok {
+		buf.WriteString(fmt.Sprintf("  -1: %d, / crv: %s /\n", crv, getCurveName(int(crv))))
 	}
 
 	// x (-2)
-	if x, ok := getBytes(-2); ok {
+	if x, ok := key.Bytes(-2); ok {
 		buf.WriteString(fmt.Sprintf("  -2: h'%s', / x /\n", hex.EncodeToString(x)))
 	}
 
 	// y (-3)
-	if y, ok := getBytes(-3); ok {
+	if y, ok := key.Bytes(-3); ok {
 		buf.WriteString(fmt.Sprintf("  -3: h'%s', / y /\n", hex.EncodeToString(y)))
 	}
 
 	// d (-4) - private key
-	if d, ok := getBytes(-4); ok {
+	if d, ok := key.Bytes(-4); ok {
 		buf.WriteString(fmt.Sprintf("  -4: h'%s' / d (private key) /\n", hex.EncodeToString(d)))
 	}
 
@@ -292,15 +252,12 @@ func generateCoseSign1EDN(data interface{}) string {
 
 	// Extract components
 	protectedBytes, _ := arr[0].([]byte)
-	unprotected, _ := arr[1].(map[interface{}]interface{})
+	unprotected, _ := coseLabels(arr[1])
 	payload, _ := arr[2].([]byte) // Can be nil for detached
 	signature, _ := arr[3].([]byte)
 
 	// Decode protected header
-	var protected map[interface{}]interface{}
-	if len(protectedBytes) > 0 {
-		cbor.Unmarshal(protectedBytes, &protected)
-	}
+	protected, _ := cose.DecodeHeaders(protectedBytes)
 
 	buf.WriteString("/ COSE_Sign1 /\n18([\n")
 
@@ -320,9 +277,7 @@ func generateCoseSign1EDN(data interface{}) string {
 	if len(unprotected) > 0 {
 		buf.WriteString("{\n")
 		for label, value := range unprotected {
-			labelInt := toInt(label)
-			labelName := getHeaderLabelName(labelInt)
-			buf.WriteString(fmt.Sprintf("    %d: %s, / %s /\n", labelInt, formatEDNValue(value), labelName))
+			buf.WriteString(fmt.Sprintf("    %s: %s, / %s /\n", formatEDNValueCompact(label), formatEDNValue(value), headerLabelName(label)))
 		}
 		buf.WriteString("  }")
 	} else {
@@ -351,21 +306,19 @@ func generateCoseSign1EDN(data interface{}) string {
 }
 
 // formatHeaderMapComment formats a header map for inline comment
-func formatHeaderMapComment(m map[interface{}]interface{}) string {
+func formatHeaderMapComment(headers cose.Headers) string {
 	var buf bytes.Buffer
 	buf.WriteString("{ ")
 	first := true
-	for label, value := range m {
+	for label, value := range headers {
 		if !first {
 			buf.WriteString(", ")
 		}
 		first = false
-		labelInt := toInt(label)
-		labelName := getHeaderLabelName(labelInt)
-		buf.WriteString(fmt.Sprintf("%d: %s", labelInt, formatEDNValueCompact(value)))
-		if labelName != fmt.Sprintf("label_%d", labelInt) {
+		buf.WriteString(fmt.Sprintf("%s: %s", formatEDNValueCompact(label), formatEDNValueCompact(value)))
+		if labelInt, ok := label.(int64); ok && headerLabelName(label) != fmt.Sprintf("label_%d", labelInt) {
 			buf.WriteString(" /")
-			buf.WriteString(labelName)
+			buf.WriteString(headerLabelName(label))
 			buf.WriteString("/")
 		}
 	}
@@ -378,29 +331,28 @@ func formatEDNValueCompact(value interface{}) string {
 	switch v := value.(type) {
 	case []byte:
 		return fmt.Sprintf("h'%s'", hex.EncodeToString(v))
-	case int, int64:
-		val := toInt(v)
-		return fmt.Sprintf("%d", val)
-	case uint, uint64:
-		return fmt.Sprintf("%v", v)
+	case int, int64, uint, uint64:
+		return fmt.Sprintf("%d", v)
 	case string:
 		return fmt.Sprintf("\"%s\"", v)
 	case map[interface{}]interface{}:
 		// Nested map (e.g., CWT claims)
+		claims, ok := coseLabels(v)
+		if !ok {
+			return toExtendedDiagnostic(v, 0)
+		}
 		var nestedBuf bytes.Buffer
 		nestedBuf.WriteString("{ ")
 		nestedFirst := true
-		for k, val := range v {
+		for k, val := range claims {
 			if !nestedFirst {
 				nestedBuf.WriteString(", ")
 			}
 			nestedFirst = false
-			kInt := toInt(k)
-			kName := getCWTClaimName(kInt)
-			nestedBuf.WriteString(fmt.Sprintf("%d: %s", kInt, formatEDNValueCompact(val)))
-			if kName != fmt.Sprintf("claim_%d", kInt) {
+			nestedBuf.WriteString(fmt.Sprintf("%s: %s", formatEDNValueCompact(k), formatEDNValueCompact(val)))
+			if kInt, ok := k.(int64); ok && getCWTClaimName(int(kInt)) != fmt.Sprintf("claim_%d", kInt) {
 				nestedBuf.WriteString(" /")
-				nestedBuf.WriteString(kName)
+				nestedBuf.WriteString(getCWTClaimName(int(kInt)))
 				nestedBuf.WriteString("/")
 			}
 		}
@@ -445,7 +397,10 @@ func formatEDNValue(value interface{}) string {
 	case string:
 		return fmt.Sprintf("\"%s\"", v)
 	case map[interface{}]interface{}:
-		return formatHeaderMapComment(v)
+		if headers, ok := coseLabels(v); ok {
+			return formatHeaderMapComment(headers)
+		}
+		return toExtendedDiagnostic(v, 0)
 	case nil:
 		return "null"
 	default:
@@ -453,217 +408,6 @@ func formatEDNValue(value interface{}) string {
 	}
 }
 
-// toInt converts various integer types to int
-func toInt(v interface{}) int {
-	switch val := v.(type) {
-	case int:
-		return val
-	case int64:
-		return int(val)
-	case uint64:
-		return int(val)
-	default:
-		return 0
-	}
-}
-
-// prettyPrintCoseKey formats a COSE Key
-func prettyPrintCoseKey(data interface{}) string {
-	m := data.(map[interface{}]interface{})
-	var buf bytes.Buffer
-
-	buf.WriteString("### COSE Key\n\n")
-	buf.WriteString("```\n")
-
-	// Helper to get integer value from map with flexible key types
-	getInt := func(label int) (int, bool) {
-		if v, ok := m[int64(label)]; ok {
-			if i, ok := v.(int64); ok {
-				return int(i), true
-			}
-			if i, ok := v.(uint64); ok {
-				return int(i), true
-			}
-		}
-		if v, ok := m[label]; ok {
-			if i, ok := v.(int64); ok {
-				return int(i), true
-			}
-			if i, ok := v.(uint64); ok {
-				return int(i), true
-			}
-		}
-		if v, ok := m[uint64(label)]; ok {
-			if i, ok := v.(int64); ok {
-				return int(i), true
-			}
-			if i, ok := v.(uint64); ok {
-				return int(i), true
-			}
-		}
-		return 0, false
-	}
-
-	// Helper to get bytes value from map
-	getBytes := func(label int) ([]byte, bool) {
-		if v, ok := m[int64(label)].([]byte); ok {
-			return v, true
-		}
-		if v, ok := m[label].([]byte); ok {
-			return v, true
-		}
-		if v, ok := m[uint64(label)].([]byte); ok {
-			return v, true
-		}
-		return nil, false
-	}
-
-	// kty (1)
-	if kty, ok := getInt(1); ok {
-		buf.WriteString(fmt.Sprintf("kty (1): %s\n", getKeyTypeName(kty)))
-	}
-
-	// kid (2)
-	if kid, ok := getBytes(2); ok {
-		buf.WriteString(fmt.Sprintf("kid (2): %s\n", formatHex(kid, 32)))
-	}
-
-	// alg (3)
-	if alg, ok := getInt(3); ok {
-		buf.WriteString(fmt.Sprintf("alg (3): %s\n", getAlgorithmName(alg)))
-	}
-
-	// crv (-1)
-	if crv, ok := getInt(-1); ok {
-		buf.WriteString(fmt.Sprintf("crv (-1): %s\n", getCurveName(crv)))
-	}
-
-	// x (-2)
-	if x, ok := getBytes(-2); ok {
-		buf.WriteString(fmt.Sprintf("x (-2): %s\n", formatHex(x, 32)))
-	}
-
-	// y (-3)
-	if y, ok := getBytes(-3); ok {
-		buf.WriteString(fmt.Sprintf("y (-3): %s\n", formatHex(y, 32)))
-	}
-
-	// d (-4) - private key
-	if d, ok := getBytes(-4); ok {
-		buf.WriteString(fmt.Sprintf("d (-4): [PRIVATE KEY - %d bytes]\n", len(d)))
-	}
-
-	buf.WriteString("```\n\n")
-
-	// Extended diagnostic notation
-	buf.WriteString("#### Extended Diagnostic Notation\n\n")
-	buf.WriteString("```cbor-diag\n")
-	buf.WriteString(toExtendedDiagnostic(data, 0))
-	buf.WriteString("\n```\n\n")
-
-	return buf.String()
-}
-
-// prettyPrintCoseSign1 formats a COSE Sign1 structure
-func prettyPrintCoseSign1(data interface{}) string {
-	arr := data.([]interface{})
-	var buf bytes.Buffer
-
-	buf.WriteString("### COSE Sign1\n\n")
-
-	// Extract components
-	protectedBytes, _ := arr[0].([]byte)
-	unprotected, _ := arr[1].(map[interface{}]interface{})
-	payload, _ := arr[2].([]byte) // Can be nil for detached
-	signature, _ := arr[3].([]byte)
-
-	// Decode protected header
-	var protected map[interface{}]interface{}
-	if len(protectedBytes) > 0 {
-		cbor.Unmarshal(protectedBytes, &protected)
-	}
-
-	// Protected Header
-	buf.WriteString("#### Protected Header\n\n")
-	buf.WriteString("```\n")
-	if len(protected) == 0 {
-		buf.WriteString("(empty)\n")
-	} else {
-		for label, value := range protected {
-			labelInt, _ := label.(int64)
-			labelName := getHeaderLabelName(int(labelInt))
-			buf.WriteString(formatHeaderValue(labelName, int(labelInt), value))
-		}
-	}
-	buf.WriteString("```\n\n")
-
-	// Unprotected Header
-	buf.WriteString("#### Unprotected Header\n\n")
-	buf.WriteString("```\n")
-	if len(unprotected) == 0 {
-		buf.WriteString("(empty)\n")
-	} else {
-		for label, value := range unprotected {
-			labelInt, _ := label.(int64)
-			labelName := getHeaderLabelName(int(labelInt))
-			buf.WriteString(formatHeaderValue(labelName, int(labelInt), value))
-		}
-	}
-	buf.WriteString("```\n\n")
-
-	// Payload
-	buf.WriteString("#### Payload\n\n")
-	buf.WriteString("```\n")
-	if payload == nil {
-		buf.WriteString("detached (null)\n")
-	} else {
-		buf.WriteString(formatHex(payload, 64))
-		buf.WriteString("\n")
-	}
-	buf.WriteString("```\n\n")
-
-	// Signature
-	buf.WriteString("#### Signature\n\n")
-	buf.WriteString("```\n")
-	if signature != nil {
-		buf.WriteString(formatHex(signature, 32))
-		buf.WriteString(fmt.Sprintf("\nLength: %d bytes\n", len(signature)))
-	}
-	buf.WriteString("```\n\n")
-
-	// Extended diagnostic notation
-	buf.WriteString("#### Extended Diagnostic Notation\n\n")
-	buf.WriteString("```cbor-diag\n")
-	buf.WriteString("/ COSE_Sign1 / 18([\n")
-	buf.WriteString(fmt.Sprintf("  / protected / %s,\n", toExtendedDiagnostic(protectedBytes, 1)))
-	buf.WriteString(fmt.Sprintf("  / unprotected / %s,\n", toExtendedDiagnostic(unprotected, 1)))
-	buf.WriteString(fmt.Sprintf("  / payload / %s,\n", toExtendedDiagnostic(payload, 1)))
-	buf.WriteString(fmt.Sprintf("  / signature / %s\n", toExtendedDiagnostic(signature, 1)))
-	buf.WriteString("])\n")
-	buf.WriteString("```\n\n")
-
-	return buf.String()
-}
-
-// formatHeaderValue formats a header label-value pair
-func formatHeaderValue(labelName string, labelInt int, value interface{}) string {
-	switch v := value.(type) {
-	case []byte:
-		return fmt.Sprintf("%s (%d): %s\n", labelName, labelInt, formatHex(v, 16))
-	case int64:
-		if labelInt == cose.HeaderLabelAlg {
-			return fmt.Sprintf("%s (%d): %s\n", labelName, labelInt, getAlgorithmName(int(v)))
-		}
-		return fmt.Sprintf("%s (%d): %d\n", labelName, labelInt, v)
-	case string:
-		return fmt.Sprintf("%s (%d): %s\n", labelName, labelInt, v)
-	case map[interface{}]interface{}:
-		return fmt.Sprintf("%s (%d): %s\n", labelName, labelInt, toExtendedDiagnostic(v, 0))
-	default:
-		return fmt.Sprintf("%s (%d): %v\n", labelName, labelInt, v)
-	}
-}
-
 // toExtendedDiagnostic converts CBOR data to extended diagnostic notation
 func toExtendedDiagnostic(value interface{}, indent int) string {
 	spaces := ""
@@ -814,6 +558,14 @@ func getAlgorithmName(alg int) string {
 	}
 }
 
+// headerLabelName returns the name of a normalized COSE header label
+func headerLabelName(label interface{}) string {
+	if labelInt, ok := label.(int64); ok {
+		return getHeaderLabelName(int(labelInt))
+	}
+	return fmt.Sprintf("%v", label)
+}
+
 // getHeaderLabelName returns the name of a COSE header label
 func getHeaderLabelName(label int) string {
 	switch label {
@@ -835,6 +587,8 @@ func getHeaderLabelName(label int) string {
 		return "cwt_claims"
 	case cose.HeaderLabelTyp:
 		return "typ"
+	case cose.HeaderLabelX5Chain:
+		return "x5chain"
 	case cose.HeaderLabelIss:
 		return "iss"
 	case cose.HeaderLabelSub:
@@ -0,0 +1,72 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/cli"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+)
+
+func TestDiagnose(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	diagnose := func(t *testing.T, data []byte) string {
+		t.Helper()
+		inputPath := filepath.Join(tmpDir, "input.cbor")
+		outputPath := filepath.Join(tmpDir, "report.md")
+		if err := os.WriteFile(inputPath, data, 0644); err != nil {
+			t.Fatalf("failed to write input: %v", err)
+		}
+
+		rootCmd := cli.NewRootCommand("test", "abc123", "2024-01-01")
+		rootCmd.SetArgs([]string{"diagnose", inputPath, "--output", outputPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("failed to diagnose: %v", err)
+		}
+		report, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("failed to read report: %v", err)
+		}
+		return string(report)
+	}
+
+	keyPair, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+
+	t.Run("names COSE Sign1 header labels", func(t *testing.T) {
+		signer, _ := cose.NewES256Signer(keyPair.Private)
+		headers := cose.CreateProtectedHeaders(cose.ProtectedHeadersOptions{
+			Alg: cose.AlgorithmES256,
+			Kid: []byte("kid"),
+		})
+		signed, err := cose.CreateCoseSign1(headers, []byte("payload"), signer, cose.CoseSign1Options{})
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		signed.Unprotected[cose.HeaderLabelVerifiableDataProof] = map[interface{}]interface{}{-1: []byte("proof")}
+		encoded, _ := cose.EncodeCoseSign1(signed)
+
+		report := diagnose(t, encoded)
+		for _, want := range []string{"**Type:** COSE Sign1", "1: -7 /alg/", "4: h'6b6964' /kid/", "396: ", "/ vdp /"} {
+			if !strings.Contains(report, want) {
+				t.Errorf("report is missing %q:\n%s", want, report)
+			}
+		}
+	})
+
+	t.Run("names COSE Key parameters", func(t *testing.T) {
+		publicKey, _ := cose.ExportPublicKeyToCOSECBOR(keyPair.Public)
+
+		report := diagnose(t, publicKey)
+		for _, want := range []string{"**Type:** COSE Key", "/ kty: EC2 /", "/ crv: P-256 /"} {
+			if !strings.Contains(report, want) {
+				t.Errorf("report is missing %q:\n%s", want, report)
+			}
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/fsck"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/service"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+)
+
+type serviceFsckOptions struct {
+	definition string
+	log        string
+	repair     bool
+	jsonOutput bool
+}
+
+// NewServiceFsckCommand creates the service fsck command
+func NewServiceFsckCommand() *cobra.Command {
+	opts := &serviceFsckOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check that tiles, statements and checkpoints agree",
+		Long: `Check the integrity of a stopped transparency service.
+
+Every leaf is read back from the hash tiles and compared with
+statements.statement_hash, every entry bundle is checked against the leaves,
+the root hash is recomputed at every recorded checkpoint, and each checkpoint in
+tree_state and storage is re-verified with keys.public. Truncated, oversized and
+torn hash tiles are reported.
+
+Without --repair nothing is modified and the repairs that would be applied are
+listed. With --repair, recoverable inconsistencies are fixed:
+  - registrations interrupted before the tree size advanced are completed
+  - leaves written beyond the tree size are truncated
+  - missing leaves are restored from their statements rows
+  - damaged entry bundles are rewritten from the stored statements
+  - missing or stale checkpoint copies in storage are rewritten from tree_state
+  - a stale compact range (.tree-state) is rebuilt from the hash tiles
+
+Stop the service before running with --repair.
+
+Example:
+  scitt service fsck --definition ./demo/scitt.yaml
+  scitt service fsck --definition ./demo/scitt.yaml --repair`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServiceFsck(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.definition, "definition", "", "path to service definition file (YAML)")
+	cmd.Flags().StringVar(&opts.log, "log", "", "name of the log to use from a multi-log definition")
+	cmd.Flags().BoolVar(&opts.repair, "repair", false, "apply recoverable repairs")
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "print the report as JSON")
+
+	cmd.MarkFlagRequired("definition")
+
+	return cmd
+}
+
+func runServiceFsck(opts *serviceFsckOptions) error {
+	cfg, err := loadLogDefinition(opts.definition, opts.log)
+	if err != nil {
+		return err
+	}
+	if cfg.Mirror != nil {
+		return fmt.Errorf("fsck does not support mirrors; run 'scitt mirror' to re-verify the mirrored tiles")
+	}
+	if cfg.Sharding != nil {
+		return fmt.Errorf("fsck does not support sharded logs")
+	}
+	if _, err := os.Stat(cfg.Database.Path); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	publicKey, err := loadLogPublicKey(cfg.Keys.Public)
+	if err != nil {
+		return err
+	}
+	store, err := service.OpenStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.OpenDatabase(database.DatabaseOptions{
+		Path:      cfg.Database.Path,
+		EnableWAL: cfg.Database.EnableWAL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer database.CloseDatabase(db)
+
+	report, err := fsck.Run(fsck.Config{
+		DB:        db,
+		Storage:   store,
+		PublicKey: publicKey,
+		Repair:    opts.repair,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printFsckReport(report, opts.repair)
+	}
+
+	if !report.OK() {
+		return fmt.Errorf("%d inconsistencies found", len(report.Findings))
+	}
+	return nil
+}
+
+// printFsckReport prints a human-readable fsck report
+func printFsckReport(report *fsck.Report, repaired bool) {
+	for _, f := range report.Repaired {
+		fmt.Printf("✓ Repaired %s: %s\n", f.Kind, f.Detail)
+	}
+
+	fmt.Printf("Tree size:    %d\n", report.TreeSize)
+	if report.RootHash != "" {
+		fmt.Printf("Root hash:    %s\n", report.RootHash)
+	}
+	fmt.Printf("Leaves:       %d\n", report.Leaves)
+	fmt.Printf("Statements:   %d\n", report.Statements)
+	fmt.Printf("Checkpoints:  %d\n", report.Checkpoints)
+
+	if report.OK() {
+		fmt.Printf("✓ Tiles, statements and checkpoints are consistent\n")
+		return
+	}
+
+	for _, f := range report.Findings {
+		fmt.Printf("✗ %s: %s\n", f.Kind, f.Detail)
+		if f.Repair != "" {
+			fmt.Printf("    repair: %s\n", f.Repair)
+		}
+	}
+	if n := report.Repairable(); n > 0 && !repaired {
+		fmt.Printf("\nRun with --repair to apply %d repairs\n", n)
+	}
+}
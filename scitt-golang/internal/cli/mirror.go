@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/mirror"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/service"
+)
+
+// defaultMirrorInterval is the time between syncs when the definition sets none
+const defaultMirrorInterval = time.Minute
+
+type mirrorOptions struct {
+	definition string
+	once       bool
+}
+
+// NewMirrorCommand creates the mirror command
+func NewMirrorCommand() *cobra.Command {
+	opts := &mirrorOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "mirror",
+		Short: "Replicate a transparency log into local storage",
+		Long: `Replicate the hash tiles, entry bundles and checkpoints of the service named
+by mirror.source in a service definition into that definition's tile storage.
+
+Each sync fetches the source's checkpoint, verifies it with keys.public, fetches
+the tiles added since the last sync and only accepts them if they produce the
+signed root and every entry hashes to its leaf. The checkpoint is written last, so an interrupted sync resumes
+from the previous one.
+
+Serve the mirror read-only with 'scitt service start' on the same definition.
+
+Example:
+  scitt mirror --definition ./mirror/scitt.yaml
+  scitt mirror --definition ./mirror/scitt.yaml --once`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMirror(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.definition, "definition", "", "path to the mirror's service definition file (YAML)")
+	cmd.Flags().BoolVar(&opts.once, "once", false, "sync once and exit")
+
+	cmd.MarkFlagRequired("definition")
+
+	return cmd
+}
+
+func runMirror(opts *mirrorOptions) error {
+	cfg, err := config.LoadConfig(opts.definition)
+	if err != nil {
+		return fmt.Errorf("failed to load service definition: %w", err)
+	}
+	if cfg.Mirror == nil {
+		return fmt.Errorf("service definition has no mirror section")
+	}
+
+	publicKey, err := loadLogPublicKey(cfg.Keys.Public)
+	if err != nil {
+		return err
+	}
+	store, err := service.OpenStorage(cfg.Storage)
+	if err != nil {
+		return err
+	}
+
+	m, err := mirror.New(mirror.Config{
+		SourceURL: cfg.Mirror.Source,
+		PublicKey: publicKey,
+		Storage:   store,
+	})
+	if err != nil {
+		return err
+	}
+
+	interval := cfg.Mirror.Interval
+	if interval == 0 {
+		interval = defaultMirrorInterval
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := m.Sync(ctx)
+		switch {
+		case errors.Is(err, mirror.ErrInconsistent) || errors.Is(err, mirror.ErrInvalidCheckpoint):
+			return &exitError{code: ExitCodeAlert, err: fmt.Errorf("✗ mirror sync rejected: %w", err)}
+		case err != nil && opts.once:
+			return err
+		case err != nil:
+			slog.Warn("mirror sync failed", "source", cfg.Mirror.Source, "error", err)
+		case opts.once || result.NewSize != result.OldSize:
+			fmt.Printf("✓ Mirrored %s at tree size %d (+%d entries)\n", cfg.Mirror.Source, result.NewSize, result.NewSize-result.OldSize)
+		}
+
+		if opts.once {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("mirror stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/cli"
+)
+
+func TestMirrorCommand(t *testing.T) {
+	rootCmd := cli.NewRootCommand("test", "abc123", "2024-01-01")
+	mirrorCmd, _, err := rootCmd.Find([]string{"mirror"})
+	if err != nil || mirrorCmd.Name() != "mirror" {
+		t.Fatalf("failed to find mirror command: %v", err)
+	}
+
+	for _, flag := range []string{"definition", "once"} {
+		if mirrorCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("--%s flag not found", flag)
+		}
+	}
+}
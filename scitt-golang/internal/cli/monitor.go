@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/monitor"
+)
+
+// ExitCodeAlert is the exit code used when the monitor detects misbehaviour
+const ExitCodeAlert = 2
+
+// exitError carries a specific process exit code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the process exit code for an error returned by a command
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return 1
+}
+
+type monitorOptions struct {
+	serviceURL string
+	keyPaths   []string
+	statePath  string
+	interval   time.Duration
+	once       bool
+	webhookURL string
+	issuer     string
+	subject    string
+	shard      string
+	keepGoing  bool
+}
+
+// NewMonitorCommand creates the monitor command
+func NewMonitorCommand() *cobra.Command {
+	opts := &monitorOptions{
+		statePath: "monitor-state.json",
+		interval:  30 * time.Second,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "monitor",
+		Short: "Audit a transparency service's checkpoints",
+		Long: `Poll a transparency service's checkpoint and verify it is signed by a pinned
+service key and consistent with the last checkpoint the monitor trusted.
+
+The trusted checkpoint is stored in the state file. A checkpoint with an invalid
+signature, a smaller tree size (rollback) or an inconsistent root (fork) raises
+an alert: it is logged, POSTed as JSON to --webhook, and the monitor exits with
+code 2 unless --continue is set.
+
+With --issuer and/or --subject, the receipt of every new matching entry is
+verified against the pinned keys and the trusted checkpoint.
+
+On a sharded service the monitor follows the active shard: when it rotates, the
+previous shard is audited up to its final checkpoint and the new shard's first
+checkpoint is trusted. --shard pins the monitor to one shard instead.
+
+Example:
+  scitt monitor \
+    --service http://localhost:56177 \
+    --key ./demo/pub.cbor \
+    --issuer https://example.com/issuer \
+    --interval 1m`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMonitor(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.serviceURL, "service", "", "transparency service URL (required)")
+	cmd.Flags().StringArrayVar(&opts.keyPaths, "key", nil, "pinned service public key file, COSE Key CBOR or JWK (repeatable, required)")
+	cmd.Flags().StringVar(&opts.statePath, "state", opts.statePath, "path to the monitor state file")
+	cmd.Flags().DurationVar(&opts.interval, "interval", opts.interval, "polling interval")
+	cmd.Flags().BoolVar(&opts.once, "once", false, "poll once and exit")
+	cmd.Flags().StringVar(&opts.webhookURL, "webhook", "", "URL to POST alerts to as JSON")
+	cmd.Flags().StringVar(&opts.issuer, "issuer", "", "verify inclusion of new entries from this issuer")
+	cmd.Flags().StringVar(&opts.subject, "subject", "", "verify inclusion of new entries about this subject")
+	cmd.Flags().StringVar(&opts.shard, "shard", "", "audit this shard of a sharded service instead of the active shard")
+	cmd.Flags().BoolVar(&opts.keepGoing, "continue", false, "keep polling after an alert instead of exiting")
+
+	cmd.MarkFlagRequired("service")
+	cmd.MarkFlagRequired("key")
+
+	return cmd
+}
+
+func runMonitor(opts *monitorOptions) error {
+	if opts.interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	publicKeys := make([]*ecdsa.PublicKey, 0, len(opts.keyPaths))
+	for _, path := range opts.keyPaths {
+		publicKey, err := loadLogPublicKey(path)
+		if err != nil {
+			return err
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	m, err := monitor.New(monitor.Config{
+		ServiceURL: opts.serviceURL,
+		PublicKeys: publicKeys,
+		StatePath:  opts.statePath,
+		Issuer:     opts.issuer,
+		Subject:    opts.subject,
+		Shard:      opts.shard,
+		WebhookURL: opts.webhookURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("✓ Monitoring %s\n", opts.serviceURL)
+	if state := m.State(); state.Checkpoint != "" {
+		fmt.Printf("  Trusted tree size: %d\n", state.TreeSize)
+	}
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	var alerted bool
+	for {
+		alerts, err := m.Poll(ctx)
+		switch {
+		case err != nil && opts.once:
+			return err
+		case err != nil:
+			slog.Warn("monitor poll failed", "service", opts.serviceURL, "error", err)
+		case len(alerts) > 0:
+			alerted = true
+			if !opts.keepGoing {
+				return &exitError{code: ExitCodeAlert, err: fmt.Errorf("✗ %s: %s", alerts[0].Kind, alerts[0].Detail)}
+			}
+		}
+
+		if opts.once {
+			if alerted {
+				return &exitError{code: ExitCodeAlert, err: fmt.Errorf("✗ monitor raised alerts")}
+			}
+			fmt.Printf("✓ Checkpoint verified at tree size %d\n", m.State().TreeSize)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("monitor stopped")
+			if alerted {
+				return &exitError{code: ExitCodeAlert, err: fmt.Errorf("✗ monitor raised alerts")}
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
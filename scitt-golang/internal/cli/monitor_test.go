@@ -0,0 +1,31 @@
+package cli_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/cli"
+)
+
+func TestMonitorCommand(t *testing.T) {
+	rootCmd := cli.NewRootCommand("test", "abc123", "2024-01-01")
+	monitorCmd, _, err := rootCmd.Find([]string{"monitor"})
+	if err != nil || monitorCmd.Name() != "monitor" {
+		t.Fatalf("failed to find monitor command: %v", err)
+	}
+
+	for _, flag := range []string{"service", "key", "state", "interval", "once", "webhook", "issuer", "subject", "shard", "continue"} {
+		if monitorCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("--%s flag not found", flag)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if code := cli.ExitCode(nil); code != 0 {
+		t.Errorf("expected 0 for nil, got %d", code)
+	}
+	if code := cli.ExitCode(errors.New("failed")); code != 1 {
+		t.Errorf("expected 1 for a plain error, got %d", code)
+	}
+}
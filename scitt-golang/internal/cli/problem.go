@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// problemDetails is a decoded SCRAPI error response (RFC 9290 / RFC 9457)
+type problemDetails struct {
+	Title    string
+	Detail   string
+	Instance string
+}
+
+// decodeProblemDetails decodes a concise problem details (CBOR) or problem+json body
+// Returns nil if the response is not a problem details document
+func decodeProblemDetails(resp *http.Response, body []byte) *problemDetails {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "application/concise-problem-details+cbor":
+		var fields map[int64]interface{}
+		if err := cbor.Unmarshal(body, &fields); err != nil {
+			return nil
+		}
+		problem := &problemDetails{}
+		problem.Title, _ = fields[-1].(string)
+		problem.Detail, _ = fields[-2].(string)
+		problem.Instance, _ = fields[-3].(string)
+		return problem
+
+	case "application/problem+json":
+		var fields struct {
+			Title    string `json:"title"`
+			Detail   string `json:"detail"`
+			Instance string `json:"instance"`
+		}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil
+		}
+		return &problemDetails{Title: fields.Title, Detail: fields.Detail, Instance: fields.Instance}
+	}
+
+	return nil
+}
+
+// describeErrorResponse returns a one-line description of a failed HTTP response
+func describeErrorResponse(resp *http.Response, body []byte) string {
+	if problem := decodeProblemDetails(resp, body); problem != nil {
+		if problem.Detail == "" {
+			return problem.Title
+		}
+		return fmt.Sprintf("%s: %s", problem.Title, problem.Detail)
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// printErrorResponse prints a failed HTTP response, decoding problem details when present
+func printErrorResponse(action string, resp *http.Response, body []byte) {
+	fmt.Printf("✗ %s failed: HTTP %d\n", action, resp.StatusCode)
+
+	if problem := decodeProblemDetails(resp, body); problem != nil {
+		fmt.Printf("  Error:  %s\n", problem.Title)
+		if problem.Detail != "" {
+			fmt.Printf("  Detail: %s\n", problem.Detail)
+		}
+		return
+	}
+
+	fmt.Printf("  Response: %s\n", strings.TrimSpace(string(body)))
+}
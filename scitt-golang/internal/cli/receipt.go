@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/fxamacker/cbor/v2"
 	"github.com/spf13/cobra"
@@ -43,6 +45,11 @@ type receiptVerifyOptions struct {
 	receipt   string
 	statement string
 	artifact  string // Optional: verify artifact hash matches statement payload
+
+	// Optional freshness bounds on the registration time (iat) of the entry
+	maxAge           time.Duration
+	registeredAfter  string
+	registeredBefore string
 }
 
 // NewReceiptVerifyCommand creates the receipt verify command
@@ -61,10 +68,13 @@ This command:
   4. Reconstructs the Merkle root from the inclusion proof and statement hash
   5. Verifies the COSE signature on the receipt
   6. If --artifact is provided, verifies the artifact hash matches the statement payload
+  7. If freshness bounds are given, checks the registration time (iat) of the entry
 
 Example:
   scitt receipt verify --receipt receipt.cbor --statement statement.cbor
-  scitt receipt verify --receipt receipt.cbor --statement statement.cbor --artifact data.parquet`,
+  scitt receipt verify --receipt receipt.cbor --statement statement.cbor --artifact data.parquet
+  scitt receipt verify --receipt receipt.cbor --statement statement.cbor --max-age 720h
+  scitt receipt verify --receipt receipt.cbor --statement statement.cbor --registered-after 2026-01-01T00:00:00Z`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runReceiptVerify(opts)
 		},
@@ -73,6 +83,9 @@ Example:
 	cmd.Flags().StringVarP(&opts.receipt, "receipt", "r", "", "receipt file (required)")
 	cmd.Flags().StringVarP(&opts.statement, "statement", "s", "", "statement file (required)")
 	cmd.Flags().StringVarP(&opts.artifact, "artifact", "a", "", "artifact file (optional: verify hash matches statement)")
+	cmd.Flags().DurationVar(&opts.maxAge, "max-age", 0, "reject receipts for entries registered longer ago than this")
+	cmd.Flags().StringVar(&opts.registeredAfter, "registered-after", "", "reject receipts for entries registered before this RFC 3339 time")
+	cmd.Flags().StringVar(&opts.registeredBefore, "registered-before", "", "reject receipts for entries registered after this RFC 3339 time")
 
 	cmd.MarkFlagRequired("receipt")
 	cmd.MarkFlagRequired("statement")
@@ -133,28 +146,16 @@ func runReceiptVerify(opts *receiptVerifyOptions) error {
 		return fmt.Errorf("failed to get protected headers: %w", err)
 	}
 
-	// 4. Extract issuer URL from CWT claims
-	// Note: header keys might be int64 or uint64 depending on CBOR decoder
-	var cwtClaims map[interface{}]interface{}
-	var ok bool
-
-	// Try both int64 and uint64 keys for header label 15 (CWT claims)
-	cwtClaims, ok = headers[int64(cose.HeaderLabelCWTClaims)].(map[interface{}]interface{})
-	if !ok {
-		cwtClaims, ok = headers[uint64(cose.HeaderLabelCWTClaims)].(map[interface{}]interface{})
-		if !ok {
-			return fmt.Errorf("CWT claims not found in receipt protected headers")
-		}
+	// 4. Extract issuer URL and registration time from CWT claims
+	claims, err := merkle.GetReceiptClaims(receipt)
+	if err != nil {
+		return err
 	}
+	issuer := claims.Iss
 
-	// Try both int64 and uint64 keys for claim 1 (iss)
-	var issuer string
-	issuer, ok = cwtClaims[int64(cose.CWTClaimIss)].(string)
-	if !ok {
-		issuer, ok = cwtClaims[uint64(cose.CWTClaimIss)].(string)
-		if !ok {
-			return fmt.Errorf("issuer (iss) not found in CWT claims")
-		}
+	// Check freshness before fetching keys, so stale receipts fail offline
+	if err := checkReceiptFreshness(claims, opts, time.Now()); err != nil {
+		return err
 	}
 
 	// 5. Fetch SCITT keys from issuer's well-known endpoint
@@ -167,7 +168,8 @@ func runReceiptVerify(opts *receiptVerifyOptions) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to fetch SCITT keys: HTTP %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to fetch SCITT keys: HTTP %d: %s", resp.StatusCode, describeErrorResponse(resp, body))
 	}
 
 	keysData, err := io.ReadAll(resp.Body)
@@ -175,31 +177,21 @@ func runReceiptVerify(opts *receiptVerifyOptions) error {
 		return fmt.Errorf("failed to read SCITT keys response: %w", err)
 	}
 
-	// 6. Decode COSE Key Set
-	var keySetArray []interface{}
-	if err := cbor.Unmarshal(keysData, &keySetArray); err != nil {
+	// 6. Decode COSE Key Set, keeping each key's encoding
+	var keySetArray []cbor.RawMessage
+	if err := cose.Unmarshal(keysData, &keySetArray); err != nil {
 		return fmt.Errorf("failed to decode COSE Key Set: %w", err)
 	}
 
 	// 7. Extract kid from receipt
-	// Try both int64 and uint64 keys for header label 4 (kid)
-	var kidFromReceipt []byte
-	kidFromReceipt, ok = headers[int64(cose.HeaderLabelKid)].([]byte)
+	kidFromReceipt, ok := headers.Bytes(cose.HeaderLabelKid)
 	if !ok {
-		kidFromReceipt, ok = headers[uint64(cose.HeaderLabelKid)].([]byte)
-		if !ok {
-			return fmt.Errorf("kid not found in receipt protected headers")
-		}
+		return fmt.Errorf("kid not found in receipt protected headers")
 	}
 
 	// 8. Find matching key in key set
 	var matchingKeyData []byte
-	for _, keyInterface := range keySetArray {
-		keyBytes, err := cbor.Marshal(keyInterface)
-		if err != nil {
-			continue
-		}
-
+	for _, keyBytes := range keySetArray {
 		// Extract kid from this key
 		keyKid, err := cose.GetKidFromCOSEKey(keyBytes)
 		if err != nil {
@@ -223,120 +215,69 @@ func runReceiptVerify(opts *receiptVerifyOptions) error {
 		return fmt.Errorf("failed to import public key: %w", err)
 	}
 
-	// 10. Extract inclusion proof from unprotected headers
-	// Try both int64 and uint64 keys for header label 396 (VDP)
-	var vdpHeader interface{}
-	vdpHeader, ok = receipt.Unprotected[int64(cose.HeaderLabelVerifiableDataProof)]
-	if !ok {
-		vdpHeader, ok = receipt.Unprotected[uint64(cose.HeaderLabelVerifiableDataProof)]
-		if !ok {
-			return fmt.Errorf("verifiable data proof not found in unprotected headers")
-		}
-	}
+	// 10. Reconstruct the Merkle root from the inclusion proof and the statement hash
+	// (the entry is the SHA-256 hash of the complete statement) and verify the
+	// receipt signature over it
+	leafHash := sha256.Sum256(statementData)
 
-	vdpMap, ok := vdpHeader.(map[interface{}]interface{})
-	if !ok {
-		return fmt.Errorf("verifiable data proof is not a map")
+	inclusionProof, _, err := merkle.VerifyReceipt(receipt, leafHash, publicKey)
+	if err != nil {
+		return err
 	}
 
-	// Try both int64 and uint64 keys for inclusion proof (-1)
-	var inclusionProofCBOR []byte
-	inclusionProofCBOR, ok = vdpMap[int64(-1)].([]byte)
-	if !ok {
-		inclusionProofCBOR, ok = vdpMap[uint64(18446744073709551615)].([]byte) // -1 as uint64
-		if !ok {
-			return fmt.Errorf("inclusion proof not found in verifiable data proof")
-		}
+	// Success - print summary
+	fmt.Println("✓ Receipt verification successful")
+	if opts.artifact != "" {
+		fmt.Printf("  Artifact: %s\n", opts.artifact)
 	}
-
-	// 11. Decode inclusion proof [tree-size, leaf-index, inclusion-path]
-	var inclusionProofArray []interface{}
-	if err := cbor.Unmarshal(inclusionProofCBOR, &inclusionProofArray); err != nil {
-		return fmt.Errorf("failed to decode inclusion proof: %w", err)
+	fmt.Printf("  Statement: %s\n", opts.statement)
+	fmt.Printf("  Receipt: %s\n", opts.receipt)
+	fmt.Printf("  Issuer: %s\n", issuer)
+	if claims.Iat != nil {
+		fmt.Printf("  Registered at: %s\n", claims.Iat.Format(time.RFC3339))
 	}
+	fmt.Printf("  Tree size: %d\n", inclusionProof.TreeSize)
+	fmt.Printf("  Leaf index: %d\n", inclusionProof.LeafIndex)
 
-	if len(inclusionProofArray) != 3 {
-		return fmt.Errorf("invalid inclusion proof structure: expected 3 elements, got %d", len(inclusionProofArray))
-	}
+	return nil
+}
 
-	treeSize, ok := inclusionProofArray[0].(int64)
-	if !ok {
-		// Try uint64
-		if ts, ok := inclusionProofArray[0].(uint64); ok {
-			treeSize = int64(ts)
-		} else {
-			return fmt.Errorf("tree size is not an integer")
-		}
+// checkReceiptFreshness enforces the requested bounds on a receipt's
+// registration time; a receipt without iat fails any bound
+func checkReceiptFreshness(claims *merkle.ReceiptClaims, opts *receiptVerifyOptions, now time.Time) error {
+	if opts.maxAge == 0 && opts.registeredAfter == "" && opts.registeredBefore == "" {
+		return nil
 	}
-
-	leafIndex, ok := inclusionProofArray[1].(int64)
-	if !ok {
-		// Try uint64
-		if li, ok := inclusionProofArray[1].(uint64); ok {
-			leafIndex = int64(li)
-		} else {
-			return fmt.Errorf("leaf index is not an integer")
-		}
+	if claims.Iat == nil {
+		return fmt.Errorf("receipt has no registration time (iat) to check freshness against")
 	}
+	iat := *claims.Iat
 
-	inclusionPathInterface, ok := inclusionProofArray[2].([]interface{})
-	if !ok {
-		return fmt.Errorf("inclusion path is not an array")
+	if opts.maxAge < 0 {
+		return fmt.Errorf("invalid --max-age: %s", opts.maxAge)
+	}
+	if opts.maxAge > 0 && now.Sub(iat) > opts.maxAge {
+		return fmt.Errorf("receipt is stale: entry registered at %s, more than %s ago", iat.Format(time.RFC3339), opts.maxAge)
 	}
 
-	// Convert inclusion path to [][32]byte
-	var auditPath [][32]byte
-	for i, hashInterface := range inclusionPathInterface {
-		hashBytes, ok := hashInterface.([]byte)
-		if !ok {
-			return fmt.Errorf("hash at index %d is not bytes", i)
+	if opts.registeredAfter != "" {
+		after, err := time.Parse(time.RFC3339, opts.registeredAfter)
+		if err != nil {
+			return fmt.Errorf("invalid --registered-after: %w", err)
 		}
-		if len(hashBytes) != 32 {
-			return fmt.Errorf("hash at index %d has invalid length: %d", i, len(hashBytes))
+		if iat.Before(after) {
+			return fmt.Errorf("entry registered at %s, before %s", iat.Format(time.RFC3339), opts.registeredAfter)
 		}
-		var hash [32]byte
-		copy(hash[:], hashBytes)
-		auditPath = append(auditPath, hash)
-	}
-
-	// 12. Compute entry (leaf hash) from statement CBOR
-	// The entry is SHA-256 hash of the complete statement
-	leafHash := sha256.Sum256(statementData)
-
-	// 13. Reconstruct Merkle root from inclusion proof using tessera/merkle library
-	inclusionProof := &merkle.InclusionProof{
-		LeafIndex: leafIndex,
-		TreeSize:  treeSize,
-		AuditPath: auditPath,
 	}
-
-	reconstructedRoot := merkle.ReconstructRootFromInclusionProof(leafHash, inclusionProof)
-
-	// 14. Verify COSE signature on receipt using reconstructed root as external payload
-	verifier, err := cose.NewES256Verifier(publicKey)
-	if err != nil {
-		return fmt.Errorf("failed to create verifier: %w", err)
-	}
-
-	valid, err := cose.VerifyCoseSign1(receipt, verifier, reconstructedRoot[:])
-	if err != nil {
-		return fmt.Errorf("failed to verify receipt signature: %w", err)
-	}
-
-	if !valid {
-		return fmt.Errorf("receipt signature is invalid")
-	}
-
-	// Success - print summary
-	fmt.Println("✓ Receipt verification successful")
-	if opts.artifact != "" {
-		fmt.Printf("  Artifact: %s\n", opts.artifact)
+	if opts.registeredBefore != "" {
+		before, err := time.Parse(time.RFC3339, opts.registeredBefore)
+		if err != nil {
+			return fmt.Errorf("invalid --registered-before: %w", err)
+		}
+		if iat.After(before) {
+			return fmt.Errorf("entry registered at %s, after %s", iat.Format(time.RFC3339), opts.registeredBefore)
+		}
 	}
-	fmt.Printf("  Statement: %s\n", opts.statement)
-	fmt.Printf("  Receipt: %s\n", opts.receipt)
-	fmt.Printf("  Issuer: %s\n", issuer)
-	fmt.Printf("  Tree size: %d\n", treeSize)
-	fmt.Printf("  Leaf index: %d\n", leafIndex)
 
 	return nil
 }
@@ -352,7 +293,9 @@ func NewReceiptInfoCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "info",
 		Short: "Display receipt information",
-		Long: `Display information about a SCITT receipt.
+		Long: `Display information about a SCITT receipt: its issuer, the subject and
+registration time (iat) of the entry, any other CWT claims, and the tree size
+and leaf index of its inclusion proof. The receipt is not verified.
 
 Example:
   scitt receipt info --receipt receipt.cbor`,
@@ -375,18 +318,46 @@ func runReceiptInfo(opts *receiptInfoOptions) error {
 		return fmt.Errorf("failed to read receipt file: %w", err)
 	}
 
-	// TODO: Implement receipt parsing and display
-	// This requires:
-	// 1. Decode receipt CBOR structure
-	// 2. Parse checkpoint, inclusion proof, metadata
-	// 3. Display in human-readable format
+	receipt, err := cose.DecodeCoseSign1(receiptData)
+	if err != nil {
+		return fmt.Errorf("failed to decode receipt: %w", err)
+	}
+	claims, err := merkle.GetReceiptClaims(receipt)
+	if err != nil {
+		return err
+	}
+	proof, err := merkle.ReceiptInclusionProof(receipt)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(receiptData)
 
 	fmt.Printf("Receipt Information:\n")
 	fmt.Printf("  File: %s\n", opts.receipt)
 	fmt.Printf("  Size: %d bytes\n", len(receiptData))
-	fmt.Printf("  Hash: %s\n", hex.EncodeToString(receiptData[:32]))
-	fmt.Println("\nNote: Detailed receipt parsing not yet implemented")
-	fmt.Println("This will be completed as part of integration testing (T027)")
+	fmt.Printf("  Hash: %s\n", hex.EncodeToString(hash[:]))
+	fmt.Printf("  Issuer: %s\n", claims.Iss)
+	if claims.Sub != "" {
+		fmt.Printf("  Subject: %s\n", claims.Sub)
+	}
+	if claims.Iat != nil {
+		fmt.Printf("  Registered at: %s\n", claims.Iat.Format(time.RFC3339))
+	}
+	if claims.Cti != nil {
+		fmt.Printf("  Receipt ID (cti): %s\n", hex.EncodeToString(claims.Cti))
+	}
+	labels := make([]string, 0, len(claims.Extra))
+	values := make(map[string]interface{}, len(claims.Extra))
+	for label, value := range claims.Extra {
+		labels = append(labels, fmt.Sprint(label))
+		values[fmt.Sprint(label)] = value
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		fmt.Printf("  Claim %s: %v\n", label, values[label])
+	}
+	fmt.Printf("  Tree size: %d\n", proof.TreeSize)
+	fmt.Printf("  Leaf index: %d\n", proof.LeafIndex)
 
 	return nil
 }
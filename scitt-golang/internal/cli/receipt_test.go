@@ -0,0 +1,117 @@
+package cli_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/cli"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/server"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+)
+
+func TestReceiptVerifyFreshness(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	keyPair, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	privateKey, _ := cose.ExportPrivateKeyToCOSECBOR(keyPair.Private)
+	publicKey, _ := cose.ExportPublicKeyToCOSECBOR(keyPair.Public)
+	privatePath := filepath.Join(tmpDir, "service-key.cbor")
+	publicPath := filepath.Join(tmpDir, "service-key-pub.cbor")
+	os.WriteFile(privatePath, privateKey, 0600)
+	os.WriteFile(publicPath, publicKey, 0644)
+
+	// The receipt issuer must be the URL its keys are served from
+	ts := httptest.NewUnstartedServer(nil)
+	cfg := config.DefaultConfig()
+	cfg.Issuer = "http://" + ts.Listener.Addr().String()
+	cfg.Database.Path = filepath.Join(tmpDir, "scitt.db")
+	cfg.Storage = config.StorageConfig{Type: "memory"}
+	cfg.Keys = config.KeysConfig{Private: privatePath, Public: publicPath}
+	cfg.Server.APIKey = "test-key"
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+	ts.Config.Handler = srv.Handler()
+	ts.Start()
+	defer ts.Close()
+
+	// Register a statement and keep it with its receipt
+	signer, _ := cose.NewES256Signer(keyPair.Private)
+	headers := cose.CreateProtectedHeaders(cose.ProtectedHeadersOptions{
+		Alg:       cose.AlgorithmES256,
+		CWTClaims: cose.CreateCWTClaims(cose.CWTClaimsOptions{Iss: "https://issuer.example.com", Sub: "artifact"}),
+	})
+	signed, err := cose.CreateCoseSign1(headers, []byte("payload"), signer, cose.CoseSign1Options{})
+	if err != nil {
+		t.Fatalf("failed to sign statement: %v", err)
+	}
+	statement, _ := cose.EncodeCoseSign1(signed)
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/entries", bytes.NewReader(statement))
+	req.Header.Set("Authorization", "Bearer test-key")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to register statement: %v", err)
+	}
+	receipt, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("failed to register statement: %d", resp.StatusCode)
+	}
+
+	statementPath := filepath.Join(tmpDir, "statement.cbor")
+	receiptPath := filepath.Join(tmpDir, "receipt.cbor")
+	os.WriteFile(statementPath, statement, 0644)
+	os.WriteFile(receiptPath, receipt, 0644)
+
+	verify := func(extra ...string) error {
+		rootCmd := cli.NewRootCommand("test", "abc123", "2024-01-01")
+		rootCmd.SetArgs(append([]string{"receipt", "verify", "--receipt", receiptPath, "--statement", statementPath}, extra...))
+		rootCmd.SetOut(io.Discard)
+		rootCmd.SetErr(io.Discard)
+		return rootCmd.Execute()
+	}
+
+	t.Run("accepts a receipt within the bounds", func(t *testing.T) {
+		hourAgo := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		if err := verify("--max-age", "1h", "--registered-after", hourAgo); err != nil {
+			t.Errorf("expected the receipt to verify: %v", err)
+		}
+	})
+
+	t.Run("rejects a receipt outside the bounds", func(t *testing.T) {
+		hourAhead := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		hourAgo := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		for _, bounds := range [][]string{
+			{"--max-age", "1ns"},
+			{"--registered-after", hourAhead},
+			{"--registered-before", hourAgo},
+			{"--registered-after", "yesterday"},
+		} {
+			if err := verify(bounds...); err == nil {
+				t.Errorf("%v: expected an error", bounds)
+			}
+		}
+	})
+
+	t.Run("displays receipt claims", func(t *testing.T) {
+		rootCmd := cli.NewRootCommand("test", "abc123", "2024-01-01")
+		rootCmd.SetArgs([]string{"receipt", "info", "--receipt", receiptPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("failed to display receipt: %v", err)
+		}
+	})
+}
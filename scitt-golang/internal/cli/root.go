@@ -45,7 +45,11 @@ SCITT transparency services, including:
 	rootCmd.AddCommand(NewIssuerCommand())
 	rootCmd.AddCommand(NewStatementCommand())
 	rootCmd.AddCommand(NewReceiptCommand())
+	rootCmd.AddCommand(NewArtifactCommand())
 	rootCmd.AddCommand(NewDiagnoseCommand())
+	rootCmd.AddCommand(NewWitnessCommand())
+	rootCmd.AddCommand(NewMonitorCommand())
+	rootCmd.AddCommand(NewMirrorCommand())
 
 	return rootCmd
 }
@@ -102,6 +102,23 @@ func TestRootCommand(t *testing.T) {
 			t.Errorf("expected receipt command, got '%s'", receiptCmd.Use)
 		}
 	})
+
+	t.Run("has artifact lookup subcommand", func(t *testing.T) {
+		cmd := cli.NewRootCommand("1.0.0", "abc123", "2025-01-01")
+
+		lookupCmd, _, err := cmd.Find([]string{"artifact", "lookup"})
+		if err != nil {
+			t.Fatalf("failed to find artifact lookup command: %v", err)
+		}
+
+		if lookupCmd.Use != "lookup" {
+			t.Errorf("expected lookup command, got '%s'", lookupCmd.Use)
+		}
+
+		if lookupCmd.Flags().Lookup("file") == nil {
+			t.Error("expected file flag to exist")
+		}
+	})
 }
 
 // TestStatementSubcommands tests statement subcommands
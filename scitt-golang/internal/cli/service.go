@@ -1,14 +1,18 @@
 package cli
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/logging"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/server"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
 )
@@ -21,12 +25,22 @@ func NewServiceCommand() *cobra.Command {
 		Long: `Manage SCITT transparency service configuration and lifecycle.
 
 Subcommands:
-  create - Create a new service definition
-  start  - Start the transparency service`,
+  create  - Create a new service definition
+  start   - Start the transparency service
+  apikey  - Manage client API keys
+  fsck    - Check that tiles, statements and checkpoints agree
+  reindex - Rebuild the metadata database from stored statements
+  export  - Export the log as a portable archive
+  import  - Restore the log from a portable archive`,
 	}
 
 	cmd.AddCommand(NewServiceCreateCommand())
 	cmd.AddCommand(NewServiceStartCommand())
+	cmd.AddCommand(NewServiceAPIKeyCommand())
+	cmd.AddCommand(NewServiceFsckCommand())
+	cmd.AddCommand(NewServiceReindexCommand())
+	cmd.AddCommand(NewServiceExportCommand())
+	cmd.AddCommand(NewServiceImportCommand())
 
 	return cmd
 }
@@ -177,6 +191,12 @@ func runServiceDefinitionCreate(opts *serviceDefinitionCreateOptions) error {
 				Enabled:        true,
 				AllowedOrigins: []string{"*"},
 			},
+			Timeouts:        config.DefaultTimeouts(),
+			MaxRequestBytes: config.DefaultMaxRequestBytes,
+		},
+		Logging: config.LoggingConfig{
+			Level:  "info",
+			Format: "text",
 		},
 	}
 
@@ -202,6 +222,16 @@ func runServiceDefinitionCreate(opts *serviceDefinitionCreateOptions) error {
 	return nil
 }
 
+// loadLogDefinition loads a service definition and selects one of its logs
+// The log name is required for, and only accepted by, multi-log definitions
+func loadLogDefinition(definition, log string) (*config.Config, error) {
+	cfg, err := config.LoadConfig(definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service definition: %w", err)
+	}
+	return cfg.Log(log)
+}
+
 type serviceStartOptions struct {
 	definition string
 	host       string
@@ -263,12 +293,25 @@ func runServiceStart(opts *serviceStartOptions) error {
 
 	if verbose {
 		fmt.Println("Starting SCITT transparency service...")
-		fmt.Printf("  Issuer:   %s\n", cfg.Issuer)
-		fmt.Printf("  Database: %s\n", cfg.Database.Path)
-		fmt.Printf("  Storage:  %s (%s)\n", cfg.Storage.Type, cfg.Storage.Path)
+		if len(cfg.Logs) > 0 {
+			for _, log := range cfg.Logs {
+				fmt.Printf("  Log:      %s (%s)\n", log.Name, log.Issuer)
+			}
+		} else {
+			fmt.Printf("  Issuer:   %s\n", cfg.Issuer)
+			fmt.Printf("  Database: %s\n", cfg.Database.Path)
+			fmt.Printf("  Storage:  %s (%s)\n", cfg.Storage.Type, cfg.Storage.Path)
+		}
 		fmt.Printf("  Server:   %s:%d\n", cfg.Server.Host, cfg.Server.Port)
 	}
 
+	// Configure structured logging for the service
+	logger, err := logging.New(cfg.Logging, os.Stderr)
+	if err != nil {
+		return fmt.Errorf("invalid logging configuration: %w", err)
+	}
+	slog.SetDefault(logger)
+
 	// Create server
 	srv, err := server.NewServer(cfg)
 	if err != nil {
@@ -276,7 +319,15 @@ func runServiceStart(opts *serviceStartOptions) error {
 	}
 	defer srv.Close()
 
-	// Start server (blocks until error or shutdown)
-	log.Fatal(srv.Start())
+	// Serve until SIGINT/SIGTERM, then drain in-flight requests before the
+	// deferred Close releases the database
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := srv.Run(ctx); err != nil {
+		return fmt.Errorf("server error: %w", err)
+	}
+
+	slog.Info("server stopped")
 	return nil
 }
@@ -47,6 +47,50 @@ func TestServiceDefinitionCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("has apikey subcommand", func(t *testing.T) {
+		for _, name := range []string{"create", "list", "revoke"} {
+			if _, _, err := rootCmd.Find([]string{"service", "apikey", name}); err != nil {
+				t.Errorf("apikey %s subcommand not found: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("has fsck subcommand", func(t *testing.T) {
+		fsckCmd, _, err := rootCmd.Find([]string{"service", "fsck"})
+		if err != nil || fsckCmd.Name() != "fsck" {
+			t.Fatalf("fsck subcommand not found: %v", err)
+		}
+		for _, flag := range []string{"definition", "repair", "json"} {
+			if fsckCmd.Flags().Lookup(flag) == nil {
+				t.Errorf("--%s flag not found", flag)
+			}
+		}
+	})
+
+	t.Run("has reindex subcommand", func(t *testing.T) {
+		reindexCmd, _, err := rootCmd.Find([]string{"service", "reindex"})
+		if err != nil || reindexCmd.Name() != "reindex" {
+			t.Fatalf("reindex subcommand not found: %v", err)
+		}
+		if reindexCmd.Flags().Lookup("definition") == nil {
+			t.Error("--definition flag not found")
+		}
+	})
+
+	t.Run("has export and import subcommands", func(t *testing.T) {
+		for name, flag := range map[string]string{"export": "output", "import": "input"} {
+			cmd, _, err := rootCmd.Find([]string{"service", name})
+			if err != nil || cmd.Name() != name {
+				t.Fatalf("%s subcommand not found: %v", name, err)
+			}
+			for _, f := range []string{"definition", flag} {
+				if cmd.Flags().Lookup(f) == nil {
+					t.Errorf("%s: --%s flag not found", name, f)
+				}
+			}
+		}
+	})
+
 	t.Run("has start subcommand", func(t *testing.T) {
 		found := false
 		for _, cmd := range serviceCmd.Commands() {
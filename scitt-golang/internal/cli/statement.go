@@ -314,22 +314,22 @@ func runStatementVerify(opts *statementVerifyOptions) error {
 	headers, err := cose.GetProtectedHeaders(coseSign1Struct)
 	if err == nil {
 		// Hash envelope parameters
-		if hashAlg, ok := headers[uint64(cose.HeaderLabelPayloadHashAlg)]; ok {
-			fmt.Printf("  Hash Algorithm:   SHA-256 (label %d)\n", hashAlg)
+		if hashAlg, ok := headers.PayloadHashAlg(); ok {
+			fmt.Printf("  Hash Algorithm:   %s (%d)\n", cose.HashAlgorithmName(int(hashAlg)), hashAlg)
 		}
-		if contentType, ok := headers[uint64(cose.HeaderLabelPayloadPreimageContentType)].(string); ok {
+		if contentType, ok := headers.PayloadPreimageContentType(); ok {
 			fmt.Printf("  Content Type:     %s\n", contentType)
 		}
-		if location, ok := headers[uint64(cose.HeaderLabelPayloadLocation)].(string); ok {
+		if location, ok := headers.PayloadLocation(); ok {
 			fmt.Printf("  Content Location: %s\n", location)
 		}
 
 		// CWT claims
-		if cwtClaims, ok := headers[uint64(cose.HeaderLabelCWTClaims)].(map[interface{}]interface{}); ok {
-			if iss, ok := cwtClaims[uint64(1)].(string); ok {
+		if cwtClaims, ok := headers.CWTClaims(); ok {
+			if iss, ok := cwtClaims.Iss(); ok {
 				fmt.Printf("  Issuer:           %s\n", iss)
 			}
-			if sub, ok := cwtClaims[uint64(2)].(string); ok {
+			if sub, ok := cwtClaims.Sub(); ok {
 				fmt.Printf("  Subject:          %s\n", sub)
 			}
 		}
@@ -469,16 +469,16 @@ func runStatementRegister(opts *statementRegisterOptions) error {
 
 	// Check response status
 	if resp.StatusCode == http.StatusUnauthorized {
+		bodyBytes, _ := io.ReadAll(resp.Body)
 		fmt.Printf("✗ Registration failed: Unauthorized (401)\n")
 		fmt.Printf("  The API key is invalid or missing\n")
-		return fmt.Errorf("authentication failed: invalid API key")
+		return fmt.Errorf("authentication failed: %s", describeErrorResponse(resp, bodyBytes))
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		fmt.Printf("✗ Registration failed: HTTP %d\n", resp.StatusCode)
-		fmt.Printf("  Response: %s\n", string(bodyBytes))
-		return fmt.Errorf("registration failed with status %d", resp.StatusCode)
+		printErrorResponse("Registration", resp, bodyBytes)
+		return fmt.Errorf("registration failed with status %d: %s", resp.StatusCode, describeErrorResponse(resp, bodyBytes))
 	}
 
 	// Read receipt response
@@ -492,10 +492,17 @@ func runStatementRegister(opts *statementRegisterOptions) error {
 		return fmt.Errorf("failed to write receipt file: %w", err)
 	}
 
-	fmt.Printf("✓ Statement registered successfully\n")
+	if resp.StatusCode == http.StatusOK {
+		fmt.Printf("✓ Statement already registered, existing receipt returned\n")
+	} else {
+		fmt.Printf("✓ Statement registered successfully\n")
+	}
 	fmt.Printf("  Statement:  %s (%d bytes)\n", opts.statement, len(statementBytes))
 	fmt.Printf("  Leaf Hash:  %s\n", leafHashHex)
 	fmt.Printf("  Receipt:    %s (%d bytes)\n", opts.receipt, len(receiptBytes))
+	if location := resp.Header.Get("Location"); location != "" {
+		fmt.Printf("  Entry:      %s\n", location)
+	}
 	fmt.Printf("  Service:    %s\n", opts.service)
 
 	return nil
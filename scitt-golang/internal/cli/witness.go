@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/witness"
+)
+
+// NewWitnessCommand creates the witness command
+func NewWitnessCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "witness",
+		Short: "Run a checkpoint witness",
+		Long: `Run a C2SP tlog-witness that cosigns checkpoints of transparency logs.
+
+A witness only cosigns a checkpoint that is signed by a log it knows and is
+consistent with the last checkpoint it cosigned for that log, so clients
+requiring its cosignature are protected from split views.
+
+Subcommands:
+  keygen - Generate a witness key pair
+  serve  - Serve the add-checkpoint endpoint`,
+	}
+
+	cmd.AddCommand(NewWitnessKeygenCommand())
+	cmd.AddCommand(NewWitnessServeCommand())
+
+	return cmd
+}
+
+type witnessKeygenOptions struct {
+	name            string
+	keyPath         string
+	verifierKeyPath string
+}
+
+// NewWitnessKeygenCommand creates the witness keygen command
+func NewWitnessKeygenCommand() *cobra.Command {
+	opts := &witnessKeygenOptions{
+		keyPath:         "witness.key",
+		verifierKeyPath: "witness.vkey",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate a witness key pair",
+		Long: `Generate an Ed25519 witness key pair for cosignature/v1.
+
+The verifier key (<name>+<hash>+<key>) is what logs list under
+witnessing.witnesses[].verifier_key in their service definition.
+
+Example:
+  scitt witness keygen --name witness.example.com`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWitnessKeygen(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "", "witness name used in cosignature lines (required)")
+	cmd.Flags().StringVar(&opts.keyPath, "key", opts.keyPath, "path to save the signing key")
+	cmd.Flags().StringVar(&opts.verifierKeyPath, "verifier-key", opts.verifierKeyPath, "path to save the verifier key")
+
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func runWitnessKeygen(opts *witnessKeygenOptions) error {
+	skey, vkey, err := witness.GenerateKey(opts.name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(opts.keyPath, []byte(skey+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write signing key: %w", err)
+	}
+	if err := os.WriteFile(opts.verifierKeyPath, []byte(vkey+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write verifier key: %w", err)
+	}
+
+	fmt.Printf("✓ Witness key pair generated\n")
+	fmt.Printf("  Name:         %s\n", opts.name)
+	fmt.Printf("  Verifier key: %s\n", vkey)
+	fmt.Printf("  Signing key:  %s\n", opts.keyPath)
+
+	return nil
+}
+
+type witnessServeOptions struct {
+	keyPath   string
+	logs      []string
+	statePath string
+	host      string
+	port      int
+}
+
+// NewWitnessServeCommand creates the witness serve command
+func NewWitnessServeCommand() *cobra.Command {
+	opts := &witnessServeOptions{
+		statePath: "witness-state.json",
+		host:      "127.0.0.1",
+		port:      7380,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the add-checkpoint endpoint",
+		Long: `Serve POST /add-checkpoint for the given logs.
+
+Each --log maps a checkpoint origin (the log's issuer URL, the first line of its
+checkpoints) to the log's public key file (COSE Key CBOR or JWK). The latest
+cosigned size of each log is persisted in the state file so the witness never
+cosigns a checkpoint inconsistent with one it cosigned before.
+
+Example:
+  scitt witness serve \
+    --key witness.key \
+    --log http://127.0.0.1:56177=./demo/pub.cbor \
+    --state witness-state.json \
+    --port 7380`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWitnessServe(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.keyPath, "key", "", "path to the witness signing key (required)")
+	cmd.Flags().StringArrayVar(&opts.logs, "log", nil, "log to witness as <origin>=<public key file> (repeatable, required)")
+	cmd.Flags().StringVar(&opts.statePath, "state", opts.statePath, "path to the witness state file")
+	cmd.Flags().StringVar(&opts.host, "host", opts.host, "host to bind")
+	cmd.Flags().IntVar(&opts.port, "port", opts.port, "port to listen on")
+
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("log")
+
+	return cmd
+}
+
+func runWitnessServe(opts *witnessServeOptions) error {
+	skey, err := os.ReadFile(opts.keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read witness key: %w", err)
+	}
+	signer, err := witness.NewSigner(string(skey))
+	if err != nil {
+		return err
+	}
+
+	logs := make(map[string]*ecdsa.PublicKey, len(opts.logs))
+	for _, spec := range opts.logs {
+		// Split on the last '=' since origins are URLs and may contain one
+		idx := strings.LastIndex(spec, "=")
+		if idx <= 0 || idx == len(spec)-1 {
+			return fmt.Errorf("invalid --log %q (expected <origin>=<public key file>)", spec)
+		}
+		publicKey, err := loadLogPublicKey(spec[idx+1:])
+		if err != nil {
+			return err
+		}
+		logs[spec[:idx]] = publicKey
+	}
+
+	w, err := witness.New(witness.Config{
+		Signer:    signer,
+		Logs:      logs,
+		StatePath: opts.statePath,
+	})
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{
+		Addr:              net.JoinHostPort(opts.host, strconv.Itoa(opts.port)),
+		Handler:           w.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	fmt.Printf("✓ Witness %s listening on http://%s\n", signer.Name(), httpServer.Addr)
+	fmt.Printf("  Verifier key: %s\n", signer.VerifierKey())
+	for origin := range logs {
+		fmt.Printf("  Log:          %s\n", origin)
+	}
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("witness server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down witness: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("witness server error: %w", err)
+	}
+
+	slog.Info("witness stopped")
+	return nil
+}
+
+// loadLogPublicKey loads a log's checkpoint verification key from a COSE Key (.cbor) or JWK file
+func loadLogPublicKey(path string) (*ecdsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log public key: %w", err)
+	}
+
+	if strings.HasSuffix(path, ".cbor") {
+		publicKey, err := cose.ImportPublicKeyFromCOSECBOR(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import CBOR public key: %w", err)
+		}
+		return publicKey, nil
+	}
+
+	jwk, err := cose.UnmarshalJWK(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JWK: %w", err)
+	}
+	publicKey, err := cose.ImportPublicKeyFromJWK(jwk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import JWK public key: %w", err)
+	}
+	return publicKey, nil
+}
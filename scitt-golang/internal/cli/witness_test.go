@@ -0,0 +1,65 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/cli"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/witness"
+)
+
+func TestWitnessCommand(t *testing.T) {
+	rootCmd := cli.NewRootCommand("test", "abc123", "2024-01-01")
+	witnessCmd, _, err := rootCmd.Find([]string{"witness"})
+	if err != nil || witnessCmd.Name() != "witness" {
+		t.Fatalf("failed to find witness command: %v", err)
+	}
+
+	for _, name := range []string{"keygen", "serve"} {
+		found := false
+		for _, cmd := range witnessCmd.Commands() {
+			if cmd.Name() == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s subcommand not found", name)
+		}
+	}
+}
+
+func TestWitnessKeygen(t *testing.T) {
+	t.Run("generates a matching key pair", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		keyPath := filepath.Join(tmpDir, "witness.key")
+		vkeyPath := filepath.Join(tmpDir, "witness.vkey")
+
+		rootCmd := cli.NewRootCommand("test", "abc123", "2024-01-01")
+		rootCmd.SetArgs([]string{"witness", "keygen", "--name", "witness.example.com", "--key", keyPath, "--verifier-key", vkeyPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("failed to execute command: %v", err)
+		}
+
+		skey, err := os.ReadFile(keyPath)
+		if err != nil {
+			t.Fatalf("failed to read signing key: %v", err)
+		}
+		vkey, err := os.ReadFile(vkeyPath)
+		if err != nil {
+			t.Fatalf("failed to read verifier key: %v", err)
+		}
+
+		signer, err := witness.NewSigner(string(skey))
+		if err != nil {
+			t.Fatalf("failed to parse signing key: %v", err)
+		}
+		if _, err := witness.ParseVerifierKey(string(vkey)); err != nil {
+			t.Fatalf("failed to parse verifier key: %v", err)
+		}
+		if signer.VerifierKey()+"\n" != string(vkey) {
+			t.Errorf("verifier key does not match signing key")
+		}
+	})
+}
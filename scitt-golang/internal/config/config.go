@@ -5,12 +5,19 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the SCITT service configuration
 type Config struct {
+	// Name identifies the log in metrics labels (set per log when several are hosted)
+	Name string `yaml:"name,omitempty"`
+
 	// Issuer is the transparency service URL
 	Issuer string `yaml:"issuer"`
 
@@ -25,6 +32,61 @@ type Config struct {
 
 	// HTTP server configuration
 	Server ServerConfig `yaml:"server"`
+
+	// Registration policy
+	Registration RegistrationConfig `yaml:"registration"`
+
+	// Claims added to receipts
+	Receipts ReceiptsConfig `yaml:"receipts,omitempty"`
+
+	// Client authentication (in addition to API keys)
+	Auth AuthConfig `yaml:"auth,omitempty"`
+
+	// Logging configuration
+	Logging LoggingConfig `yaml:"logging,omitempty"`
+
+	// Witnessing submits new checkpoints to external witnesses for cosigning
+	Witnessing *WitnessingConfig `yaml:"witnessing,omitempty"`
+
+	// Mirror serves a read-only replica of another log, kept up to date by "scitt mirror"
+	Mirror *MirrorConfig `yaml:"mirror,omitempty"`
+
+	// Sharding splits the log into temporal shards, freezing each when its interval ends
+	Sharding *ShardingConfig `yaml:"sharding,omitempty"`
+
+	// Logs hosts several independent logs in one server process; the issuer,
+	// database, storage, keys and registration settings above are then unused
+	Logs []LogConfig `yaml:"logs,omitempty"`
+}
+
+// LogConfig represents one log hosted by a multi-log server
+// The server, auth and logging settings of the definition are shared by every log
+type LogConfig struct {
+	// Name identifies the log in metrics labels and --log flags
+	Name string `yaml:"name"`
+
+	// Requests are routed to the log by path prefix (e.g. "/staging"), Host header, or both
+	PathPrefix string `yaml:"path_prefix,omitempty"`
+	Host       string `yaml:"host,omitempty"`
+
+	Issuer       string             `yaml:"issuer"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Storage      StorageConfig      `yaml:"storage"`
+	Keys         KeysConfig         `yaml:"keys"`
+	Registration RegistrationConfig `yaml:"registration"`
+	Receipts     ReceiptsConfig     `yaml:"receipts,omitempty"`
+
+	// APIKey is the log's service-wide key; client keys are stored in the log's database
+	APIKey string `yaml:"api_key"`
+
+	Witnessing *WitnessingConfig `yaml:"witnessing,omitempty"`
+	Sharding   *ShardingConfig   `yaml:"sharding,omitempty"`
+}
+
+// LoggingConfig represents structured logging configuration
+type LoggingConfig struct {
+	Level  string `yaml:"level,omitempty"`  // "debug", "info" (default), "warn" or "error"
+	Format string `yaml:"format,omitempty"` // "text" (default) or "json"
 }
 
 // DatabaseConfig represents database configuration
@@ -53,16 +115,184 @@ type S3Config struct {
 
 // KeysConfig represents service key configuration
 type KeysConfig struct {
-	Private string `yaml:"private"` // Path to private key (PEM)
+	Private string `yaml:"private"` // Path to private key (PEM; not used by a mirror)
 	Public  string `yaml:"public"`  // Path to public key (JWK)
 }
 
 // ServerConfig represents HTTP server configuration
 type ServerConfig struct {
-	Host   string     `yaml:"host"`
-	Port   int        `yaml:"port"`
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+
+	// APIKey is the service-wide key with every scope; per-client keys are
+	// managed with "scitt service apikey" and stored hashed in the database
 	APIKey string     `yaml:"api_key"`
 	CORS   CORSConfig `yaml:"cors"`
+
+	// Timeouts and request limits (zero values use the defaults)
+	Timeouts        TimeoutsConfig `yaml:"timeouts"`
+	MaxRequestBytes int64          `yaml:"max_request_bytes"`
+
+	// TLS configuration (plain HTTP when omitted)
+	TLS *TLSConfig `yaml:"tls,omitempty"`
+
+	// RequireReadAuth requires a credential with the read scope for receipts and lookups
+	RequireReadAuth bool `yaml:"require_read_auth,omitempty"`
+
+	// Health configures the readiness checks served at /health/ready
+	Health HealthConfig `yaml:"health,omitempty"`
+}
+
+// HealthConfig represents readiness check configuration
+type HealthConfig struct {
+	// MaxCheckpointAge marks the service not ready when the tree head has not
+	// advanced for longer than this (zero disables the check)
+	MaxCheckpointAge time.Duration `yaml:"max_checkpoint_age,omitempty"`
+}
+
+// TimeoutsConfig represents HTTP server timeouts
+type TimeoutsConfig struct {
+	Read       time.Duration `yaml:"read"`
+	ReadHeader time.Duration `yaml:"read_header"`
+	Write      time.Duration `yaml:"write"`
+	Idle       time.Duration `yaml:"idle"`
+
+	// Shutdown bounds how long in-flight requests are drained on SIGINT/SIGTERM
+	Shutdown time.Duration `yaml:"shutdown"`
+}
+
+// TLSConfig represents native TLS configuration
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"` // Path to server certificate (PEM)
+	KeyFile  string `yaml:"key_file"`  // Path to server private key (PEM)
+
+	// ClientCAFile enables mutual TLS for the registration endpoint:
+	// POST /entries requires a client certificate issued by this CA (PEM)
+	ClientCAFile string `yaml:"client_ca_file,omitempty"`
+}
+
+// DefaultMaxRequestBytes is the default, and largest, limit on registration
+// request bodies: the largest statement an entry bundle can hold (64 KiB - 1)
+const DefaultMaxRequestBytes int64 = merkle.MaxEntrySize
+
+// DefaultTimeouts returns the default HTTP server timeouts
+func DefaultTimeouts() TimeoutsConfig {
+	return TimeoutsConfig{
+		Read:       30 * time.Second,
+		ReadHeader: 10 * time.Second,
+		Write:      30 * time.Second,
+		Idle:       120 * time.Second,
+		Shutdown:   30 * time.Second,
+	}
+}
+
+// WithDefaults returns the timeouts with zero values replaced by defaults
+func (t TimeoutsConfig) WithDefaults() TimeoutsConfig {
+	defaults := DefaultTimeouts()
+	if t.Read == 0 {
+		t.Read = defaults.Read
+	}
+	if t.ReadHeader == 0 {
+		t.ReadHeader = defaults.ReadHeader
+	}
+	if t.Write == 0 {
+		t.Write = defaults.Write
+	}
+	if t.Idle == 0 {
+		t.Idle = defaults.Idle
+	}
+	if t.Shutdown == 0 {
+		t.Shutdown = defaults.Shutdown
+	}
+	return t
+}
+
+// RegistrationConfig represents the statement registration policy
+type RegistrationConfig struct {
+	// AllowDuplicates appends a new log entry when an identical statement is
+	// re-submitted, instead of returning the existing entry's receipt
+	AllowDuplicates bool `yaml:"allow_duplicates"`
+}
+
+// ReceiptsConfig represents the CWT claims a log adds to its receipts
+// Every receipt carries iss, sub (the statement's subject or entry ID) and,
+// when the entry's metadata is indexed, iat (its registration time)
+type ReceiptsConfig struct {
+	// CTI adds a random CWT ID (cti) to each receipt
+	CTI bool `yaml:"cti,omitempty"`
+
+	// Claims are extra CWT claims keyed by integer label, with string,
+	// integer or boolean values (iss, sub, iat and cti are reserved)
+	Claims map[int64]interface{} `yaml:"claims,omitempty"`
+}
+
+// AuthConfig represents client authentication configuration
+type AuthConfig struct {
+	// OIDC accepts JWT bearer tokens from an OpenID Connect provider
+	OIDC *OIDCConfig `yaml:"oidc,omitempty"`
+}
+
+// OIDCConfig represents JWT bearer token validation
+type OIDCConfig struct {
+	Issuer   string `yaml:"issuer"`   // Required token iss claim
+	Audience string `yaml:"audience"` // Required token aud claim
+
+	// Verification keys, loaded from a local JWKS file or fetched from a URL
+	JWKSFile    string        `yaml:"jwks_file,omitempty"`
+	JWKSURL     string        `yaml:"jwks_url,omitempty"`
+	JWKSRefresh time.Duration `yaml:"jwks_refresh,omitempty"`
+
+	// Scopes granted to valid tokens (default: register)
+	Scopes []string `yaml:"scopes,omitempty"`
+
+	// ClaimMapping constrains the statements a token may register
+	ClaimMapping ClaimMappingConfig `yaml:"claim_mapping,omitempty"`
+}
+
+// ClaimMappingConfig maps token claims to allowed statement issuers and subjects
+//
+// Patterns may reference token claims as {claim} and use * as a wildcard, e.g.
+// statement_subject: "pkg:github/{repository}@*". Empty patterns allow any value.
+type ClaimMappingConfig struct {
+	StatementIssuer  string `yaml:"statement_issuer,omitempty"`
+	StatementSubject string `yaml:"statement_subject,omitempty"`
+}
+
+// WitnessingConfig represents checkpoint cosigning by C2SP tlog-witness witnesses
+type WitnessingConfig struct {
+	Witnesses []WitnessConfig `yaml:"witnesses"`
+
+	// Timeout bounds each submission to a witness (default 10s)
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// WitnessConfig identifies one witness
+type WitnessConfig struct {
+	URL         string `yaml:"url"`          // Base URL; checkpoints are POSTed to <url>/add-checkpoint
+	VerifierKey string `yaml:"verifier_key"` // <name>+<hash>+<key>, as printed by scitt witness keygen
+}
+
+// MirrorConfig represents replication of another transparency service
+// The log's tiles and checkpoints are copied into this service's storage, and
+// keys.public must be the mirrored log's checkpoint verification key
+type MirrorConfig struct {
+	Source string `yaml:"source"` // Base URL of the mirrored service
+
+	// Interval between syncs when "scitt mirror" runs continuously (default 1m)
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// Shard intervals
+const (
+	ShardIntervalYearly  = "yearly"
+	ShardIntervalMonthly = "monthly"
+)
+
+// ShardingConfig represents temporal log sharding
+// Each shard is a separate log with its own origin, tree and checkpoints; when
+// its interval ends a final checkpoint is published and the shard is frozen
+type ShardingConfig struct {
+	Interval string `yaml:"interval"` // "yearly" or "monthly" (UTC)
 }
 
 // CORSConfig represents CORS configuration
@@ -93,6 +323,10 @@ func LoadConfig(path string) (*Config, error) {
 
 // Validate validates the configuration
 func (c *Config) Validate() error {
+	if len(c.Logs) > 0 {
+		return c.validateLogs()
+	}
+
 	if c.Issuer == "" {
 		return fmt.Errorf("issuer is required")
 	}
@@ -113,7 +347,7 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("S3 configuration is required for S3 storage")
 	}
 
-	if c.Keys.Private == "" {
+	if c.Keys.Private == "" && c.Mirror == nil {
 		return fmt.Errorf("private key path is required")
 	}
 
@@ -125,9 +359,175 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
 
+	if c.Server.MaxRequestBytes < 0 || c.Server.MaxRequestBytes > DefaultMaxRequestBytes {
+		return fmt.Errorf("invalid max request bytes: %d (entry bundles hold statements of at most %d bytes)", c.Server.MaxRequestBytes, DefaultMaxRequestBytes)
+	}
+
+	if c.Server.Health.MaxCheckpointAge < 0 {
+		return fmt.Errorf("invalid max checkpoint age: %s", c.Server.Health.MaxCheckpointAge)
+	}
+
+	if c.Server.TLS != nil && (c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "") {
+		return fmt.Errorf("TLS requires both cert_file and key_file")
+	}
+
+	switch c.Logging.Level {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("invalid log level: %s (expected debug, info, warn or error)", c.Logging.Level)
+	}
+
+	switch c.Logging.Format {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("invalid log format: %s (expected text or json)", c.Logging.Format)
+	}
+
+	for label, value := range c.Receipts.Claims {
+		switch label {
+		case 1, 2, 6, 7:
+			return fmt.Errorf("receipt claim %d is set by the service", label)
+		}
+		switch value.(type) {
+		case string, int, int64, bool:
+		default:
+			return fmt.Errorf("receipt claim %d must be a string, integer or boolean", label)
+		}
+	}
+
+	if oidc := c.Auth.OIDC; oidc != nil {
+		if oidc.Issuer == "" || oidc.Audience == "" {
+			return fmt.Errorf("OIDC requires issuer and audience")
+		}
+		if (oidc.JWKSFile == "") == (oidc.JWKSURL == "") {
+			return fmt.Errorf("OIDC requires exactly one of jwks_file or jwks_url")
+		}
+	}
+
+	if w := c.Witnessing; w != nil {
+		if w.Timeout < 0 {
+			return fmt.Errorf("invalid witness timeout: %s", w.Timeout)
+		}
+		for i, witness := range w.Witnesses {
+			if witness.URL == "" || witness.VerifierKey == "" {
+				return fmt.Errorf("witness %d requires url and verifier_key", i)
+			}
+		}
+	}
+
+	if m := c.Mirror; m != nil {
+		if m.Source == "" {
+			return fmt.Errorf("mirror requires source")
+		}
+		if m.Interval < 0 {
+			return fmt.Errorf("invalid mirror interval: %s", m.Interval)
+		}
+		if c.Witnessing != nil {
+			return fmt.Errorf("a mirror cannot submit checkpoints to witnesses")
+		}
+	}
+
+	if sh := c.Sharding; sh != nil {
+		switch sh.Interval {
+		case ShardIntervalYearly, ShardIntervalMonthly:
+		default:
+			return fmt.Errorf("invalid shard interval: %s (expected yearly or monthly)", sh.Interval)
+		}
+		if c.Mirror != nil {
+			return fmt.Errorf("a mirror cannot be sharded")
+		}
+	}
+
+	return nil
+}
+
+// validateLogs validates a multi-log configuration and the definition of each log
+func (c *Config) validateLogs() error {
+	if c.Mirror != nil {
+		return fmt.Errorf("a mirror cannot host several logs")
+	}
+
+	names := make(map[string]bool)
+	prefixes := make(map[string]bool)
+	hosts := make(map[string]bool)
+	for i, log := range c.Logs {
+		if !validLogName.MatchString(log.Name) {
+			return fmt.Errorf("log %d: invalid name %q (lowercase letters, digits and dashes)", i, log.Name)
+		}
+		if names[log.Name] {
+			return fmt.Errorf("log %s: duplicate name", log.Name)
+		}
+		names[log.Name] = true
+
+		if log.PathPrefix == "" && log.Host == "" {
+			return fmt.Errorf("log %s: path_prefix or host is required", log.Name)
+		}
+		if log.PathPrefix != "" {
+			if !strings.HasPrefix(log.PathPrefix, "/") || strings.HasSuffix(log.PathPrefix, "/") {
+				return fmt.Errorf("log %s: path_prefix must start and not end with /: %q", log.Name, log.PathPrefix)
+			}
+			if prefixes[log.PathPrefix] {
+				return fmt.Errorf("log %s: duplicate path_prefix %s", log.Name, log.PathPrefix)
+			}
+			prefixes[log.PathPrefix] = true
+		}
+		if log.Host != "" {
+			if hosts[log.Host] {
+				return fmt.Errorf("log %s: duplicate host %s", log.Name, log.Host)
+			}
+			hosts[log.Host] = true
+		}
+
+		if err := c.ForLog(log).Validate(); err != nil {
+			return fmt.Errorf("log %s: %w", log.Name, err)
+		}
+	}
+
 	return nil
 }
 
+// validLogName matches the names of hosted logs
+var validLogName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// ForLog returns the definition of one hosted log: the shared server, auth and
+// logging settings with the log's own issuer, storage, keys and policy
+func (c *Config) ForLog(log LogConfig) *Config {
+	cfg := *c
+	cfg.Logs = nil
+	cfg.Name = log.Name
+	cfg.Issuer = log.Issuer
+	cfg.Database = log.Database
+	cfg.Storage = log.Storage
+	cfg.Keys = log.Keys
+	cfg.Registration = log.Registration
+	cfg.Receipts = log.Receipts
+	cfg.Server.APIKey = log.APIKey
+	cfg.Witnessing = log.Witnessing
+	cfg.Sharding = log.Sharding
+	return &cfg
+}
+
+// Log returns the definition of the hosted log with the given name
+// A single-log definition is returned as is when name is empty
+func (c *Config) Log(name string) (*Config, error) {
+	if len(c.Logs) == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("the definition does not host several logs")
+		}
+		return c, nil
+	}
+
+	for _, log := range c.Logs {
+		if log.Name == name {
+			return c.ForLog(log), nil
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the definition hosts several logs; select one with --log")
+	}
+	return nil, fmt.Errorf("log %s is not defined", name)
+}
+
 // DefaultConfig returns a default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -151,6 +551,12 @@ func DefaultConfig() *Config {
 				Enabled:        true,
 				AllowedOrigins: []string{"*"},
 			},
+			Timeouts:        DefaultTimeouts(),
+			MaxRequestBytes: DefaultMaxRequestBytes,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
 		},
 	}
 }
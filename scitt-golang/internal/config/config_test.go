@@ -3,7 +3,9 @@ package config_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
 )
@@ -110,6 +112,202 @@ func TestConfigValidation(t *testing.T) {
 		}
 	})
 
+	t.Run("rejects TLS without key file", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Server.TLS = &config.TLSConfig{CertFile: "server.crt"}
+
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject TLS without key file")
+		}
+
+		cfg.Server.TLS.KeyFile = "server.key"
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("TLS with cert and key should be valid: %v", err)
+		}
+	})
+
+	t.Run("rejects incomplete OIDC config", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Auth.OIDC = &config.OIDCConfig{Issuer: "https://idp.example.com", JWKSFile: "jwks.json"}
+
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject OIDC without audience")
+		}
+
+		cfg.Auth.OIDC.Audience = "scitt"
+		cfg.Auth.OIDC.JWKSURL = "https://idp.example.com/jwks"
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject OIDC with both jwks_file and jwks_url")
+		}
+
+		cfg.Auth.OIDC.JWKSURL = ""
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("OIDC with issuer, audience and JWKS should be valid: %v", err)
+		}
+	})
+
+	t.Run("rejects incomplete witness config", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Witnessing = &config.WitnessingConfig{
+			Witnesses: []config.WitnessConfig{{URL: "http://127.0.0.1:7380"}},
+		}
+
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject witness without verifier key")
+		}
+
+		cfg.Witnessing.Witnesses[0].VerifierKey = "witness+01020304+BA=="
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("witness with url and verifier key should be valid: %v", err)
+		}
+	})
+
+	t.Run("rejects incomplete mirror config", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Mirror = &config.MirrorConfig{}
+
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject mirror without source")
+		}
+
+		cfg.Mirror.Source = "https://transparency.example"
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("mirror with source should be valid: %v", err)
+		}
+
+		cfg.Keys.Private = ""
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("mirror without a private key should be valid: %v", err)
+		}
+
+		cfg.Witnessing = &config.WitnessingConfig{}
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject a mirror with witnessing")
+		}
+	})
+
+	t.Run("rejects invalid sharding config", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Sharding = &config.ShardingConfig{Interval: "weekly"}
+
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject unknown shard interval")
+		}
+
+		cfg.Sharding.Interval = config.ShardIntervalYearly
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("yearly sharding should be valid: %v", err)
+		}
+
+		cfg.Mirror = &config.MirrorConfig{Source: "https://transparency.example"}
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject a sharded mirror")
+		}
+	})
+
+	t.Run("validates hosted logs", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Issuer = ""
+		cfg.Logs = []config.LogConfig{
+			{
+				Name:       "production",
+				PathPrefix: "/production",
+				Issuer:     "https://ts.example/production",
+				Database:   config.DatabaseConfig{Path: "production.db"},
+				Storage:    config.StorageConfig{Type: "memory"},
+				Keys:       config.KeysConfig{Private: "priv.cbor", Public: "pub.cbor"},
+			},
+			{
+				Name:     "staging",
+				Host:     "staging.ts.example",
+				Issuer:   "https://staging.ts.example",
+				Database: config.DatabaseConfig{Path: "staging.db"},
+				Storage:  config.StorageConfig{Type: "memory"},
+				Keys:     config.KeysConfig{Private: "priv.cbor", Public: "pub.cbor"},
+			},
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("hosted logs should be valid: %v", err)
+		}
+
+		invalid := []func(logs []config.LogConfig){
+			func(logs []config.LogConfig) { logs[1].Name = "production" },
+			func(logs []config.LogConfig) { logs[1].Name = "Staging" },
+			func(logs []config.LogConfig) { logs[1].Host = "" },
+			func(logs []config.LogConfig) { logs[1].PathPrefix = "/production" },
+			func(logs []config.LogConfig) { logs[0].PathPrefix = "/production/" },
+			func(logs []config.LogConfig) { logs[1].Issuer = "" },
+		}
+		for i, modify := range invalid {
+			broken := *cfg
+			broken.Logs = append([]config.LogConfig(nil), cfg.Logs...)
+			modify(broken.Logs)
+			if err := broken.Validate(); err == nil {
+				t.Errorf("case %d: expected invalid hosted logs", i)
+			}
+		}
+	})
+
+	t.Run("rejects max request bytes beyond an entry bundle", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Server.MaxRequestBytes = config.DefaultMaxRequestBytes + 1
+
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject statements larger than an entry bundle holds")
+		}
+
+		cfg.Server.MaxRequestBytes = config.DefaultMaxRequestBytes
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected the largest entry size to be accepted: %v", err)
+		}
+	})
+
+	t.Run("rejects negative max checkpoint age", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Server.Health.MaxCheckpointAge = -time.Second
+
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject negative max checkpoint age")
+		}
+	})
+
+	t.Run("rejects invalid logging config", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Logging.Level = "verbose"
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject unknown log level")
+		}
+
+		cfg.Logging.Level = "debug"
+		cfg.Logging.Format = "xml"
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject unknown log format")
+		}
+
+		cfg.Logging.Format = "json"
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("debug JSON logging should be valid: %v", err)
+		}
+	})
+
+	t.Run("rejects reserved or structured receipt claims", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Receipts.Claims = map[int64]interface{}{-70001: "build-farm", 5: 1700000000}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("extra receipt claims should be valid: %v", err)
+		}
+
+		cfg.Receipts.Claims = map[int64]interface{}{6: 1700000000}
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject overriding iat")
+		}
+
+		cfg.Receipts.Claims = map[int64]interface{}{-70001: []interface{}{"a"}}
+		if err := cfg.Validate(); err == nil {
+			t.Error("should reject a structured claim value")
+		}
+	})
+
 	t.Run("accepts valid config", func(t *testing.T) {
 		cfg := &config.Config{
 			Issuer: "https://example.com",
@@ -138,6 +336,49 @@ func TestConfigValidation(t *testing.T) {
 	})
 }
 
+// TestLogDefinitions tests deriving the definition of one hosted log
+func TestLogDefinitions(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Server.APIKey = "shared"
+	cfg.Logs = []config.LogConfig{{
+		Name:         "staging",
+		PathPrefix:   "/staging",
+		Issuer:       "https://ts.example/staging",
+		Database:     config.DatabaseConfig{Path: "staging.db"},
+		Storage:      config.StorageConfig{Type: "memory"},
+		Registration: config.RegistrationConfig{AllowDuplicates: true},
+		APIKey:       "staging-key",
+	}}
+
+	t.Run("combines shared server settings with the log", func(t *testing.T) {
+		log, err := cfg.Log("staging")
+		if err != nil {
+			t.Fatalf("failed to get log: %v", err)
+		}
+		if log.Name != "staging" || log.Issuer != "https://ts.example/staging" || log.Database.Path != "staging.db" {
+			t.Errorf("unexpected log definition: %+v", log)
+		}
+		if log.Server.Port != cfg.Server.Port || log.Server.APIKey != "staging-key" || !log.Registration.AllowDuplicates {
+			t.Errorf("unexpected server settings: %+v", log.Server)
+		}
+		if len(log.Logs) != 0 {
+			t.Error("expected a single-log definition")
+		}
+	})
+
+	t.Run("requires a known log name", func(t *testing.T) {
+		if _, err := cfg.Log(""); err == nil {
+			t.Error("expected an error without a log name")
+		}
+		if _, err := cfg.Log("production"); err == nil {
+			t.Error("expected an error for an unknown log")
+		}
+		if _, err := config.DefaultConfig().Log("staging"); err == nil {
+			t.Error("expected an error selecting a log of a single-log definition")
+		}
+	})
+}
+
 // TestConfigSaveLoad tests saving and loading configuration
 func TestConfigSaveLoad(t *testing.T) {
 	t.Run("can save and load config", func(t *testing.T) {
@@ -265,3 +506,54 @@ func TestCORSConfig(t *testing.T) {
 		}
 	})
 }
+
+// TestTimeoutsConfig tests HTTP server timeout configuration
+func TestTimeoutsConfig(t *testing.T) {
+	t.Run("fills zero values with defaults", func(t *testing.T) {
+		timeouts := config.TimeoutsConfig{Write: 5 * time.Second}.WithDefaults()
+
+		if timeouts.Write != 5*time.Second {
+			t.Errorf("expected configured write timeout to be kept, got %s", timeouts.Write)
+		}
+
+		if timeouts.Read != config.DefaultTimeouts().Read {
+			t.Errorf("expected default read timeout, got %s", timeouts.Read)
+		}
+
+		if timeouts.Shutdown == 0 {
+			t.Error("expected non-zero shutdown timeout")
+		}
+	})
+
+	t.Run("parses durations from YAML", func(t *testing.T) {
+		tempDir := t.TempDir()
+		configPath := filepath.Join(tempDir, "config.yaml")
+
+		original := config.DefaultConfig()
+		original.Server.Timeouts.Read = 45 * time.Second
+		if err := config.SaveConfig(original, configPath); err != nil {
+			t.Fatalf("failed to save config: %v", err)
+		}
+
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatalf("failed to read config: %v", err)
+		}
+		if !strings.Contains(string(data), "read: 45s") {
+			t.Errorf("expected human-readable duration in YAML, got:\n%s", data)
+		}
+
+		loaded, err := config.LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		if loaded.Server.Timeouts.Read != 45*time.Second {
+			t.Errorf("expected read timeout 45s, got %s", loaded.Server.Timeouts.Read)
+		}
+
+		if loaded.Server.MaxRequestBytes != config.DefaultMaxRequestBytes {
+			t.Errorf("expected max request bytes %d, got %d", config.DefaultMaxRequestBytes, loaded.Server.MaxRequestBytes)
+		}
+	})
+}
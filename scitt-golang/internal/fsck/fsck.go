@@ -0,0 +1,801 @@
+// Package fsck checks that a log's hash tiles, entry bundles, statement metadata
+// and recorded checkpoints agree, and repairs the inconsistencies that can be
+// recovered
+package fsck
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// Finding kinds
+const (
+	KindTile       = "tile"       // Hash tile is missing, torn, truncated or oversized
+	KindTreeSize   = "tree-size"  // current_tree_size disagrees with the tiles
+	KindLeaf       = "leaf"       // A leaf is missing or disagrees with its statement
+	KindStatement  = "statement"  // A statements row is malformed or outside the tree
+	KindCheckpoint = "checkpoint" // A recorded checkpoint is invalid or missing from storage
+	KindTreeState  = "tree-state" // The persisted compact range disagrees with the tiles
+	KindBundle     = "bundle"     // An entry bundle is malformed or disagrees with the leaves
+)
+
+// Finding is one inconsistency
+type Finding struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+	Repair string `json:"repair,omitempty"` // What --repair does about it (empty when not recoverable)
+}
+
+// Report is the outcome of a check
+type Report struct {
+	TreeSize    int64     `json:"tree_size"`
+	RootHash    string    `json:"root_hash,omitempty"` // Hex; empty when leaves are missing
+	Leaves      int64     `json:"leaves"`              // Leaf hashes found in hash tiles
+	Statements  int       `json:"statements"`
+	Checkpoints int       `json:"checkpoints"`
+	Findings    []Finding `json:"findings"`
+	Repaired    []Finding `json:"repaired,omitempty"`
+}
+
+// OK reports whether no inconsistencies remain
+func (r *Report) OK() bool {
+	return len(r.Findings) == 0
+}
+
+// Repairable returns the number of findings --repair would fix
+func (r *Report) Repairable() int {
+	var n int
+	for _, f := range r.Findings {
+		if f.Repair != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Config configures a check
+type Config struct {
+	DB        *sql.DB
+	Storage   storage.Storage
+	PublicKey *ecdsa.PublicKey // Checkpoint verification key
+	Repair    bool             // Apply recoverable repairs (otherwise only report them)
+}
+
+// Run checks the log and, with Repair, fixes what it can and checks again
+// The service must not be running while repairs are applied
+func Run(cfg Config) (*Report, error) {
+	report, plan, err := check(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Repair || report.Repairable() == 0 {
+		return report, nil
+	}
+
+	if err := plan.apply(cfg); err != nil {
+		return nil, err
+	}
+
+	final, _, err := check(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range report.Findings {
+		if f.Repair != "" {
+			final.Repaired = append(final.Repaired, f)
+		}
+	}
+	return final, nil
+}
+
+// repairPlan collects the repairs for the findings of one check
+type repairPlan struct {
+	treeSize    *int64            // New current_tree_size
+	tiles       map[int64][]byte  // Hash tile contents to write (nil deletes the tile)
+	bundles     map[int64][]byte  // Entry bundle contents to write (nil deletes the bundle)
+	checkpoints map[string][]byte // Storage keys to rewrite from tree_state
+	treeState   *int64            // Tree size to rebuild the compact range at
+	hashOnly    bool              // The rebuilt tree state stays hash-only
+}
+
+// apply writes the planned repairs
+func (p *repairPlan) apply(cfg Config) error {
+	indices := make([]int64, 0, len(p.tiles))
+	for index := range p.tiles {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	for _, index := range indices {
+		key := merkle.HashTileIndexToPath(index, nil)
+		if data := p.tiles[index]; data != nil {
+			if err := cfg.Storage.Put(key, data); err != nil {
+				return fmt.Errorf("failed to rewrite hash tile: %w", err)
+			}
+		} else if err := cfg.Storage.Delete(key); err != nil {
+			return fmt.Errorf("failed to delete hash tile: %w", err)
+		}
+	}
+
+	for index, data := range p.bundles {
+		key := merkle.EntryTileIndexToPath(index, nil)
+		if data != nil {
+			if err := cfg.Storage.Put(key, data); err != nil {
+				return fmt.Errorf("failed to rewrite entry bundle: %w", err)
+			}
+		} else if err := cfg.Storage.Delete(key); err != nil {
+			return fmt.Errorf("failed to delete entry bundle: %w", err)
+		}
+	}
+
+	if p.treeSize != nil {
+		if err := database.SetCurrentTreeSize(cfg.DB, *p.treeSize); err != nil {
+			return err
+		}
+	}
+
+	for key, note := range p.checkpoints {
+		if err := cfg.Storage.Put(key, note); err != nil {
+			return fmt.Errorf("failed to rewrite checkpoint: %w", err)
+		}
+	}
+
+	if p.treeState != nil {
+		tree := merkle.NewTileLog(cfg.Storage)
+		if err := tree.Rebuild(*p.treeState); err != nil {
+			return fmt.Errorf("failed to rebuild tree state: %w", err)
+		}
+		if p.hashOnly {
+			if err := tree.DropBundles(); err != nil {
+				return fmt.Errorf("failed to rebuild tree state: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// leafSlot is a leaf position recovered from the hash tiles
+type leafSlot struct {
+	hash  [merkle.HashSize]byte
+	known bool
+}
+
+// checker accumulates the state of one check
+type checker struct {
+	cfg    Config
+	report *Report
+	plan   *repairPlan
+
+	tiles      map[int64][]byte // Stored hash tiles by index
+	leaves     []leafSlot       // Leaf positions as laid out in the tiles
+	statements map[int64]string // Statement hash by entry ID
+}
+
+func (c *checker) add(kind, detail, repair string) {
+	c.report.Findings = append(c.report.Findings, Finding{Kind: kind, Detail: detail, Repair: repair})
+}
+
+// check inspects the log without modifying it
+func check(cfg Config) (*Report, *repairPlan, error) {
+	treeSize, err := database.GetCurrentTreeSize(cfg.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := &checker{
+		cfg:    cfg,
+		report: &Report{TreeSize: treeSize, Findings: []Finding{}},
+		plan: &repairPlan{
+			tiles:       make(map[int64][]byte),
+			bundles:     make(map[int64][]byte),
+			checkpoints: make(map[string][]byte),
+		},
+		tiles:      make(map[int64][]byte),
+		statements: make(map[int64]string),
+	}
+
+	if err := c.loadTiles(); err != nil {
+		return nil, nil, err
+	}
+	if err := c.loadStatements(); err != nil {
+		return nil, nil, err
+	}
+
+	hashOnly, err := c.hashOnly()
+	if err != nil {
+		return nil, nil, err
+	}
+	c.plan.hashOnly = hashOnly
+
+	target := c.reconcileLeaves()
+	if !hashOnly {
+		if err := c.checkBundles(target); err != nil {
+			return nil, nil, err
+		}
+	}
+	roots, err := c.computeRoots()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkCheckpoints(roots, target); err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkTreeState(target); err != nil {
+		return nil, nil, err
+	}
+
+	return c.report, c.plan, nil
+}
+
+// loadTiles reads every hash tile and lays its leaves out by position
+func (c *checker) loadTiles() error {
+	keys, err := c.cfg.Storage.List("tile/0/")
+	if err != nil {
+		return fmt.Errorf("failed to list hash tiles: %w", err)
+	}
+
+	maxIndex := int64(-1)
+	for _, key := range keys {
+		parsed, err := merkle.ParseTilePath(key)
+		if err != nil || parsed.IsPartial || merkle.HashTileIndexToPath(parsed.Index, nil) != key {
+			c.add(KindTile, fmt.Sprintf("unexpected key %s in hash tile storage", key), "")
+			continue
+		}
+		data, err := c.cfg.Storage.Get(key)
+		if err != nil {
+			return fmt.Errorf("failed to read hash tile %s: %w", key, err)
+		}
+		c.tiles[parsed.Index] = data
+		if parsed.Index > maxIndex {
+			maxIndex = parsed.Index
+		}
+	}
+
+	for index := int64(0); index <= maxIndex; index++ {
+		key := merkle.HashTileIndexToPath(index, nil)
+		data, ok := c.tiles[index]
+		if !ok {
+			c.add(KindTile, fmt.Sprintf("hash tile %s is missing", key), "")
+			c.leaves = append(c.leaves, make([]leafSlot, merkle.TileSize)...)
+			continue
+		}
+
+		if len(data)%merkle.HashSize != 0 {
+			c.add(KindTile, fmt.Sprintf("hash tile %s is torn: %d bytes is not a whole number of hashes", key, len(data)), "")
+		}
+		count := len(data) / merkle.HashSize
+		if count > merkle.TileSize {
+			c.add(KindTile, fmt.Sprintf("hash tile %s is oversized: %d leaves", key, count), "")
+			count = merkle.TileSize
+		}
+		if count < merkle.TileSize && index < maxIndex {
+			c.add(KindTile, fmt.Sprintf("hash tile %s is truncated: %d of %d leaves", key, count, merkle.TileSize), "")
+		}
+
+		for i := 0; i < merkle.TileSize; i++ {
+			var slot leafSlot
+			if i < count {
+				copy(slot.hash[:], data[i*merkle.HashSize:])
+				slot.known = true
+			}
+			if i < count || index < maxIndex {
+				c.leaves = append(c.leaves, slot)
+			}
+		}
+	}
+
+	for _, slot := range c.leaves {
+		if slot.known {
+			c.report.Leaves++
+		}
+	}
+
+	return nil
+}
+
+// loadStatements maps statements rows to entry IDs
+func (c *checker) loadStatements() error {
+	statements, err := database.FindStatementsBy(c.cfg.DB, database.StatementQueryFilters{})
+	if err != nil {
+		return err
+	}
+	c.report.Statements = len(statements)
+
+	for _, stmt := range statements {
+		parsed, err := merkle.ParseEntryTilePath(stmt.EntryTileKey)
+		if err != nil || stmt.EntryTileOffset < 0 || stmt.EntryTileOffset >= merkle.TileSize {
+			c.add(KindStatement, fmt.Sprintf("statement %s has an invalid tile position %s+%d", stmt.StatementHash, stmt.EntryTileKey, stmt.EntryTileOffset), "")
+			continue
+		}
+		entryID := merkle.TileCoordinatesToEntryID(parsed.Index, stmt.EntryTileOffset)
+		if existing, ok := c.statements[entryID]; ok {
+			c.add(KindStatement, fmt.Sprintf("entry %d has two statements rows (%s and %s)", entryID, existing, stmt.StatementHash), "")
+			continue
+		}
+		c.statements[entryID] = stmt.StatementHash
+	}
+
+	return nil
+}
+
+// reconcileLeaves compares tile leaves, statements and current_tree_size,
+// planning recoverable repairs, and returns the repaired tree size
+func (c *checker) reconcileLeaves() int64 {
+	treeSize := c.report.TreeSize
+	resolvable := true
+
+	// Leaves inside the tree must exist and match their statements
+	var restored []int64
+	for entryID := int64(0); entryID < treeSize; entryID++ {
+		slot := c.leaf(entryID)
+		hash, hasStatement := c.statements[entryID]
+		switch {
+		case !slot.known && hasStatement:
+			restored = append(restored, entryID)
+		case !slot.known:
+			c.add(KindLeaf, fmt.Sprintf("leaf %d is missing from the tiles and has no statements row", entryID), "")
+			resolvable = false
+		case !hasStatement:
+			c.add(KindLeaf, fmt.Sprintf("leaf %d has no statements row", entryID), "")
+		case hash != hex.EncodeToString(slot.hash[:]):
+			c.add(KindLeaf, fmt.Sprintf("leaf %d is %x but its statement hash is %s", entryID, slot.hash, hash), "")
+		}
+	}
+	if len(restored) > 0 {
+		c.add(KindLeaf, fmt.Sprintf("%d leaves inside the tree are missing from the tiles (first: %d)", len(restored), restored[0]),
+			repairIf(resolvable, "restore the leaves from statements.statement_hash"))
+	}
+
+	// Registrations interrupted after writing the tile and statement but before
+	// advancing the tree size can be completed
+	target := treeSize
+	for {
+		slot := c.leaf(target)
+		hash, ok := c.statements[target]
+		if !slot.known || !ok || hash != hex.EncodeToString(slot.hash[:]) {
+			break
+		}
+		target++
+	}
+	if target > treeSize {
+		c.add(KindTreeSize, fmt.Sprintf("current_tree_size is %d but entries %d-%d are in the tiles and statements", treeSize, treeSize, target-1),
+			fmt.Sprintf("advance current_tree_size to %d", target))
+		c.plan.treeSize = &target
+	}
+
+	// Anything else beyond the tree was never registered
+	var orphanLeaves int
+	for entryID := target; entryID < int64(len(c.leaves)); entryID++ {
+		if c.leaves[entryID].known {
+			orphanLeaves++
+		}
+	}
+	if orphanLeaves > 0 {
+		c.add(KindTile, fmt.Sprintf("%d leaves beyond tree size %d are in the tiles", orphanLeaves, target),
+			repairIf(resolvable, fmt.Sprintf("truncate the hash tiles to %d leaves", target)))
+	}
+	for entryID := range c.statements {
+		if entryID >= target {
+			c.add(KindStatement, fmt.Sprintf("statements row for entry %d is beyond tree size %d", entryID, target), "")
+		}
+	}
+
+	// Rewrite every tile whose contents differ from the reconciled leaves
+	if resolvable {
+		c.planTiles(target)
+	}
+
+	return target
+}
+
+// planTiles plans the hash tile contents for a tree of the given size,
+// marking tile findings repairable when a rewrite fixes them
+func (c *checker) planTiles(treeSize int64) {
+	lastIndex := int64(-1)
+	if treeSize > 0 {
+		lastIndex = merkle.EntryIDToTileIndex(treeSize - 1)
+	}
+
+	rewrite := make(map[int64]bool)
+	for index := int64(0); index <= lastIndex; index++ {
+		var want []byte
+		for entryID := merkle.TileCoordinatesToEntryID(index, 0); entryID < treeSize && merkle.EntryIDToTileIndex(entryID) == index; entryID++ {
+			slot := c.leaf(entryID)
+			if !slot.known {
+				decoded, _ := hex.DecodeString(c.statements[entryID])
+				copy(slot.hash[:], decoded)
+			}
+			want = append(want, slot.hash[:]...)
+		}
+		if data, ok := c.tiles[index]; !ok || !bytes.Equal(data, want) {
+			c.plan.tiles[index] = want
+			rewrite[index] = true
+		}
+	}
+	for index := range c.tiles {
+		if index > lastIndex {
+			c.plan.tiles[index] = nil
+			rewrite[index] = true
+		}
+	}
+
+	if len(rewrite) == 0 {
+		return
+	}
+	for i, f := range c.report.Findings {
+		if f.Kind == KindTile && f.Repair == "" && !strings.HasPrefix(f.Detail, "unexpected key") {
+			c.report.Findings[i].Repair = "rewrite the hash tile from the reconciled leaves"
+		}
+	}
+}
+
+// hashOnly reports whether the tree state marks the log as hash-only, without
+// entry bundles; a malformed tree state is reported by checkTreeState
+func (c *checker) hashOnly() (bool, error) {
+	data, err := c.cfg.Storage.Get(merkle.TreeStatePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read tree state: %w", err)
+	}
+	var state merkle.TileLogState
+	return data != nil && json.Unmarshal(data, &state) == nil && state.HashOnly, nil
+}
+
+// checkBundles compares every entry bundle with the reconciled leaves of a tree
+// of the given size; bundles are rewritten from the stored statements
+func (c *checker) checkBundles(treeSize int64) error {
+	keys, err := c.cfg.Storage.List("tile/entries/")
+	if err != nil {
+		return fmt.Errorf("failed to list entry bundles: %w", err)
+	}
+
+	lastIndex := int64(-1)
+	if treeSize > 0 {
+		lastIndex = merkle.EntryIDToTileIndex(treeSize - 1)
+	}
+	for _, key := range keys {
+		parsed, err := merkle.ParseEntryTilePath(key)
+		if err != nil || parsed.IsPartial || merkle.EntryTileIndexToPath(parsed.Index, nil) != key {
+			c.add(KindBundle, fmt.Sprintf("unexpected key %s in entry bundle storage", key), "")
+			continue
+		}
+		if parsed.Index > lastIndex {
+			c.add(KindBundle, fmt.Sprintf("entry bundle %s is beyond tree size %d", key, treeSize), "delete the entry bundle")
+			c.plan.bundles[parsed.Index] = nil
+		}
+	}
+
+	for index := int64(0); index <= lastIndex; index++ {
+		key := merkle.EntryTileIndexToPath(index, nil)
+		var leaves [][merkle.HashSize]byte
+		for entryID := merkle.TileCoordinatesToEntryID(index, 0); entryID < treeSize && merkle.EntryIDToTileIndex(entryID) == index; entryID++ {
+			leaf, ok := c.reconciledLeaf(entryID)
+			if !ok {
+				// Already reported as a missing leaf
+				break
+			}
+			leaves = append(leaves, leaf)
+		}
+
+		data, err := c.cfg.Storage.Get(key)
+		if err != nil {
+			return fmt.Errorf("failed to read entry bundle %s: %w", key, err)
+		}
+		entries, err := merkle.DecodeEntryBundle(data)
+		var problem string
+		switch {
+		case data == nil:
+			problem = "is missing"
+		case err != nil:
+			problem = fmt.Sprintf("is malformed: %v", err)
+		case len(entries) != len(leaves):
+			problem = fmt.Sprintf("holds %d entries, expected %d", len(entries), len(leaves))
+		default:
+			for offset, entry := range entries {
+				if hash := sha256.Sum256(entry); hash != leaves[offset] {
+					problem = fmt.Sprintf("entry %d does not hash to its leaf", offset)
+					break
+				}
+			}
+		}
+		if problem == "" {
+			continue
+		}
+
+		bundle, err := c.bundleFromStatements(leaves)
+		if err != nil {
+			return err
+		}
+		c.add(KindBundle, fmt.Sprintf("entry bundle %s %s", key, problem), repairIf(bundle != nil, "rewrite the entry bundle from the stored statements"))
+		if bundle != nil {
+			c.plan.bundles[index] = bundle
+		}
+	}
+
+	return nil
+}
+
+// bundleFromStatements encodes the stored statements of the given leaves as an
+// entry bundle, returning nil when a statement is missing from storage
+func (c *checker) bundleFromStatements(leaves [][merkle.HashSize]byte) ([]byte, error) {
+	var bundle []byte
+	for _, leaf := range leaves {
+		key := merkle.StatementPath(leaf[:])
+		entry, err := c.cfg.Storage.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read statement %s: %w", key, err)
+		}
+		if entry == nil || sha256.Sum256(entry) != leaf {
+			return nil, nil
+		}
+		if bundle, err = merkle.AppendEntryBundle(bundle, entry); err != nil {
+			return nil, nil
+		}
+	}
+	return bundle, nil
+}
+
+// reconciledLeaf returns a leaf from the tiles, or from its statements row when
+// the tiles lost it
+func (c *checker) reconciledLeaf(entryID int64) ([merkle.HashSize]byte, bool) {
+	slot := c.leaf(entryID)
+	if slot.known {
+		return slot.hash, true
+	}
+	decoded, err := hex.DecodeString(c.statements[entryID])
+	if err != nil || len(decoded) != merkle.HashSize {
+		return [merkle.HashSize]byte{}, false
+	}
+	copy(slot.hash[:], decoded)
+	return slot.hash, true
+}
+
+// repairIf returns the repair when it can be applied
+func repairIf(ok bool, repair string) string {
+	if !ok {
+		return ""
+	}
+	return repair
+}
+
+// leaf returns the leaf slot at a position (unknown when beyond the tiles)
+func (c *checker) leaf(entryID int64) leafSlot {
+	if entryID < int64(len(c.leaves)) {
+		return c.leaves[entryID]
+	}
+	return leafSlot{}
+}
+
+// computeRoots returns the root hash of the tree at every size a checkpoint
+// was recorded for, computed from the tile leaves
+func (c *checker) computeRoots() (map[int64][merkle.HashSize]byte, error) {
+	states, err := database.GetTreeStateHistory(c.cfg.DB, 0)
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[int64]bool{c.report.TreeSize: true}
+	for _, state := range states {
+		wanted[state.TreeSize] = true
+	}
+
+	roots := make(map[int64][merkle.HashSize]byte)
+	rf := &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+	cr := rf.NewEmptyRange(0)
+	for size := int64(1); size <= int64(len(c.leaves)); size++ {
+		slot := c.leaves[size-1]
+		if !slot.known {
+			break
+		}
+		if err := cr.Append(rfc6962.DefaultHasher.HashLeaf(slot.hash[:]), nil); err != nil {
+			return nil, fmt.Errorf("failed to compute root: %w", err)
+		}
+		if wanted[size] {
+			root, err := cr.GetRootHash(nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute root: %w", err)
+			}
+			var hash [merkle.HashSize]byte
+			copy(hash[:], root)
+			roots[size] = hash
+		}
+	}
+
+	if root, ok := roots[c.report.TreeSize]; ok {
+		c.report.RootHash = hex.EncodeToString(root[:])
+	}
+	return roots, nil
+}
+
+// checkCheckpoints verifies every checkpoint recorded in tree_state and its copies in storage
+func (c *checker) checkCheckpoints(roots map[int64][merkle.HashSize]byte, treeSize int64) error {
+	states, err := database.GetTreeStateHistory(c.cfg.DB, 0)
+	if err != nil {
+		return err
+	}
+	c.report.Checkpoints = len(states)
+
+	recorded := make(map[string]bool)
+	for i, state := range states {
+		recorded[state.CheckpointStorageKey] = true
+		valid := c.checkCheckpoint(fmt.Sprintf("tree_state checkpoint at size %d", state.TreeSize), state.CheckpointSignedNote, state.TreeSize, roots, treeSize)
+		if !valid {
+			continue
+		}
+		if state.RootHash != "" {
+			checkpoint, _ := merkle.DecodeCheckpoint(state.CheckpointSignedNote)
+			if state.RootHash != hex.EncodeToString(checkpoint.RootHash[:]) {
+				c.add(KindCheckpoint, fmt.Sprintf("tree_state root_hash at size %d does not match its checkpoint", state.TreeSize), "")
+			}
+		}
+
+		// Storage must hold the same note under its key, and the latest as "checkpoint"
+		keys := []string{state.CheckpointStorageKey}
+		if i == 0 {
+			keys = append(keys, merkle.CheckpointPath)
+		}
+		for _, key := range keys {
+			if key == "" {
+				continue
+			}
+			stored, err := c.cfg.Storage.Get(key)
+			if err != nil {
+				return fmt.Errorf("failed to read checkpoint %s: %w", key, err)
+			}
+			if string(stored) != state.CheckpointSignedNote {
+				problem := "differs from"
+				if stored == nil {
+					problem = "is missing, recorded in"
+				}
+				c.add(KindCheckpoint, fmt.Sprintf("checkpoint %s %s tree_state at size %d", key, problem, state.TreeSize),
+					fmt.Sprintf("rewrite %s from tree_state", key))
+				c.plan.checkpoints[key] = []byte(state.CheckpointSignedNote)
+			}
+		}
+	}
+
+	// Checkpoints in storage that tree_state does not know about must still be valid
+	keys, err := c.cfg.Storage.List("checkpoints/")
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if recorded[key] {
+			continue
+		}
+		note, err := c.cfg.Storage.Get(key)
+		if err != nil {
+			return fmt.Errorf("failed to read checkpoint %s: %w", key, err)
+		}
+		c.checkCheckpoint("checkpoint "+key, string(note), -1, roots, treeSize)
+	}
+
+	return nil
+}
+
+// checkCheckpoint verifies a checkpoint's signature and that its root matches
+// the tiles, returning whether the note is a valid checkpoint
+// expectedSize is the size it was recorded at (-1 when unknown)
+func (c *checker) checkCheckpoint(name, note string, expectedSize int64, roots map[int64][merkle.HashSize]byte, treeSize int64) bool {
+	checkpoint, err := merkle.DecodeCheckpoint(note)
+	if err != nil {
+		c.add(KindCheckpoint, fmt.Sprintf("%s is malformed: %v", name, err), "")
+		return false
+	}
+	if valid, err := merkle.VerifyCheckpoint(checkpoint, c.cfg.PublicKey); err != nil || !valid {
+		c.add(KindCheckpoint, fmt.Sprintf("%s has an invalid signature", name), "")
+		return false
+	}
+	if expectedSize >= 0 && checkpoint.TreeSize != expectedSize {
+		c.add(KindCheckpoint, fmt.Sprintf("%s is for tree size %d", name, checkpoint.TreeSize), "")
+		return false
+	}
+	if checkpoint.TreeSize > treeSize {
+		c.add(KindCheckpoint, fmt.Sprintf("%s is beyond tree size %d", name, treeSize), "")
+		return false
+	}
+	if checkpoint.TreeSize == 0 {
+		return true
+	}
+
+	root, ok := roots[checkpoint.TreeSize]
+	if !ok && expectedSize < 0 {
+		// Roots are only precomputed for sizes recorded in tree_state
+		root, ok = c.rootAt(checkpoint.TreeSize)
+	}
+	if ok && root != checkpoint.RootHash {
+		c.add(KindCheckpoint, fmt.Sprintf("%s does not match the root of the tiles at size %d", name, checkpoint.TreeSize), "")
+		return false
+	}
+
+	return true
+}
+
+// checkTreeState compares the compact range persisted beside the tiles with the
+// reconciled leaves; a missing one is rebuilt by the service when it starts
+func (c *checker) checkTreeState(treeSize int64) error {
+	data, err := c.cfg.Storage.Get(merkle.TreeStatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read tree state: %w", err)
+	}
+	if data == nil {
+		return nil
+	}
+
+	// Leaves that cannot be reconciled are reported already
+	rf := &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+	cr := rf.NewEmptyRange(0)
+	for entryID := int64(0); entryID < treeSize; entryID++ {
+		slot := c.leaf(entryID)
+		if !slot.known {
+			decoded, err := hex.DecodeString(c.statements[entryID])
+			if err != nil || len(decoded) != merkle.HashSize {
+				return nil
+			}
+			copy(slot.hash[:], decoded)
+		}
+		if err := cr.Append(rfc6962.DefaultHasher.HashLeaf(slot.hash[:]), nil); err != nil {
+			return fmt.Errorf("failed to compute compact range: %w", err)
+		}
+	}
+
+	var state merkle.TileLogState
+	matches := json.Unmarshal(data, &state) == nil && state.Size == treeSize && len(state.Hashes) == len(cr.Hashes())
+	for i, hash := range cr.Hashes() {
+		matches = matches && bytes.Equal(state.Hashes[i], hash)
+	}
+	if matches {
+		return nil
+	}
+
+	// The compact range is only rebuilt from leaves that agree with their statements
+	repair := "rebuild the compact range from the hash tiles"
+	for _, f := range c.report.Findings {
+		if f.Kind == KindLeaf && f.Repair == "" {
+			repair = ""
+		}
+	}
+	c.add(KindTreeState, fmt.Sprintf("%s does not match the tiles at tree size %d", merkle.TreeStatePath, treeSize), repair)
+	if repair != "" {
+		c.plan.treeState = &treeSize
+	}
+
+	return nil
+}
+
+// rootAt computes the root hash of the first size leaves
+func (c *checker) rootAt(size int64) ([merkle.HashSize]byte, bool) {
+	if size > int64(len(c.leaves)) {
+		return [merkle.HashSize]byte{}, false
+	}
+	rf := &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+	cr := rf.NewEmptyRange(0)
+	for _, slot := range c.leaves[:size] {
+		if !slot.known {
+			return [merkle.HashSize]byte{}, false
+		}
+		if err := cr.Append(rfc6962.DefaultHasher.HashLeaf(slot.hash[:]), nil); err != nil {
+			return [merkle.HashSize]byte{}, false
+		}
+	}
+	root, err := cr.GetRootHash(nil)
+	if err != nil {
+		return [merkle.HashSize]byte{}, false
+	}
+	var hash [merkle.HashSize]byte
+	copy(hash[:], root)
+	return hash, true
+}
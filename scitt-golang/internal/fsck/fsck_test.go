@@ -0,0 +1,355 @@
+package fsck_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/fsck"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/server"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/testutil"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+)
+
+// testLog is a stopped log with a published checkpoint
+type testLog struct {
+	db        *sql.DB
+	storage   storage.Storage
+	publicKey *ecdsa.PublicKey
+}
+
+// newTestLog registers n statements, publishes a checkpoint and stops the service
+func newTestLog(t *testing.T, n int) *testLog {
+	t.Helper()
+
+	keys := testutil.NewKeys(t)
+	cfg := testutil.NewConfig(t, keys)
+	cfg.Storage = config.StorageConfig{Type: "local", Path: filepath.Join(t.TempDir(), "tiles")}
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	issuerKey, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	signer, err := cose.NewES256Signer(issuerKey.Private)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	headers := cose.CreateProtectedHeaders(cose.ProtectedHeadersOptions{Alg: cose.AlgorithmES256})
+
+	for i := 0; i < n; i++ {
+		statement, err := cose.CreateCoseSign1(headers, []byte(fmt.Sprintf("statement %d", i)), signer, cose.CoseSign1Options{})
+		if err != nil {
+			t.Fatalf("failed to create COSE Sign1: %v", err)
+		}
+		encoded, err := cose.EncodeCoseSign1(statement)
+		if err != nil {
+			t.Fatalf("failed to encode COSE Sign1: %v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(encoded))
+		req.Header.Set("Authorization", "Bearer "+cfg.Server.APIKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/checkpoint", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to publish checkpoint: %d", w.Code)
+	}
+	srv.Close()
+
+	db, err := database.OpenDatabase(database.DatabaseOptions{Path: cfg.Database.Path, EnableWAL: true})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.CloseDatabase(db) })
+
+	store, err := storage.NewLocalStorage(cfg.Storage.Path)
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+
+	return &testLog{db: db, storage: store, publicKey: keys.Public}
+}
+
+func (l *testLog) run(t *testing.T, repair bool) *fsck.Report {
+	t.Helper()
+	report, err := fsck.Run(fsck.Config{DB: l.db, Storage: l.storage, PublicKey: l.publicKey, Repair: repair})
+	if err != nil {
+		t.Fatalf("fsck failed: %v", err)
+	}
+	return report
+}
+
+func (l *testLog) tile(t *testing.T) []byte {
+	t.Helper()
+	data, err := l.storage.Get(merkle.HashTileIndexToPath(0, nil))
+	if err != nil {
+		t.Fatalf("failed to read hash tile: %v", err)
+	}
+	return data
+}
+
+func (l *testLog) putTile(t *testing.T, data []byte) {
+	t.Helper()
+	if err := l.storage.Put(merkle.HashTileIndexToPath(0, nil), data); err != nil {
+		t.Fatalf("failed to write hash tile: %v", err)
+	}
+}
+
+// hasFinding reports whether a finding of the kind exists, and whether it is repairable
+func hasFinding(report *fsck.Report, kind string) (found, repairable bool) {
+	for _, f := range report.Findings {
+		if f.Kind == kind {
+			found = true
+			repairable = repairable || f.Repair != ""
+		}
+	}
+	return found, repairable
+}
+
+func TestRun(t *testing.T) {
+	t.Run("consistent log", func(t *testing.T) {
+		log := newTestLog(t, 3)
+
+		report := log.run(t, false)
+		if !report.OK() {
+			t.Fatalf("expected no findings, got %+v", report.Findings)
+		}
+		if report.TreeSize != 3 || report.Leaves != 3 || report.Statements != 3 || report.Checkpoints != 1 {
+			t.Errorf("unexpected report %+v", report)
+		}
+		if report.RootHash == "" {
+			t.Error("expected root hash")
+		}
+	})
+
+	t.Run("completes an interrupted registration", func(t *testing.T) {
+		log := newTestLog(t, 3)
+		if err := database.SetCurrentTreeSize(log.db, 2); err != nil {
+			t.Fatalf("failed to set tree size: %v", err)
+		}
+
+		report := log.run(t, false)
+		if found, repairable := hasFinding(report, fsck.KindTreeSize); !found || !repairable {
+			t.Fatalf("expected repairable tree size finding, got %+v", report.Findings)
+		}
+		if size, _ := database.GetCurrentTreeSize(log.db); size != 2 {
+			t.Errorf("dry run changed tree size to %d", size)
+		}
+
+		report = log.run(t, true)
+		if !report.OK() || len(report.Repaired) == 0 {
+			t.Fatalf("expected repair, got findings %+v", report.Findings)
+		}
+		if size, _ := database.GetCurrentTreeSize(log.db); size != 3 {
+			t.Errorf("expected tree size 3 after repair, got %d", size)
+		}
+	})
+
+	t.Run("truncates leaves beyond the tree", func(t *testing.T) {
+		log := newTestLog(t, 3)
+		tile := log.tile(t)
+		log.putTile(t, append(tile, bytes.Repeat([]byte{0xab}, merkle.HashSize)...))
+
+		report := log.run(t, false)
+		if found, repairable := hasFinding(report, fsck.KindTile); !found || !repairable {
+			t.Fatalf("expected repairable tile finding, got %+v", report.Findings)
+		}
+		if got := log.tile(t); len(got) != 4*merkle.HashSize {
+			t.Errorf("dry run modified the tile: %d bytes", len(got))
+		}
+
+		if report := log.run(t, true); !report.OK() {
+			t.Fatalf("expected repair, got findings %+v", report.Findings)
+		}
+		if got := log.tile(t); !bytes.Equal(got, tile) {
+			t.Error("expected tile truncated to the tree")
+		}
+	})
+
+	t.Run("restores missing leaves from statements", func(t *testing.T) {
+		log := newTestLog(t, 3)
+		tile := log.tile(t)
+		log.putTile(t, tile[:merkle.HashSize])
+
+		report := log.run(t, false)
+		if found, repairable := hasFinding(report, fsck.KindLeaf); !found || !repairable {
+			t.Fatalf("expected repairable leaf finding, got %+v", report.Findings)
+		}
+
+		if report := log.run(t, true); !report.OK() {
+			t.Fatalf("expected repair, got findings %+v", report.Findings)
+		}
+		if got := log.tile(t); !bytes.Equal(got, tile) {
+			t.Error("expected tile restored")
+		}
+	})
+
+	t.Run("reports leaves that disagree with statements", func(t *testing.T) {
+		log := newTestLog(t, 3)
+		tile := log.tile(t)
+		tile[merkle.HashSize] ^= 0xff
+		log.putTile(t, tile)
+
+		report := log.run(t, true)
+		if found, repairable := hasFinding(report, fsck.KindLeaf); !found || repairable {
+			t.Fatalf("expected unrepairable leaf finding, got %+v", report.Findings)
+		}
+		if found, _ := hasFinding(report, fsck.KindCheckpoint); !found {
+			t.Errorf("expected checkpoint root mismatch, got %+v", report.Findings)
+		}
+		if !bytes.Equal(log.tile(t), tile) {
+			t.Error("an unrepairable leaf must not be rewritten")
+		}
+	})
+
+	t.Run("rewrites a missing checkpoint from tree_state", func(t *testing.T) {
+		log := newTestLog(t, 3)
+		note, _ := log.storage.Get(merkle.CheckpointPath)
+		if err := log.storage.Delete(merkle.CheckpointPath); err != nil {
+			t.Fatalf("failed to delete checkpoint: %v", err)
+		}
+
+		report := log.run(t, false)
+		if found, repairable := hasFinding(report, fsck.KindCheckpoint); !found || !repairable {
+			t.Fatalf("expected repairable checkpoint finding, got %+v", report.Findings)
+		}
+
+		if report := log.run(t, true); !report.OK() {
+			t.Fatalf("expected repair, got findings %+v", report.Findings)
+		}
+		if restored, _ := log.storage.Get(merkle.CheckpointPath); !bytes.Equal(restored, note) {
+			t.Error("expected checkpoint rewritten")
+		}
+	})
+
+	t.Run("rebuilds a stale tree state", func(t *testing.T) {
+		log := newTestLog(t, 3)
+		state, _ := log.storage.Get(merkle.TreeStatePath)
+		tile := log.tile(t)
+		log.putTile(t, tile[:2*merkle.HashSize])
+		tl := merkle.NewTileLog(log.storage)
+		if err := tl.Rebuild(2); err != nil {
+			t.Fatalf("failed to rebuild tree state: %v", err)
+		}
+		log.putTile(t, tile)
+
+		report := log.run(t, false)
+		if found, repairable := hasFinding(report, fsck.KindTreeState); !found || !repairable {
+			t.Fatalf("expected repairable tree state finding, got %+v", report.Findings)
+		}
+
+		if report := log.run(t, true); !report.OK() {
+			t.Fatalf("expected repair, got findings %+v", report.Findings)
+		}
+		if restored, _ := log.storage.Get(merkle.TreeStatePath); !bytes.Equal(restored, state) {
+			t.Error("expected tree state rebuilt")
+		}
+	})
+
+	t.Run("rewrites a damaged entry bundle from statements", func(t *testing.T) {
+		log := newTestLog(t, 3)
+		key := merkle.EntryTileIndexToPath(0, nil)
+		bundle, _ := log.storage.Get(key)
+		if err := log.storage.Put(key, bundle[:len(bundle)-1]); err != nil {
+			t.Fatalf("failed to write entry bundle: %v", err)
+		}
+
+		report := log.run(t, false)
+		if found, repairable := hasFinding(report, fsck.KindBundle); !found || !repairable {
+			t.Fatalf("expected repairable bundle finding, got %+v", report.Findings)
+		}
+
+		if report := log.run(t, true); !report.OK() {
+			t.Fatalf("expected repair, got findings %+v", report.Findings)
+		}
+		if restored, _ := log.storage.Get(key); !bytes.Equal(restored, bundle) {
+			t.Error("expected entry bundle rewritten")
+		}
+	})
+
+	t.Run("reports an entry bundle whose statements are lost", func(t *testing.T) {
+		log := newTestLog(t, 3)
+		key := merkle.EntryTileIndexToPath(0, nil)
+		if err := log.storage.Delete(key); err != nil {
+			t.Fatalf("failed to delete entry bundle: %v", err)
+		}
+		tile := log.tile(t)
+		if err := log.storage.Delete(merkle.StatementPath(tile[:merkle.HashSize])); err != nil {
+			t.Fatalf("failed to delete statement: %v", err)
+		}
+
+		report := log.run(t, true)
+		if found, repairable := hasFinding(report, fsck.KindBundle); !found || repairable {
+			t.Fatalf("expected unrepairable bundle finding, got %+v", report.Findings)
+		}
+	})
+
+	t.Run("accepts a hash-only log and keeps it hash-only", func(t *testing.T) {
+		log := newTestLog(t, 3)
+		tl := merkle.NewTileLog(log.storage)
+		if err := tl.Load(); err != nil {
+			t.Fatalf("failed to load tree state: %v", err)
+		}
+		if err := tl.DropBundles(); err != nil {
+			t.Fatalf("failed to drop entry bundles: %v", err)
+		}
+
+		if report := log.run(t, false); !report.OK() {
+			t.Fatalf("expected no findings, got %+v", report.Findings)
+		}
+
+		if err := log.storage.Delete(merkle.TreeStatePath); err != nil {
+			t.Fatalf("failed to delete tree state: %v", err)
+		}
+		if err := merkle.NewTileLog(log.storage).Rebuild(2); err != nil {
+			t.Fatalf("failed to rebuild tree state: %v", err)
+		}
+		state, _ := log.storage.Get(merkle.TreeStatePath)
+		var stale merkle.TileLogState
+		json.Unmarshal(state, &stale)
+		stale.HashOnly = true
+		state, _ = json.Marshal(stale)
+		log.storage.Put(merkle.TreeStatePath, state)
+
+		if report := log.run(t, true); !report.OK() || len(report.Repaired) == 0 {
+			t.Fatalf("expected repair, got findings %+v", report.Findings)
+		}
+		if err := tl.Load(); err != nil || !tl.HashOnly() || tl.Size() != 3 {
+			t.Errorf("expected a hash-only tree state of size 3, got %v/%d (%v)", tl.HashOnly(), tl.Size(), err)
+		}
+	})
+
+	t.Run("rejects checkpoints signed by another key", func(t *testing.T) {
+		log := newTestLog(t, 1)
+		other, err := cose.GenerateES256KeyPair()
+		if err != nil {
+			t.Fatalf("failed to generate key pair: %v", err)
+		}
+		log.publicKey = other.Public
+
+		report := log.run(t, false)
+		if found, repairable := hasFinding(report, fsck.KindCheckpoint); !found || repairable {
+			t.Fatalf("expected unrepairable checkpoint finding, got %+v", report.Findings)
+		}
+	})
+}
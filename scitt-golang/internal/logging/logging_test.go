@@ -0,0 +1,105 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/logging"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("writes JSON at the configured level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := logging.New(config.LoggingConfig{Level: "warn", Format: "json"}, &buf)
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+
+		logger.Info("hidden")
+		logger.Warn("shown", "entry_id", 7)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("expected 1 log line, got %d: %s", len(lines), buf.String())
+		}
+
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+			t.Fatalf("log line is not JSON: %v", err)
+		}
+		if record["msg"] != "shown" || record["entry_id"] != float64(7) {
+			t.Errorf("unexpected record: %v", record)
+		}
+	})
+
+	t.Run("defaults to text at info", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := logging.New(config.LoggingConfig{}, &buf)
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+
+		logger.Debug("hidden")
+		logger.Info("shown", "iss", "https://issuer.example")
+
+		if output := buf.String(); strings.Contains(output, "hidden") || !strings.Contains(output, "iss=https://issuer.example") {
+			t.Errorf("unexpected output: %s", output)
+		}
+	})
+
+	t.Run("rejects unknown level and format", func(t *testing.T) {
+		if _, err := logging.New(config.LoggingConfig{Level: "verbose"}, &bytes.Buffer{}); err == nil {
+			t.Error("should reject unknown level")
+		}
+		if _, err := logging.New(config.LoggingConfig{Format: "xml"}, &bytes.Buffer{}); err == nil {
+			t.Error("should reject unknown format")
+		}
+	})
+}
+
+func TestRequestContext(t *testing.T) {
+	t.Run("annotates the context logger with the request ID", func(t *testing.T) {
+		var buf bytes.Buffer
+		base := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		ctx := logging.WithRequestID(logging.WithLogger(context.Background(), base), "req-1")
+		logging.FromContext(ctx).Info("hello")
+
+		if logging.RequestID(ctx) != "req-1" {
+			t.Errorf("unexpected request ID: %s", logging.RequestID(ctx))
+		}
+		if !strings.Contains(buf.String(), `"request_id":"req-1"`) {
+			t.Errorf("log line missing request ID: %s", buf.String())
+		}
+	})
+
+	t.Run("falls back to the default logger", func(t *testing.T) {
+		if logging.FromContext(context.Background()) != slog.Default() {
+			t.Error("expected the default logger")
+		}
+	})
+}
+
+func TestValidRequestID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"":                           false,
+		"abc-123":                    true,
+		"3f1c2a8e-0b7d-4c55-9e43-1f": true,
+		"has space":                  false,
+		"line\nbreak":                false,
+		strings.Repeat("a", 129):     false,
+	} {
+		if got := logging.ValidRequestID(id); got != want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", id, got, want)
+		}
+	}
+
+	if id := logging.NewRequestID(); !logging.ValidRequestID(id) || len(id) != 32 {
+		t.Errorf("unexpected generated request ID: %s", id)
+	}
+}
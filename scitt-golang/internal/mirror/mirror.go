@@ -0,0 +1,352 @@
+// Package mirror replicates a transparency service's hash tiles, entry bundles,
+// receipts and checkpoints into another storage backend, verifying every tile
+// against a signed checkpoint and every entry and receipt against its leaf
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// maxCheckpointBytes bounds a checkpoint fetched from the source
+const maxCheckpointBytes = 64 << 10
+
+// maxReceiptBytes bounds a receipt fetched from the source
+const maxReceiptBytes = 64 << 10
+
+// Errors reported by Sync when the source misbehaves
+var (
+	ErrInvalidCheckpoint = errors.New("checkpoint is not signed by the log key")
+	ErrInconsistent      = errors.New("source is inconsistent with the mirrored log")
+)
+
+// Config configures a mirror
+type Config struct {
+	SourceURL  string
+	PublicKey  *ecdsa.PublicKey // The mirrored log's checkpoint and receipt verification key
+	Storage    storage.Storage  // Destination for tiles, receipts and checkpoints
+	HTTPClient *http.Client
+}
+
+// Mirror copies one log into a storage backend
+type Mirror struct {
+	cfg    Config
+	client *http.Client
+	rf     *compact.RangeFactory
+}
+
+// SyncResult describes one sync
+type SyncResult struct {
+	OldSize int64
+	NewSize int64
+}
+
+// New creates a mirror
+func New(cfg Config) (*Mirror, error) {
+	if cfg.SourceURL == "" {
+		return nil, fmt.Errorf("source URL is required")
+	}
+	if cfg.PublicKey == nil {
+		return nil, fmt.Errorf("log public key is required")
+	}
+	if cfg.Storage == nil {
+		return nil, fmt.Errorf("storage is required")
+	}
+	cfg.SourceURL = strings.TrimSuffix(cfg.SourceURL, "/")
+
+	m := &Mirror{
+		cfg:    cfg,
+		client: cfg.HTTPClient,
+		rf:     &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren},
+	}
+	if m.client == nil {
+		m.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return m, nil
+}
+
+// Sync brings the mirror up to the source's latest checkpoint
+// New tiles are fetched from the last mirrored size, appended to the mirrored
+// tree and accepted only if the resulting root matches the signed checkpoint;
+// the checkpoint is written last, so an interrupted sync resumes from the
+// previous one
+func (m *Mirror) Sync(ctx context.Context) (*SyncResult, error) {
+	note, err := m.fetch(ctx, "/checkpoint", maxCheckpointBytes)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint, err := m.verifyCheckpoint(note)
+	if err != nil {
+		return nil, err
+	}
+
+	local, cr, err := m.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{NewSize: checkpoint.TreeSize}
+	if local != nil {
+		result.OldSize = local.TreeSize
+	}
+
+	switch {
+	case checkpoint.TreeSize < result.OldSize:
+		return nil, fmt.Errorf("%w: tree size went from %d to %d", ErrInconsistent, result.OldSize, checkpoint.TreeSize)
+	case local != nil && checkpoint.TreeSize == local.TreeSize && checkpoint.RootHash != local.RootHash:
+		return nil, fmt.Errorf("%w: root hash changed at tree size %d", ErrInconsistent, checkpoint.TreeSize)
+	case checkpoint.TreeSize == 0 || (local != nil && checkpoint.TreeSize == local.TreeSize):
+		// Nothing to copy; keep the latest copy, which may carry more witness cosignatures
+		if err := m.storeCheckpoint(checkpoint.TreeSize, note); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	if err := m.fetchTiles(ctx, cr, result.OldSize, checkpoint.TreeSize); err != nil {
+		return nil, err
+	}
+
+	root, err := cr.GetRootHash(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute root hash: %w", err)
+	}
+	if !bytes.Equal(root, checkpoint.RootHash[:]) {
+		return nil, fmt.Errorf("%w: tiles do not match the checkpoint root at tree size %d", ErrInconsistent, checkpoint.TreeSize)
+	}
+
+	state, err := json.Marshal(merkle.TileLogState{Size: checkpoint.TreeSize, Root: root, Hashes: cr.Hashes()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tree state: %w", err)
+	}
+	if err := m.cfg.Storage.Put(merkle.TreeStatePath, state); err != nil {
+		return nil, fmt.Errorf("failed to store tree state: %w", err)
+	}
+	if err := m.storeCheckpoint(checkpoint.TreeSize, note); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// verifyCheckpoint decodes a checkpoint and verifies the log's signature
+func (m *Mirror) verifyCheckpoint(note []byte) (*merkle.Checkpoint, error) {
+	checkpoint, err := merkle.DecodeCheckpoint(string(note))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCheckpoint, err)
+	}
+	if valid, err := merkle.VerifyCheckpoint(checkpoint, m.cfg.PublicKey); err != nil || !valid {
+		return nil, ErrInvalidCheckpoint
+	}
+	return checkpoint, nil
+}
+
+// loadState returns the mirrored checkpoint (nil before the first sync) and the
+// compact range of the mirrored tree, rebuilding it from the tiles when the
+// saved tree state does not match the checkpoint
+func (m *Mirror) loadState() (*merkle.Checkpoint, *compact.Range, error) {
+	note, err := m.cfg.Storage.Get(merkle.CheckpointPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read mirrored checkpoint: %w", err)
+	}
+	if note == nil {
+		return nil, m.rf.NewEmptyRange(0), nil
+	}
+
+	local, err := m.verifyCheckpoint(note)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mirrored checkpoint: %w", err)
+	}
+
+	data, err := m.cfg.Storage.Get(merkle.TreeStatePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read tree state: %w", err)
+	}
+	if data != nil {
+		var state merkle.TileLogState
+		if err := json.Unmarshal(data, &state); err == nil && state.Size == local.TreeSize && bytes.Equal(state.Root, local.RootHash[:]) {
+			if cr, err := m.rf.NewRange(0, uint64(state.Size), state.Hashes); err == nil {
+				return local, cr, nil
+			}
+		}
+	}
+
+	cr := m.rf.NewEmptyRange(0)
+	for entryID := int64(0); entryID < local.TreeSize; entryID++ {
+		tile, err := m.cfg.Storage.Get(merkle.HashTileIndexToPath(merkle.EntryIDToTileIndex(entryID), nil))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read hash tile: %w", err)
+		}
+		offset := merkle.EntryIDToTileOffset(entryID) * merkle.HashSize
+		if len(tile) < offset+merkle.HashSize {
+			return nil, nil, fmt.Errorf("mirrored hash tiles end before tree size %d", local.TreeSize)
+		}
+		if err := cr.Append(rfc6962.DefaultHasher.HashLeaf(tile[offset:offset+merkle.HashSize]), nil); err != nil {
+			return nil, nil, fmt.Errorf("failed to rebuild tree: %w", err)
+		}
+	}
+	if root, err := cr.GetRootHash(nil); err != nil || (local.TreeSize > 0 && !bytes.Equal(root, local.RootHash[:])) {
+		return nil, nil, fmt.Errorf("mirrored hash tiles do not match the mirrored checkpoint")
+	}
+
+	return local, cr, nil
+}
+
+// fetchTiles copies the hash tiles, entry bundles and receipts covering leaves
+// [oldSize, newSize) and appends those leaves to the compact range
+func (m *Mirror) fetchTiles(ctx context.Context, cr *compact.Range, oldSize, newSize int64) error {
+	for index := merkle.EntryIDToTileIndex(oldSize); merkle.TileCoordinatesToEntryID(index, 0) < newSize; index++ {
+		width := merkle.TileSize
+		if remaining := newSize - merkle.TileCoordinatesToEntryID(index, 0); remaining < merkle.TileSize {
+			width = int(remaining)
+		}
+
+		var path, bundlePath string
+		if width == merkle.TileSize {
+			path = merkle.HashTileIndexToPath(index, nil)
+			bundlePath = merkle.EntryTileIndexToPath(index, nil)
+		} else {
+			path = merkle.HashTileIndexToPath(index, &width)
+			bundlePath = merkle.EntryTileIndexToPath(index, &width)
+		}
+
+		tile, err := m.fetch(ctx, "/"+path, int64(merkle.FullTileBytes))
+		if err != nil {
+			return err
+		}
+		if len(tile) != width*merkle.HashSize {
+			return fmt.Errorf("%w: tile %s has %d bytes, expected %d", ErrInconsistent, path, len(tile), width*merkle.HashSize)
+		}
+
+		// Every entry must hash to its leaf
+		bundle, err := m.fetch(ctx, "/"+bundlePath, int64(merkle.TileSize*(2+merkle.MaxEntrySize)))
+		if err != nil {
+			return err
+		}
+		entries, err := merkle.DecodeEntryBundle(bundle)
+		if err != nil || len(entries) != width {
+			return fmt.Errorf("%w: entry bundle %s does not hold %d entries", ErrInconsistent, bundlePath, width)
+		}
+		for offset, entry := range entries {
+			hash := sha256.Sum256(entry)
+			if !bytes.Equal(hash[:], tile[offset*merkle.HashSize:(offset+1)*merkle.HashSize]) {
+				return fmt.Errorf("%w: entry %d of bundle %s does not match its leaf", ErrInconsistent, offset, bundlePath)
+			}
+		}
+
+		// Leaves already mirrored must not change
+		start := 0
+		if merkle.EntryIDToTileIndex(oldSize) == index {
+			start = merkle.EntryIDToTileOffset(oldSize)
+		}
+		key := merkle.HashTileIndexToPath(index, nil)
+		if start > 0 {
+			existing, err := m.cfg.Storage.Get(key)
+			if err != nil {
+				return fmt.Errorf("failed to read hash tile: %w", err)
+			}
+			prefix := start * merkle.HashSize
+			if len(existing) < prefix || !bytes.Equal(existing[:prefix], tile[:prefix]) {
+				return fmt.Errorf("%w: tile %s changes mirrored leaves", ErrInconsistent, path)
+			}
+		}
+
+		for offset := start; offset < width; offset++ {
+			leaf := tile[offset*merkle.HashSize : (offset+1)*merkle.HashSize]
+			if err := m.fetchReceipt(ctx, merkle.TileCoordinatesToEntryID(index, offset), leaf); err != nil {
+				return err
+			}
+			if err := cr.Append(rfc6962.DefaultHasher.HashLeaf(leaf), nil); err != nil {
+				return fmt.Errorf("failed to append leaf: %w", err)
+			}
+		}
+
+		// The bundle is stored first so a stored leaf always has its entry
+		if err := m.cfg.Storage.Put(merkle.EntryTileIndexToPath(index, nil), bundle); err != nil {
+			return fmt.Errorf("failed to store entry bundle: %w", err)
+		}
+		if err := m.cfg.Storage.Put(key, tile); err != nil {
+			return fmt.Errorf("failed to store hash tile: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// fetchReceipt copies the receipt the source issued for an entry, after
+// checking it is signed by the log key and proves the entry's leaf
+func (m *Mirror) fetchReceipt(ctx context.Context, entryID int64, leaf []byte) error {
+	data, err := m.fetch(ctx, fmt.Sprintf("/entries/%d", entryID), maxReceiptBytes)
+	if err != nil {
+		return err
+	}
+	receipt, err := cose.DecodeCoseSign1(data)
+	if err != nil {
+		return fmt.Errorf("%w: receipt for entry %d is not a COSE Sign1: %v", ErrInconsistent, entryID, err)
+	}
+	proof, _, err := merkle.VerifyReceipt(receipt, [merkle.HashSize]byte(leaf), m.cfg.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: receipt for entry %d: %v", ErrInconsistent, entryID, err)
+	}
+	if proof.LeafIndex != entryID {
+		return fmt.Errorf("%w: receipt for entry %d proves leaf %d", ErrInconsistent, entryID, proof.LeafIndex)
+	}
+	if err := m.cfg.Storage.Put(merkle.ReceiptPath(entryID), data); err != nil {
+		return fmt.Errorf("failed to store receipt: %w", err)
+	}
+	return nil
+}
+
+// storeCheckpoint writes a verified checkpoint under its tree size and as the latest
+func (m *Mirror) storeCheckpoint(treeSize int64, note []byte) error {
+	if err := m.cfg.Storage.Put(merkle.CheckpointHistoryPath(treeSize), note); err != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	if err := m.cfg.Storage.Put(merkle.CheckpointPath, note); err != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	return nil
+}
+
+// fetch GETs a path from the source
+func (m *Mirror) fetch(ctx context.Context, path string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.cfg.SourceURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: HTTP %d", path, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%s exceeds %d bytes", path, limit)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,225 @@
+package mirror_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/mirror"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/server"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/testutil"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+)
+
+// testLog is a running transparency service to mirror
+type testLog struct {
+	cfg        *config.Config
+	srv        *server.Server
+	url        string
+	apiKey     string
+	publicKey  *ecdsa.PublicKey
+	statements [][]byte
+}
+
+func newTestLog(t *testing.T) *testLog {
+	t.Helper()
+
+	keys := testutil.NewKeys(t)
+	cfg := testutil.NewConfig(t, keys)
+	cfg.Storage = config.StorageConfig{Type: "local", Path: filepath.Join(t.TempDir(), "origin-tiles")}
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	return &testLog{cfg: cfg, srv: srv, url: ts.URL, apiKey: cfg.Server.APIKey, publicKey: keys.Public}
+}
+
+// register adds n statements to the log
+func (l *testLog) register(t *testing.T, n int) {
+	t.Helper()
+
+	keyPair, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	signer, err := cose.NewES256Signer(keyPair.Private)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	headers := cose.CreateProtectedHeaders(cose.ProtectedHeadersOptions{Alg: cose.AlgorithmES256})
+
+	for i := 0; i < n; i++ {
+		payload := []byte(fmt.Sprintf("statement %d", len(l.statements)))
+		statement, err := cose.CreateCoseSign1(headers, payload, signer, cose.CoseSign1Options{})
+		if err != nil {
+			t.Fatalf("failed to create COSE Sign1: %v", err)
+		}
+		encoded, err := cose.EncodeCoseSign1(statement)
+		if err != nil {
+			t.Fatalf("failed to encode COSE Sign1: %v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(encoded))
+		req.Header.Set("Authorization", "Bearer "+l.apiKey)
+		w := httptest.NewRecorder()
+		l.srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		l.statements = append(l.statements, encoded)
+	}
+}
+
+func get(t *testing.T, handler http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("replicates incrementally and serves read-only", func(t *testing.T) {
+		origin := newTestLog(t)
+		origin.register(t, 3)
+
+		mirrorDir := filepath.Join(t.TempDir(), "mirror-tiles")
+		store, err := storage.NewLocalStorage(mirrorDir)
+		if err != nil {
+			t.Fatalf("failed to create storage: %v", err)
+		}
+		m, err := mirror.New(mirror.Config{SourceURL: origin.url, PublicKey: origin.publicKey, Storage: store})
+		if err != nil {
+			t.Fatalf("failed to create mirror: %v", err)
+		}
+
+		result, err := m.Sync(ctx)
+		if err != nil {
+			t.Fatalf("sync failed: %v", err)
+		}
+		if result.OldSize != 0 || result.NewSize != 3 {
+			t.Errorf("expected sync 0 -> 3, got %+v", result)
+		}
+
+		// Cross a tile boundary on the next sync
+		origin.register(t, merkle.TileSize)
+		result, err = m.Sync(ctx)
+		if err != nil {
+			t.Fatalf("sync failed: %v", err)
+		}
+		if result.OldSize != 3 || result.NewSize != 3+merkle.TileSize {
+			t.Errorf("expected sync 3 -> %d, got %+v", 3+merkle.TileSize, result)
+		}
+
+		// Serve the mirror with the log's public key only, its own database and storage
+		mirrorCfg := *origin.cfg
+		mirrorCfg.Keys.Private = ""
+		mirrorCfg.Database.Path = filepath.Join(t.TempDir(), "mirror.db")
+		mirrorCfg.Storage = config.StorageConfig{Type: "local", Path: mirrorDir}
+		mirrorCfg.Mirror = &config.MirrorConfig{Source: origin.url}
+		mirrorSrv, err := server.NewServer(&mirrorCfg)
+		if err != nil {
+			t.Fatalf("failed to create mirror server: %v", err)
+		}
+		defer mirrorSrv.Close()
+
+		for _, path := range []string{"/checkpoint", "/proofs/consistency?old=3&new=259", "/tile/entries/000", "/tile/entries/001.p/3", "/entries/0", "/entries/257"} {
+			want := get(t, origin.srv.Handler(), path)
+			got := get(t, mirrorSrv.Handler(), path)
+			if got.Code != http.StatusOK || !bytes.Equal(got.Body.Bytes(), want.Body.Bytes()) {
+				t.Errorf("%s: mirror response differs from origin (status %d)", path, got.Code)
+			}
+		}
+
+		// The mirror serves the receipt the origin issued, which verifies with the log key
+		w := get(t, mirrorSrv.Handler(), "/entries/257")
+		receipt, err := cose.DecodeCoseSign1(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("failed to decode receipt: %v", err)
+		}
+		if _, _, err := merkle.VerifyReceipt(receipt, sha256.Sum256(origin.statements[257]), origin.publicKey); err != nil {
+			t.Fatalf("mirror receipt does not verify: %v", err)
+		}
+
+		// A mirror cannot sign, so it issues no receipts of its own
+		for _, path := range []string{"/entries/257?refresh=true", "/entries/257?tree_size=258", "/entries/259"} {
+			if w := get(t, mirrorSrv.Handler(), path); w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected status 404, got %d", path, w.Code)
+			}
+		}
+
+		// Readiness does not depend on a signing key
+		if w := get(t, mirrorSrv.Handler(), "/health/ready"); w.Code != http.StatusOK {
+			t.Errorf("expected mirror to be ready, got %d: %s", w.Code, w.Body.String())
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(origin.statements[0]))
+		req.Header.Set("Authorization", "Bearer "+origin.apiKey)
+		rec := httptest.NewRecorder()
+		mirrorSrv.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected registration on a mirror to be rejected with 403, got %d", rec.Code)
+		}
+	})
+
+	t.Run("rejects tiles that do not match the checkpoint", func(t *testing.T) {
+		origin := newTestLog(t)
+		origin.register(t, 2)
+
+		// Serve the origin's checkpoint with a corrupted entry tile
+		source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := get(t, origin.srv.Handler(), r.URL.Path)
+			body := rec.Body.Bytes()
+			if strings.HasPrefix(r.URL.Path, "/tile/") {
+				body[0] ^= 0xff
+			}
+			w.WriteHeader(rec.Code)
+			io.Copy(w, bytes.NewReader(body))
+		}))
+		defer source.Close()
+
+		store := storage.NewMemoryStorage()
+		m, err := mirror.New(mirror.Config{SourceURL: source.URL, PublicKey: origin.publicKey, Storage: store})
+		if err != nil {
+			t.Fatalf("failed to create mirror: %v", err)
+		}
+		if _, err := m.Sync(ctx); !errors.Is(err, mirror.ErrInconsistent) {
+			t.Fatalf("expected ErrInconsistent, got %v", err)
+		}
+		if note, _ := store.Get(merkle.CheckpointPath); note != nil {
+			t.Error("a rejected sync must not record a checkpoint")
+		}
+	})
+
+	t.Run("rejects checkpoints signed by another key", func(t *testing.T) {
+		origin := newTestLog(t)
+		origin.register(t, 1)
+		other := newTestLog(t)
+
+		m, err := mirror.New(mirror.Config{SourceURL: origin.url, PublicKey: other.publicKey, Storage: storage.NewMemoryStorage()})
+		if err != nil {
+			t.Fatalf("failed to create mirror: %v", err)
+		}
+		if _, err := m.Sync(ctx); !errors.Is(err, mirror.ErrInvalidCheckpoint) {
+			t.Fatalf("expected ErrInvalidCheckpoint, got %v", err)
+		}
+	})
+}
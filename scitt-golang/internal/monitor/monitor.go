@@ -0,0 +1,503 @@
+// Package monitor audits a transparency service by following its checkpoints,
+// verifying every new checkpoint is signed by a pinned key and consistent with
+// the last one it trusted
+// On a sharded service the monitor audits one shard: a pinned one, or the
+// active shard, auditing each shard up to its final checkpoint when it rotates
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+)
+
+// maxResponseBytes bounds responses read from the service
+const maxResponseBytes = 16 << 20
+
+// AlertKind classifies misbehaviour detected by the monitor
+type AlertKind string
+
+// Alert kinds
+const (
+	AlertInvalidSignature AlertKind = "invalid-signature" // Checkpoint or receipt not signed by a pinned key
+	AlertRollback         AlertKind = "rollback"          // Tree size went backwards
+	AlertFork             AlertKind = "fork"              // New checkpoint is not consistent with the trusted one
+	AlertInclusion        AlertKind = "inclusion"         // A watched entry's inclusion could not be verified
+)
+
+// Alert reports evidence that the service misbehaved
+type Alert struct {
+	Kind       AlertKind `json:"kind"`
+	Detail     string    `json:"detail"`
+	Service    string    `json:"service"`
+	Shard      string    `json:"shard,omitempty"`
+	TreeSize   int64     `json:"tree_size"`
+	Checkpoint string    `json:"checkpoint,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// State is what the monitor trusts, persisted between runs
+type State struct {
+	Shard      string    `json:"shard,omitempty"` // Shard of a sharded service the state belongs to
+	TreeSize   int64     `json:"tree_size"`
+	RootHash   string    `json:"root_hash"` // Hex-encoded
+	Checkpoint string    `json:"checkpoint"`
+	NextEntry  int64     `json:"next_entry"` // First watched entry not yet verified
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Config configures a monitor
+type Config struct {
+	ServiceURL string
+	PublicKeys []*ecdsa.PublicKey // Pinned service keys
+	StatePath  string             // JSON state file (empty keeps state in memory only)
+
+	// Issuer and Subject select entries whose inclusion is verified as the log grows
+	Issuer  string
+	Subject string
+
+	Shard string // Shard of a sharded service to audit (default: the active shard)
+
+	WebhookURL string // Alerts are POSTed here as JSON (optional)
+	HTTPClient *http.Client
+	Logger     *slog.Logger
+}
+
+// Monitor follows one service's checkpoints
+type Monitor struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+	state  State
+}
+
+// New creates a monitor, loading any persisted state
+func New(cfg Config) (*Monitor, error) {
+	if cfg.ServiceURL == "" {
+		return nil, fmt.Errorf("service URL is required")
+	}
+	if len(cfg.PublicKeys) == 0 {
+		return nil, fmt.Errorf("at least one pinned service key is required")
+	}
+	cfg.ServiceURL = strings.TrimSuffix(cfg.ServiceURL, "/")
+
+	m := &Monitor{cfg: cfg, client: cfg.HTTPClient, logger: cfg.Logger}
+	if m.client == nil {
+		m.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if m.logger == nil {
+		m.logger = slog.Default()
+	}
+
+	if cfg.StatePath != "" {
+		data, err := os.ReadFile(cfg.StatePath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read monitor state: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &m.state); err != nil {
+				return nil, fmt.Errorf("failed to parse monitor state: %w", err)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// State returns the monitor's trusted state
+func (m *Monitor) State() State {
+	return m.state
+}
+
+// Poll fetches the latest checkpoint, verifies it against the trusted state and,
+// when watching, verifies the inclusion of new matching entries
+// Alerts are logged, sent to the webhook and returned; an error means the poll
+// could not complete (e.g. the service is unreachable) and should be retried
+func (m *Monitor) Poll(ctx context.Context) ([]Alert, error) {
+	shard, err := m.auditedShard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new shard is a new tree: audit the previous shard up to its final
+	// checkpoint, then trust the new shard's first checkpoint
+	if shard != m.state.Shard {
+		if m.state.Checkpoint != "" && m.state.Shard != "" {
+			if alerts, err := m.poll(ctx); err != nil || len(alerts) > 0 {
+				return alerts, err
+			}
+		}
+		m.logger.Info("following shard", "service", m.cfg.ServiceURL, "shard", shard, "previous_shard", m.state.Shard)
+		m.state = State{Shard: shard}
+	}
+
+	return m.poll(ctx)
+}
+
+// auditedShard returns the shard to audit: the pinned shard, or the active
+// shard named by the service configuration ("" for an unsharded service)
+func (m *Monitor) auditedShard(ctx context.Context) (string, error) {
+	if m.cfg.Shard != "" {
+		return m.cfg.Shard, nil
+	}
+
+	body, err := m.get(ctx, "/.well-known/scitt-configuration")
+	if err != nil {
+		return "", err
+	}
+	var configuration struct {
+		Sharding *struct {
+			ActiveShard string `json:"active_shard"`
+		} `json:"sharding"`
+	}
+	if err := json.Unmarshal(body, &configuration); err != nil {
+		return "", fmt.Errorf("failed to parse service configuration: %w", err)
+	}
+	if configuration.Sharding == nil {
+		return "", nil
+	}
+	return configuration.Sharding.ActiveShard, nil
+}
+
+// logPath returns the path of a log route on the audited shard
+func (m *Monitor) logPath(path string) string {
+	if m.state.Shard == "" {
+		return path
+	}
+	return "/shards/" + url.PathEscape(m.state.Shard) + path
+}
+
+// poll audits the log of the shard the trusted state belongs to
+func (m *Monitor) poll(ctx context.Context) ([]Alert, error) {
+	note, err := m.get(ctx, m.logPath("/checkpoint"))
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := merkle.DecodeCheckpoint(string(note))
+	if err != nil {
+		return m.alert(ctx, AlertInvalidSignature, fmt.Sprintf("malformed checkpoint: %v", err), 0, string(note)), nil
+	}
+	if !m.verifyCheckpoint(checkpoint) {
+		return m.alert(ctx, AlertInvalidSignature, "checkpoint is not signed by a pinned key", checkpoint.TreeSize, string(note)), nil
+	}
+
+	trustedRoot, err := m.trustedRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case m.state.Checkpoint == "":
+		// Trust on first use
+	case checkpoint.TreeSize < m.state.TreeSize:
+		return m.alert(ctx, AlertRollback, fmt.Sprintf("tree size went from %d to %d", m.state.TreeSize, checkpoint.TreeSize), checkpoint.TreeSize, string(note)), nil
+	case checkpoint.TreeSize == m.state.TreeSize:
+		if checkpoint.RootHash != trustedRoot {
+			return m.alert(ctx, AlertFork, fmt.Sprintf("root hash changed at tree size %d", checkpoint.TreeSize), checkpoint.TreeSize, string(note)), nil
+		}
+	default:
+		consistent, err := m.verifyConsistency(ctx, m.state.TreeSize, trustedRoot, checkpoint.TreeSize, checkpoint.RootHash)
+		if err != nil {
+			return nil, err
+		}
+		if !consistent {
+			return m.alert(ctx, AlertFork, fmt.Sprintf("tree at size %d is not consistent with trusted size %d", checkpoint.TreeSize, m.state.TreeSize), checkpoint.TreeSize, string(note)), nil
+		}
+	}
+
+	if m.state.TreeSize != checkpoint.TreeSize || m.state.Checkpoint == "" {
+		m.logger.Info("checkpoint verified", "service", m.cfg.ServiceURL, "shard", m.state.Shard, "tree_size", checkpoint.TreeSize, "previous_size", m.state.TreeSize)
+	}
+	m.state.TreeSize = checkpoint.TreeSize
+	m.state.RootHash = hex.EncodeToString(checkpoint.RootHash[:])
+	m.state.Checkpoint = string(note)
+	m.state.UpdatedAt = time.Now().UTC()
+
+	var alerts []Alert
+	if m.cfg.Issuer != "" || m.cfg.Subject != "" {
+		alerts, err = m.watchEntries(ctx, checkpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.saveState(); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// verifyCheckpoint reports whether any pinned key signed the checkpoint
+func (m *Monitor) verifyCheckpoint(checkpoint *merkle.Checkpoint) bool {
+	for _, key := range m.cfg.PublicKeys {
+		if valid, err := merkle.VerifyCheckpoint(checkpoint, key); err == nil && valid {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedRoot decodes the root hash of the trusted state
+func (m *Monitor) trustedRoot() ([merkle.HashSize]byte, error) {
+	var root [merkle.HashSize]byte
+	if m.state.RootHash == "" {
+		return root, nil
+	}
+	decoded, err := hex.DecodeString(m.state.RootHash)
+	if err != nil || len(decoded) != merkle.HashSize {
+		return root, fmt.Errorf("corrupt monitor state: invalid root hash")
+	}
+	copy(root[:], decoded)
+	return root, nil
+}
+
+// verifyConsistency fetches and verifies a consistency proof between two tree heads
+func (m *Monitor) verifyConsistency(ctx context.Context, oldSize int64, oldRoot [merkle.HashSize]byte, newSize int64, newRoot [merkle.HashSize]byte) (bool, error) {
+	if oldSize == 0 {
+		return true, nil
+	}
+	if oldSize == newSize {
+		return oldRoot == newRoot, nil
+	}
+
+	body, err := m.get(ctx, m.logPath(fmt.Sprintf("/proofs/consistency?old=%d&new=%d", oldSize, newSize)))
+	if err != nil {
+		return false, err
+	}
+
+	var response struct {
+		Proof []string `json:"proof"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return false, fmt.Errorf("failed to parse consistency proof: %w", err)
+	}
+
+	proof := &merkle.ConsistencyProof{OldSize: oldSize, NewSize: newSize}
+	for _, h := range response.Proof {
+		decoded, err := hex.DecodeString(h)
+		if err != nil || len(decoded) != merkle.HashSize {
+			return false, nil
+		}
+		var hash [merkle.HashSize]byte
+		copy(hash[:], decoded)
+		proof.Proof = append(proof.Proof, hash)
+	}
+
+	return merkle.VerifyConsistencyProof(proof, oldRoot, newRoot), nil
+}
+
+// watchEntries verifies the inclusion of matching entries registered since the last poll
+// The listing spans every shard; entries of other shards are skipped
+func (m *Monitor) watchEntries(ctx context.Context, checkpoint *merkle.Checkpoint) ([]Alert, error) {
+	query := url.Values{}
+	if m.cfg.Issuer != "" {
+		query.Set("iss", m.cfg.Issuer)
+	}
+	if m.cfg.Subject != "" {
+		query.Set("sub", m.cfg.Subject)
+	}
+
+	body, err := m.get(ctx, "/entries?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Entries []struct {
+			EntryID       int64  `json:"entry_id"`
+			StatementHash string `json:"statement_hash"`
+			Shard         string `json:"shard"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse entries: %w", err)
+	}
+
+	var alerts []Alert
+	for _, entry := range response.Entries {
+		if entry.Shard != m.state.Shard || entry.EntryID < m.state.NextEntry || entry.EntryID >= checkpoint.TreeSize {
+			continue
+		}
+
+		detail, err := m.verifyEntry(ctx, entry.EntryID, entry.StatementHash, checkpoint)
+		if err != nil {
+			return alerts, err
+		}
+		if detail != "" {
+			alerts = append(alerts, m.alert(ctx, AlertInclusion, detail, checkpoint.TreeSize, "")...)
+			continue
+		}
+
+		m.logger.Info("entry inclusion verified", "service", m.cfg.ServiceURL, "shard", m.state.Shard, "entry_id", entry.EntryID, "statement_hash", entry.StatementHash)
+	}
+
+	m.state.NextEntry = checkpoint.TreeSize
+	return alerts, nil
+}
+
+// verifyEntry checks an entry's receipt against the pinned keys and the trusted
+// checkpoint, returning a description of the failure (empty when verified)
+func (m *Monitor) verifyEntry(ctx context.Context, entryID int64, statementHash string, checkpoint *merkle.Checkpoint) (string, error) {
+	leafBytes, err := hex.DecodeString(statementHash)
+	if err != nil || len(leafBytes) != merkle.HashSize {
+		return fmt.Sprintf("entry %d has an invalid statement hash", entryID), nil
+	}
+	var leaf [merkle.HashSize]byte
+	copy(leaf[:], leafBytes)
+
+	receiptBytes, err := m.get(ctx, m.logPath(fmt.Sprintf("/entries/%d", entryID)))
+	if err != nil {
+		return "", err
+	}
+	receipt, err := cose.DecodeCoseSign1(receiptBytes)
+	if err != nil {
+		return fmt.Sprintf("entry %d receipt is malformed: %v", entryID, err), nil
+	}
+
+	var proof *merkle.InclusionProof
+	var root [merkle.HashSize]byte
+	for _, key := range m.cfg.PublicKeys {
+		if proof, root, err = merkle.VerifyReceipt(receipt, leaf, key); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Sprintf("entry %d receipt does not verify: %v", entryID, err), nil
+	}
+	if proof.LeafIndex != entryID {
+		return fmt.Sprintf("entry %d receipt proves leaf index %d", entryID, proof.LeafIndex), nil
+	}
+
+	// The receipt's tree must be consistent with the checkpoint just verified
+	var consistent bool
+	if proof.TreeSize >= checkpoint.TreeSize {
+		consistent, err = m.verifyConsistency(ctx, checkpoint.TreeSize, checkpoint.RootHash, proof.TreeSize, root)
+	} else {
+		consistent, err = m.verifyConsistency(ctx, proof.TreeSize, root, checkpoint.TreeSize, checkpoint.RootHash)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !consistent {
+		return fmt.Sprintf("entry %d receipt tree of size %d is not consistent with checkpoint size %d", entryID, proof.TreeSize, checkpoint.TreeSize), nil
+	}
+
+	return "", nil
+}
+
+// alert logs an alert and delivers it to the webhook
+func (m *Monitor) alert(ctx context.Context, kind AlertKind, detail string, treeSize int64, checkpoint string) []Alert {
+	alert := Alert{
+		Kind:       kind,
+		Detail:     detail,
+		Service:    m.cfg.ServiceURL,
+		Shard:      m.state.Shard,
+		TreeSize:   treeSize,
+		Checkpoint: checkpoint,
+		Time:       time.Now().UTC(),
+	}
+
+	m.logger.Error("monitor alert",
+		"kind", string(alert.Kind),
+		"service", alert.Service,
+		"shard", alert.Shard,
+		"tree_size", alert.TreeSize,
+		"trusted_size", m.state.TreeSize,
+		"detail", alert.Detail,
+	)
+
+	if m.cfg.WebhookURL != "" {
+		if err := m.sendWebhook(ctx, alert); err != nil {
+			m.logger.Error("failed to deliver alert webhook", "url", m.cfg.WebhookURL, "error", err)
+		}
+	}
+
+	return []Alert{alert}
+}
+
+// sendWebhook POSTs an alert as JSON
+func (m *Monitor) sendWebhook(ctx context.Context, alert Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// get fetches a path from the service
+func (m *Monitor) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.cfg.ServiceURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: HTTP %d", path, resp.StatusCode)
+	}
+
+	return body, nil
+}
+
+// saveState writes the monitor state atomically using a temp file and rename
+func (m *Monitor) saveState() error {
+	if m.cfg.StatePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode monitor state: %w", err)
+	}
+
+	tempPath := m.cfg.StatePath + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write monitor state: %w", err)
+	}
+	if err := os.Rename(tempPath, m.cfg.StatePath); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write monitor state: %w", err)
+	}
+
+	return nil
+}
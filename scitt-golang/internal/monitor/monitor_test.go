@@ -0,0 +1,334 @@
+package monitor_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/monitor"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/server"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/service"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/testutil"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+)
+
+const testIssuer = "https://issuer.example.com"
+
+// testService is a transparency service with its own database and tile storage
+type testService struct {
+	srv    *server.Server
+	apiKey string
+}
+
+func newTestService(t *testing.T, keys *testutil.Keys) *testService {
+	t.Helper()
+
+	cfg := testutil.NewConfig(t, keys)
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	return &testService{srv: srv, apiKey: cfg.Server.APIKey}
+}
+
+// register adds statements with the given payloads to the service
+func (s *testService) register(t *testing.T, payloads ...string) {
+	t.Helper()
+
+	for _, payload := range payloads {
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t, payload)))
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+		w := httptest.NewRecorder()
+		s.srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+// switchableServer serves whichever handler is current, simulating a service
+// that changes what it presents to the monitor
+type switchableServer struct {
+	handler atomic.Value
+}
+
+func (s *switchableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.Load().(http.Handler).ServeHTTP(w, r)
+}
+
+func newSwitchableServer(t *testing.T, handler http.Handler) (*switchableServer, string) {
+	t.Helper()
+	s := &switchableServer{}
+	s.handler.Store(handler)
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, ts.URL
+}
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("trusts the first checkpoint and follows consistent growth", func(t *testing.T) {
+		keys := testutil.NewKeys(t)
+		service := newTestService(t, keys)
+		service.register(t, "a", "b")
+		_, url := newSwitchableServer(t, service.srv.Handler())
+
+		statePath := filepath.Join(t.TempDir(), "monitor-state.json")
+		m, err := monitor.New(monitor.Config{ServiceURL: url, PublicKeys: []*ecdsa.PublicKey{keys.Public}, StatePath: statePath})
+		if err != nil {
+			t.Fatalf("failed to create monitor: %v", err)
+		}
+
+		if alerts, err := m.Poll(ctx); err != nil || len(alerts) != 0 {
+			t.Fatalf("expected clean poll, got alerts %v, error %v", alerts, err)
+		}
+		if m.State().TreeSize != 2 {
+			t.Errorf("expected trusted size 2, got %d", m.State().TreeSize)
+		}
+
+		service.register(t, "c", "d", "e")
+
+		// A new monitor resumes from the persisted state
+		m, err = monitor.New(monitor.Config{ServiceURL: url, PublicKeys: []*ecdsa.PublicKey{keys.Public}, StatePath: statePath})
+		if err != nil {
+			t.Fatalf("failed to create monitor: %v", err)
+		}
+		if m.State().TreeSize != 2 {
+			t.Fatalf("expected persisted size 2, got %d", m.State().TreeSize)
+		}
+		if alerts, err := m.Poll(ctx); err != nil || len(alerts) != 0 {
+			t.Fatalf("expected clean poll, got alerts %v, error %v", alerts, err)
+		}
+		if m.State().TreeSize != 5 {
+			t.Errorf("expected trusted size 5, got %d", m.State().TreeSize)
+		}
+	})
+
+	t.Run("detects a fork", func(t *testing.T) {
+		keys := testutil.NewKeys(t)
+		honest := newTestService(t, keys)
+		honest.register(t, "a", "b")
+		forked := newTestService(t, keys)
+		forked.register(t, "a", "x", "y")
+
+		switcher, url := newSwitchableServer(t, honest.srv.Handler())
+		m, err := monitor.New(monitor.Config{ServiceURL: url, PublicKeys: []*ecdsa.PublicKey{keys.Public}})
+		if err != nil {
+			t.Fatalf("failed to create monitor: %v", err)
+		}
+		if alerts, err := m.Poll(ctx); err != nil || len(alerts) != 0 {
+			t.Fatalf("expected clean poll, got alerts %v, error %v", alerts, err)
+		}
+
+		switcher.handler.Store(forked.srv.Handler())
+		alerts, err := m.Poll(ctx)
+		if err != nil {
+			t.Fatalf("poll failed: %v", err)
+		}
+		if len(alerts) != 1 || alerts[0].Kind != monitor.AlertFork {
+			t.Fatalf("expected a fork alert, got %v", alerts)
+		}
+		if m.State().TreeSize != 2 {
+			t.Errorf("trusted state must not advance after an alert, got size %d", m.State().TreeSize)
+		}
+	})
+
+	t.Run("detects a rollback", func(t *testing.T) {
+		keys := testutil.NewKeys(t)
+		ahead := newTestService(t, keys)
+		ahead.register(t, "a", "b", "c")
+		behind := newTestService(t, keys)
+		behind.register(t, "a", "b")
+
+		switcher, url := newSwitchableServer(t, ahead.srv.Handler())
+		m, err := monitor.New(monitor.Config{ServiceURL: url, PublicKeys: []*ecdsa.PublicKey{keys.Public}})
+		if err != nil {
+			t.Fatalf("failed to create monitor: %v", err)
+		}
+		if _, err := m.Poll(ctx); err != nil {
+			t.Fatalf("poll failed: %v", err)
+		}
+
+		switcher.handler.Store(behind.srv.Handler())
+		alerts, err := m.Poll(ctx)
+		if err != nil {
+			t.Fatalf("poll failed: %v", err)
+		}
+		if len(alerts) != 1 || alerts[0].Kind != monitor.AlertRollback {
+			t.Fatalf("expected a rollback alert, got %v", alerts)
+		}
+	})
+
+	t.Run("rejects checkpoints not signed by a pinned key", func(t *testing.T) {
+		service := newTestService(t, testutil.NewKeys(t))
+		service.register(t, "a")
+		_, url := newSwitchableServer(t, service.srv.Handler())
+
+		// Alerts are also delivered to the webhook
+		var received []monitor.Alert
+		webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var alert monitor.Alert
+			if err := json.NewDecoder(r.Body).Decode(&alert); err == nil {
+				received = append(received, alert)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer webhook.Close()
+
+		m, err := monitor.New(monitor.Config{
+			ServiceURL: url,
+			PublicKeys: []*ecdsa.PublicKey{testutil.NewKeys(t).Public},
+			WebhookURL: webhook.URL,
+		})
+		if err != nil {
+			t.Fatalf("failed to create monitor: %v", err)
+		}
+
+		alerts, err := m.Poll(ctx)
+		if err != nil {
+			t.Fatalf("poll failed: %v", err)
+		}
+		if len(alerts) != 1 || alerts[0].Kind != monitor.AlertInvalidSignature {
+			t.Fatalf("expected an invalid-signature alert, got %v", alerts)
+		}
+		if m.State().Checkpoint != "" {
+			t.Error("an unverified checkpoint must not be trusted")
+		}
+		if len(received) != 1 || received[0].Kind != monitor.AlertInvalidSignature {
+			t.Errorf("expected the alert to be delivered to the webhook, got %v", received)
+		}
+	})
+
+	t.Run("verifies inclusion of watched entries", func(t *testing.T) {
+		keys := testutil.NewKeys(t)
+		service := newTestService(t, keys)
+		service.register(t, "a", "b")
+		_, url := newSwitchableServer(t, service.srv.Handler())
+
+		m, err := monitor.New(monitor.Config{
+			ServiceURL: url,
+			PublicKeys: []*ecdsa.PublicKey{keys.Public},
+			Issuer:     testIssuer,
+		})
+		if err != nil {
+			t.Fatalf("failed to create monitor: %v", err)
+		}
+		if alerts, err := m.Poll(ctx); err != nil || len(alerts) != 0 {
+			t.Fatalf("expected clean poll, got alerts %v, error %v", alerts, err)
+		}
+		if m.State().NextEntry != 2 {
+			t.Errorf("expected next entry 2, got %d", m.State().NextEntry)
+		}
+
+		service.register(t, "c")
+		if alerts, err := m.Poll(ctx); err != nil || len(alerts) != 0 {
+			t.Fatalf("expected clean poll, got alerts %v, error %v", alerts, err)
+		}
+		if m.State().NextEntry != 3 {
+			t.Errorf("expected next entry 3, got %d", m.State().NextEntry)
+		}
+	})
+
+	t.Run("follows the active shard across a rotation", func(t *testing.T) {
+		keys := testutil.NewKeys(t)
+		cfg := testutil.NewConfig(t, keys)
+		cfg.Sharding = &config.ShardingConfig{Interval: config.ShardIntervalYearly}
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+		sharded := &testService{srv: srv, apiKey: cfg.Server.APIKey}
+		_, url := newSwitchableServer(t, srv.Handler())
+
+		now := time.Now()
+		current := service.ShardName(config.ShardIntervalYearly, now)
+		next := service.ShardName(config.ShardIntervalYearly, now.AddDate(1, 0, 0))
+
+		sharded.register(t, "a", "b")
+		m, err := monitor.New(monitor.Config{ServiceURL: url, PublicKeys: []*ecdsa.PublicKey{keys.Public}, Issuer: testIssuer})
+		if err != nil {
+			t.Fatalf("failed to create monitor: %v", err)
+		}
+		if alerts, err := m.Poll(ctx); err != nil || len(alerts) != 0 {
+			t.Fatalf("expected clean poll, got alerts %v, error %v", alerts, err)
+		}
+		if state := m.State(); state.Shard != current || state.TreeSize != 2 || state.NextEntry != 2 {
+			t.Fatalf("expected shard %s at size 2, got %+v", current, state)
+		}
+
+		// The rotated shard is audited to its final checkpoint before the new one is trusted
+		sharded.register(t, "c")
+		if err := srv.Service().RotateShards(now.AddDate(1, 0, 0)); err != nil {
+			t.Fatalf("failed to rotate shards: %v", err)
+		}
+		sharded.register(t, "d")
+		if alerts, err := m.Poll(ctx); err != nil || len(alerts) != 0 {
+			t.Fatalf("expected clean poll across the rotation, got alerts %v, error %v", alerts, err)
+		}
+		if state := m.State(); state.Shard != next || state.TreeSize != 1 || state.NextEntry != 1 {
+			t.Errorf("expected shard %s at size 1, got %+v", next, state)
+		}
+
+		// A pinned monitor keeps auditing its shard
+		pinned, err := monitor.New(monitor.Config{ServiceURL: url, PublicKeys: []*ecdsa.PublicKey{keys.Public}, Issuer: testIssuer, Shard: current})
+		if err != nil {
+			t.Fatalf("failed to create monitor: %v", err)
+		}
+		if alerts, err := pinned.Poll(ctx); err != nil || len(alerts) != 0 {
+			t.Fatalf("expected clean poll, got alerts %v, error %v", alerts, err)
+		}
+		if state := pinned.State(); state.Shard != current || state.TreeSize != 3 {
+			t.Errorf("expected shard %s at size 3, got %+v", current, state)
+		}
+	})
+
+	t.Run("requires pinned keys", func(t *testing.T) {
+		if _, err := monitor.New(monitor.Config{ServiceURL: "http://localhost"}); err == nil {
+			t.Error("expected an error without pinned keys")
+		}
+	})
+}
+
+func createTestStatement(t *testing.T, payload string) []byte {
+	t.Helper()
+
+	keyPair, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	signer, err := cose.NewES256Signer(keyPair.Private)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	headers := cose.CreateProtectedHeaders(cose.ProtectedHeadersOptions{
+		Alg: cose.AlgorithmES256,
+		Cty: "application/json",
+		CWTClaims: cose.CreateCWTClaims(cose.CWTClaimsOptions{
+			Iss: testIssuer,
+			Sub: "test-artifact",
+		}),
+	})
+
+	statement, err := cose.CreateCoseSign1(headers, []byte(payload), signer, cose.CoseSign1Options{})
+	if err != nil {
+		t.Fatalf("failed to create COSE Sign1: %v", err)
+	}
+	encoded, err := cose.EncodeCoseSign1(statement)
+	if err != nil {
+		t.Fatalf("failed to encode COSE Sign1: %v", err)
+	}
+	return encoded
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/metrics"
+)
+
+var (
+	httpRequestsTotal = metrics.NewCounterVec(
+		"scitt_http_requests_total",
+		"HTTP requests by route, method and status code.",
+		"route", "method", "status", "log",
+	)
+	httpRequestDuration = metrics.NewHistogramVec(
+		"scitt_http_request_duration_seconds",
+		"HTTP request latency by route and method.",
+		metrics.DefaultBuckets,
+		"route", "method", "log",
+	)
+)
+
+// The log label is only set when several logs are hosted in one process
+func init() {
+	httpRequestsTotal.OmitEmpty("log")
+	httpRequestDuration.OmitEmpty("log")
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// metricsMiddleware records request counts and latencies per route and status
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		route := routeLabel(r.URL.Path)
+		httpRequestsTotal.WithLabelValues(route, r.Method, strconv.Itoa(status), s.config.Name).Inc()
+		httpRequestDuration.WithLabelValues(route, r.Method, s.config.Name).Observe(metrics.Since(start))
+	})
+}
+
+// routeLabel maps a request path to its route template so that entry IDs and
+// digests do not create unbounded label values
+func routeLabel(path string) string {
+	switch {
+	case path == "/entries" || path == "/health" || path == "/health/live" || path == "/health/ready" || path == "/metrics" || path == "/openapi.json" ||
+		path == "/.well-known/scitt-configuration" || path == "/.well-known/scitt-keys" || path == "/checkpoint" || path == "/proofs/consistency" ||
+		path == "/proofs/inclusion":
+		return path
+	case strings.HasPrefix(path, "/entries/"):
+		return "/entries/{id}"
+	case strings.HasPrefix(path, "/artifacts/"):
+		return "/artifacts/{alg}/{digest}"
+	case strings.HasPrefix(path, "/tile/"):
+		return "/tile/{path}"
+	case strings.HasPrefix(path, "/shards/"):
+		if _, rest, ok := strings.Cut(strings.TrimPrefix(path, "/shards/"), "/"); ok {
+			if route := routeLabel("/" + rest); strings.HasPrefix(route, "/") && route != "/" {
+				return "/shards/{shard}" + route
+			}
+		}
+		return "other"
+	case path == "/":
+		return path
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/logging"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/service"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+)
+
+// Content types for error responses
+const (
+	ContentTypeConciseProblemDetails = "application/concise-problem-details+cbor"
+	ContentTypeProblemJSON           = "application/problem+json"
+)
+
+// Concise problem details labels (RFC 9290)
+const (
+	problemLabelTitle    = -1
+	problemLabelDetail   = -2
+	problemLabelInstance = -3
+)
+
+// statusForErrorKind maps a service error kind to its HTTP status code
+func statusForErrorKind(kind service.ErrorKind) int {
+	switch kind {
+	case service.ErrorKindNotFound:
+		return http.StatusNotFound
+	case service.ErrorKindInvalidStatement:
+		return http.StatusBadRequest
+	case service.ErrorKindPolicyViolation:
+		return http.StatusForbidden
+	case service.ErrorKindUnauthorized:
+		return http.StatusUnauthorized
+	case service.ErrorKindForbidden:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeServiceError writes err as a problem details response
+// Internal causes are logged but never returned to the client
+func (s *Server) writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	serviceErr := service.AsError(err)
+	status := statusForErrorKind(serviceErr.Kind)
+
+	level := slog.LevelDebug
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logging.FromContext(r.Context()).Log(r.Context(), level, "request failed",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", status,
+		"kind", string(serviceErr.Kind),
+		"error", err,
+	)
+
+	writeProblem(w, r, status, serviceErr.Kind.Title(), serviceErr.Detail)
+}
+
+// writeProblem writes a problem details response
+// CBOR concise problem details are returned unless the client prefers JSON
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, title, detail string) {
+	if prefersJSON(r) {
+		w.Header().Set("Content-Type", ContentTypeProblemJSON)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"title":    title,
+			"status":   status,
+			"detail":   detail,
+			"instance": r.URL.Path,
+		})
+		return
+	}
+
+	body, err := cose.Marshal(map[int]interface{}{
+		problemLabelTitle:    title,
+		problemLabelDetail:   detail,
+		problemLabelInstance: r.URL.Path,
+	})
+	if err != nil {
+		http.Error(w, title, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeConciseProblemDetails)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeMethodNotAllowed writes a 405 problem details response
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusMethodNotAllowed, "Method Not Allowed", r.Method+" is not supported on "+r.URL.Path)
+}
+
+// prefersJSON reports whether the client asked for JSON error bodies
+func prefersJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, ContentTypeProblemJSON) || strings.Contains(accept, "application/json")
+}
@@ -1,17 +1,34 @@
 package server
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/auth"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/logging"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/service"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/metrics"
 	"gopkg.in/yaml.v3"
 )
 
@@ -23,10 +40,24 @@ type Server struct {
 	config  *config.Config
 	service *service.TransparencyService
 	mux     *http.ServeMux
+	shard   *http.ServeMux     // read-only routes of a single shard, under /shards/{shard}
+	oidc    *auth.OIDCVerifier // nil unless auth.oidc is configured
+
+	// logs are the servers of the logs hosted by a multi-log definition; such a
+	// server has no service of its own and dispatches requests to its logs
+	logs  []*Server
+	mount config.LogConfig // path prefix and host of a hosted log
+
+	mu         sync.Mutex
+	httpServer *http.Server // set once Start is called
 }
 
 // NewServer creates a new HTTP server
 func NewServer(cfg *config.Config) (*Server, error) {
+	if len(cfg.Logs) > 0 {
+		return newMultiLogServer(cfg)
+	}
+
 	// Create transparency service
 	svc, err := service.NewTransparencyService(cfg)
 	if err != nil {
@@ -37,6 +68,14 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		config:  cfg,
 		service: svc,
 		mux:     http.NewServeMux(),
+		shard:   http.NewServeMux(),
+	}
+
+	if cfg.Auth.OIDC != nil {
+		server.oidc, err = auth.NewOIDCVerifier(cfg.Auth.OIDC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure OIDC authentication: %w", err)
+		}
 	}
 
 	// Register routes
@@ -45,12 +84,43 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	return server, nil
 }
 
+// newMultiLogServer creates a server hosting every log of a multi-log definition
+// Health, metrics and API documentation are served for the process as a whole
+func newMultiLogServer(cfg *config.Config) (*Server, error) {
+	server := &Server{
+		config: cfg,
+		mux:    http.NewServeMux(),
+	}
+
+	for _, mount := range cfg.Logs {
+		log, err := NewServer(cfg.ForLog(mount))
+		if err != nil {
+			server.Close()
+			return nil, fmt.Errorf("failed to create log %s: %w", mount.Name, err)
+		}
+		log.mount = mount
+		server.logs = append(server.logs, log)
+	}
+
+	server.mux.HandleFunc("/", server.handleSwaggerUI)
+	server.mux.HandleFunc("/health", server.handleHealth)
+	server.mux.HandleFunc("/health/live", server.handleLiveness)
+	server.mux.HandleFunc("/health/ready", server.handleReadiness)
+	server.mux.HandleFunc("/openapi.json", server.handleOpenAPISpec)
+	server.mux.Handle("/metrics", metrics.Default.Handler())
+
+	return server, nil
+}
+
 // registerRoutes registers all HTTP routes
 func (s *Server) registerRoutes() {
 	// API Documentation
 	s.mux.HandleFunc("/", s.handleSwaggerUI)
 	s.mux.HandleFunc("/health", s.handleHealth)
+	s.mux.HandleFunc("/health/live", s.handleLiveness)
+	s.mux.HandleFunc("/health/ready", s.handleReadiness)
 	s.mux.HandleFunc("/openapi.json", s.handleOpenAPISpec)
+	s.mux.Handle("/metrics", metrics.Default.Handler())
 
 	// Well-known endpoints (should be at the top)
 	s.mux.HandleFunc("/.well-known/scitt-configuration", s.handleSCITTConfiguration)
@@ -59,64 +129,329 @@ func (s *Server) registerRoutes() {
 	// SCRAPI routes
 	s.mux.HandleFunc("/entries", s.handleEntries)
 	s.mux.HandleFunc("/entries/", s.handleEntriesWithID)
+
+	// Artifact lookup
+	s.mux.HandleFunc("/artifacts/", s.handleArtifacts)
+
+	// Checkpoints and proofs for monitors and mirrors
+	s.mux.HandleFunc("/checkpoint", s.handleCheckpoint)
+	s.mux.HandleFunc("/proofs/consistency", s.handleConsistencyProof)
+	s.mux.HandleFunc("/proofs/inclusion", s.handleInclusionProof)
+	s.mux.HandleFunc("/tile/", s.handleTile)
+
+	// Receipts, checkpoints, proofs and tiles of active and frozen shards
+	s.mux.HandleFunc("/shards/", s.handleShards)
+	s.shard.HandleFunc("/entries/", s.handleEntriesWithID)
+	s.shard.HandleFunc("/checkpoint", s.handleCheckpoint)
+	s.shard.HandleFunc("/proofs/consistency", s.handleConsistencyProof)
+	s.shard.HandleFunc("/proofs/inclusion", s.handleInclusionProof)
+	s.shard.HandleFunc("/tile/", s.handleTile)
+}
+
+// shardLogKey is the request context key of the shard log addressed by a /shards/ path
+type shardLogKey struct{}
+
+// logFor returns the log a request addresses: the shard named in a /shards/
+// path, otherwise the log accepting registrations
+func (s *Server) logFor(r *http.Request) (*service.TransparencyService, error) {
+	if log, ok := r.Context().Value(shardLogKey{}).(*service.TransparencyService); ok {
+		return log, nil
+	}
+	return s.service.ActiveLog()
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server and blocks until it is shut down
+// Returns nil after a graceful Shutdown
 func (s *Server) Start() error {
-	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
-	log.Printf("SCITT Transparency Service")
-	log.Printf("Documentation: http://%s/", addr)
+	httpServer, err := s.setupHTTPServer()
+	if err != nil {
+		return err
+	}
+	return s.serve(httpServer)
+}
+
+// Run starts the HTTP server and gracefully shuts it down when ctx is cancelled
+// In-flight requests are drained for up to the configured shutdown timeout
+func (s *Server) Run(ctx context.Context) error {
+	httpServer, err := s.setupHTTPServer()
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.serve(httpServer)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
 
-	// Wrap mux with middleware
-	handler := s.loggingMiddleware(s.corsMiddleware(s.mux))
+	slog.Info("shutting down, draining in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.Server.Timeouts.WithDefaults().Shutdown)
+	defer cancel()
 
-	return http.ListenAndServe(addr, handler)
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+
+	return <-errCh
+}
+
+// serve listens on the configured address until the server is shut down
+func (s *Server) serve(httpServer *http.Server) error {
+	scheme := "http"
+	if s.config.Server.TLS != nil {
+		scheme = "https"
+	}
+	attrs := []any{"addr", httpServer.Addr}
+	if len(s.logs) > 0 {
+		for _, log := range s.logs {
+			attrs = append(attrs, "log."+log.config.Name, log.config.Issuer)
+		}
+	} else {
+		attrs = append(attrs, "issuer", s.config.Issuer)
+	}
+	attrs = append(attrs, "documentation", fmt.Sprintf("%s://%s/", scheme, httpServer.Addr))
+	slog.Info("SCITT transparency service listening", attrs...)
+
+	var err error
+	if s.config.Server.TLS != nil {
+		err = httpServer.ListenAndServeTLS(s.config.Server.TLS.CertFile, s.config.Server.TLS.KeyFile)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to finish
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+	return httpServer.Shutdown(ctx)
+}
+
+// Service returns the transparency service behind the server
+func (s *Server) Service() *service.TransparencyService {
+	return s.service
+}
+
+// Log returns the server of a hosted log, or nil if no log has that name
+func (s *Server) Log(name string) *Server {
+	for _, log := range s.logs {
+		if log.config.Name == name {
+			return log
+		}
+	}
+	return nil
 }
 
 // Close closes the server and releases resources
 func (s *Server) Close() error {
-	return s.service.Close()
+	var errs []error
+	for _, log := range s.logs {
+		errs = append(errs, log.Close())
+	}
+	if s.service != nil {
+		errs = append(errs, s.service.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// setupHTTPServer configures an http.Server with timeouts and optional TLS
+func (s *Server) setupHTTPServer() (*http.Server, error) {
+	timeouts := s.config.Server.Timeouts.WithDefaults()
+
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port),
+		Handler:           s.Handler(),
+		ReadTimeout:       timeouts.Read,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+
+	if s.config.Server.TLS != nil {
+		tlsConfig := &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+
+		// Client certificates are verified when presented and required
+		// only for registration (see requireClientCertificate)
+		if s.config.Server.TLS.ClientCAFile != "" {
+			caPEM, err := os.ReadFile(s.config.Server.TLS.ClientCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read client CA file: %w", err)
+			}
+
+			clientCAs := x509.NewCertPool()
+			if !clientCAs.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("no certificates found in client CA file: %s", s.config.Server.TLS.ClientCAFile)
+			}
+
+			tlsConfig.ClientCAs = clientCAs
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+
+		httpServer.TLSConfig = tlsConfig
+	}
+
+	s.mu.Lock()
+	s.httpServer = httpServer
+	s.mu.Unlock()
+
+	return httpServer, nil
+}
+
+// authenticate resolves the bearer credential of a request and checks it grants scope
+// Accepts the service API key from the configuration, an OIDC JWT when configured,
+// or a client API key from the database
+func (s *Server) authenticate(r *http.Request, scope auth.Scope) (*auth.Principal, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, service.NewUnauthorizedError("missing API key", nil)
+	}
+
+	const bearerPrefix = "Bearer "
+	if !strings.HasPrefix(authHeader, bearerPrefix) {
+		return nil, service.NewUnauthorizedError("invalid authorization format", nil)
+	}
+	token := strings.TrimPrefix(authHeader, bearerPrefix)
+
+	principal, ok := auth.AuthenticateStaticAPIKey(s.config.Server.APIKey, token)
+	if !ok && s.oidc != nil && auth.LooksLikeJWT(token) {
+		var err error
+		principal, err = s.oidc.Verify(token)
+		if err != nil {
+			return nil, service.NewUnauthorizedError("invalid bearer token", err)
+		}
+	} else if !ok {
+		var err error
+		principal, err = s.service.AuthenticateAPIKey(token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !principal.HasScope(scope) {
+		return nil, service.NewForbiddenError(fmt.Sprintf("credential lacks the %s scope", scope), nil)
+	}
+
+	return principal, nil
+}
+
+// maxRequestBytes returns the configured registration body limit; validation
+// keeps it within the largest statement an entry bundle can hold
+func (s *Server) maxRequestBytes() int64 {
+	if s.config.Server.MaxRequestBytes > 0 {
+		return s.config.Server.MaxRequestBytes
+	}
+	return config.DefaultMaxRequestBytes
+}
+
+// requireClientCertificate reports whether mTLS is enabled and the request lacks a verified client certificate
+func (s *Server) requireClientCertificate(r *http.Request) bool {
+	if s.config.Server.TLS == nil || s.config.Server.TLS.ClientCAFile == "" {
+		return false
+	}
+	return r.TLS == nil || len(r.TLS.VerifiedChains) == 0
 }
 
 // Handler returns the HTTP handler for testing
 func (s *Server) Handler() http.Handler {
-	return s.loggingMiddleware(s.corsMiddleware(s.mux))
+	return s.requestIDMiddleware(s.loggingMiddleware(http.HandlerFunc(s.dispatch)))
 }
 
-// handleEntries handles POST /entries (register statement)
-func (s *Server) handleEntries(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// logHandler returns the handler of the server's own routes
+func (s *Server) logHandler() http.Handler {
+	return s.metricsMiddleware(s.corsMiddleware(s.mux))
+}
+
+// dispatch routes a request to the hosted log it addresses by Host header and
+// path prefix; the prefix is stripped before the log's routes are matched
+func (s *Server) dispatch(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	for _, log := range s.logs {
+		if log.mount.Host != "" && !strings.EqualFold(log.mount.Host, host) {
+			continue
+		}
+		prefix := log.mount.PathPrefix
+		if prefix == "" {
+			log.logHandler().ServeHTTP(w, r)
+			return
+		}
+		if r.URL.Path != prefix && !strings.HasPrefix(r.URL.Path, prefix+"/") {
+			continue
+		}
+
+		logRequest := r.Clone(r.Context())
+		logRequest.URL.Path = strings.TrimPrefix(r.URL.Path, prefix)
+		logRequest.URL.RawPath = ""
+		if logRequest.URL.Path == "" {
+			logRequest.URL.Path = "/"
+		}
+		log.logHandler().ServeHTTP(w, logRequest)
 		return
 	}
 
-	// Validate API key
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		log.Printf("Missing Authorization header")
-		http.Error(w, "Unauthorized: missing API key", http.StatusUnauthorized)
+	s.logHandler().ServeHTTP(w, r)
+}
+
+// location returns the URL path of a log resource as seen by clients
+func (s *Server) location(path string) string {
+	return s.mount.PathPrefix + path
+}
+
+// handleEntries handles POST /entries (register statement) and GET /entries (list entries)
+func (s *Server) handleEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		s.handleListEntries(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
-	// Extract Bearer token
-	const bearerPrefix = "Bearer "
-	if !strings.HasPrefix(authHeader, bearerPrefix) {
-		log.Printf("Invalid Authorization header format")
-		http.Error(w, "Unauthorized: invalid authorization format", http.StatusUnauthorized)
+	// Registration requires a verified client certificate when mTLS is enabled
+	if s.requireClientCertificate(r) {
+		s.service.RecordRejectedRegistration(string(service.ErrorKindUnauthorized))
+		s.writeServiceError(w, r, service.NewUnauthorizedError("client certificate required", nil))
 		return
 	}
 
-	apiKey := strings.TrimPrefix(authHeader, bearerPrefix)
-	if apiKey != s.config.Server.APIKey {
-		log.Printf("Invalid API key provided")
-		http.Error(w, "Unauthorized: invalid API key", http.StatusUnauthorized)
+	// Authenticate the client and check it may register statements
+	principal, err := s.authenticate(r, auth.ScopeRegister)
+	if err != nil {
+		s.service.RecordRejectedRegistration(string(service.AsError(err).Kind))
+		s.writeServiceError(w, r, err)
 		return
 	}
 
 	// Read request body
-	body, err := io.ReadAll(r.Body)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxRequestBytes()))
 	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.service.RecordRejectedRegistration("too-large")
+			writeProblem(w, r, http.StatusRequestEntityTooLarge, "Request Entity Too Large", fmt.Sprintf("signed statement exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, "Bad Request", "failed to read request body")
 		return
 	}
 	defer r.Body.Close()
@@ -124,41 +459,92 @@ func (s *Server) handleEntries(w http.ResponseWriter, r *http.Request) {
 	// Register statement
 	req := &service.RegisterStatementRequest{
 		Statement: body,
+		Principal: principal,
 	}
 
-	resp, err := s.service.RegisterStatement(req)
+	resp, err := s.service.RegisterStatement(r.Context(), req)
 	if err != nil {
-		log.Printf("Failed to register statement: %v", err)
-		http.Error(w, fmt.Sprintf("Failed to register statement: %v", err), http.StatusBadRequest)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
 	// Return COSE receipt as application/cose (per SCRAPI specification)
+	// A re-submitted statement returns the existing entry with 200 instead of 201
 	w.Header().Set("Content-Type", "application/cose")
-	w.WriteHeader(http.StatusCreated)
+	if resp.Shard != "" {
+		w.Header().Set("Location", s.location(fmt.Sprintf("/shards/%s/entries/%d", resp.Shard, resp.EntryID)))
+	} else {
+		w.Header().Set("Location", s.location(fmt.Sprintf("/entries/%d", resp.EntryID)))
+	}
+	if resp.AlreadyRegistered {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
 	w.Write(resp.Receipt)
 }
 
 // handleEntriesWithID handles GET /entries/{entryId} (get receipt)
 func (s *Server) handleEntriesWithID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
+	if s.config.Server.RequireReadAuth {
+		if _, err := s.authenticate(r, auth.ScopeRead); err != nil {
+			s.writeServiceError(w, r, err)
+			return
+		}
+	}
+
 	// Extract entry ID from path
 	path := strings.TrimPrefix(r.URL.Path, "/entries/")
 	entryID, err := strconv.ParseInt(path, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid entry ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("invalid entry ID: %s", path))
+		return
+	}
+
+	// A tree size pins the receipt to the checkpoint published at that size
+	var opts service.ReceiptOptions
+	if value := r.URL.Query().Get("tree_size"); value != "" {
+		opts.TreeSize, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || opts.TreeSize <= entryID {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", "tree_size must be a tree size greater than the entry ID")
+			return
+		}
+	}
+
+	// Refresh issues a receipt against the current tree without storing it
+	if value := r.URL.Query().Get("refresh"); value != "" {
+		opts.Refresh, err = strconv.ParseBool(value)
+		if err != nil || (opts.Refresh && opts.TreeSize > 0) {
+			writeProblem(w, r, http.StatusBadRequest, "Bad Request", "refresh must be a boolean and cannot be combined with tree_size")
+			return
+		}
+	}
+
+	log, err := s.logFor(r)
+	if err != nil {
+		s.writeServiceError(w, r, err)
 		return
 	}
 
 	// Get receipt
-	receipt, err := s.service.GetReceipt(entryID)
+	receipt, err := log.GetReceiptWithOptions(r.Context(), entryID, opts)
 	if err != nil {
-		log.Printf("Failed to get receipt: %v", err)
-		http.Error(w, "Receipt not found", http.StatusNotFound)
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	// The receipt hash identifies the stored receipt
+	hash := sha256.Sum256(receipt)
+	etag := `"` + hex.EncodeToString(hash[:]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -168,10 +554,358 @@ func (s *Server) handleEntriesWithID(w http.ResponseWriter, r *http.Request) {
 	w.Write(receipt)
 }
 
+// etagMatches reports whether an If-None-Match header lists an entity tag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// handleArtifacts handles GET /artifacts/{alg}/{digest}/statements (lookup by artifact digest)
+func (s *Server) handleArtifacts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	if s.config.Server.RequireReadAuth {
+		if _, err := s.authenticate(r, auth.ScopeRead); err != nil {
+			s.writeServiceError(w, r, err)
+			return
+		}
+	}
+
+	// Extract algorithm and digest from path
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/artifacts/"), "/")
+	if len(parts) != 3 || parts[2] != "statements" {
+		writeProblem(w, r, http.StatusNotFound, "Not Found", "unknown artifact resource")
+		return
+	}
+
+	hashAlg, err := cose.ParseHashAlgorithm(parts[0])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("unsupported hash algorithm: %s", parts[0]))
+		return
+	}
+
+	digest, err := hex.DecodeString(parts[1])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Bad Request", "digest must be hex encoded")
+		return
+	}
+
+	digestSize, err := cose.HashAlgorithmSize(hashAlg)
+	if err != nil || len(digest) != digestSize {
+		writeProblem(w, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("invalid digest length for %s", cose.HashAlgorithmName(hashAlg)))
+		return
+	}
+
+	entries, err := s.service.LookupArtifact(r.Context(), hashAlg, digest)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	// Bundle format returns the receipts as a single CBOR document
+	if r.URL.Query().Get("format") == "bundle" {
+		bundle := make([]map[string]interface{}, 0, len(entries))
+		for _, entry := range entries {
+			statementHash, err := hex.DecodeString(entry.Statement.StatementHash)
+			if err != nil {
+				s.writeServiceError(w, r, fmt.Errorf("invalid statement hash for entry %d: %w", entry.EntryID, err))
+				return
+			}
+			item := map[string]interface{}{
+				"entry_id":       entry.EntryID,
+				"statement_hash": statementHash,
+				"receipt":        entry.Receipt,
+			}
+			if entry.Shard != "" {
+				item["shard"] = entry.Shard
+			}
+			bundle = append(bundle, item)
+		}
+
+		bundleBytes, err := cose.Marshal(bundle)
+		if err != nil {
+			s.writeServiceError(w, r, fmt.Errorf("failed to encode bundle: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/cbor")
+		w.WriteHeader(http.StatusOK)
+		w.Write(bundleBytes)
+		return
+	}
+
+	results := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		result := map[string]interface{}{
+			"entry_id":              entry.EntryID,
+			"statement_hash":        entry.Statement.StatementHash,
+			"iss":                   entry.Statement.Iss,
+			"sub":                   entry.Statement.Sub,
+			"cty":                   entry.Statement.Cty,
+			"typ":                   entry.Statement.Typ,
+			"preimage_content_type": entry.Statement.PreimageContentType,
+			"payload_location":      entry.Statement.PayloadLocation,
+			"registered_at":         entry.Statement.RegisteredAt,
+			"receipt":               base64.StdEncoding.EncodeToString(entry.Receipt),
+		}
+		if entry.Shard != "" {
+			result["shard"] = entry.Shard
+		}
+		results = append(results, result)
+	}
+
+	response := map[string]interface{}{
+		"hash_algorithm": cose.HashAlgorithmName(hashAlg),
+		"digest":         hex.EncodeToString(digest),
+		"entries":        results,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleListEntries handles GET /entries?iss=...&sub=... (entries by statement issuer and subject)
+func (s *Server) handleListEntries(w http.ResponseWriter, r *http.Request) {
+	if s.config.Server.RequireReadAuth {
+		if _, err := s.authenticate(r, auth.ScopeRead); err != nil {
+			s.writeServiceError(w, r, err)
+			return
+		}
+	}
+
+	var filters database.StatementQueryFilters
+	if iss := r.URL.Query().Get("iss"); iss != "" {
+		filters.Iss = &iss
+	}
+	if sub := r.URL.Query().Get("sub"); sub != "" {
+		filters.Sub = &sub
+	}
+	if filters.Iss == nil && filters.Sub == nil {
+		writeProblem(w, r, http.StatusBadRequest, "Bad Request", "iss or sub query parameter is required")
+		return
+	}
+
+	entries, err := s.service.ListEntries(r.Context(), filters)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	results := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		result := map[string]interface{}{
+			"entry_id":       entry.EntryID,
+			"statement_hash": entry.Statement.StatementHash,
+			"iss":            entry.Statement.Iss,
+			"sub":            entry.Statement.Sub,
+			"cty":            entry.Statement.Cty,
+			"typ":            entry.Statement.Typ,
+			"registered_at":  entry.Statement.RegisteredAt,
+		}
+		if entry.Shard != "" {
+			result["shard"] = entry.Shard
+		}
+		results = append(results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"entries": results})
+}
+
+// handleCheckpoint handles GET /checkpoint (latest signed tree head as a signed note)
+func (s *Server) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	if s.config.Server.RequireReadAuth {
+		if _, err := s.authenticate(r, auth.ScopeRead); err != nil {
+			s.writeServiceError(w, r, err)
+			return
+		}
+	}
+
+	log, err := s.logFor(r)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	checkpoint, err := log.GetPublishedCheckpoint(r.Context())
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, checkpoint)
+}
+
+// handleConsistencyProof handles GET /proofs/consistency?old=<size>&new=<size>
+func (s *Server) handleConsistencyProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	if s.config.Server.RequireReadAuth {
+		if _, err := s.authenticate(r, auth.ScopeRead); err != nil {
+			s.writeServiceError(w, r, err)
+			return
+		}
+	}
+
+	oldSize, errOld := strconv.ParseInt(r.URL.Query().Get("old"), 10, 64)
+	newSize, errNew := strconv.ParseInt(r.URL.Query().Get("new"), 10, 64)
+	if errOld != nil || errNew != nil || oldSize < 1 || oldSize > newSize {
+		writeProblem(w, r, http.StatusBadRequest, "Bad Request", "old and new must be tree sizes with 1 <= old <= new")
+		return
+	}
+
+	log, err := s.logFor(r)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	proof, err := log.GetConsistencyProof(r.Context(), oldSize, newSize)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	hashes := make([]string, 0, len(proof.Proof))
+	for _, hash := range proof.Proof {
+		hashes = append(hashes, hex.EncodeToString(hash[:]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"old_size": proof.OldSize,
+		"new_size": proof.NewSize,
+		"proof":    hashes,
+	})
+}
+
+// handleInclusionProof handles GET /proofs/inclusion?leaf=<hash>&tree_size=<n>
+func (s *Server) handleInclusionProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	if s.config.Server.RequireReadAuth {
+		if _, err := s.authenticate(r, auth.ScopeRead); err != nil {
+			s.writeServiceError(w, r, err)
+			return
+		}
+	}
+
+	leaf, errLeaf := hex.DecodeString(r.URL.Query().Get("leaf"))
+	treeSize, errSize := strconv.ParseInt(r.URL.Query().Get("tree_size"), 10, 64)
+	if errLeaf != nil || len(leaf) != merkle.HashSize || errSize != nil || treeSize < 1 {
+		writeProblem(w, r, http.StatusBadRequest, "Bad Request", "leaf must be a hex SHA-256 statement hash and tree_size a tree size of at least 1")
+		return
+	}
+
+	log, err := s.logFor(r)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	proof, err := log.GetInclusionProof(r.Context(), leaf, treeSize)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	hashes := make([]string, 0, len(proof.AuditPath))
+	for _, hash := range proof.AuditPath {
+		hashes = append(hashes, hex.EncodeToString(hash[:]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"leaf_index": proof.LeafIndex,
+		"tree_size":  proof.TreeSize,
+		"proof":      hashes,
+	})
+}
+
+// handleTile handles GET /tile/0/<N>[.p/<W>] (hash tiles) and
+// /tile/entries/<N>[.p/<W>] (entry bundles)
+func (s *Server) handleTile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	if s.config.Server.RequireReadAuth {
+		if _, err := s.authenticate(r, auth.ScopeRead); err != nil {
+			s.writeServiceError(w, r, err)
+			return
+		}
+	}
+
+	log, err := s.logFor(r)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	tile, err := log.GetTile(r.Context(), strings.TrimPrefix(r.URL.Path, "/"))
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	// A tile path always names the same leaves, so tiles can be cached forever
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.WriteHeader(http.StatusOK)
+	w.Write(tile)
+}
+
+// handleShards handles /shards/{shard}/... (receipts, checkpoints, consistency
+// proofs and tiles of an active or frozen shard)
+func (s *Server) handleShards(w http.ResponseWriter, r *http.Request) {
+	name, path, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/shards/"), "/")
+	if !ok || name == "" {
+		writeProblem(w, r, http.StatusNotFound, "Not Found", "unknown shard resource")
+		return
+	}
+
+	log, err := s.service.Shard(name)
+	if err != nil {
+		s.writeServiceError(w, r, err)
+		return
+	}
+
+	shardRequest := r.Clone(context.WithValue(r.Context(), shardLogKey{}, log))
+	shardRequest.URL.Path = "/" + path
+	s.shard.ServeHTTP(w, shardRequest)
+}
+
 // handleSCITTConfiguration handles GET /.well-known/scitt-configuration
 func (s *Server) handleSCITTConfiguration(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
@@ -187,15 +921,14 @@ func (s *Server) handleSCITTConfiguration(w http.ResponseWriter, r *http.Request
 // handleSCITTKeys handles GET /.well-known/scitt-keys
 func (s *Server) handleSCITTKeys(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
 	// Get keys as COSE Key Set in CBOR format
 	keySet, err := s.service.GetSCITTKeys()
 	if err != nil {
-		log.Printf("Failed to get SCITT keys: %v", err)
-		http.Error(w, "Failed to get keys", http.StatusInternalServerError)
+		s.writeServiceError(w, r, err)
 		return
 	}
 
@@ -208,13 +941,21 @@ func (s *Server) handleSCITTKeys(w http.ResponseWriter, r *http.Request) {
 // handleHealth handles GET /health
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
 	health := map[string]interface{}{
 		"status": "healthy",
-		"issuer": s.config.Issuer,
+	}
+	if len(s.logs) > 0 {
+		logs := make([]map[string]string, 0, len(s.logs))
+		for _, log := range s.logs {
+			logs = append(logs, map[string]string{"name": log.config.Name, "issuer": log.config.Issuer})
+		}
+		health["logs"] = logs
+	} else {
+		health["issuer"] = s.config.Issuer
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -222,11 +963,101 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(health)
 }
 
-// loggingMiddleware logs all HTTP requests
+// handleLiveness handles GET /health/live
+// The process is alive when it can serve requests; dependencies are not checked
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": service.HealthStatusOK,
+	})
+}
+
+// handleReadiness handles GET /health/ready
+// Returns per-component status and 503 when any component is degraded
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	report := s.checkReadiness(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+		logging.FromContext(r.Context()).Warn("readiness check failed", "components", report.Components)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// checkReadiness checks the service, or every hosted log with components
+// reported as "<log>.<component>"
+func (s *Server) checkReadiness(ctx context.Context) *service.HealthReport {
+	if len(s.logs) == 0 {
+		return s.service.CheckReadiness(ctx)
+	}
+
+	report := &service.HealthReport{
+		Status:     service.HealthStatusOK,
+		Components: make(map[string]service.ComponentHealth),
+	}
+	for _, log := range s.logs {
+		logReport := log.service.CheckReadiness(ctx)
+		if !logReport.Ready() {
+			report.Status = service.HealthStatusDegraded
+		}
+		for name, component := range logReport.Components {
+			report.Components[log.config.Name+"."+name] = component
+		}
+	}
+	return report
+}
+
+// requestIDMiddleware assigns each request a correlation ID
+// A well-formed X-Request-ID from the client is honored; otherwise a random ID is generated.
+// The ID is echoed in the response and attached to the request logger.
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(logging.RequestIDHeader)
+		if !logging.ValidRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+
+		w.Header().Set(logging.RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// loggingMiddleware logs all HTTP requests once they complete
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("%s %s %s", r.Method, r.URL.Path, r.RemoteAddr)
-		next.ServeHTTP(w, r)
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		logging.FromContext(r.Context()).Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote_addr", r.RemoteAddr,
+		)
 	})
 }
 
@@ -330,7 +1161,7 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 	// Parse YAML to map
 	var spec map[string]interface{}
 	if err := yaml.Unmarshal([]byte(openapiSpec), &spec); err != nil {
-		log.Printf("Failed to parse OpenAPI spec: %v", err)
+		logging.FromContext(r.Context()).Error("failed to parse OpenAPI spec", "error", err)
 		http.Error(w, "Failed to load API specification", http.StatusInternalServerError)
 		return
 	}
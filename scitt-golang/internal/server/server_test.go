@@ -1,19 +1,40 @@
 package server_test
 
 import (
+	"archive/tar"
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/auth"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/server"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/service"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/testutil"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/witness"
 )
 
 func TestNewServer(t *testing.T) {
@@ -87,6 +108,249 @@ func TestHealthEndpoint(t *testing.T) {
 	})
 }
 
+func TestReadinessEndpoints(t *testing.T) {
+	type healthReport struct {
+		Status     string `json:"status"`
+		Components map[string]struct {
+			Status string `json:"status"`
+			Detail string `json:"detail"`
+		} `json:"components"`
+	}
+
+	newServer := func(t *testing.T, configure func(cfg *config.Config)) (*server.Server, string, string) {
+		t.Helper()
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		t.Cleanup(cleanup)
+
+		tileDir := filepath.Join(t.TempDir(), "tiles")
+		cfg.Storage = config.StorageConfig{Type: "local", Path: tileDir}
+		if configure != nil {
+			configure(cfg)
+		}
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		t.Cleanup(func() { srv.Close() })
+		return srv, apiKey, tileDir
+	}
+
+	ready := func(t *testing.T, srv *server.Server) (int, healthReport) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+		var report healthReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("failed to parse readiness report: %v", err)
+		}
+		return w.Code, report
+	}
+
+	register := func(t *testing.T, srv *server.Server, apiKey string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", w.Code)
+		}
+	}
+
+	t.Run("liveness does not check dependencies", func(t *testing.T) {
+		srv, _, _ := newServer(t, nil)
+
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("reports every component ready", func(t *testing.T) {
+		srv, apiKey, _ := newServer(t, nil)
+		register(t, srv, apiKey)
+
+		code, report := ready(t, srv)
+		if code != http.StatusOK || report.Status != "ok" {
+			t.Fatalf("expected ready, got %d: %+v", code, report)
+		}
+		for _, name := range []string{"database", "storage", "signing_key", "tree", "checkpoint"} {
+			if report.Components[name].Status != "ok" {
+				t.Errorf("component %s not ok: %+v", name, report.Components[name])
+			}
+		}
+	})
+
+	t.Run("returns 503 when entry tiles do not match the tree size", func(t *testing.T) {
+		srv, apiKey, tileDir := newServer(t, nil)
+		register(t, srv, apiKey)
+
+		// Lose the entry tiles behind the service's back
+		if err := os.RemoveAll(filepath.Join(tileDir, "tile", "entries")); err != nil {
+			t.Fatalf("failed to remove entry tiles: %v", err)
+		}
+
+		code, report := ready(t, srv)
+		if code != http.StatusServiceUnavailable || report.Status != "degraded" {
+			t.Fatalf("expected 503 degraded, got %d: %+v", code, report)
+		}
+		if report.Components["tree"].Status != "error" || report.Components["tree"].Detail == "" {
+			t.Errorf("expected tree error, got %+v", report.Components["tree"])
+		}
+		if report.Components["database"].Status != "ok" {
+			t.Errorf("expected database ok, got %+v", report.Components["database"])
+		}
+	})
+
+	t.Run("returns 503 when the checkpoint is stale", func(t *testing.T) {
+		srv, _, _ := newServer(t, func(cfg *config.Config) {
+			cfg.Server.Health.MaxCheckpointAge = time.Nanosecond
+		})
+		time.Sleep(time.Millisecond)
+
+		code, report := ready(t, srv)
+		if code != http.StatusServiceUnavailable || report.Components["checkpoint"].Status != "error" {
+			t.Errorf("expected stale checkpoint, got %d: %+v", code, report)
+		}
+	})
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	// One accepted registration, one rejected for missing credentials and one receipt lookup
+	req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if w := serve(req); w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+	serve(httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t))))
+	serve(httptest.NewRequest(http.MethodGet, "/entries/0", nil))
+
+	w := serve(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`scitt_http_requests_total{route="/entries",method="POST",status="201"}`,
+		`scitt_http_requests_total{route="/entries/{id}",method="GET",status="200"}`,
+		`scitt_http_request_duration_seconds_count{route="/entries",method="POST"}`,
+		`scitt_registrations_total{outcome="accepted",reason=""}`,
+		`scitt_registrations_total{outcome="rejected",reason="unauthorized"}`,
+		"scitt_tree_size 1\n",
+		"scitt_checkpoint_age_seconds ",
+		"scitt_integration_batch_size_count ",
+		`scitt_proof_generation_duration_seconds_count{proof="inclusion"}`,
+		`scitt_storage_operation_duration_seconds_count{backend="memory",operation="put"}`,
+		`scitt_database_query_duration_seconds_count{query="insert_statement"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+}
+
+func TestRequestLogging(t *testing.T) {
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	// Capture structured logs from the default logger
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(previous)
+
+	t.Run("traces a statement from registration to receipt", func(t *testing.T) {
+		logs.Reset()
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.Set("X-Request-ID", "trace-register-1")
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", w.Code)
+		}
+		if got := w.Header().Get("X-Request-ID"); got != "trace-register-1" {
+			t.Errorf("expected request ID to be echoed, got %q", got)
+		}
+
+		var registered, receipt, access map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("log line is not JSON: %s", line)
+			}
+			if record["request_id"] != "trace-register-1" {
+				t.Errorf("log line missing request ID: %s", line)
+			}
+			switch record["msg"] {
+			case "statement registered":
+				registered = record
+			case "receipt issued":
+				receipt = record
+			case "request":
+				access = record
+			}
+		}
+
+		if registered == nil || receipt == nil || access == nil {
+			t.Fatalf("expected registration, receipt and request log lines, got:\n%s", logs.String())
+		}
+		if registered["entry_id"] != float64(0) || registered["iss"] != "https://issuer.example.com" {
+			t.Errorf("unexpected registration fields: %v", registered)
+		}
+		if hash, _ := registered["statement_hash"].(string); len(hash) != 64 {
+			t.Errorf("expected statement hash, got %v", registered["statement_hash"])
+		}
+		if registered["credential_id"] != "config" {
+			t.Errorf("expected credential ID, got %v", registered["credential_id"])
+		}
+		if receipt["entry_id"] != float64(0) || receipt["kid"] == "" || receipt["tree_size"] != float64(1) {
+			t.Errorf("unexpected receipt fields: %v", receipt)
+		}
+		if access["status"] != float64(http.StatusCreated) || access["path"] != "/entries" {
+			t.Errorf("unexpected request fields: %v", access)
+		}
+	})
+
+	t.Run("generates a request ID when none is supplied", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set("X-Request-ID", "not valid\n")
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		if got := w.Header().Get("X-Request-ID"); len(got) != 32 {
+			t.Errorf("expected a generated request ID, got %q", got)
+		}
+	})
+}
+
 func TestSCITTConfigurationEndpoint(t *testing.T) {
 	t.Run("returns service configuration", func(t *testing.T) {
 		cfg, _, cleanup := setupTestConfig(t)
@@ -210,6 +474,133 @@ func TestRegisterStatementEndpoint(t *testing.T) {
 		}
 	})
 
+	t.Run("returns existing receipt for duplicate statement", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		statement := createTestStatement(t)
+
+		register := func() *http.Response {
+			req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(statement))
+			req.Header.Set("Content-Type", "application/cose")
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+			return w.Result()
+		}
+
+		first := register()
+		if first.StatusCode != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", first.StatusCode)
+		}
+		if first.Header.Get("Location") != "/entries/0" {
+			t.Errorf("expected Location /entries/0, got %s", first.Header.Get("Location"))
+		}
+
+		second := register()
+		if second.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(second.Body)
+			t.Fatalf("expected status 200 for duplicate, got %d: %s", second.StatusCode, string(body))
+		}
+		if second.Header.Get("Location") != "/entries/0" {
+			t.Errorf("expected Location /entries/0, got %s", second.Header.Get("Location"))
+		}
+
+		body, _ := io.ReadAll(second.Body)
+		if _, err := cose.DecodeCoseSign1(body); err != nil {
+			t.Fatalf("failed to decode receipt: %v", err)
+		}
+
+		// The log must not have grown
+		req := httptest.NewRequest(http.MethodGet, "/entries/1", nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected no second entry, got status %d", w.Code)
+		}
+	})
+
+	t.Run("appends duplicate statement when policy allows", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+		cfg.Registration.AllowDuplicates = true
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		statement := createTestStatement(t)
+
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(statement))
+			req.Header.Set("Content-Type", "application/cose")
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("registration %d: expected status 201, got %d: %s", i, w.Code, w.Body.String())
+			}
+			expected := fmt.Sprintf("/entries/%d", i)
+			if w.Header().Get("Location") != expected {
+				t.Errorf("registration %d: expected Location %s, got %s", i, expected, w.Header().Get("Location"))
+			}
+		}
+	})
+
+	t.Run("keeps the tree and database in step when the insert fails", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		register := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+			return w
+		}
+
+		// Fail the statements insert, as a busy or full database would
+		db, err := database.OpenDatabase(database.DatabaseOptions{Path: cfg.Database.Path, EnableWAL: true})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+		if _, err := db.Exec(`CREATE TRIGGER fail_insert BEFORE INSERT ON statements BEGIN SELECT RAISE(ABORT, 'injected failure'); END`); err != nil {
+			t.Fatalf("failed to create trigger: %v", err)
+		}
+
+		if w := register(); w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status 500, got %d: %s", w.Code, w.Body.String())
+		}
+
+		if _, err := db.Exec(`DROP TRIGGER fail_insert`); err != nil {
+			t.Fatalf("failed to drop trigger: %v", err)
+		}
+
+		w := register()
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		if location := w.Header().Get("Location"); location != "/entries/0" {
+			t.Errorf("expected the failed registration not to take a leaf, got %s", location)
+		}
+	})
+
 	t.Run("rejects invalid content type", func(t *testing.T) {
 		cfg, apiKey, cleanup := setupTestConfig(t)
 		defer cleanup()
@@ -296,8 +687,8 @@ func TestGetReceiptEndpoint(t *testing.T) {
 		}
 	})
 
-	t.Run("returns 404 for non-existent entry", func(t *testing.T) {
-		cfg, _, cleanup := setupTestConfig(t)
+	t.Run("serves the receipt issued at registration", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
 		defer cleanup()
 
 		srv, err := server.NewServer(cfg)
@@ -306,19 +697,67 @@ func TestGetReceiptEndpoint(t *testing.T) {
 		}
 		defer srv.Close()
 
-		req := httptest.NewRequest(http.MethodGet, "/entries/999999", nil)
-		w := httptest.NewRecorder()
+		serve := func(req *http.Request) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+			return w
+		}
+		register := func() []byte {
+			req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+			w := serve(req)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("failed to register statement: %d", w.Code)
+			}
+			return w.Body.Bytes()
+		}
 
-		srv.Handler().ServeHTTP(w, req)
+		issued := register()
+		register()
 
-		resp := w.Result()
-		if resp.StatusCode != http.StatusNotFound {
-			t.Errorf("expected status 404, got %d", resp.StatusCode)
+		// The log grew, but the receipt is the one issued when the entry was integrated
+		w := serve(httptest.NewRequest(http.MethodGet, "/entries/0", nil))
+		if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), issued) {
+			t.Fatalf("expected the receipt issued at registration, got status %d", w.Code)
+		}
+		hash := sha256.Sum256(issued)
+		etag := `"` + hex.EncodeToString(hash[:]) + `"`
+		if got := w.Header().Get("ETag"); got != etag {
+			t.Errorf("expected ETag %s, got %s", etag, got)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/entries/0", nil)
+		req.Header.Set("If-None-Match", etag)
+		if w := serve(req); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("expected status 304 without a body, got %d", w.Code)
+		}
+
+		// A refresh issues a receipt against the current tree without replacing the stored one
+		w = serve(httptest.NewRequest(http.MethodGet, "/entries/0?refresh=true", nil))
+		if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+			t.Fatalf("expected a new receipt, got status %d", w.Code)
+		}
+		refreshed := w.Body.Bytes()
+		receipt, err := cose.DecodeCoseSign1(refreshed)
+		if err != nil {
+			t.Fatalf("failed to decode receipt: %v", err)
+		}
+		if proof, err := merkle.ReceiptInclusionProof(receipt); err != nil || proof.TreeSize != 2 {
+			t.Errorf("expected a receipt for tree size 2, got %+v (%v)", proof, err)
+		}
+		if w := serve(httptest.NewRequest(http.MethodGet, "/entries/0", nil)); !bytes.Equal(w.Body.Bytes(), issued) {
+			t.Error("expected a refresh to leave the stored receipt in place")
+		}
+
+		for _, path := range []string{"/entries/0?refresh=maybe", "/entries/0?refresh=true&tree_size=2"} {
+			if w := serve(httptest.NewRequest(http.MethodGet, path, nil)); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", path, w.Code)
+			}
 		}
 	})
 
-	t.Run("returns 400 for invalid entry ID", func(t *testing.T) {
-		cfg, _, cleanup := setupTestConfig(t)
+	t.Run("reissues a receipt that no longer matches its record", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
 		defer cleanup()
 
 		srv, err := server.NewServer(cfg)
@@ -327,22 +766,40 @@ func TestGetReceiptEndpoint(t *testing.T) {
 		}
 		defer srv.Close()
 
-		req := httptest.NewRequest(http.MethodGet, "/entries/invalid", nil)
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
 		w := httptest.NewRecorder()
-
 		srv.Handler().ServeHTTP(w, req)
+		issued := w.Body.Bytes()
 
-		resp := w.Result()
-		if resp.StatusCode != http.StatusBadRequest {
-			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		db, err := database.OpenDatabase(database.DatabaseOptions{Path: cfg.Database.Path})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+		if _, err := db.Exec("UPDATE receipts SET receipt_hash = 'tampered'"); err != nil {
+			t.Fatalf("failed to tamper with receipt: %v", err)
+		}
+
+		w = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/entries/0", nil))
+		if w.Code != http.StatusOK || bytes.Equal(w.Body.Bytes(), issued) {
+			t.Fatalf("expected a reissued receipt, got status %d", w.Code)
+		}
+		record, err := database.GetReceiptByLeafIndex(db, 0)
+		if err != nil || record == nil {
+			t.Fatalf("expected a receipt record: %v", err)
+		}
+		hash := sha256.Sum256(w.Body.Bytes())
+		if record.ReceiptHash != hex.EncodeToString(hash[:]) || record.TreeSize != 1 {
+			t.Errorf("expected the reissued receipt to be recorded, got %+v", record)
 		}
 	})
-}
 
-func TestOpenAPIEndpoints(t *testing.T) {
-	t.Run("serves Swagger UI at root", func(t *testing.T) {
-		cfg, _, cleanup := setupTestConfig(t)
+	t.Run("binds receipts to the registered entry", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
 		defer cleanup()
+		cfg.Receipts = config.ReceiptsConfig{CTI: true, Claims: map[int64]interface{}{-70001: "build-farm"}}
 
 		srv, err := server.NewServer(cfg)
 		if err != nil {
@@ -350,29 +807,37 @@ func TestOpenAPIEndpoints(t *testing.T) {
 		}
 		defer srv.Close()
 
-		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
 		w := httptest.NewRecorder()
-
 		srv.Handler().ServeHTTP(w, req)
-
-		resp := w.Result()
-		if resp.StatusCode != http.StatusOK {
-			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("failed to register statement: %d", w.Code)
 		}
 
-		contentType := resp.Header.Get("Content-Type")
-		if !contains(contentType, "text/html") {
-			t.Errorf("expected Content-Type text/html, got %s", contentType)
+		receipt, err := cose.DecodeCoseSign1(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("failed to decode receipt: %v", err)
 		}
-
-		body, _ := io.ReadAll(resp.Body)
-		html := string(body)
-		if !contains(html, "swagger-ui") {
-			t.Error("expected Swagger UI HTML")
+		claims, err := merkle.GetReceiptClaims(receipt)
+		if err != nil {
+			t.Fatalf("failed to get receipt claims: %v", err)
+		}
+		if claims.Iss != cfg.Issuer || claims.Sub != "test-artifact" {
+			t.Errorf("expected the service issuer and statement subject, got %q and %q", claims.Iss, claims.Sub)
+		}
+		if claims.Iat == nil || time.Since(*claims.Iat) > time.Minute || time.Until(*claims.Iat) > time.Minute {
+			t.Errorf("expected the registration time as iat, got %v", claims.Iat)
+		}
+		if len(claims.Cti) != 16 {
+			t.Errorf("expected a 16 byte cti, got %x", claims.Cti)
+		}
+		if claims.Extra[int64(-70001)] != "build-farm" {
+			t.Errorf("expected the configured claim, got %v", claims.Extra)
 		}
 	})
 
-	t.Run("serves OpenAPI spec as JSON", func(t *testing.T) {
+	t.Run("returns 404 for non-existent entry", func(t *testing.T) {
 		cfg, _, cleanup := setupTestConfig(t)
 		defer cleanup()
 
@@ -382,28 +847,2242 @@ func TestOpenAPIEndpoints(t *testing.T) {
 		}
 		defer srv.Close()
 
-		req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+		req := httptest.NewRequest(http.MethodGet, "/entries/999999", nil)
 		w := httptest.NewRecorder()
 
 		srv.Handler().ServeHTTP(w, req)
 
 		resp := w.Result()
-		if resp.StatusCode != http.StatusOK {
-			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", resp.StatusCode)
 		}
+	})
 
-		contentType := resp.Header.Get("Content-Type")
-		if !contains(contentType, "application/json") {
-			t.Errorf("expected Content-Type application/json, got %s", contentType)
-		}
+	t.Run("returns 400 for invalid entry ID", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
 
-		body, _ := io.ReadAll(resp.Body)
-		var spec map[string]interface{}
-		if err := json.Unmarshal(body, &spec); err != nil {
-			t.Fatalf("failed to parse OpenAPI spec: %v", err)
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
 		}
+		defer srv.Close()
 
-		// Verify OpenAPI structure
+		req := httptest.NewRequest(http.MethodGet, "/entries/invalid", nil)
+		w := httptest.NewRecorder()
+
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestArtifactLookupEndpoint(t *testing.T) {
+	// createTestStatement attaches this payload, so it is indexed by its SHA-256
+	digest := sha256.Sum256([]byte(`{"test": "data"}`))
+	lookupPath := "/artifacts/sha-256/" + hex.EncodeToString(digest[:]) + "/statements"
+
+	registerStatement := func(t *testing.T, srv *server.Server, apiKey string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Content-Type", "application/cose")
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Result().StatusCode != http.StatusCreated {
+			t.Fatalf("failed to register statement: %d", w.Result().StatusCode)
+		}
+	}
+
+	t.Run("returns matching entries with receipts", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		registerStatement(t, srv, apiKey)
+		registerStatement(t, srv, apiKey)
+
+		req := httptest.NewRequest(http.MethodGet, lookupPath, nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result struct {
+			HashAlgorithm string `json:"hash_algorithm"`
+			Entries       []struct {
+				EntryID int64  `json:"entry_id"`
+				Iss     string `json:"iss"`
+				Receipt []byte `json:"receipt"`
+			} `json:"entries"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if result.HashAlgorithm != "sha-256" {
+			t.Errorf("expected sha-256, got %s", result.HashAlgorithm)
+		}
+		if len(result.Entries) != 2 {
+			t.Fatalf("expected 2 entries, got %d", len(result.Entries))
+		}
+		for _, entry := range result.Entries {
+			if entry.Iss != "https://issuer.example.com" {
+				t.Errorf("unexpected issuer: %s", entry.Iss)
+			}
+			if len(entry.Receipt) == 0 {
+				t.Errorf("expected receipt for entry %d", entry.EntryID)
+			}
+		}
+	})
+
+	t.Run("returns CBOR bundle", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		registerStatement(t, srv, apiKey)
+
+		req := httptest.NewRequest(http.MethodGet, lookupPath+"?format=bundle", nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != "application/cbor" {
+			t.Errorf("expected application/cbor, got %s", contentType)
+		}
+
+		var bundle []map[string]interface{}
+		if err := cbor.NewDecoder(resp.Body).Decode(&bundle); err != nil {
+			t.Fatalf("failed to decode bundle: %v", err)
+		}
+		if len(bundle) != 1 {
+			t.Fatalf("expected 1 bundle entry, got %d", len(bundle))
+		}
+		if _, ok := bundle[0]["receipt"].([]byte); !ok {
+			t.Error("expected receipt bytes in bundle entry")
+		}
+	})
+
+	t.Run("returns empty list for unknown artifact", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodGet, lookupPath, nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if entries, ok := result["entries"].([]interface{}); !ok || len(entries) != 0 {
+			t.Errorf("expected empty entries, got %v", result["entries"])
+		}
+	})
+
+	t.Run("returns 400 for invalid digest", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		for _, path := range []string{
+			"/artifacts/sha-256/not-hex/statements",
+			"/artifacts/sha-256/abcd/statements",
+			"/artifacts/md5/" + hex.EncodeToString(digest[:]) + "/statements",
+		} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+
+			if w.Result().StatusCode != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", path, w.Result().StatusCode)
+			}
+		}
+	})
+
+	t.Run("returns problem details for unknown paths", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodGet, "/artifacts/sha-256/"+hex.EncodeToString(digest[:]), nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != server.ContentTypeConciseProblemDetails {
+			t.Errorf("expected %s, got %s", server.ContentTypeConciseProblemDetails, contentType)
+		}
+	})
+}
+
+func TestProblemDetailsResponses(t *testing.T) {
+	decodeConciseProblem := func(t *testing.T, resp *http.Response) map[int64]interface{} {
+		t.Helper()
+		if contentType := resp.Header.Get("Content-Type"); contentType != server.ContentTypeConciseProblemDetails {
+			t.Fatalf("expected %s, got %s", server.ContentTypeConciseProblemDetails, contentType)
+		}
+		var problem map[int64]interface{}
+		if err := cbor.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			t.Fatalf("failed to decode problem details: %v", err)
+		}
+		return problem
+	}
+
+	t.Run("invalid statement returns 400 without internal error chain", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader([]byte("not cbor")))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", resp.StatusCode)
+		}
+
+		problem := decodeConciseProblem(t, resp)
+		if problem[-1] != "Invalid Signed Statement" {
+			t.Errorf("unexpected title: %v", problem[-1])
+		}
+		if problem[-2] != "invalid COSE Sign1 structure" {
+			t.Errorf("unexpected detail: %v", problem[-2])
+		}
+	})
+
+	t.Run("statements signed with other algorithms are registered", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		keyPair, err := cose.GenerateES256KeyPair()
+		if err != nil {
+			t.Fatalf("failed to generate key pair: %v", err)
+		}
+		signer, err := cose.NewES256Signer(keyPair.Private)
+		if err != nil {
+			t.Fatalf("failed to create signer: %v", err)
+		}
+		headers := cose.CreateProtectedHeaders(cose.ProtectedHeadersOptions{Alg: cose.AlgorithmES384})
+		coseSign1, err := cose.CreateCoseSign1(headers, []byte("payload"), signer, cose.CoseSign1Options{})
+		if err != nil {
+			t.Fatalf("failed to create COSE Sign1: %v", err)
+		}
+		statement, err := cose.EncodeCoseSign1(coseSign1)
+		if err != nil {
+			t.Fatalf("failed to encode COSE Sign1: %v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(statement))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("unknown critical header returns 400", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		keyPair, _ := cose.GenerateES256KeyPair()
+		signer, _ := cose.NewES256Signer(keyPair.Private)
+		headers := cose.CreateProtectedHeaders(cose.ProtectedHeadersOptions{Alg: cose.AlgorithmES256})
+		headers[cose.HeaderLabelCrit] = []interface{}{-70000}
+		headers[-70000] = "must be understood"
+		coseSign1, err := cose.CreateCoseSign1(headers, []byte("payload"), signer, cose.CoseSign1Options{})
+		if err != nil {
+			t.Fatalf("failed to create COSE Sign1: %v", err)
+		}
+		statement, _ := cose.EncodeCoseSign1(coseSign1)
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(statement))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", resp.StatusCode)
+		}
+
+		problem := decodeConciseProblem(t, resp)
+		if problem[-1] != "Invalid Signed Statement" {
+			t.Errorf("unexpected title: %v", problem[-1])
+		}
+	})
+
+	t.Run("missing API key returns 401", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status 401, got %d", resp.StatusCode)
+		}
+
+		problem := decodeConciseProblem(t, resp)
+		if problem[-2] != "missing API key" {
+			t.Errorf("unexpected detail: %v", problem[-2])
+		}
+	})
+
+	t.Run("encodes concise problem details deterministically", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/entries/42", nil))
+
+		var problem map[int64]interface{}
+		if err := cose.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("failed to decode problem details: %v", err)
+		}
+		canonical, err := cose.Marshal(problem)
+		if err != nil {
+			t.Fatalf("failed to encode problem details: %v", err)
+		}
+		if !bytes.Equal(w.Body.Bytes(), canonical) {
+			t.Errorf("problem details are not deterministically encoded: %x", w.Body.Bytes())
+		}
+	})
+
+	t.Run("returns JSON problem details when requested", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodGet, "/entries/42", nil)
+		req.Header.Set("Accept", server.ContentTypeProblemJSON)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != server.ContentTypeProblemJSON {
+			t.Fatalf("expected %s, got %s", server.ContentTypeProblemJSON, contentType)
+		}
+
+		var problem map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			t.Fatalf("failed to decode problem details: %v", err)
+		}
+		if problem["status"] != float64(http.StatusNotFound) {
+			t.Errorf("unexpected status: %v", problem["status"])
+		}
+		if problem["instance"] != "/entries/42" {
+			t.Errorf("unexpected instance: %v", problem["instance"])
+		}
+	})
+}
+
+func TestServerLifecycle(t *testing.T) {
+	t.Run("rejects oversized registration body", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+		cfg.Server.MaxRequestBytes = 64
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected status 413, got %d", w.Code)
+		}
+	})
+
+	t.Run("caps registration bodies at the entry bundle limit", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(make([]byte, merkle.MaxEntrySize+1)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected status 413, got %d", w.Code)
+		}
+	})
+
+	t.Run("requires client certificate when mTLS is enabled", func(t *testing.T) {
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		defer cleanup()
+		cfg.Server.TLS = &config.TLSConfig{
+			CertFile:     "server.crt",
+			KeyFile:      "server.key",
+			ClientCAFile: "client-ca.crt",
+		}
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", w.Code)
+		}
+
+		// Read-only endpoints remain available without a client certificate
+		req = httptest.NewRequest(http.MethodGet, "/health", nil)
+		w = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("shuts down gracefully when context is cancelled", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to find free port: %v", err)
+		}
+		cfg.Server.Host = "127.0.0.1"
+		cfg.Server.Port = listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- srv.Run(ctx)
+		}()
+
+		// Wait for the server to accept requests
+		healthURL := fmt.Sprintf("http://127.0.0.1:%d/health", cfg.Server.Port)
+		var resp *http.Response
+		for i := 0; i < 50; i++ {
+			resp, err = http.Get(healthURL)
+			if err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatalf("server did not start: %v", err)
+		}
+		resp.Body.Close()
+
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("expected clean shutdown, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("server did not shut down")
+		}
+	})
+}
+
+func TestClientAPIKeys(t *testing.T) {
+	createKey := func(t *testing.T, cfg *config.Config, opts auth.CreateAPIKeyOptions) string {
+		t.Helper()
+		db, err := database.OpenDatabase(database.DatabaseOptions{Path: cfg.Database.Path})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+
+		token, _, err := auth.CreateAPIKey(db, opts)
+		if err != nil {
+			t.Fatalf("failed to create api key: %v", err)
+		}
+		return token
+	}
+
+	register := func(t *testing.T, srv *server.Server, token string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("accepts key with register scope", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+		token := createKey(t, cfg, auth.CreateAPIKeyOptions{
+			Name:           "supplier",
+			Scopes:         []auth.Scope{auth.ScopeRegister},
+			AllowedIssuers: []string{"https://issuer.example.com"},
+		})
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		if code := register(t, srv, token); code != http.StatusCreated {
+			t.Errorf("expected status 201, got %d", code)
+		}
+	})
+
+	t.Run("rejects key without register scope", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+		token := createKey(t, cfg, auth.CreateAPIKeyOptions{
+			Name:   "reader",
+			Scopes: []auth.Scope{auth.ScopeRead},
+		})
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		if code := register(t, srv, token); code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", code)
+		}
+	})
+
+	t.Run("rejects statement from an issuer outside the key binding", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+		token := createKey(t, cfg, auth.CreateAPIKeyOptions{
+			Name:           "other supplier",
+			Scopes:         []auth.Scope{auth.ScopeRegister},
+			AllowedIssuers: []string{"https://other.example.com"},
+		})
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		if code := register(t, srv, token); code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", code)
+		}
+	})
+
+	t.Run("requires read scope when read auth is enabled", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+		cfg.Server.RequireReadAuth = true
+		registerToken := createKey(t, cfg, auth.CreateAPIKeyOptions{
+			Name:   "supplier",
+			Scopes: []auth.Scope{auth.ScopeRegister},
+		})
+		readToken := createKey(t, cfg, auth.CreateAPIKeyOptions{
+			Name:   "auditor",
+			Scopes: []auth.Scope{auth.ScopeRead},
+		})
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		if code := register(t, srv, registerToken); code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", code)
+		}
+
+		for token, expected := range map[string]int{
+			"":            http.StatusUnauthorized,
+			registerToken: http.StatusForbidden,
+			readToken:     http.StatusOK,
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/entries/0", nil)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+
+			if w.Code != expected {
+				t.Errorf("expected status %d, got %d", expected, w.Code)
+			}
+		}
+	})
+}
+
+func TestOIDCAuthentication(t *testing.T) {
+	idpKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate IdP key: %v", err)
+	}
+
+	// Stub identity provider serving its JWKS
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "EC",
+				"kid": "idp-1",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(idpKey.X.FillBytes(make([]byte, 32))),
+				"y":   base64.RawURLEncoding.EncodeToString(idpKey.Y.FillBytes(make([]byte, 32))),
+			}},
+		})
+	}))
+	defer idp.Close()
+
+	signToken := func(t *testing.T, claims map[string]interface{}) string {
+		t.Helper()
+		header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "idp-1", "typ": "JWT"})
+		payload, _ := json.Marshal(claims)
+		signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+		digest := sha256.Sum256([]byte(signingInput))
+		r, s, err := ecdsa.Sign(rand.Reader, idpKey, digest[:])
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	claims := map[string]interface{}{
+		"iss": idp.URL,
+		"aud": "scitt",
+		"sub": "ci@issuer.example.com",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"org": "issuer",
+	}
+
+	newServer := func(t *testing.T, mapping config.ClaimMappingConfig) *server.Server {
+		t.Helper()
+		cfg, _, cleanup := setupTestConfig(t)
+		t.Cleanup(cleanup)
+		cfg.Auth.OIDC = &config.OIDCConfig{
+			Issuer:       idp.URL,
+			Audience:     "scitt",
+			JWKSURL:      idp.URL,
+			ClaimMapping: mapping,
+		}
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		t.Cleanup(func() { srv.Close() })
+		return srv
+	}
+
+	register := func(t *testing.T, srv *server.Server, token string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("accepts token matching the claim mapping", func(t *testing.T) {
+		srv := newServer(t, config.ClaimMappingConfig{
+			StatementIssuer:  "https://{org}.example.com",
+			StatementSubject: "test-*",
+		})
+
+		if code := register(t, srv, signToken(t, claims)); code != http.StatusCreated {
+			t.Errorf("expected status 201, got %d", code)
+		}
+	})
+
+	t.Run("rejects statement outside the claim mapping", func(t *testing.T) {
+		srv := newServer(t, config.ClaimMappingConfig{StatementSubject: "pkg:{org}/*"})
+
+		if code := register(t, srv, signToken(t, claims)); code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", code)
+		}
+	})
+
+	t.Run("rejects token for another audience", func(t *testing.T) {
+		srv := newServer(t, config.ClaimMappingConfig{})
+		other := map[string]interface{}{}
+		for k, v := range claims {
+			other[k] = v
+		}
+		other["aud"] = "another-service"
+
+		if code := register(t, srv, signToken(t, other)); code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", code)
+		}
+	})
+}
+
+func TestWitnessCosigning(t *testing.T) {
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+
+	publicKeyData, err := os.ReadFile(cfg.Keys.Public)
+	if err != nil {
+		t.Fatalf("failed to read service public key: %v", err)
+	}
+	logKey, err := cose.ImportPublicKeyFromCOSECBOR(publicKeyData)
+	if err != nil {
+		t.Fatalf("failed to import service public key: %v", err)
+	}
+
+	// Local witness for the service's checkpoints
+	skey, vkey, err := witness.GenerateKey("witness.example.com")
+	if err != nil {
+		t.Fatalf("failed to generate witness key: %v", err)
+	}
+	signer, err := witness.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("failed to parse witness key: %v", err)
+	}
+	w, err := witness.New(witness.Config{
+		Signer: signer,
+		Logs:   map[string]*ecdsa.PublicKey{cfg.Issuer: logKey},
+	})
+	if err != nil {
+		t.Fatalf("failed to create witness: %v", err)
+	}
+	witnessServer := httptest.NewServer(w.Handler())
+	defer witnessServer.Close()
+
+	// A second witness that is down must not block cosigning by the first
+	_, unreachableVkey, _ := witness.GenerateKey("down.example.com")
+	cfg.Witnessing = &config.WitnessingConfig{
+		Witnesses: []config.WitnessConfig{
+			{URL: witnessServer.URL, VerifierKey: vkey},
+			{URL: "http://127.0.0.1:1", VerifierKey: unreachableVkey},
+		},
+		Timeout: time.Second,
+	}
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", rec.Code)
+		}
+	}
+
+	db, err := database.OpenDatabase(database.DatabaseOptions{Path: cfg.Database.Path, EnableWAL: true})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.CloseDatabase(db)
+
+	// Cosigning runs in the background after each registration
+	var state *database.TreeState
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		state, err = database.GetTreeState(db, 3)
+		if err != nil {
+			t.Fatalf("failed to get tree state: %v", err)
+		}
+		if state != nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if state == nil {
+		t.Fatal("checkpoint at size 3 was not recorded")
+	}
+
+	verifier, _ := witness.ParseVerifierKey(vkey)
+	if _, _, err := verifier.FindCosignature(state.CheckpointSignedNote); err != nil {
+		t.Errorf("recorded checkpoint should carry the witness cosignature: %v\n%s", err, state.CheckpointSignedNote)
+	}
+	if witnessState := w.State(cfg.Issuer); witnessState == nil || witnessState.TreeSize != 3 {
+		t.Errorf("expected witness state at size 3, got %+v", witnessState)
+	}
+}
+
+func TestCheckpointAndProofEndpoints(t *testing.T) {
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		if w := serve(req); w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", w.Code)
+		}
+	}
+
+	t.Run("checkpoint is stable for a tree size", func(t *testing.T) {
+		first := serve(httptest.NewRequest(http.MethodGet, "/checkpoint", nil))
+		if first.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", first.Code)
+		}
+		if !strings.HasPrefix(first.Body.String(), cfg.Issuer+"\n3\n") {
+			t.Errorf("unexpected checkpoint:\n%s", first.Body.String())
+		}
+
+		second := serve(httptest.NewRequest(http.MethodGet, "/checkpoint", nil))
+		if first.Body.String() != second.Body.String() {
+			t.Error("expected the same checkpoint for the same tree size")
+		}
+	})
+
+	t.Run("consistency proof", func(t *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodGet, "/proofs/consistency?old=1&new=3", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			OldSize int64    `json:"old_size"`
+			NewSize int64    `json:"new_size"`
+			Proof   []string `json:"proof"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if response.OldSize != 1 || response.NewSize != 3 || len(response.Proof) == 0 {
+			t.Errorf("unexpected consistency proof: %+v", response)
+		}
+	})
+
+	t.Run("consistency proof rejects invalid sizes", func(t *testing.T) {
+		for _, query := range []string{"old=0&new=3", "old=3&new=1", "old=a&new=3", "new=3"} {
+			if w := serve(httptest.NewRequest(http.MethodGet, "/proofs/consistency?"+query, nil)); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", query, w.Code)
+			}
+		}
+		if w := serve(httptest.NewRequest(http.MethodGet, "/proofs/consistency?old=1&new=4", nil)); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404 beyond the tree size, got %d", w.Code)
+		}
+	})
+
+	t.Run("hash tiles", func(t *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodGet, "/tile/0/000.p/3", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if w.Body.Len() != 3*32 {
+			t.Errorf("expected 3 leaf hashes, got %d bytes", w.Body.Len())
+		}
+		if w.Header().Get("Cache-Control") == "" {
+			t.Error("expected tiles to be cacheable")
+		}
+
+		// The full tile and partial tiles beyond the tree size do not exist yet
+		for _, path := range []string{"/tile/0/000", "/tile/0/000.p/4", "/tile/0/001.p/1", "/tile/1/000.p/1", "/tile/0/bogus"} {
+			if w := serve(httptest.NewRequest(http.MethodGet, path, nil)); w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected status 404, got %d", path, w.Code)
+			}
+		}
+	})
+
+	t.Run("entry bundles", func(t *testing.T) {
+		tile := serve(httptest.NewRequest(http.MethodGet, "/tile/0/000.p/3", nil)).Body.Bytes()
+		w := serve(httptest.NewRequest(http.MethodGet, "/tile/entries/000.p/2", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		// Verifiers recompute the leaves from the statements in the bundle
+		entries, err := merkle.DecodeEntryBundle(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("failed to decode entry bundle: %v", err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, got %d", len(entries))
+		}
+		for i, entry := range entries {
+			leaf := sha256.Sum256(entry)
+			if !bytes.Equal(leaf[:], tile[i*32:(i+1)*32]) {
+				t.Errorf("entry %d does not hash to its leaf", i)
+			}
+		}
+
+		for _, path := range []string{"/tile/entries/000", "/tile/entries/000.p/4", "/tile/entries/bogus"} {
+			if w := serve(httptest.NewRequest(http.MethodGet, path, nil)); w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected status 404, got %d", path, w.Code)
+			}
+		}
+	})
+
+	t.Run("lists entries by issuer", func(t *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodGet, "/entries?iss=https://issuer.example.com", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var response struct {
+			Entries []struct {
+				EntryID int64 `json:"entry_id"`
+			} `json:"entries"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.Entries) != 3 {
+			t.Fatalf("expected 3 entries, got %d", len(response.Entries))
+		}
+		for i, entry := range response.Entries {
+			if entry.EntryID != int64(i) {
+				t.Errorf("expected entry %d, got %d", i, entry.EntryID)
+			}
+		}
+
+		if w := serve(httptest.NewRequest(http.MethodGet, "/entries", nil)); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 without filters, got %d", w.Code)
+		}
+	})
+}
+
+func TestTreeStateAtStartup(t *testing.T) {
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+	cfg.Storage = config.StorageConfig{Type: "local", Path: filepath.Join(t.TempDir(), "tiles")}
+
+	checkpoint := func(t *testing.T) string {
+		t.Helper()
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/checkpoint", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		return w.Body.String()
+	}
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", w.Code)
+		}
+	}
+	srv.Close()
+	published := checkpoint(t)
+
+	store, err := storage.NewLocalStorage(cfg.Storage.Path)
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+
+	t.Run("rebuilds a missing tree state from the tiles", func(t *testing.T) {
+		if err := store.Delete(merkle.TreeStatePath); err != nil {
+			t.Fatalf("failed to delete tree state: %v", err)
+		}
+		if got := checkpoint(t); got != published {
+			t.Errorf("expected the same checkpoint after rebuilding, got:\n%s", got)
+		}
+		if state, _ := store.Get(merkle.TreeStatePath); state == nil {
+			t.Error("expected the rebuilt tree state to be persisted")
+		}
+	})
+
+	t.Run("upgrades entry tiles written before entry bundles", func(t *testing.T) {
+		tile, _ := store.Get(merkle.HashTileIndexToPath(0, nil))
+		bundle, _ := store.Get(merkle.EntryTileIndexToPath(0, nil))
+		if err := store.Put(merkle.EntryTileIndexToPath(0, nil), tile); err != nil {
+			t.Fatalf("failed to write legacy entry tile: %v", err)
+		}
+		if err := store.Delete(merkle.HashTileIndexToPath(0, nil)); err != nil {
+			t.Fatalf("failed to delete hash tile: %v", err)
+		}
+
+		if got := checkpoint(t); got != published {
+			t.Errorf("expected the same checkpoint after upgrading, got:\n%s", got)
+		}
+		if upgraded, _ := store.Get(merkle.HashTileIndexToPath(0, nil)); !bytes.Equal(upgraded, tile) {
+			t.Error("expected the leaf hashes moved to the hash tile")
+		}
+		if upgraded, _ := store.Get(merkle.EntryTileIndexToPath(0, nil)); !bytes.Equal(upgraded, bundle) {
+			t.Error("expected the entry bundle written from the stored statements")
+		}
+	})
+
+	t.Run("refuses to start when the tree state disagrees with the tree size", func(t *testing.T) {
+		state, _ := store.Get(merkle.TreeStatePath)
+		defer store.Put(merkle.TreeStatePath, state)
+
+		tile, _ := store.Get(merkle.HashTileIndexToPath(0, nil))
+		if err := store.Put(merkle.HashTileIndexToPath(0, nil), tile[:2*merkle.HashSize]); err != nil {
+			t.Fatalf("failed to write tile: %v", err)
+		}
+		if err := merkle.NewTileLog(store).Rebuild(2); err != nil {
+			t.Fatalf("failed to rebuild tree state: %v", err)
+		}
+		if err := store.Put(merkle.HashTileIndexToPath(0, nil), tile); err != nil {
+			t.Fatalf("failed to write tile: %v", err)
+		}
+
+		if srv, err := server.NewServer(cfg); err == nil {
+			srv.Close()
+			t.Fatal("expected an error starting with a stale tree state")
+		}
+	})
+
+	t.Run("refuses to start when the tiles disagree with the tree state", func(t *testing.T) {
+		tile, _ := store.Get(merkle.HashTileIndexToPath(0, nil))
+		defer store.Put(merkle.HashTileIndexToPath(0, nil), tile)
+
+		tampered := append([]byte(nil), tile...)
+		tampered[0] ^= 0xff
+		if err := store.Put(merkle.HashTileIndexToPath(0, nil), tampered); err != nil {
+			t.Fatalf("failed to write tile: %v", err)
+		}
+
+		if srv, err := server.NewServer(cfg); err == nil {
+			srv.Close()
+			t.Fatal("expected an error starting with a tampered tile")
+		}
+	})
+}
+
+func TestUpgradeWithoutStatements(t *testing.T) {
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+	cfg.Storage = config.StorageConfig{Type: "local", Path: filepath.Join(t.TempDir(), "tiles")}
+
+	store, err := storage.NewLocalStorage(cfg.Storage.Path)
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+
+	start := func(t *testing.T) *server.Server {
+		t.Helper()
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to start server: %v", err)
+		}
+		return srv
+	}
+	serve := func(srv *server.Server, req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+	register := func(t *testing.T, srv *server.Server) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		if w := serve(srv, req); w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	srv := start(t)
+	for i := 0; i < 3; i++ {
+		register(t, srv)
+	}
+	published := serve(srv, httptest.NewRequest(http.MethodGet, "/checkpoint", nil)).Body.String()
+	srv.Close()
+
+	// Lay the log out as it was before statements and entry bundles were stored
+	tile, _ := store.Get(merkle.HashTileIndexToPath(0, nil))
+	keys, err := store.List("statements/")
+	if err != nil || len(keys) != 3 {
+		t.Fatalf("expected 3 stored statements, got %d (%v)", len(keys), err)
+	}
+	statements := make(map[string][]byte)
+	for _, key := range keys {
+		statements[key], _ = store.Get(key)
+		store.Delete(key)
+	}
+	store.Put(merkle.EntryTileIndexToPath(0, nil), tile)
+	store.Delete(merkle.HashTileIndexToPath(0, nil))
+	store.Delete(merkle.TreeStatePath)
+
+	t.Run("leaves storage untouched when an entry tile is malformed", func(t *testing.T) {
+		store.Put(merkle.EntryTileIndexToPath(0, nil), tile[:2*merkle.HashSize])
+		defer store.Put(merkle.EntryTileIndexToPath(0, nil), tile)
+
+		if srv, err := server.NewServer(cfg); err == nil {
+			srv.Close()
+			t.Fatal("expected an error starting with a malformed entry tile")
+		}
+		if exists, _ := store.Exists(merkle.HashTileIndexToPath(0, nil)); exists {
+			t.Error("expected no hash tile written by a failed upgrade")
+		}
+	})
+
+	t.Run("upgrades to hash tiles without entry bundles", func(t *testing.T) {
+		srv := start(t)
+		if got := serve(srv, httptest.NewRequest(http.MethodGet, "/checkpoint", nil)).Body.String(); got != published {
+			t.Errorf("expected the same checkpoint after upgrading, got:\n%s", got)
+		}
+		if w := serve(srv, httptest.NewRequest(http.MethodGet, "/tile/entries/000.p/3", nil)); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404 for an entry bundle of a hash-only log, got %d", w.Code)
+		}
+		register(t, srv)
+		srv.Close()
+
+		if upgraded, _ := store.Get(merkle.HashTileIndexToPath(0, nil)); !bytes.Equal(upgraded[:len(tile)], tile) {
+			t.Error("expected the leaf hashes moved to the hash tile")
+		}
+		if exists, _ := store.Exists(merkle.EntryTileIndexToPath(0, nil)); exists {
+			t.Error("expected the legacy entry tile removed")
+		}
+	})
+
+	t.Run("restarts as a hash-only log", func(t *testing.T) {
+		srv := start(t)
+		defer srv.Close()
+		register(t, srv)
+	})
+
+	t.Run("writes entry bundles once every statement is stored", func(t *testing.T) {
+		for key, statement := range statements {
+			store.Put(key, statement)
+		}
+
+		srv := start(t)
+		defer srv.Close()
+		w := serve(srv, httptest.NewRequest(http.MethodGet, "/tile/entries/000.p/5", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if entries, err := merkle.DecodeEntryBundle(w.Body.Bytes()); err != nil || len(entries) != 5 {
+			t.Errorf("expected 5 entries, got %d (%v)", len(entries), err)
+		}
+		register(t, srv)
+	})
+}
+
+func TestHistoricalReceipts(t *testing.T) {
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+	register := func(statement []byte) {
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(statement))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		if w := serve(req); w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", w.Code)
+		}
+	}
+
+	first := createTestStatement(t)
+	register(first)
+	register(createTestStatement(t))
+
+	// Pin the checkpoint at tree size 2, then grow the log past it
+	w := serve(httptest.NewRequest(http.MethodGet, "/checkpoint", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to publish checkpoint: %d", w.Code)
+	}
+	pinned, err := merkle.DecodeCheckpoint(w.Body.String())
+	if err != nil {
+		t.Fatalf("failed to decode checkpoint: %v", err)
+	}
+	register(createTestStatement(t))
+
+	publicKeyData, err := os.ReadFile(cfg.Keys.Public)
+	if err != nil {
+		t.Fatalf("failed to read public key: %v", err)
+	}
+	publicKey, err := cose.ImportPublicKeyFromCOSECBOR(publicKeyData)
+	if err != nil {
+		t.Fatalf("failed to load public key: %v", err)
+	}
+
+	t.Run("proves inclusion against a published checkpoint", func(t *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodGet, "/entries/0?tree_size=2", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		receipt, err := cose.DecodeCoseSign1(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("failed to decode receipt: %v", err)
+		}
+		proof, root, err := merkle.VerifyReceipt(receipt, sha256.Sum256(first), publicKey)
+		if err != nil {
+			t.Fatalf("receipt does not verify: %v", err)
+		}
+		if proof.TreeSize != 2 || root != pinned.RootHash {
+			t.Errorf("expected the receipt to commit to the pinned checkpoint, got tree size %d", proof.TreeSize)
+		}
+
+		// A refreshed receipt proves inclusion in the current tree
+		w = serve(httptest.NewRequest(http.MethodGet, "/entries/0?refresh=true", nil))
+		receipt, _ = cose.DecodeCoseSign1(w.Body.Bytes())
+		if proof, _, err := merkle.VerifyReceipt(receipt, sha256.Sum256(first), publicKey); err != nil || proof.TreeSize != 3 {
+			t.Errorf("expected a receipt for the current tree, got %+v (%v)", proof, err)
+		}
+	})
+
+	t.Run("requires a published checkpoint at the tree size", func(t *testing.T) {
+		for _, path := range []string{"/entries/0?tree_size=3", "/entries/0?tree_size=4"} {
+			if w := serve(httptest.NewRequest(http.MethodGet, path, nil)); w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected status 404, got %d", path, w.Code)
+			}
+		}
+		for _, path := range []string{"/entries/2?tree_size=2", "/entries/0?tree_size=0", "/entries/0?tree_size=x"} {
+			if w := serve(httptest.NewRequest(http.MethodGet, path, nil)); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", path, w.Code)
+			}
+		}
+	})
+
+	t.Run("serves raw inclusion proofs", func(t *testing.T) {
+		leaf := sha256.Sum256(first)
+		w := serve(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/proofs/inclusion?leaf=%x&tree_size=2", leaf), nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			LeafIndex int64    `json:"leaf_index"`
+			TreeSize  int64    `json:"tree_size"`
+			Proof     []string `json:"proof"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		proof := &merkle.InclusionProof{LeafIndex: response.LeafIndex, TreeSize: response.TreeSize}
+		for _, hash := range response.Proof {
+			decoded, _ := hex.DecodeString(hash)
+			var node [merkle.HashSize]byte
+			copy(node[:], decoded)
+			proof.AuditPath = append(proof.AuditPath, node)
+		}
+		if response.LeafIndex != 0 || !merkle.VerifyInclusionProof(leaf, proof, pinned.RootHash) {
+			t.Errorf("inclusion proof does not verify against the pinned checkpoint: %+v", response)
+		}
+	})
+
+	t.Run("inclusion proof rejects invalid queries", func(t *testing.T) {
+		leaf := sha256.Sum256(first)
+		for _, query := range []string{"tree_size=2", fmt.Sprintf("leaf=%x", leaf), fmt.Sprintf("leaf=%x&tree_size=0", leaf), "leaf=zz&tree_size=2"} {
+			if w := serve(httptest.NewRequest(http.MethodGet, "/proofs/inclusion?"+query, nil)); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", query, w.Code)
+			}
+		}
+
+		unknown := sha256.Sum256([]byte("unknown"))
+		for _, query := range []string{fmt.Sprintf("leaf=%x&tree_size=2", unknown), fmt.Sprintf("leaf=%x&tree_size=4", leaf)} {
+			if w := serve(httptest.NewRequest(http.MethodGet, "/proofs/inclusion?"+query, nil)); w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected status 404, got %d", query, w.Code)
+			}
+		}
+	})
+}
+
+func TestReindex(t *testing.T) {
+	digest := sha256.Sum256([]byte(`{"test": "data"}`))
+	lookupPath := "/artifacts/sha-256/" + hex.EncodeToString(digest[:]) + "/statements"
+
+	// newLog registers statements on a log with local storage and stops it
+	newLog := func(t *testing.T, n int) (*config.Config, string, [][]byte) {
+		t.Helper()
+		cfg, apiKey, cleanup := setupTestConfig(t)
+		t.Cleanup(cleanup)
+		cfg.Storage = config.StorageConfig{Type: "local", Path: filepath.Join(t.TempDir(), "tiles")}
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		var statements [][]byte
+		for i := 0; i < n; i++ {
+			statement := createTestStatement(t)
+			req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(statement))
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected status 201, got %d", w.Code)
+			}
+			statements = append(statements, statement)
+		}
+		return cfg, apiKey, statements
+	}
+
+	reindex := func(t *testing.T, cfg *config.Config) (*service.ReindexResult, error) {
+		t.Helper()
+		store, err := storage.NewLocalStorage(cfg.Storage.Path)
+		if err != nil {
+			t.Fatalf("failed to open storage: %v", err)
+		}
+		db, err := database.OpenDatabase(database.DatabaseOptions{Path: cfg.Database.Path, EnableWAL: true})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+		return service.Reindex(db, store)
+	}
+
+	t.Run("rebuilds a lost database from stored statements", func(t *testing.T) {
+		cfg, _, statements := newLog(t, 3)
+
+		// Lose the database, keeping the tiles and statements in storage
+		cfg.Database.Path = filepath.Join(t.TempDir(), "rebuilt.db")
+		result, err := reindex(t, cfg)
+		if err != nil {
+			t.Fatalf("reindex failed: %v", err)
+		}
+		if result.TreeSize != 3 || result.Statements != 3 {
+			t.Errorf("expected 3 statements, got %+v", result)
+		}
+
+		apiKey, err := config.GenerateAPIKey()
+		if err != nil {
+			t.Fatalf("failed to generate API key: %v", err)
+		}
+		cfg.Server.APIKey = apiKey
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, lookupPath, nil))
+		var lookup struct {
+			Entries []struct {
+				EntryID int64  `json:"entry_id"`
+				Iss     string `json:"iss"`
+			} `json:"entries"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&lookup); err != nil {
+			t.Fatalf("failed to decode lookup: %v", err)
+		}
+		if len(lookup.Entries) != 3 || lookup.Entries[0].Iss != "https://issuer.example.com" {
+			t.Errorf("expected 3 rebuilt entries by artifact, got %+v", lookup.Entries)
+		}
+
+		// Existing statements are deduplicated and new ones append after them
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(statements[1]))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("expected re-registration to return 200, got %d", w.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated || w.Header().Get("Location") != "/entries/3" {
+			t.Errorf("expected new entry 3, got %d at %q", w.Code, w.Header().Get("Location"))
+		}
+	})
+
+	t.Run("keeps registration times so receipts keep their iat", func(t *testing.T) {
+		cfg, _, _ := newLog(t, 2)
+		registeredAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		// Backdate the registrations and drop the receipts issued so far
+		db, err := database.OpenDatabase(database.DatabaseOptions{Path: cfg.Database.Path, EnableWAL: true})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		for _, query := range []string{"UPDATE statements SET registered_at = '2020-01-02 03:04:05'", "DELETE FROM receipts"} {
+			if _, err := db.Exec(query); err != nil {
+				t.Fatalf("failed to update database: %v", err)
+			}
+		}
+		database.CloseDatabase(db)
+		store, err := storage.NewLocalStorage(cfg.Storage.Path)
+		if err != nil {
+			t.Fatalf("failed to open storage: %v", err)
+		}
+		for entryID := int64(0); entryID < 2; entryID++ {
+			if err := store.Delete(merkle.ReceiptPath(entryID)); err != nil {
+				t.Fatalf("failed to delete receipt: %v", err)
+			}
+		}
+
+		// receiptIat fetches an entry's receipt and returns its iat (nil if absent)
+		receiptIat := func(t *testing.T, path string) *time.Time {
+			t.Helper()
+			apiKey, err := config.GenerateAPIKey()
+			if err != nil {
+				t.Fatalf("failed to generate API key: %v", err)
+			}
+			cfg.Server.APIKey = apiKey
+			srv, err := server.NewServer(cfg)
+			if err != nil {
+				t.Fatalf("failed to create server: %v", err)
+			}
+			defer srv.Close()
+
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: expected status 200, got %d", path, w.Code)
+			}
+			receipt, err := cose.DecodeCoseSign1(w.Body.Bytes())
+			if err != nil {
+				t.Fatalf("failed to decode receipt: %v", err)
+			}
+			claims, err := merkle.GetReceiptClaims(receipt)
+			if err != nil {
+				t.Fatalf("failed to read receipt claims: %v", err)
+			}
+			return claims.Iat
+		}
+
+		// Reindexing in place keeps the registration times of the existing rows
+		if _, err := reindex(t, cfg); err != nil {
+			t.Fatalf("reindex failed: %v", err)
+		}
+		if iat := receiptIat(t, "/entries/0"); iat == nil || !iat.Equal(registeredAt) {
+			t.Errorf("expected iat %s after reindex, got %v", registeredAt, iat)
+		}
+
+		// A lost database recovers them from the stored receipts, and a
+		// receipt for an entry whose registration time is unknown omits iat
+		cfg.Database.Path = filepath.Join(t.TempDir(), "rebuilt.db")
+		if _, err := reindex(t, cfg); err != nil {
+			t.Fatalf("reindex failed: %v", err)
+		}
+		if iat := receiptIat(t, "/entries/0?refresh=true"); iat == nil || !iat.Equal(registeredAt) {
+			t.Errorf("expected iat %s after rebuilding the database, got %v", registeredAt, iat)
+		}
+		if iat := receiptIat(t, "/entries/1"); iat != nil {
+			t.Errorf("expected no iat for an unknown registration time, got %v", iat)
+		}
+	})
+
+	t.Run("rejects a statement that does not hash to its leaf", func(t *testing.T) {
+		cfg, _, statements := newLog(t, 2)
+
+		store, err := storage.NewLocalStorage(cfg.Storage.Path)
+		if err != nil {
+			t.Fatalf("failed to open storage: %v", err)
+		}
+		leaf := sha256.Sum256(statements[1])
+		if err := store.Put(merkle.StatementPath(leaf[:]), statements[0]); err != nil {
+			t.Fatalf("failed to overwrite statement: %v", err)
+		}
+
+		if _, err := reindex(t, cfg); err == nil || !strings.Contains(err.Error(), "does not hash to its leaf") {
+			t.Fatalf("expected hash mismatch, got %v", err)
+		}
+
+		db, err := database.OpenDatabase(database.DatabaseOptions{Path: cfg.Database.Path, EnableWAL: true})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+		if size, _ := database.GetCurrentTreeSize(db); size != 2 {
+			t.Errorf("a failed reindex must not modify the database, tree size is %d", size)
+		}
+	})
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+	cfg.Storage = config.StorageConfig{Type: "local", Path: filepath.Join(t.TempDir(), "tiles")}
+
+	origin, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	serve := func(srv *server.Server, req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+	register := func(n int) {
+		for i := 0; i < n; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+			if w := serve(origin, req); w.Code != http.StatusCreated {
+				t.Fatalf("expected status 201, got %d", w.Code)
+			}
+		}
+	}
+	register(3)
+	serve(origin, httptest.NewRequest(http.MethodGet, "/checkpoint", nil))
+	register(2)
+	origin.Close()
+
+	svc, err := service.NewTransparencyService(cfg)
+	if err != nil {
+		t.Fatalf("failed to open service: %v", err)
+	}
+	var archive bytes.Buffer
+	manifest, err := svc.ExportArchive(ctx, &archive)
+	svc.Close()
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if manifest.TreeSize != 5 || manifest.Statements != 5 || manifest.Checkpoints != 2 {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	publicKey := func(t *testing.T) *ecdsa.PublicKey {
+		data, err := os.ReadFile(cfg.Keys.Public)
+		if err != nil {
+			t.Fatalf("failed to read public key: %v", err)
+		}
+		key, err := cose.ImportPublicKeyFromCOSECBOR(data)
+		if err != nil {
+			t.Fatalf("failed to import public key: %v", err)
+		}
+		return key
+	}(t)
+
+	// restoreTarget returns fresh storage and a fresh database for the same keys
+	restoreTarget := func(t *testing.T) (*config.Config, storage.Storage, *sql.DB) {
+		t.Helper()
+		restored := *cfg
+		restored.Database.Path = filepath.Join(t.TempDir(), "restored.db")
+		restored.Storage = config.StorageConfig{Type: "local", Path: filepath.Join(t.TempDir(), "restored-tiles")}
+		store, err := storage.NewLocalStorage(restored.Storage.Path)
+		if err != nil {
+			t.Fatalf("failed to open storage: %v", err)
+		}
+		db, err := database.OpenDatabase(database.DatabaseOptions{Path: restored.Database.Path, EnableWAL: true})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		return &restored, store, db
+	}
+
+	t.Run("restores a log that serves identical checkpoints and proofs", func(t *testing.T) {
+		restored, store, db := restoreTarget(t)
+		if _, err := service.ImportArchive(db, store, publicKey, bytes.NewReader(archive.Bytes())); err != nil {
+			t.Fatalf("import failed: %v", err)
+		}
+
+		// A log can only be imported once
+		if _, err := service.ImportArchive(db, store, publicKey, bytes.NewReader(archive.Bytes())); err == nil {
+			t.Error("expected import into a non-empty log to fail")
+		}
+		database.CloseDatabase(db)
+
+		srv, err := server.NewServer(restored)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		w := serve(srv, httptest.NewRequest(http.MethodGet, "/checkpoint", nil))
+		if w.Code != http.StatusOK || w.Body.String() != manifest.Checkpoint {
+			t.Errorf("expected the archived checkpoint, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := serve(srv, httptest.NewRequest(http.MethodGet, "/proofs/consistency?old=3&new=5", nil)); w.Code != http.StatusOK {
+			t.Errorf("expected consistency proof from the archived checkpoint, got %d", w.Code)
+		}
+		w = serve(srv, httptest.NewRequest(http.MethodGet, "/entries/4", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected receipt for the last entry, got %d", w.Code)
+		}
+
+		// The receipt keeps the registration time recorded in the archive
+		if len(manifest.RegisteredAt) != 5 {
+			t.Fatalf("expected 5 registration times in the manifest, got %d", len(manifest.RegisteredAt))
+		}
+		registeredAt, err := time.Parse(time.RFC3339, manifest.RegisteredAt[4])
+		if err != nil {
+			t.Fatalf("invalid registration time: %v", err)
+		}
+		receipt, err := cose.DecodeCoseSign1(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("failed to decode receipt: %v", err)
+		}
+		if claims, err := merkle.GetReceiptClaims(receipt); err != nil || claims.Iat == nil || !claims.Iat.Equal(registeredAt) {
+			t.Errorf("expected iat %s after import, got %+v (%v)", registeredAt, claims, err)
+		}
+	})
+
+	t.Run("rejects an archive whose tiles do not match the checkpoint", func(t *testing.T) {
+		tampered := rewriteArchive(t, archive.Bytes(), func(name string, data []byte) []byte {
+			if name == "tile/0/000" {
+				data[0] ^= 0xff
+			}
+			return data
+		})
+
+		_, store, db := restoreTarget(t)
+		defer database.CloseDatabase(db)
+		_, err := service.ImportArchive(db, store, publicKey, bytes.NewReader(tampered))
+		if err == nil || !strings.Contains(err.Error(), "does not match the signed checkpoint") {
+			t.Fatalf("expected root mismatch, got %v", err)
+		}
+		if keys, _ := store.List(""); len(keys) != 0 {
+			t.Errorf("a rejected import must not leave data in storage, found %v", keys)
+		}
+		if size, _ := database.GetCurrentTreeSize(db); size != 0 {
+			t.Errorf("a rejected import must not modify the database, tree size is %d", size)
+		}
+	})
+
+	t.Run("rejects an archive signed by another key", func(t *testing.T) {
+		other, err := cose.GenerateES256KeyPair()
+		if err != nil {
+			t.Fatalf("failed to generate key pair: %v", err)
+		}
+		_, store, db := restoreTarget(t)
+		defer database.CloseDatabase(db)
+		if _, err := service.ImportArchive(db, store, other.Public, bytes.NewReader(archive.Bytes())); err == nil {
+			t.Error("expected import with another key to fail")
+		}
+	})
+}
+
+// rewriteArchive copies a tar archive, passing each member through edit
+func rewriteArchive(t *testing.T, archive []byte, edit func(name string, data []byte) []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	tr := tar.NewReader(bytes.NewReader(archive))
+	tw := tar.NewWriter(&out)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read archive: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read archive member: %v", err)
+		}
+		data = edit(header.Name, data)
+		header.Size = int64(len(data))
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write archive header: %v", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("failed to write archive member: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestSharding(t *testing.T) {
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+	cfg.Storage = config.StorageConfig{Type: "local", Path: filepath.Join(t.TempDir(), "tiles")}
+	cfg.Sharding = &config.ShardingConfig{Interval: config.ShardIntervalYearly}
+
+	now := time.Now()
+	current := service.ShardName(config.ShardIntervalYearly, now)
+	next := service.ShardName(config.ShardIntervalYearly, now.AddDate(1, 0, 0))
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer func() { srv.Close() }()
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+	register := func(statement []byte) string {
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(statement))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := serve(req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		return w.Header().Get("Location")
+	}
+
+	frozenStatement := createTestStatement(t)
+	register(createTestStatement(t))
+	if location := register(frozenStatement); location != "/shards/"+current+"/entries/1" {
+		t.Fatalf("expected entry in shard %s, got location %s", current, location)
+	}
+
+	if err := srv.Service().RotateShards(now.AddDate(1, 0, 0)); err != nil {
+		t.Fatalf("failed to rotate shards: %v", err)
+	}
+	if location := register(createTestStatement(t)); location != "/shards/"+next+"/entries/0" {
+		t.Fatalf("expected entry in shard %s, got location %s", next, location)
+	}
+
+	publicKeyData, err := os.ReadFile(cfg.Keys.Public)
+	if err != nil {
+		t.Fatalf("failed to read public key: %v", err)
+	}
+	publicKey, err := cose.ImportPublicKeyFromCOSECBOR(publicKeyData)
+	if err != nil {
+		t.Fatalf("failed to load public key: %v", err)
+	}
+
+	t.Run("frozen shard keeps its final checkpoint and receipts", func(t *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodGet, "/shards/"+current+"/checkpoint", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if !strings.HasPrefix(w.Body.String(), service.ShardOrigin(cfg.Issuer, current)+"\n2\n") {
+			t.Fatalf("unexpected final checkpoint:\n%s", w.Body.String())
+		}
+		checkpoint, err := merkle.DecodeCheckpoint(w.Body.String())
+		if err != nil {
+			t.Fatalf("failed to decode checkpoint: %v", err)
+		}
+
+		w = serve(httptest.NewRequest(http.MethodGet, "/shards/"+current+"/entries/1", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		receipt, err := cose.DecodeCoseSign1(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("failed to decode receipt: %v", err)
+		}
+		_, root, err := merkle.VerifyReceipt(receipt, sha256.Sum256(frozenStatement), publicKey)
+		if err != nil {
+			t.Fatalf("receipt from frozen shard does not verify: %v", err)
+		}
+		if root != checkpoint.RootHash {
+			t.Error("expected the receipt to commit to the final checkpoint")
+		}
+
+		if w := serve(httptest.NewRequest(http.MethodGet, "/shards/"+current+"/tile/0/000.p/2", nil)); w.Code != http.StatusOK || w.Body.Len() != 2*32 {
+			t.Errorf("expected frozen shard tile with 2 leaves, got %d (%d bytes)", w.Code, w.Body.Len())
+		}
+	})
+
+	t.Run("frozen shard rejects registrations", func(t *testing.T) {
+		frozen, err := srv.Service().Shard(current)
+		if err != nil {
+			t.Fatalf("failed to get shard: %v", err)
+		}
+		_, err = frozen.RegisterStatement(context.Background(), &service.RegisterStatementRequest{Statement: createTestStatement(t)})
+		if service.AsError(err).Kind != service.ErrorKindForbidden {
+			t.Errorf("expected forbidden error, got %v", err)
+		}
+	})
+
+	t.Run("unprefixed routes serve the active shard", func(t *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodGet, "/checkpoint", nil))
+		if !strings.HasPrefix(w.Body.String(), service.ShardOrigin(cfg.Issuer, next)+"\n1\n") {
+			t.Errorf("expected checkpoint of shard %s, got:\n%s", next, w.Body.String())
+		}
+		if w := serve(httptest.NewRequest(http.MethodGet, "/entries/1", nil)); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404 for an entry beyond the active shard, got %d", w.Code)
+		}
+		if w := serve(httptest.NewRequest(http.MethodGet, "/shards/1999/checkpoint", nil)); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404 for an unknown shard, got %d", w.Code)
+		}
+		w = serve(httptest.NewRequest(http.MethodGet, "/shards/"+current, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404 for a bare shard path, got %d", w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != server.ContentTypeConciseProblemDetails {
+			t.Errorf("expected %s, got %s", server.ContentTypeConciseProblemDetails, contentType)
+		}
+	})
+
+	t.Run("lists entries across shards", func(t *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodGet, "/entries?iss=https://issuer.example.com", nil))
+		var response struct {
+			Entries []struct {
+				EntryID int64  `json:"entry_id"`
+				Shard   string `json:"shard"`
+			} `json:"entries"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.Entries) != 3 || response.Entries[0].Shard != current || response.Entries[2].Shard != next || response.Entries[2].EntryID != 0 {
+			t.Errorf("unexpected entries: %+v", response.Entries)
+		}
+	})
+
+	t.Run("labels tree metrics by shard", func(t *testing.T) {
+		body := serve(httptest.NewRequest(http.MethodGet, "/metrics", nil)).Body.String()
+		for _, want := range []string{
+			`scitt_tree_size{shard="` + current + `"} 2` + "\n",
+			`scitt_tree_size{shard="` + next + `"} 1` + "\n",
+			`scitt_checkpoint_age_seconds{shard="` + current + `"} `,
+			`scitt_checkpoint_age_seconds{shard="` + next + `"} `,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("metrics missing %q", want)
+			}
+		}
+	})
+
+	checkConfiguration := func(t *testing.T) {
+		t.Helper()
+		w := serve(httptest.NewRequest(http.MethodGet, "/.well-known/scitt-configuration", nil))
+		var response struct {
+			Sharding struct {
+				Interval    string `json:"interval"`
+				ActiveShard string `json:"active_shard"`
+				Shards      []struct {
+					Name            string `json:"name"`
+					Origin          string `json:"origin"`
+					Status          string `json:"status"`
+					FinalTreeSize   int64  `json:"final_tree_size"`
+					FinalCheckpoint string `json:"final_checkpoint"`
+				} `json:"shards"`
+			} `json:"sharding"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode configuration: %v", err)
+		}
+
+		sharding := response.Sharding
+		if sharding.Interval != "yearly" || sharding.ActiveShard != next || len(sharding.Shards) != 2 {
+			t.Fatalf("unexpected sharding configuration: %+v", sharding)
+		}
+		frozen, active := sharding.Shards[0], sharding.Shards[1]
+		if frozen.Name != current || frozen.Status != service.ShardStatusFrozen || frozen.FinalTreeSize != 2 ||
+			!strings.HasPrefix(frozen.FinalCheckpoint, service.ShardOrigin(cfg.Issuer, current)+"\n2\n") {
+			t.Errorf("unexpected frozen shard: %+v", frozen)
+		}
+		if active.Name != next || active.Status != service.ShardStatusActive || active.Origin != service.ShardOrigin(cfg.Issuer, next) {
+			t.Errorf("unexpected active shard: %+v", active)
+		}
+	}
+
+	t.Run("configuration lists active and frozen shards", checkConfiguration)
+
+	t.Run("shards survive a restart", func(t *testing.T) {
+		srv.Close()
+		srv, err = server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to reopen server: %v", err)
+		}
+
+		checkConfiguration(t)
+		if location := register(createTestStatement(t)); location != "/shards/"+next+"/entries/1" {
+			t.Errorf("expected entry in shard %s, got location %s", next, location)
+		}
+	})
+
+	t.Run("refuses to shard an existing log", func(t *testing.T) {
+		unsharded, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(unsharded)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+unsharded.Server.APIKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		srv.Close()
+
+		unsharded.Sharding = &config.ShardingConfig{Interval: config.ShardIntervalMonthly}
+		if _, err := server.NewServer(unsharded); err == nil {
+			t.Error("expected an error sharding a log with entries")
+		}
+	})
+}
+
+func TestShardRotationFailure(t *testing.T) {
+	cfg, apiKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+	cfg.Storage = config.StorageConfig{Type: "local", Path: filepath.Join(t.TempDir(), "tiles")}
+	cfg.Sharding = &config.ShardingConfig{Interval: config.ShardIntervalYearly}
+
+	now := time.Now()
+	current := service.ShardName(config.ShardIntervalYearly, now)
+	next := service.ShardName(config.ShardIntervalYearly, now.AddDate(1, 0, 0))
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	register := func(t *testing.T) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(createTestStatement(t)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		return w.Header().Get("Location")
+	}
+
+	// A directory in place of the next shard's database makes opening it fail
+	ext := filepath.Ext(cfg.Database.Path)
+	blocked := strings.TrimSuffix(cfg.Database.Path, ext) + "-" + next + ext
+	if err := os.MkdirAll(blocked, 0755); err != nil {
+		t.Fatalf("failed to block shard database: %v", err)
+	}
+
+	t.Run("keeps the active shard when opening the next one fails", func(t *testing.T) {
+		if err := srv.Service().RotateShards(now.AddDate(1, 0, 0)); err == nil {
+			t.Fatal("expected rotation to fail")
+		}
+		if location := register(t); location != "/shards/"+current+"/entries/0" {
+			t.Errorf("expected entry in shard %s, got location %s", current, location)
+		}
+	})
+
+	t.Run("rotates once the next shard can be opened", func(t *testing.T) {
+		if err := os.Remove(blocked); err != nil {
+			t.Fatalf("failed to unblock shard database: %v", err)
+		}
+		if err := srv.Service().RotateShards(now.AddDate(1, 0, 0)); err != nil {
+			t.Fatalf("failed to rotate shards: %v", err)
+		}
+		if location := register(t); location != "/shards/"+next+"/entries/0" {
+			t.Errorf("expected entry in shard %s, got location %s", next, location)
+		}
+	})
+}
+
+func TestMultipleLogs(t *testing.T) {
+	production, productionKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+	staging, stagingKey, cleanup := setupTestConfig(t)
+	defer cleanup()
+
+	logConfig := func(name string, cfg *config.Config) config.LogConfig {
+		return config.LogConfig{
+			Name:     name,
+			Issuer:   cfg.Issuer + "/" + name,
+			Database: cfg.Database,
+			Storage:  cfg.Storage,
+			Keys:     cfg.Keys,
+			APIKey:   cfg.Server.APIKey,
+		}
+	}
+	cfg := *production
+	productionLog := logConfig("production", production)
+	productionLog.Host = "prod.example.com"
+	stagingLog := logConfig("staging", staging)
+	stagingLog.PathPrefix = "/staging"
+	cfg.Logs = []config.LogConfig{productionLog, stagingLog}
+	cfg.Server.Port = 56177
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("invalid multi-log configuration: %v", err)
+	}
+
+	srv, err := server.NewServer(&cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+	register := func(host, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(createTestStatement(t)))
+		req.Host = host
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		return serve(req)
+	}
+
+	t.Run("serves each log's configuration", func(t *testing.T) {
+		for _, tc := range []struct {
+			host, path, issuer string
+		}{
+			{"prod.example.com:443", "/.well-known/scitt-configuration", productionLog.Issuer},
+			{"example.com", "/staging/.well-known/scitt-configuration", stagingLog.Issuer},
+		} {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Host = tc.host
+			w := serve(req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s%s: expected status 200, got %d", tc.host, tc.path, w.Code)
+			}
+			var configuration map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&configuration); err != nil {
+				t.Fatalf("failed to decode configuration: %v", err)
+			}
+			if configuration["issuer"] != tc.issuer {
+				t.Errorf("%s%s: expected issuer %s, got %v", tc.host, tc.path, tc.issuer, configuration["issuer"])
+			}
+		}
+	})
+
+	t.Run("registers with each log's API key", func(t *testing.T) {
+		if w := register("prod.example.com", "/entries", productionKey); w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		w := register("example.com", "/staging/entries", stagingKey)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		if location := w.Header().Get("Location"); location != "/staging/entries/0" {
+			t.Errorf("expected location under the path prefix, got %s", location)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/staging/entries/0", nil)
+		if w := serve(req); w.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("rejects another log's API key", func(t *testing.T) {
+		if w := register("prod.example.com", "/entries", stagingKey); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", w.Code)
+		}
+		if w := register("example.com", "/staging/entries", productionKey); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("returns 404 outside every log", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/entries/0", nil)
+		req.Host = "example.com"
+		if w := serve(req); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("reports readiness of every log", func(t *testing.T) {
+		w := serve(httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var report service.HealthReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("failed to decode report: %v", err)
+		}
+		for _, component := range []string{"production.database", "staging.tree"} {
+			if _, ok := report.Components[component]; !ok {
+				t.Errorf("readiness report missing %s", component)
+			}
+		}
+	})
+
+	t.Run("labels metrics by log", func(t *testing.T) {
+		body := serve(httptest.NewRequest(http.MethodGet, "/metrics", nil)).Body.String()
+		for _, want := range []string{
+			`scitt_http_requests_total{route="/entries",method="POST",status="201",log="staging"}`,
+			`scitt_registrations_total{outcome="accepted",reason="",log="production"}`,
+			`scitt_registrations_total{outcome="rejected",reason="unauthorized",log="staging"}`,
+			`scitt_tree_size{log="production"} 1` + "\n",
+			`scitt_checkpoint_age_seconds{log="staging"} `,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("metrics missing %q", want)
+			}
+		}
+	})
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	t.Run("serves Swagger UI at root", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+
+		contentType := resp.Header.Get("Content-Type")
+		if !contains(contentType, "text/html") {
+			t.Errorf("expected Content-Type text/html, got %s", contentType)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		html := string(body)
+		if !contains(html, "swagger-ui") {
+			t.Error("expected Swagger UI HTML")
+		}
+	})
+
+	t.Run("serves OpenAPI spec as JSON", func(t *testing.T) {
+		cfg, _, cleanup := setupTestConfig(t)
+		defer cleanup()
+
+		srv, err := server.NewServer(cfg)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		defer srv.Close()
+
+		req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+		w := httptest.NewRecorder()
+
+		srv.Handler().ServeHTTP(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+
+		contentType := resp.Header.Get("Content-Type")
+		if !contains(contentType, "application/json") {
+			t.Errorf("expected Content-Type application/json, got %s", contentType)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		var spec map[string]interface{}
+		if err := json.Unmarshal(body, &spec); err != nil {
+			t.Fatalf("failed to parse OpenAPI spec: %v", err)
+		}
+
+		// Verify OpenAPI structure
 		if _, ok := spec["openapi"]; !ok {
 			t.Error("expected openapi field in spec")
 		}
@@ -518,71 +3197,16 @@ func TestCORSMiddleware(t *testing.T) {
 func setupTestConfig(t *testing.T) (*config.Config, string, func()) {
 	t.Helper()
 
-	// Create temporary directory
-	tmpDir := t.TempDir()
-
-	// Generate test keys
-	keyPair, err := cose.GenerateES256KeyPair()
-	if err != nil {
-		t.Fatalf("failed to generate key pair: %v", err)
-	}
-
-	// Save private key as COSE CBOR (with kid set automatically)
-	privateKeyCBOR, err := cose.ExportPrivateKeyToCOSECBOR(keyPair.Private)
-	if err != nil {
-		t.Fatalf("failed to export private key: %v", err)
-	}
-	privateKeyPath := filepath.Join(tmpDir, "service-key.cbor")
-	if err := os.WriteFile(privateKeyPath, privateKeyCBOR, 0600); err != nil {
-		t.Fatalf("failed to write private key: %v", err)
-	}
-
-	// Save public key as COSE CBOR (with kid set automatically)
-	publicKeyCBOR, err := cose.ExportPublicKeyToCOSECBOR(keyPair.Public)
-	if err != nil {
-		t.Fatalf("failed to export public key: %v", err)
-	}
-	publicKeyPath := filepath.Join(tmpDir, "service-key-pub.cbor")
-	if err := os.WriteFile(publicKeyPath, publicKeyCBOR, 0644); err != nil {
-		t.Fatalf("failed to write public key: %v", err)
-	}
-
-	// Generate API key for tests
-	apiKey, err := config.GenerateAPIKey()
-	if err != nil {
-		t.Fatalf("failed to generate API key: %v", err)
-	}
-
-	// Create config
-	cfg := &config.Config{
-		Issuer: "https://test.example.com",
-		Database: config.DatabaseConfig{
-			Path:      filepath.Join(tmpDir, "test.db"),
-			EnableWAL: true,
-		},
-		Storage: config.StorageConfig{
-			Type: "memory",
-		},
-		Keys: config.KeysConfig{
-			Private: privateKeyPath,
-			Public:  publicKeyPath,
-		},
-		Server: config.ServerConfig{
-			Host:   "127.0.0.1",
-			Port:   0, // Random port
-			APIKey: apiKey,
-			CORS: config.CORSConfig{
-				Enabled:        true,
-				AllowedOrigins: []string{"*"},
-			},
-		},
+	cfg := testutil.NewConfig(t, testutil.NewKeys(t))
+	cfg.Server.CORS = config.CORSConfig{
+		Enabled:        true,
+		AllowedOrigins: []string{"*"},
 	}
 
-	cleanup := func() {
-		os.RemoveAll(tmpDir)
-	}
+	// Temporary directories are removed by the testing package
+	cleanup := func() {}
 
-	return cfg, apiKey, cleanup
+	return cfg, cfg.Server.APIKey, cleanup
 }
 
 func createTestStatement(t *testing.T) []byte {
@@ -0,0 +1,576 @@
+package service
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// ArchiveFormat identifies the layout of a log archive
+const ArchiveFormat = "scitt-log-archive/v1"
+
+// Names of the archive members that are not storage keys
+const (
+	archiveManifestName = "manifest.json"
+	archiveKeysName     = "scitt-keys.cbor"
+)
+
+// maxArchiveCheckpointBytes bounds a checkpoint read from an archive
+const maxArchiveCheckpointBytes = 64 << 10
+
+// ArchiveManifest describes the log in an archive
+// It is the first member of the tar stream; every other member is stored
+// under its storage key (hash tiles, statements and checkpoints/<size>); entry
+// bundles are rewritten from the statements on import
+type ArchiveManifest struct {
+	Format      string    `json:"format"`
+	Issuer      string    `json:"issuer"`
+	TreeSize    int64     `json:"tree_size"`
+	RootHash    string    `json:"root_hash"`  // Hex
+	Checkpoint  string    `json:"checkpoint"` // Signed checkpoint at TreeSize
+	Statements  int64     `json:"statements"` // Distinct statement blobs
+	Checkpoints int       `json:"checkpoints"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// RegisteredAt holds the registration time of each entry (RFC 3339, by
+	// entry ID; empty when unknown) so receipts keep their iat after import
+	RegisteredAt []string `json:"registered_at,omitempty"`
+}
+
+// ExportArchive writes the log as a tar archive: a manifest with the final
+// signed checkpoint, the service key set, the hash tiles up to that
+// checkpoint, every statement they commit to and the recorded checkpoints
+func (s *TransparencyService) ExportArchive(ctx context.Context, w io.Writer) (*ArchiveManifest, error) {
+	if s.IsMirror() {
+		return nil, fmt.Errorf("mirrors cannot be exported; export the source log")
+	}
+	if s.IsSharded() {
+		return nil, fmt.Errorf("sharded logs cannot be exported")
+	}
+
+	note, err := s.GetPublishedCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint, err := merkle.DecodeCheckpoint(note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	keys, err := s.GetSCITTKeys()
+	if err != nil {
+		return nil, err
+	}
+	states, err := database.GetTreeStateHistory(s.db, 0)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].TreeSize < states[j].TreeSize })
+
+	manifest := &ArchiveManifest{
+		Format:      ArchiveFormat,
+		Issuer:      checkpoint.Issuer,
+		TreeSize:    checkpoint.TreeSize,
+		RootHash:    hex.EncodeToString(checkpoint.RootHash[:]),
+		Checkpoint:  note,
+		Checkpoints: len(states),
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	// Hash tiles are cut at the checkpoint's tree size; statements are
+	// collected first so the manifest can count them
+	tiles, blobs, err := s.archiveEntries(checkpoint.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Statements = int64(len(blobs))
+	if manifest.RegisteredAt, err = s.archiveRegistrationTimes(checkpoint.TreeSize); err != nil {
+		return nil, err
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeArchiveMember(tw, archiveManifestName, manifestJSON, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := writeArchiveMember(tw, archiveKeysName, keys, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	for index, tile := range tiles {
+		key := merkle.HashTileIndexToPath(int64(index), nil)
+		if err := writeArchiveMember(tw, key, tile, manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range blobs {
+		data, err := s.storage.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read statement %s: %w", key, err)
+		}
+		if err := writeArchiveMember(tw, key, data, manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, state := range states {
+		key := merkle.CheckpointHistoryPath(state.TreeSize)
+		if err := writeArchiveMember(tw, key, []byte(state.CheckpointSignedNote), manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// archiveEntries returns the hash tiles holding the first treeSize leaves,
+// cut at treeSize, and the storage keys of the statements they commit to in
+// leaf order without duplicates
+func (s *TransparencyService) archiveEntries(treeSize int64) ([][]byte, []string, error) {
+	var tiles [][]byte
+	var keys []string
+	seen := make(map[string]bool)
+
+	for index := int64(0); merkle.TileCoordinatesToEntryID(index, 0) < treeSize; index++ {
+		key := merkle.HashTileIndexToPath(index, nil)
+		tile, err := s.storage.Get(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read hash tile %s: %w", key, err)
+		}
+		width := treeSize - merkle.TileCoordinatesToEntryID(index, 0)
+		if width > merkle.TileSize {
+			width = merkle.TileSize
+		}
+		if int64(len(tile)) < width*merkle.HashSize {
+			return nil, nil, fmt.Errorf("hash tile %s ends before tree size %d", key, treeSize)
+		}
+		tile = tile[:width*merkle.HashSize]
+		tiles = append(tiles, tile)
+
+		for offset := 0; offset*merkle.HashSize < len(tile); offset++ {
+			statementKey := merkle.StatementPath(tile[offset*merkle.HashSize : (offset+1)*merkle.HashSize])
+			if seen[statementKey] {
+				continue
+			}
+			exists, err := s.storage.Exists(statementKey)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to check statement %s: %w", statementKey, err)
+			}
+			if !exists {
+				return nil, nil, fmt.Errorf("statement for entry %d is not in storage", merkle.TileCoordinatesToEntryID(index, offset))
+			}
+			seen[statementKey] = true
+			keys = append(keys, statementKey)
+		}
+	}
+
+	return tiles, keys, nil
+}
+
+// archiveRegistrationTimes returns the registration time of each of the first
+// treeSize entries, or nil when none is known
+func (s *TransparencyService) archiveRegistrationTimes(treeSize int64) ([]string, error) {
+	times := make([]string, treeSize)
+	known := false
+	for entryID := int64(0); entryID < treeSize; entryID++ {
+		stmt, err := s.entryStatement(entryID)
+		if err != nil {
+			return nil, err
+		}
+		if stmt != nil && stmt.RegisteredAt != "" {
+			times[entryID] = stmt.RegisteredAt
+			known = true
+		}
+	}
+	if !known {
+		return nil, nil
+	}
+	return times, nil
+}
+
+// writeArchiveMember writes one file to a tar archive
+func writeArchiveMember(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+		Format:  tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write archive header for %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// ImportArchive restores an archive into empty storage and database
+// Tiles and statements are written as they are read, but the log is only
+// accepted, by rebuilding the statements table, writing the entry bundles and
+// recording the checkpoints, once the root recomputed from the tiles matches
+// the manifest's checkpoint signed by publicKey; a rejected archive is removed
+// from storage again
+func ImportArchive(db *sql.DB, store storage.Storage, publicKey *ecdsa.PublicKey, r io.Reader) (*ArchiveManifest, error) {
+	if err := checkImportTarget(db, store); err != nil {
+		return nil, err
+	}
+
+	imp := &archiveImport{db: db, store: store, publicKey: publicKey, checkpoints: make(map[int64]string)}
+	manifest, err := imp.read(r)
+	if err == nil {
+		err = imp.verify(manifest)
+	}
+	if err == nil {
+		_, err = reindex(db, store, manifest.RegisteredAt)
+	}
+	if err != nil {
+		imp.discard()
+		return nil, err
+	}
+
+	if err := imp.recordCheckpoints(manifest); err != nil {
+		return nil, err
+	}
+	tree := merkle.NewTileLog(store)
+	if err := tree.Rebuild(manifest.TreeSize); err != nil {
+		return nil, fmt.Errorf("failed to record tree state: %w", err)
+	}
+	if err := tree.RebuildBundles(); err != nil {
+		return nil, fmt.Errorf("failed to write entry bundles: %w", err)
+	}
+	return manifest, nil
+}
+
+// checkImportTarget requires an empty log to import into
+func checkImportTarget(db *sql.DB, store storage.Storage) error {
+	treeSize, err := database.GetCurrentTreeSize(db)
+	if err != nil {
+		return err
+	}
+	tiles, err := store.List("tile/")
+	if err != nil {
+		return fmt.Errorf("failed to list tiles: %w", err)
+	}
+	note, err := store.Get(merkle.CheckpointPath)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if treeSize != 0 || len(tiles) != 0 || note != nil {
+		return fmt.Errorf("import requires empty storage and an empty database")
+	}
+	return nil
+}
+
+// archiveImport tracks one import
+type archiveImport struct {
+	db        *sql.DB
+	store     storage.Storage
+	publicKey *ecdsa.PublicKey
+
+	keys        []*ecdsa.PublicKey
+	leaves      int64            // Leaves in the imported hash tiles
+	checkpoints map[int64]string // Recorded checkpoints by tree size
+	written     []string         // Storage keys written so far
+}
+
+// read reads the archive, writing tiles and statements to storage
+func (imp *archiveImport) read(r io.Reader) (*ArchiveManifest, error) {
+	tr := tar.NewReader(r)
+
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if header.Name != archiveManifestName {
+		return nil, fmt.Errorf("archive does not start with %s", archiveManifestName)
+	}
+	var manifest ArchiveManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if manifest.Format != ArchiveFormat {
+		return nil, fmt.Errorf("unsupported archive format %q", manifest.Format)
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected archive member %s", header.Name)
+		}
+		if err := imp.readMember(header.Name, header.Size, tr); err != nil {
+			return nil, err
+		}
+	}
+
+	return &manifest, nil
+}
+
+// readMember validates one archive member and stores it
+func (imp *archiveImport) readMember(name string, size int64, r io.Reader) error {
+	switch {
+	case name == archiveKeysName:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		keys, err := cose.ImportCOSEKeySetFromCBOR(data)
+		if err != nil {
+			return fmt.Errorf("failed to import archive key set: %w", err)
+		}
+		imp.keys = keys
+		return nil
+
+	case strings.HasPrefix(name, "tile/"):
+		// Archives written before entry bundles hold the leaf hashes under tile/entries
+		var index int64
+		if strings.HasPrefix(name, "tile/entries/") {
+			parsed, err := merkle.ParseEntryTilePath(name)
+			if err != nil || parsed.IsPartial || merkle.EntryTileIndexToPath(parsed.Index, nil) != name {
+				return fmt.Errorf("unexpected archive member %s", name)
+			}
+			index = parsed.Index
+		} else {
+			parsed, err := merkle.ParseTilePath(name)
+			if err != nil || parsed.IsPartial || merkle.HashTileIndexToPath(parsed.Index, nil) != name {
+				return fmt.Errorf("unexpected archive member %s", name)
+			}
+			index = parsed.Index
+		}
+		if size == 0 || size > merkle.FullTileBytes || size%merkle.HashSize != 0 {
+			return fmt.Errorf("hash tile %s has an invalid length of %d bytes", name, size)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		imp.leaves += int64(len(data) / merkle.HashSize)
+		return imp.put(merkle.HashTileIndexToPath(index, nil), data)
+
+	case strings.HasPrefix(name, "statements/"):
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		hash := sha256.Sum256(data)
+		if merkle.StatementPath(hash[:]) != name {
+			return fmt.Errorf("statement %s does not match its content", name)
+		}
+		return imp.put(name, data)
+
+	case strings.HasPrefix(name, "checkpoints/"):
+		treeSize, err := strconv.ParseInt(strings.TrimPrefix(name, "checkpoints/"), 10, 64)
+		if err != nil || merkle.CheckpointHistoryPath(treeSize) != name {
+			return fmt.Errorf("unexpected archive member %s", name)
+		}
+		if size > maxArchiveCheckpointBytes {
+			return fmt.Errorf("checkpoint %s exceeds %d bytes", name, maxArchiveCheckpointBytes)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		imp.checkpoints[treeSize] = string(data)
+		return nil
+
+	default:
+		return fmt.Errorf("unexpected archive member %s", name)
+	}
+}
+
+func (imp *archiveImport) put(key string, data []byte) error {
+	if err := imp.store.Put(key, data); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	imp.written = append(imp.written, key)
+	return nil
+}
+
+// verify checks the manifest's checkpoint and every recorded checkpoint
+// against the root recomputed from the imported tiles
+func (imp *archiveImport) verify(manifest *ArchiveManifest) error {
+	trusted := false
+	for _, key := range imp.keys {
+		trusted = trusted || key.Equal(imp.publicKey)
+	}
+	if !trusted {
+		return fmt.Errorf("archive key set does not contain the service's public key")
+	}
+
+	checkpoint, err := imp.verifyCheckpoint(manifest.Checkpoint)
+	if err != nil {
+		return fmt.Errorf("manifest checkpoint: %w", err)
+	}
+	if checkpoint.TreeSize != manifest.TreeSize || hex.EncodeToString(checkpoint.RootHash[:]) != manifest.RootHash {
+		return fmt.Errorf("manifest does not match its checkpoint")
+	}
+	if imp.leaves != manifest.TreeSize {
+		return fmt.Errorf("archive holds %d entries but its checkpoint is for tree size %d", imp.leaves, manifest.TreeSize)
+	}
+	if manifest.RegisteredAt != nil && int64(len(manifest.RegisteredAt)) != manifest.TreeSize {
+		return fmt.Errorf("manifest records %d registration times for tree size %d", len(manifest.RegisteredAt), manifest.TreeSize)
+	}
+	for entryID, registeredAt := range manifest.RegisteredAt {
+		if _, err := time.Parse(time.RFC3339, registeredAt); registeredAt != "" && err != nil {
+			return fmt.Errorf("invalid registration time of entry %d: %w", entryID, err)
+		}
+	}
+
+	sizes := map[int64]bool{manifest.TreeSize: true}
+	for treeSize := range imp.checkpoints {
+		if treeSize > manifest.TreeSize {
+			return fmt.Errorf("checkpoint at tree size %d is beyond the archived tree", treeSize)
+		}
+		sizes[treeSize] = true
+	}
+	roots, err := archiveRoots(imp.store, manifest.TreeSize, sizes)
+	if err != nil {
+		return err
+	}
+
+	if manifest.TreeSize > 0 && roots[manifest.TreeSize] != checkpoint.RootHash {
+		return fmt.Errorf("recomputed root at tree size %d does not match the signed checkpoint", manifest.TreeSize)
+	}
+	for treeSize, note := range imp.checkpoints {
+		recorded, err := imp.verifyCheckpoint(note)
+		if err != nil {
+			return fmt.Errorf("checkpoint at tree size %d: %w", treeSize, err)
+		}
+		if recorded.TreeSize != treeSize {
+			return fmt.Errorf("checkpoint stored at tree size %d is for tree size %d", treeSize, recorded.TreeSize)
+		}
+		if treeSize > 0 && roots[treeSize] != recorded.RootHash {
+			return fmt.Errorf("recomputed root at tree size %d does not match its checkpoint", treeSize)
+		}
+	}
+
+	return nil
+}
+
+func (imp *archiveImport) verifyCheckpoint(note string) (*merkle.Checkpoint, error) {
+	checkpoint, err := merkle.DecodeCheckpoint(note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	if valid, err := merkle.VerifyCheckpoint(checkpoint, imp.publicKey); err != nil || !valid {
+		return nil, fmt.Errorf("checkpoint is not signed by the service key")
+	}
+	return checkpoint, nil
+}
+
+// archiveRoots computes the root hash of the stored tree at the given sizes
+func archiveRoots(store storage.Storage, treeSize int64, sizes map[int64]bool) (map[int64][merkle.HashSize]byte, error) {
+	roots := make(map[int64][merkle.HashSize]byte)
+	rf := &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+	cr := rf.NewEmptyRange(0)
+
+	var tile []byte
+	for entryID := int64(0); entryID < treeSize; entryID++ {
+		offset := merkle.EntryIDToTileOffset(entryID)
+		if offset == 0 {
+			key := merkle.HashTileIndexToPath(merkle.EntryIDToTileIndex(entryID), nil)
+			var err error
+			if tile, err = store.Get(key); err != nil {
+				return nil, fmt.Errorf("failed to read hash tile %s: %w", key, err)
+			}
+		}
+		if len(tile) < (offset+1)*merkle.HashSize {
+			return nil, fmt.Errorf("archive hash tiles end before tree size %d", treeSize)
+		}
+		leaf := tile[offset*merkle.HashSize : (offset+1)*merkle.HashSize]
+		if err := cr.Append(rfc6962.DefaultHasher.HashLeaf(leaf), nil); err != nil {
+			return nil, fmt.Errorf("failed to append leaf: %w", err)
+		}
+
+		if sizes[entryID+1] {
+			root, err := cr.GetRootHash(nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute root: %w", err)
+			}
+			var hash [merkle.HashSize]byte
+			copy(hash[:], root)
+			roots[entryID+1] = hash
+		}
+	}
+
+	return roots, nil
+}
+
+// recordCheckpoints records the archived checkpoints in tree_state and storage,
+// with the manifest's checkpoint as the latest
+func (imp *archiveImport) recordCheckpoints(manifest *ArchiveManifest) error {
+	if _, ok := imp.checkpoints[manifest.TreeSize]; !ok {
+		imp.checkpoints[manifest.TreeSize] = manifest.Checkpoint
+	}
+
+	sizes := make([]int64, 0, len(imp.checkpoints))
+	for treeSize := range imp.checkpoints {
+		sizes = append(sizes, treeSize)
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+
+	var latest database.TreeState
+	for _, treeSize := range sizes {
+		note := imp.checkpoints[treeSize]
+		checkpoint, err := merkle.DecodeCheckpoint(note)
+		if err != nil {
+			return fmt.Errorf("failed to decode checkpoint: %w", err)
+		}
+		latest = database.TreeState{
+			TreeSize:             treeSize,
+			RootHash:             hex.EncodeToString(checkpoint.RootHash[:]),
+			CheckpointStorageKey: merkle.CheckpointHistoryPath(treeSize),
+			CheckpointSignedNote: note,
+		}
+		if err := database.SaveTreeState(imp.db, latest); err != nil {
+			return err
+		}
+		if err := imp.store.Put(latest.CheckpointStorageKey, []byte(note)); err != nil {
+			return fmt.Errorf("failed to store checkpoint: %w", err)
+		}
+	}
+
+	if err := imp.store.Put(merkle.CheckpointPath, []byte(latest.CheckpointSignedNote)); err != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	return nil
+}
+
+// discard removes what a rejected import wrote to storage
+func (imp *archiveImport) discard() {
+	for _, key := range imp.written {
+		imp.store.Delete(key)
+	}
+}
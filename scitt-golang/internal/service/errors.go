@@ -0,0 +1,108 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorKind classifies service errors so transports can map them to responses
+type ErrorKind string
+
+const (
+	// ErrorKindNotFound indicates the requested entry or resource does not exist
+	ErrorKindNotFound ErrorKind = "not-found"
+
+	// ErrorKindInvalidStatement indicates a malformed signed statement
+	ErrorKindInvalidStatement ErrorKind = "invalid-statement"
+
+	// ErrorKindPolicyViolation indicates a well-formed statement rejected by registration policy
+	ErrorKindPolicyViolation ErrorKind = "policy-violation"
+
+	// ErrorKindUnauthorized indicates missing or invalid client credentials
+	ErrorKindUnauthorized ErrorKind = "unauthorized"
+
+	// ErrorKindForbidden indicates valid credentials lacking the required scope
+	ErrorKindForbidden ErrorKind = "forbidden"
+
+	// ErrorKindInternal indicates a failure inside the service
+	ErrorKindInternal ErrorKind = "internal"
+)
+
+// Error is a classified service error
+// Detail is safe to return to clients; Err holds the internal cause for logging
+type Error struct {
+	Kind   ErrorKind
+	Detail string
+	Err    error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Detail, e.Err)
+	}
+	return e.Detail
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Title returns a short human-readable summary of the error kind
+func (k ErrorKind) Title() string {
+	switch k {
+	case ErrorKindNotFound:
+		return "Not Found"
+	case ErrorKindInvalidStatement:
+		return "Invalid Signed Statement"
+	case ErrorKindPolicyViolation:
+		return "Registration Policy Violation"
+	case ErrorKindUnauthorized:
+		return "Unauthorized"
+	case ErrorKindForbidden:
+		return "Forbidden"
+	default:
+		return "Internal Error"
+	}
+}
+
+// NewNotFoundError creates a not found error
+func NewNotFoundError(detail string, err error) *Error {
+	return &Error{Kind: ErrorKindNotFound, Detail: detail, Err: err}
+}
+
+// NewInvalidStatementError creates an invalid statement error
+func NewInvalidStatementError(detail string, err error) *Error {
+	return &Error{Kind: ErrorKindInvalidStatement, Detail: detail, Err: err}
+}
+
+// NewPolicyViolationError creates a registration policy violation error
+func NewPolicyViolationError(detail string, err error) *Error {
+	return &Error{Kind: ErrorKindPolicyViolation, Detail: detail, Err: err}
+}
+
+// NewUnauthorizedError creates an unauthorized error
+func NewUnauthorizedError(detail string, err error) *Error {
+	return &Error{Kind: ErrorKindUnauthorized, Detail: detail, Err: err}
+}
+
+// NewForbiddenError creates a forbidden error
+func NewForbiddenError(detail string, err error) *Error {
+	return &Error{Kind: ErrorKindForbidden, Detail: detail, Err: err}
+}
+
+// NewInternalError creates an internal error
+func NewInternalError(detail string, err error) *Error {
+	return &Error{Kind: ErrorKindInternal, Detail: detail, Err: err}
+}
+
+// AsError returns the classified service error in err's chain
+// Unclassified errors are reported as internal errors with a generic detail
+func AsError(err error) *Error {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr
+	}
+	return NewInternalError("internal server error", err)
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+)
+
+// Health statuses reported for the service and its components
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusError    = "error"
+)
+
+// healthProbeKey is the storage key written and removed by the storage check
+const healthProbeKey = ".health/probe"
+
+// ComponentHealth is the result of checking one dependency
+type ComponentHealth struct {
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// HealthReport is the readiness of the service and each of its components
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// Ready reports whether every component is healthy
+func (r *HealthReport) Ready() bool {
+	return r.Status == HealthStatusOK
+}
+
+// CheckReadiness verifies the database, storage, signing key (except on a
+// mirror), tree consistency and checkpoint so that a broken replica can be taken out of rotation
+func (s *TransparencyService) CheckReadiness(ctx context.Context) *HealthReport {
+	report := &HealthReport{
+		Status:     HealthStatusOK,
+		Components: make(map[string]ComponentHealth),
+	}
+
+	type namedCheck struct {
+		name  string
+		check func(context.Context) error
+	}
+	checks := []namedCheck{
+		{"database", s.checkDatabase},
+		{"storage", s.checkStorage},
+	}
+	// A mirror holds no signing key
+	if !s.IsMirror() {
+		checks = append(checks, namedCheck{"signing_key", s.checkSigningKey})
+	}
+	checks = append(checks,
+		namedCheck{"tree", s.onActiveLog((*TransparencyService).checkTree)},
+		namedCheck{"checkpoint", s.onActiveLog((*TransparencyService).checkCheckpoint)},
+	)
+
+	for _, c := range checks {
+		start := time.Now()
+		err := c.check(ctx)
+
+		component := ComponentHealth{
+			Status:    HealthStatusOK,
+			LatencyMS: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			component.Status = HealthStatusError
+			component.Detail = err.Error()
+			report.Status = HealthStatusDegraded
+		}
+		report.Components[c.name] = component
+	}
+
+	return report
+}
+
+// onActiveLog runs a log check against the active shard of a sharded service
+func (s *TransparencyService) onActiveLog(check func(*TransparencyService, context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		log, err := s.ActiveLog()
+		if err != nil {
+			return err
+		}
+		return check(log, ctx)
+	}
+}
+
+// checkDatabase verifies the database is reachable and the log state is readable
+func (s *TransparencyService) checkDatabase(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database unreachable: %w", err)
+	}
+	if _, err := database.GetCurrentTreeSize(s.db); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkStorage verifies tile storage accepts writes and returns what was written
+func (s *TransparencyService) checkStorage(ctx context.Context) error {
+	probe := make([]byte, 16)
+	if _, err := rand.Read(probe); err != nil {
+		return fmt.Errorf("failed to generate probe: %w", err)
+	}
+
+	if err := s.storage.Put(healthProbeKey, probe); err != nil {
+		return fmt.Errorf("storage write failed: %w", err)
+	}
+	defer s.storage.Delete(healthProbeKey)
+
+	data, err := s.storage.Get(healthProbeKey)
+	if err != nil {
+		return fmt.Errorf("storage read failed: %w", err)
+	}
+	if string(data) != string(probe) {
+		return fmt.Errorf("storage returned different data than was written")
+	}
+
+	return nil
+}
+
+// checkSigningKey verifies the receipt signing key produces signatures the
+// published verification key accepts
+func (s *TransparencyService) checkSigningKey(ctx context.Context) error {
+	signer, err := cose.NewES256Signer(s.privateKey)
+	if err != nil {
+		return err
+	}
+	verifier, err := cose.NewES256Verifier(s.publicKey)
+	if err != nil {
+		return err
+	}
+
+	probe := []byte("scitt readiness probe")
+	signature, err := signer.Sign(probe)
+	if err != nil {
+		return fmt.Errorf("signing failed: %w", err)
+	}
+
+	valid, err := verifier.Verify(probe, signature)
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+	if !valid {
+		return fmt.Errorf("signing key does not match the published verification key")
+	}
+
+	return nil
+}
+
+// checkTree verifies the hash tiles hold exactly current_tree_size leaves and
+// the last entry bundle, unless the log is hash-only, as many entries
+// A mirror's tiles may run ahead of its checkpoint while a sync is in progress
+func (s *TransparencyService) checkTree(ctx context.Context) error {
+	treeSize, err := s.treeSize()
+	if err != nil {
+		return err
+	}
+
+	// The tile after the one holding the last entry must not exist
+	var nextTileIndex int64
+	if treeSize > 0 {
+		nextTileIndex = merkle.EntryIDToTileIndex(treeSize-1) + 1
+	}
+	nextTile := merkle.HashTileIndexToPath(nextTileIndex, nil)
+	extra, err := s.storage.Get(nextTile)
+	if err != nil {
+		return fmt.Errorf("failed to read hash tile: %w", err)
+	}
+	if len(extra) > 0 && !s.IsMirror() {
+		return fmt.Errorf("hash tile %s holds leaves beyond tree size %d", nextTile, treeSize)
+	}
+
+	if treeSize == 0 {
+		return nil
+	}
+
+	// The last tile and bundle must end exactly at the last entry
+	lastEntry := treeSize - 1
+	lastIndex := merkle.EntryIDToTileIndex(lastEntry)
+	expected := merkle.EntryIDToTileOffset(lastEntry) + 1
+
+	lastTile := merkle.HashTileIndexToPath(lastIndex, nil)
+	data, err := s.storage.Get(lastTile)
+	if err != nil {
+		return fmt.Errorf("failed to read hash tile: %w", err)
+	}
+	leaves := len(data) / merkle.HashSize
+	if s.IsMirror() && leaves > expected {
+		leaves = expected
+	}
+	if leaves != expected || len(data)%merkle.HashSize != 0 {
+		return fmt.Errorf("hash tile %s holds %d leaves, expected %d for tree size %d", lastTile, leaves, expected, treeSize)
+	}
+
+	if s.tree != nil && s.tree.HashOnly() {
+		return nil
+	}
+
+	lastBundle := merkle.EntryTileIndexToPath(lastIndex, nil)
+	data, err = s.storage.Get(lastBundle)
+	if err != nil {
+		return fmt.Errorf("failed to read entry bundle: %w", err)
+	}
+	entries, err := merkle.DecodeEntryBundle(data)
+	if err != nil {
+		return fmt.Errorf("entry bundle %s is malformed: %w", lastBundle, err)
+	}
+	count := len(entries)
+	if s.IsMirror() && count > expected {
+		count = expected
+	}
+	if count != expected {
+		return fmt.Errorf("entry bundle %s holds %d entries, expected %d for tree size %d", lastBundle, count, expected, treeSize)
+	}
+
+	return nil
+}
+
+// checkCheckpoint verifies a checkpoint can be signed for the current tree and,
+// when configured, that the tree head has advanced recently enough
+func (s *TransparencyService) checkCheckpoint(ctx context.Context) error {
+	encoded, err := s.GetCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := merkle.DecodeCheckpoint(encoded)
+	if err != nil {
+		return fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+
+	valid, err := merkle.VerifyCheckpoint(checkpoint, s.publicKey)
+	if err != nil {
+		return fmt.Errorf("failed to verify checkpoint: %w", err)
+	}
+	if !valid {
+		return fmt.Errorf("checkpoint signature is invalid")
+	}
+
+	if maxAge := s.config.Server.Health.MaxCheckpointAge; maxAge > 0 {
+		if age := time.Duration(s.checkpointAge() * float64(time.Second)); age > maxAge {
+			return fmt.Errorf("checkpoint is %s old (max %s)", age.Round(time.Second), maxAge)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/metrics"
+)
+
+// Registration outcomes used as metric labels
+const (
+	registrationAccepted  = "accepted"
+	registrationDuplicate = "duplicate"
+	registrationRejected  = "rejected"
+)
+
+var (
+	registrationsTotal = metrics.NewCounterVec(
+		"scitt_registrations_total",
+		"Statement registrations by outcome (accepted, duplicate, rejected) and rejection reason.",
+		"outcome", "reason", "log",
+	)
+	treeSizeGauge = metrics.NewGaugeVec(
+		"scitt_tree_size",
+		"Number of entries in the transparency log.",
+		"log", "shard",
+	)
+	checkpointAgeGauge = metrics.NewGaugeFuncVec(
+		"scitt_checkpoint_age_seconds",
+		"Seconds since the signed tree head last advanced.",
+		"log", "shard",
+	)
+	integrationBatchSize = metrics.NewHistogramVec(
+		"scitt_integration_batch_size",
+		"Number of entries integrated into the tree per batch.",
+		[]float64{1, 2, 5, 10, 25, 50, 100, 250},
+		"log",
+	)
+	proofDuration = metrics.NewHistogramVec(
+		"scitt_proof_generation_duration_seconds",
+		"Latency of Merkle proof and root computation by kind.",
+		metrics.DefaultBuckets,
+		"proof", "log",
+	)
+	witnessCosignaturesTotal = metrics.NewCounterVec(
+		"scitt_witness_cosignatures_total",
+		"Checkpoint submissions to witnesses by witness and outcome (cosigned, failed).",
+		"witness", "outcome", "log",
+	)
+)
+
+// The log label is only set when several logs are hosted in one process, and
+// the shard label on the tree gauges only for the logs of a sharded service
+func init() {
+	registrationsTotal.OmitEmpty("log")
+	treeSizeGauge.OmitEmpty("log", "shard")
+	checkpointAgeGauge.OmitEmpty("log", "shard")
+	integrationBatchSize.OmitEmpty("log")
+	proofDuration.OmitEmpty("log")
+	witnessCosignaturesTotal.OmitEmpty("log")
+}
+
+// RecordRejectedRegistration counts a registration rejected before it reached the
+// service, such as one failing authentication or exceeding the size limit
+func (s *TransparencyService) RecordRejectedRegistration(reason string) {
+	registrationsTotal.WithLabelValues(registrationRejected, reason, s.config.Name).Inc()
+}
+
+// recordRegistration counts the outcome of a RegisterStatement call
+func (s *TransparencyService) recordRegistration(resp *RegisterStatementResponse, err error) {
+	switch {
+	case err != nil:
+		s.RecordRejectedRegistration(string(AsError(err).Kind))
+	case resp.AlreadyRegistered:
+		registrationsTotal.WithLabelValues(registrationDuplicate, "", s.config.Name).Inc()
+	default:
+		registrationsTotal.WithLabelValues(registrationAccepted, "", s.config.Name).Inc()
+	}
+}
+
+// recordTreeGrowth updates tree metrics after entries were integrated
+func (s *TransparencyService) recordTreeGrowth(newSize, integrated int64) {
+	s.treeUpdatedAt.Store(time.Now().UnixNano())
+	treeSizeGauge.WithLabelValues(s.config.Name, s.shardName).Set(float64(newSize))
+	integrationBatchSize.WithLabelValues(s.config.Name).Observe(float64(integrated))
+}
+
+// observeProof records the latency of a proof or root computation started at start
+func (s *TransparencyService) observeProof(proof string, start time.Time) {
+	proofDuration.WithLabelValues(proof, s.config.Name).Observe(time.Since(start).Seconds())
+}
+
+// checkpointAge returns the seconds since the tree last advanced
+func (s *TransparencyService) checkpointAge() float64 {
+	return time.Since(time.Unix(0, s.treeUpdatedAt.Load())).Seconds()
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+)
+
+// OpenStorage opens the tile storage backend described by a service definition
+func OpenStorage(cfg config.StorageConfig) (storage.Storage, error) {
+	switch cfg.Type {
+	case "local":
+		store, err := storage.NewLocalStorage(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize local storage: %w", err)
+		}
+		return store, nil
+	case "memory":
+		return storage.NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+	}
+}
+
+// IsMirror reports whether the service is a read-only mirror of another log
+func (s *TransparencyService) IsMirror() bool {
+	return s.config.Mirror != nil
+}
+
+// treeSize returns the size of the tree the service publishes: current_tree_size
+// for a log, or the size of the replicated checkpoint for a mirror
+func (s *TransparencyService) treeSize() (int64, error) {
+	if !s.IsMirror() {
+		treeSize, err := database.GetCurrentTreeSize(s.db)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get tree size: %w", err)
+		}
+		return treeSize, nil
+	}
+
+	note, err := s.mirroredCheckpoint()
+	if err != nil || note == "" {
+		return 0, err
+	}
+	checkpoint, err := merkle.DecodeCheckpoint(note)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode mirrored checkpoint: %w", err)
+	}
+	return checkpoint.TreeSize, nil
+}
+
+// mirroredCheckpoint returns the checkpoint replicated into storage by "scitt mirror"
+// (empty before the first sync)
+func (s *TransparencyService) mirroredCheckpoint() (string, error) {
+	data, err := s.storage.Get(merkle.CheckpointPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read mirrored checkpoint: %w", err)
+	}
+	return string(data), nil
+}
+
+// mirroredReceipt returns the receipt the mirrored log issued for an entry,
+// replicated into storage by "scitt mirror"; a mirror cannot issue receipts
+// for another tree size or refresh them
+func (s *TransparencyService) mirroredReceipt(entryID int64, opts ReceiptOptions) ([]byte, error) {
+	if opts.TreeSize > 0 || opts.Refresh {
+		return nil, NewNotFoundError("a mirror serves only the receipts issued by the mirrored log", nil)
+	}
+
+	treeSize, err := s.treeSize()
+	if err != nil {
+		return nil, err
+	}
+	if entryID < 0 || entryID >= treeSize {
+		return nil, NewNotFoundError(fmt.Sprintf("entry %d not found in tree of size %d", entryID, treeSize), nil)
+	}
+
+	receipt, err := s.storage.Get(merkle.ReceiptPath(entryID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+	if receipt == nil {
+		return nil, NewNotFoundError(fmt.Sprintf("no receipt for entry %d has been replicated", entryID), nil)
+	}
+	return receipt, nil
+}
+
+// GetTile returns a level 0 hash tile (tile/0/<N>[.p/<W>]) or an entry bundle
+// (tile/entries/<N>[.p/<W>]) of the published tree
+// Full tiles cover 256 entries; a partial tile of width W covers the first W
+func (s *TransparencyService) GetTile(ctx context.Context, path string) ([]byte, error) {
+	var index int64
+	var partial bool
+	var width int
+	bundle := strings.HasPrefix(path, "tile/entries/")
+	if bundle {
+		tile, err := merkle.ParseEntryTilePath(path)
+		if err != nil {
+			return nil, NewNotFoundError(fmt.Sprintf("tile %s not found", path), err)
+		}
+		index, partial, width = tile.Index, tile.IsPartial, tile.Width
+	} else {
+		tile, err := merkle.ParseTilePath(path)
+		if err != nil || tile.Level != 0 {
+			return nil, NewNotFoundError(fmt.Sprintf("tile %s not found", path), err)
+		}
+		index, partial, width = tile.Index, tile.IsPartial, tile.Width
+	}
+
+	if !partial {
+		width = merkle.TileSize
+	} else if width < 1 || width >= merkle.TileSize {
+		return nil, NewNotFoundError(fmt.Sprintf("tile %s not found", path), nil)
+	}
+
+	// Only entries covered by the published tree are served
+	treeSize, err := s.treeSize()
+	if err != nil {
+		return nil, err
+	}
+	if merkle.TileCoordinatesToEntryID(index, width) > treeSize {
+		return nil, NewNotFoundError(fmt.Sprintf("tile %s is beyond tree size %d", path, treeSize), nil)
+	}
+
+	if bundle {
+		return s.entryBundle(path, index, width)
+	}
+
+	data, err := s.storage.Get(merkle.HashTileIndexToPath(index, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hash tile: %w", err)
+	}
+	if len(data) < width*merkle.HashSize {
+		return nil, NewNotFoundError(fmt.Sprintf("tile %s not found", path), nil)
+	}
+
+	return data[:width*merkle.HashSize], nil
+}
+
+// entryBundle returns the first width entries of a stored entry bundle
+func (s *TransparencyService) entryBundle(path string, index int64, width int) ([]byte, error) {
+	data, err := s.storage.Get(merkle.EntryTileIndexToPath(index, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entry bundle: %w", err)
+	}
+	entries, err := merkle.DecodeEntryBundle(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode entry bundle: %w", err)
+	}
+	if len(entries) < width {
+		return nil, NewNotFoundError(fmt.Sprintf("tile %s not found", path), nil)
+	}
+
+	return merkle.EncodeEntryBundle(entries[:width])
+}
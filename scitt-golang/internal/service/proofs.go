@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+)
+
+// GetPublishedCheckpoint returns the checkpoint recorded in tree_state for the
+// current tree size, signing and recording one if none has been published yet
+// Every client polling the same tree size sees the same checkpoint, including
+// any witness cosignatures collected for it
+func (s *TransparencyService) GetPublishedCheckpoint(ctx context.Context) (string, error) {
+	if s.IsMirror() {
+		note, err := s.mirroredCheckpoint()
+		if err == nil && note == "" {
+			return "", NewNotFoundError("mirror has not replicated a checkpoint yet", nil)
+		}
+		return note, err
+	}
+
+	treeSize, err := database.GetCurrentTreeSize(s.db)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tree size: %w", err)
+	}
+
+	if state, err := database.GetTreeState(s.db, treeSize); err != nil {
+		return "", err
+	} else if state != nil {
+		return state.CheckpointSignedNote, nil
+	}
+
+	note, err := s.GetCheckpoint()
+	if err != nil {
+		return "", err
+	}
+	checkpoint, err := merkle.DecodeCheckpoint(note)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+
+	state := database.TreeState{
+		TreeSize:             checkpoint.TreeSize,
+		RootHash:             hex.EncodeToString(checkpoint.RootHash[:]),
+		CheckpointStorageKey: merkle.CheckpointHistoryPath(checkpoint.TreeSize),
+		CheckpointSignedNote: note,
+	}
+	if err := database.RecordTreeState(s.db, state); err != nil {
+		// Another request or the cosigning worker published this size first
+		existing, getErr := database.GetTreeState(s.db, checkpoint.TreeSize)
+		if getErr != nil || existing == nil {
+			return "", err
+		}
+		return existing.CheckpointSignedNote, nil
+	}
+	if err := s.storeCheckpoint(state); err != nil {
+		return "", err
+	}
+
+	return note, nil
+}
+
+// GetConsistencyProof proves that the tree at oldSize is a prefix of the tree at newSize
+func (s *TransparencyService) GetConsistencyProof(ctx context.Context, oldSize, newSize int64) (*merkle.ConsistencyProof, error) {
+	treeSize, err := s.treeSize()
+	if err != nil {
+		return nil, err
+	}
+	if newSize > treeSize {
+		return nil, NewNotFoundError(fmt.Sprintf("tree size %d exceeds current tree size %d", newSize, treeSize), nil)
+	}
+
+	start := time.Now()
+	proof, err := merkle.GenerateConsistencyProof(s.storage, oldSize, newSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate consistency proof: %w", err)
+	}
+	s.observeProof("consistency", start)
+
+	return proof, nil
+}
+
+// GetInclusionProof proves that the statement with the given hash (its leaf)
+// is included in the tree of the given size
+func (s *TransparencyService) GetInclusionProof(ctx context.Context, leaf []byte, treeSize int64) (*merkle.InclusionProof, error) {
+	current, err := s.treeSize()
+	if err != nil {
+		return nil, err
+	}
+	if treeSize > current {
+		return nil, NewNotFoundError(fmt.Sprintf("tree size %d exceeds current tree size %d", treeSize, current), nil)
+	}
+
+	stmt, err := database.GetStatementByHash(s.db, hex.EncodeToString(leaf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up statement: %w", err)
+	}
+	if stmt == nil {
+		return nil, NewNotFoundError(fmt.Sprintf("no entry has leaf %x", leaf), nil)
+	}
+	entryID, err := entryIDFromStatement(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if entryID >= treeSize {
+		return nil, NewNotFoundError(fmt.Sprintf("entry %d not found in tree of size %d", entryID, treeSize), nil)
+	}
+
+	start := time.Now()
+	proof, err := merkle.GenerateInclusionProof(s.storage, entryID, treeSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate inclusion proof: %w", err)
+	}
+	s.observeProof("inclusion", start)
+
+	return proof, nil
+}
+
+// storeCheckpoint writes a recorded checkpoint to tile storage under its own
+// key and as the latest checkpoint
+func (s *TransparencyService) storeCheckpoint(state database.TreeState) error {
+	if err := s.storage.Put(state.CheckpointStorageKey, []byte(state.CheckpointSignedNote)); err != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	if err := s.storage.Put(merkle.CheckpointPath, []byte(state.CheckpointSignedNote)); err != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	return nil
+}
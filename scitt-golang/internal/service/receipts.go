@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/logging"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+)
+
+// storedReceipt returns the receipt stored for an entry, or nil when there is
+// none or it can no longer be served and has to be reissued
+func (s *TransparencyService) storedReceipt(ctx context.Context, entryID int64) ([]byte, error) {
+	record, err := database.GetReceiptByLeafIndex(s.db, entryID)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	// A receipt beyond the current tree was issued by a log since replaced
+	treeSize, err := database.GetCurrentTreeSize(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree size: %w", err)
+	}
+	if record.TreeSize > treeSize {
+		logging.FromContext(ctx).Warn("stored receipt is beyond the tree, reissuing",
+			"entry_id", entryID, "receipt_tree_size", record.TreeSize, "tree_size", treeSize)
+		return nil, nil
+	}
+
+	data, err := s.storage.Get(record.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+	hash := sha256.Sum256(data)
+	if data == nil || hex.EncodeToString(hash[:]) != record.ReceiptHash {
+		logging.FromContext(ctx).Warn("stored receipt is missing or modified, reissuing",
+			"entry_id", entryID, "storage_key", record.StorageKey)
+		return nil, nil
+	}
+
+	return data, nil
+}
+
+// entryStatement returns the metadata of the statement registered at an entry,
+// or nil when it is not indexed (e.g. a mirror or a log not yet reindexed)
+func (s *TransparencyService) entryStatement(entryID int64) (*database.Statement, error) {
+	tileKey := merkle.EntryTileIndexToPath(merkle.EntryIDToTileIndex(entryID), nil)
+	return database.GetStatementByEntryTile(s.db, tileKey, merkle.EntryIDToTileOffset(entryID))
+}
+
+// receiptClaims builds the CWT claims of an entry's receipt: the configured
+// extra claims, iss, sub (the statement's subject, or the entry ID), iat (the
+// registration time, when the statement is indexed and the time is known) and
+// optionally a random cti
+func (s *TransparencyService) receiptClaims(entryID int64, stmt *database.Statement) (cose.CWTClaimsSet, error) {
+	claims := make(cose.CWTClaimsSet, len(s.config.Receipts.Claims)+4)
+	for label, value := range s.config.Receipts.Claims {
+		claims[label] = value
+	}
+
+	claims[cose.CWTClaimIss] = s.config.Issuer
+	claims[cose.CWTClaimSub] = strconv.FormatInt(entryID, 10)
+	if stmt != nil {
+		if stmt.Sub != nil && *stmt.Sub != "" {
+			claims[cose.CWTClaimSub] = *stmt.Sub
+		}
+		if stmt.RegisteredAt != "" {
+			registeredAt, err := time.Parse(time.RFC3339, stmt.RegisteredAt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid registration time of entry %d: %w", entryID, err)
+			}
+			claims[cose.CWTClaimIat] = registeredAt.Unix()
+		}
+	}
+
+	if s.config.Receipts.CTI {
+		cti := make([]byte, 16)
+		if _, err := rand.Read(cti); err != nil {
+			return nil, fmt.Errorf("failed to generate receipt cti: %w", err)
+		}
+		claims[cose.CWTClaimCti] = cti
+	}
+
+	return claims, nil
+}
+
+// storeReceipt keeps the receipt issued for an entry in storage and records it
+// in the receipts table, replacing the entry's previous receipt
+func (s *TransparencyService) storeReceipt(stmt *database.Statement, entryID, treeSize int64, receipt []byte) error {
+	s.receiptMu.Lock()
+	defer s.receiptMu.Unlock()
+
+	key := merkle.ReceiptPath(entryID)
+	if err := s.storage.Put(key, receipt); err != nil {
+		return fmt.Errorf("failed to store receipt: %w", err)
+	}
+
+	hash := sha256.Sum256(receipt)
+	return database.SaveReceipt(s.db, database.Receipt{
+		EntryID:     stmt.EntryID,
+		ReceiptHash: hex.EncodeToString(hash[:]),
+		StorageKey:  key,
+		TreeSize:    treeSize,
+		LeafIndex:   entryID,
+	})
+}
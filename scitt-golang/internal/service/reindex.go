@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+)
+
+// ReindexResult describes a rebuilt metadata database
+type ReindexResult struct {
+	TreeSize   int64
+	Statements int
+}
+
+// Reindex rebuilds the statements table and current tree size from storage
+// Hash tiles are walked in order and each leaf's statement is loaded from its
+// content-addressed key, checked against the leaf hash and re-parsed; nothing is
+// written unless every leaf is recovered. Registration times are kept from the
+// existing rows or recovered from the stored receipts, so receipts keep their
+// iat. The service must not be running.
+func Reindex(db *sql.DB, store storage.Storage) (*ReindexResult, error) {
+	return reindex(db, store, nil)
+}
+
+// reindex rebuilds the statements table, taking the registration time of an
+// entry from registeredAt (RFC 3339, by entry ID) when it is set there
+func reindex(db *sql.DB, store storage.Storage, registeredAt []string) (*ReindexResult, error) {
+	var statements []database.Statement
+
+	for index := int64(0); ; index++ {
+		key := merkle.HashTileIndexToPath(index, nil)
+		tile, err := store.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hash tile %s: %w", key, err)
+		}
+		if tile == nil {
+			break
+		}
+		if len(tile)%merkle.HashSize != 0 || len(tile) > merkle.FullTileBytes {
+			return nil, fmt.Errorf("hash tile %s has an invalid length of %d bytes", key, len(tile))
+		}
+
+		for offset := 0; offset*merkle.HashSize < len(tile); offset++ {
+			leaf := tile[offset*merkle.HashSize : (offset+1)*merkle.HashSize]
+			entryID := merkle.TileCoordinatesToEntryID(index, offset)
+
+			stmt, err := reindexStatement(store, leaf)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %w", entryID, err)
+			}
+			stmt.TreeSizeAtRegistration = entryID
+			stmt.EntryTileKey = merkle.EntryTileIndexToPath(index, nil)
+			stmt.EntryTileOffset = offset
+			if entryID < int64(len(registeredAt)) && registeredAt[entryID] != "" {
+				stmt.RegisteredAt = registeredAt[entryID]
+			} else if stmt.RegisteredAt, err = registrationTime(db, store, entryID, stmt); err != nil {
+				return nil, fmt.Errorf("entry %d: %w", entryID, err)
+			}
+			statements = append(statements, *stmt)
+		}
+
+		if len(tile) < merkle.FullTileBytes {
+			break
+		}
+	}
+	treeSize := int64(len(statements))
+
+	// The tiles must still hold every leaf the log has published
+	if note, err := store.Get(merkle.CheckpointPath); err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	} else if note != nil {
+		checkpoint, err := merkle.DecodeCheckpoint(string(note))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+		}
+		if checkpoint.TreeSize > treeSize {
+			return nil, fmt.Errorf("hash tiles hold %d leaves but the latest checkpoint is for tree size %d", treeSize, checkpoint.TreeSize)
+		}
+	}
+
+	if err := database.ReplaceStatements(db, statements, treeSize); err != nil {
+		return nil, err
+	}
+
+	return &ReindexResult{TreeSize: treeSize, Statements: len(statements)}, nil
+}
+
+// reindexStatement loads the statement stored for a leaf and extracts its metadata
+func reindexStatement(store storage.Storage, leaf []byte) (*database.Statement, error) {
+	key := merkle.StatementPath(leaf)
+	data, err := store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement %s: %w", key, err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("statement %s is not in storage", key)
+	}
+
+	hash := sha256.Sum256(data)
+	if !bytes.Equal(hash[:], leaf) {
+		return nil, fmt.Errorf("statement %s does not hash to its leaf", key)
+	}
+
+	coseSign1, err := cose.DecodeCoseSign1(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode statement %s: %w", key, err)
+	}
+	headers, err := cose.GetProtectedHeaders(coseSign1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode headers of statement %s: %w", key, err)
+	}
+	stmt, err := statementMetadata(coseSign1, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract metadata of statement %s: %w", key, err)
+	}
+	stmt.StatementHash = hex.EncodeToString(hash[:])
+
+	return stmt, nil
+}
+
+// registrationTime recovers when an entry was registered, from its current
+// statements row or else from the iat of its stored receipt
+// Returns "" when neither records it
+func registrationTime(db *sql.DB, store storage.Storage, entryID int64, stmt *database.Statement) (string, error) {
+	row, err := database.GetStatementByEntryTile(db, stmt.EntryTileKey, stmt.EntryTileOffset)
+	if err != nil {
+		return "", err
+	}
+	if row != nil && row.StatementHash == stmt.StatementHash && row.RegisteredAt != "" {
+		return row.RegisteredAt, nil
+	}
+
+	data, err := store.Get(merkle.ReceiptPath(entryID))
+	if err != nil {
+		return "", fmt.Errorf("failed to read receipt: %w", err)
+	}
+	if data == nil {
+		return "", nil
+	}
+
+	// A receipt that cannot be read or is for another leaf records nothing
+	receipt, err := cose.DecodeCoseSign1(data)
+	if err != nil {
+		return "", nil
+	}
+	proof, err := merkle.ReceiptInclusionProof(receipt)
+	if err != nil || proof.LeafIndex != entryID {
+		return "", nil
+	}
+	claims, err := merkle.GetReceiptClaims(receipt)
+	if err != nil || claims.Iat == nil {
+		return "", nil
+	}
+	return claims.Iat.UTC().Format(time.RFC3339), nil
+}
@@ -1,15 +1,23 @@
 package service
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/fxamacker/cbor/v2"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/auth"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/logging"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
@@ -24,6 +32,35 @@ type TransparencyService struct {
 	privateKey                  *ecdsa.PrivateKey
 	publicKey                   *ecdsa.PublicKey
 	receiptSigningKeyIdentifier []byte // kid parsed from key file
+
+	// mu serializes registrations so that duplicate detection, tile appends
+	// and tree size updates are applied atomically
+	mu sync.Mutex
+
+	// tree is the log's Merkle tree: the hash tiles, entry bundles and compact range
+	// persisted beside them (nil for a mirror and the root of a sharded service)
+	// treeMu guards it together with current_tree_size so that readers see the
+	// root of the size they read
+	tree   *merkle.TileLog
+	treeMu sync.RWMutex
+
+	// treeUpdatedAt is when the tree last advanced (unix nanoseconds), for checkpoint age
+	treeUpdatedAt atomic.Int64
+
+	// witnesses cosign new checkpoints (nil when witnessing is not configured)
+	witnesses *witnessing
+
+	// shards holds the logs of a sharded service (nil unless sharding is configured)
+	shards *sharding
+
+	// shardName and frozen are set on the log of a single shard; a frozen shard
+	// rejects registrations (guarded by mu)
+	shardName string
+	frozen    bool
+
+	// receiptMu serializes writes of stored receipts so each receipts row
+	// matches the object in storage
+	receiptMu sync.Mutex
 }
 
 // NewTransparencyService creates a new transparency service instance
@@ -38,23 +75,19 @@ func NewTransparencyService(cfg *config.Config) (*TransparencyService, error) {
 	}
 
 	// Initialize storage
-	var store storage.Storage
-	switch cfg.Storage.Type {
-	case "local":
-		store, err = storage.NewLocalStorage(cfg.Storage.Path)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize local storage: %w", err)
-		}
-	case "memory":
-		store = storage.NewMemoryStorage()
-	default:
-		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Storage.Type)
+	store, err := OpenStorage(cfg.Storage)
+	if err != nil {
+		return nil, err
 	}
+	store = storage.Instrument(store, cfg.Storage.Type)
 
-	// Load private key
-	privateKey, err := loadPrivateKey(cfg.Keys.Private)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load private key: %w", err)
+	// Load private key; a mirror serves the receipts the mirrored log issued and never signs
+	var privateKey *ecdsa.PrivateKey
+	if cfg.Mirror == nil {
+		privateKey, err = loadPrivateKey(cfg.Keys.Private)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key: %w", err)
+		}
 	}
 
 	// Load public key
@@ -74,18 +107,160 @@ func NewTransparencyService(cfg *config.Config) (*TransparencyService, error) {
 		return nil, fmt.Errorf("failed to extract kid from public key: %w", err)
 	}
 
-	return &TransparencyService{
+	svc := &TransparencyService{
 		config:                      cfg,
 		db:                          db,
 		storage:                     store,
 		privateKey:                  privateKey,
 		publicKey:                   publicKey,
 		receiptSigningKeyIdentifier: receiptSigningKeyIdentifier,
-	}, nil
+	}
+
+	// A sharded service keeps API keys and the shard registry; each shard is a log
+	if cfg.Sharding != nil {
+		if err := svc.openShards(time.Now()); err != nil {
+			svc.Close()
+			return nil, err
+		}
+		return svc, nil
+	}
+
+	if err := svc.startLog(); err != nil {
+		svc.Close()
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+// startLog initializes tree metrics from the persisted log state and starts
+// submitting checkpoints to witnesses when configured
+func (s *TransparencyService) startLog() error {
+	treeSize, err := s.treeSize()
+	if err != nil {
+		return err
+	}
+	treeUpdatedAt, err := database.GetCurrentTreeSizeUpdatedAt(s.db)
+	if err != nil {
+		return err
+	}
+	if treeUpdatedAt.IsZero() {
+		treeUpdatedAt = time.Now()
+	}
+	s.treeUpdatedAt.Store(treeUpdatedAt.UnixNano())
+	if !s.IsMirror() {
+		if err := s.openTree(treeSize); err != nil {
+			return err
+		}
+	}
+	treeSizeGauge.WithLabelValues(s.config.Name, s.shardName).Set(float64(treeSize))
+	checkpointAgeGauge.SetFunc(s.checkpointAge, s.config.Name, s.shardName)
+
+	if s.config.Witnessing != nil && len(s.config.Witnessing.Witnesses) > 0 {
+		s.witnesses, err = newWitnessing(s.config.Witnessing)
+		if err != nil {
+			return err
+		}
+		s.startWitnessing()
+	}
+
+	return nil
+}
+
+// openTree loads the compact range of the log and verifies that it, the entry
+// tiles and current_tree_size agree; the compact range of a log written before
+// it was persisted is rebuilt from the tiles
+// Entry bundles are written once every statement of the log is in storage; a
+// log upgraded from before statements were kept stays hash-only until then
+func (s *TransparencyService) openTree(treeSize int64) error {
+	tree := merkle.NewTileLog(s.storage)
+	if err := tree.Load(); err != nil {
+		return err
+	}
+
+	upgraded, err := s.upgradeTiles(treeSize)
+	if err != nil {
+		return err
+	}
+
+	if tree.Size() == 0 && treeSize > 0 {
+		if err := tree.Rebuild(treeSize); err != nil {
+			return fmt.Errorf("failed to rebuild tree state: %w", err)
+		}
+		slog.Info("tree state rebuilt from hash tiles", "tree_size", treeSize)
+	} else if tree.Size() != treeSize {
+		return fmt.Errorf("tree state holds %d leaves but current_tree_size is %d; run 'scitt service fsck --repair'", tree.Size(), treeSize)
+	} else if err := tree.Verify(); err != nil {
+		return fmt.Errorf("%w; run 'scitt service fsck --repair'", err)
+	}
+
+	if upgraded || tree.HashOnly() {
+		missing, err := tree.MissingStatement()
+		if err != nil {
+			return fmt.Errorf("failed to check statements: %w", err)
+		}
+		if missing < 0 {
+			if err := tree.RebuildBundles(); err != nil {
+				return fmt.Errorf("failed to write entry bundles: %w", err)
+			}
+			slog.Info("entry bundles written from stored statements", "tree_size", treeSize)
+		} else if upgraded {
+			// The legacy entry tiles hold leaf hashes, not entries
+			if err := tree.DropBundles(); err != nil {
+				return fmt.Errorf("failed to remove legacy entry tiles: %w", err)
+			}
+			slog.Warn("statement of an entry is not in storage; entry bundles are not written", "entry_id", missing, "tree_size", treeSize)
+		}
+	}
+
+	s.tree = tree
+	if err := s.checkTree(context.Background()); err != nil {
+		return fmt.Errorf("%w; run 'scitt service fsck --repair'", err)
+	}
+	return nil
+}
+
+// upgradeTiles moves the leaf hashes of a log written before entry bundles from
+// tile/entries to the hash tiles, reporting whether it did
+// Every entry tile is checked before any hash tile is written
+func (s *TransparencyService) upgradeTiles(treeSize int64) (bool, error) {
+	if treeSize == 0 {
+		return false, nil
+	}
+	if tile, err := s.storage.Get(merkle.HashTileIndexToPath(0, nil)); err != nil || tile != nil {
+		return false, err
+	}
+
+	for _, write := range []bool{false, true} {
+		for index := int64(0); merkle.TileCoordinatesToEntryID(index, 0) < treeSize; index++ {
+			key := merkle.EntryTileIndexToPath(index, nil)
+			data, err := s.storage.Get(key)
+			if err != nil {
+				return false, fmt.Errorf("failed to read entry tile %s: %w", key, err)
+			}
+			if !write {
+				width := treeSize - merkle.TileCoordinatesToEntryID(index, 0)
+				if width > merkle.TileSize {
+					width = merkle.TileSize
+				}
+				if int64(len(data)) < width*merkle.HashSize || len(data)%merkle.HashSize != 0 || len(data) > merkle.FullTileBytes {
+					return false, fmt.Errorf("entry tile %s is not a tile of leaf hashes; run 'scitt service fsck --repair'", key)
+				}
+				continue
+			}
+			if err := s.storage.Put(merkle.HashTileIndexToPath(index, nil), data); err != nil {
+				return false, fmt.Errorf("failed to write hash tile: %w", err)
+			}
+		}
+	}
+
+	return true, nil
 }
 
 // Close closes the service and all resources
 func (s *TransparencyService) Close() error {
+	s.closeShards()
+	s.stopWitnessing()
 	if s.db != nil {
 		return database.CloseDatabase(s.db)
 	}
@@ -94,22 +269,75 @@ func (s *TransparencyService) Close() error {
 
 // RegisterStatementRequest represents a statement registration request
 type RegisterStatementRequest struct {
-	Statement []byte // CBOR-encoded COSE Sign1
+	Statement []byte          // CBOR-encoded COSE Sign1
+	Principal *auth.Principal // Authenticated client (nil for internal callers)
 }
 
 // RegisterStatementResponse represents a statement registration response
 type RegisterStatementResponse struct {
-	EntryID       int64  // Entry ID in the log
-	StatementHash string // Hex-encoded statement hash
-	Receipt       []byte // CBOR-encoded COSE receipt
+	EntryID           int64  // Entry ID in the log
+	StatementHash     string // Hex-encoded statement hash
+	Receipt           []byte // CBOR-encoded COSE receipt
+	AlreadyRegistered bool   // True if an identical statement was already in the log
+	Shard             string // Shard the entry was registered in (empty unless sharded)
 }
 
 // RegisterStatement registers a new statement in the transparency log
-func (s *TransparencyService) RegisterStatement(req *RegisterStatementRequest) (*RegisterStatementResponse, error) {
+// The outcome is logged with the statement hash, issuer and entry ID using the logger from ctx
+func (s *TransparencyService) RegisterStatement(ctx context.Context, req *RegisterStatementRequest) (*RegisterStatementResponse, error) {
+	statementHash := sha256.Sum256(req.Statement)
+	trace := &registrationLog{
+		Logger: logging.FromContext(ctx).With("statement_hash", hex.EncodeToString(statementHash[:])),
+	}
+	if req.Principal != nil {
+		trace.with("credential_id", req.Principal.CredentialID)
+	}
+
+	resp, err := s.registerStatement(logging.WithLogger(ctx, trace.Logger), req, trace)
+	s.recordRegistration(resp, err)
+
+	switch {
+	case err != nil:
+		serviceErr := AsError(err)
+		trace.Info("statement rejected", "reason", string(serviceErr.Kind), "detail", serviceErr.Detail)
+	case resp.AlreadyRegistered:
+		trace.Info("statement already registered", "entry_id", resp.EntryID)
+	default:
+		trace.Info("statement registered", "entry_id", resp.EntryID, "tree_size", resp.EntryID+1)
+		s.notifyWitnesses()
+	}
+
+	return resp, err
+}
+
+// registrationLog accumulates structured fields while a statement is registered
+type registrationLog struct {
+	*slog.Logger
+}
+
+// with adds fields to every later log line for the registration
+func (l *registrationLog) with(args ...any) {
+	l.Logger = l.Logger.With(args...)
+}
+
+// registerStatement validates and appends a statement to the log
+func (s *TransparencyService) registerStatement(ctx context.Context, req *RegisterStatementRequest, trace *registrationLog) (*RegisterStatementResponse, error) {
+	if s.shards != nil {
+		return s.registerInActiveShard(ctx, req, trace)
+	}
+	if s.IsMirror() {
+		return nil, NewForbiddenError(fmt.Sprintf("this service is a read-only mirror of %s", s.config.Mirror.Source), nil)
+	}
+
+	// Statements are kept whole in entry bundles, which bound their size
+	if len(req.Statement) > merkle.MaxEntrySize {
+		return nil, NewPolicyViolationError(fmt.Sprintf("statement of %d bytes exceeds the %d byte limit", len(req.Statement), merkle.MaxEntrySize), nil)
+	}
+
 	// Decode COSE Sign1
 	coseSign1, err := cose.DecodeCoseSign1(req.Statement)
 	if err != nil {
-		return nil, fmt.Errorf("invalid COSE Sign1 structure: %w", err)
+		return nil, NewInvalidStatementError("invalid COSE Sign1 structure", err)
 	}
 
 	// Verify signature (basic validation - in production would also verify issuer key)
@@ -122,82 +350,90 @@ func (s *TransparencyService) RegisterStatement(req *RegisterStatementRequest) (
 	// Get protected headers to extract metadata
 	headers, err := cose.GetProtectedHeaders(coseSign1)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get protected headers: %w", err)
+		return nil, NewInvalidStatementError("invalid protected headers", err)
 	}
 
-	// Extract issuer and subject from CWT claims if present
-	var issuer, subject string
-	if cwtClaims, ok := headers[cose.HeaderLabelCWTClaims].(map[interface{}]interface{}); ok {
-		if iss, ok := cwtClaims[cose.CWTClaimIss].(string); ok {
-			issuer = iss
-		}
-		if sub, ok := cwtClaims[cose.CWTClaimSub].(string); ok {
-			subject = sub
-		}
+	// Reject critical headers the service cannot process
+	if err := headers.CheckCritical(); err != nil {
+		return nil, NewInvalidStatementError("invalid protected headers", err)
 	}
 
-	// Get content type
-	var contentType string
-	if cty, ok := headers[cose.HeaderLabelContentType].(string); ok {
-		contentType = cty
-	}
-
-	// Get current tree size
-	treeSize, err := database.GetCurrentTreeSize(s.db)
+	// Extract the indexed metadata (issuer, subject, content type, artifact digest)
+	stmt, err := statementMetadata(coseSign1, headers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tree size: %w", err)
+		return nil, err
+	}
+	issuer := stmt.Iss
+	var subject string
+	if stmt.Sub != nil {
+		subject = *stmt.Sub
 	}
 
-	// Calculate entry tile coordinates
-	entryID := treeSize
-	tileIndex := merkle.EntryIDToTileIndex(entryID)
-	tileOffset := merkle.EntryIDToTileOffset(entryID)
+	trace.with("iss", issuer, "kid", headerKid(headers))
 
-	// Hash the statement for the Merkle tree
-	leafHash := statementHash
-
-	// Append to entry tile (tessera-style tile management)
-	if err := appendToEntryTile(s.storage, entryID, leafHash[:]); err != nil {
-		return nil, fmt.Errorf("failed to append to entry tile: %w", err)
+	// Enforce the client's issuer and subject bindings
+	if req.Principal != nil && !req.Principal.AllowsIssuer(issuer) {
+		return nil, NewPolicyViolationError(fmt.Sprintf("credential is not allowed to register statements for issuer %q", issuer), nil)
+	}
+	if req.Principal != nil && !req.Principal.AllowsSubject(subject) {
+		return nil, NewPolicyViolationError(fmt.Sprintf("credential is not allowed to register statements for subject %q", subject), nil)
 	}
 
-	// Get tile path for database metadata
-	tilePath := merkle.EntryTileIndexToPath(tileIndex, nil)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Convert strings to pointers for optional fields
-	var subPtr, ctyPtr *string
-	if subject != "" {
-		subPtr = &subject
-	}
-	if contentType != "" {
-		ctyPtr = &contentType
+	if s.frozen {
+		return nil, NewForbiddenError(fmt.Sprintf("shard %s is frozen", s.shardName), errShardFrozen)
 	}
 
-	// Insert statement metadata
-	stmt := database.Statement{
-		StatementHash:          statementHashHex,
-		Iss:                    issuer,
-		Sub:                    subPtr,
-		Cty:                    ctyPtr,
-		PayloadHashAlg:         -16, // SHA-256
-		PayloadHash:            "",  // Extract from hash envelope if needed
-		TreeSizeAtRegistration: treeSize,
-		EntryTileKey:           tilePath,
-		EntryTileOffset:        int(tileOffset),
+	// Return the existing entry instead of appending a duplicate leaf
+	if !s.config.Registration.AllowDuplicates {
+		existing, err := database.GetStatementByHash(s.db, statementHashHex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for existing statement: %w", err)
+		}
+
+		if existing != nil {
+			existingEntryID, err := entryIDFromStatement(existing)
+			if err != nil {
+				return nil, err
+			}
+
+			receipt, err := s.GetReceipt(ctx, existingEntryID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate receipt: %w", err)
+			}
+
+			return &RegisterStatementResponse{
+				EntryID:           existingEntryID,
+				StatementHash:     statementHashHex,
+				Receipt:           receipt,
+				AlreadyRegistered: true,
+			}, nil
+		}
 	}
 
-	_, err = database.InsertStatement(s.db, stmt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to insert statement: %w", err)
+	// Hash the statement for the Merkle tree
+	leafHash := statementHash
+
+	// Keep the statement itself, content-addressed by its leaf hash, so the
+	// metadata database can be rebuilt from storage
+	if err := s.storage.Put(merkle.StatementPath(leafHash[:]), req.Statement); err != nil {
+		return nil, fmt.Errorf("failed to store statement: %w", err)
 	}
 
-	// Update tree size
-	if err := database.SetCurrentTreeSize(s.db, treeSize+1); err != nil {
-		return nil, fmt.Errorf("failed to update tree size: %w", err)
+	stmt.StatementHash = statementHashHex
+	if req.Principal != nil {
+		stmt.CredentialID = optionalString(req.Principal.CredentialID)
 	}
+	entryID, err := s.appendEntry(stmt, req.Statement)
+	if err != nil {
+		return nil, err
+	}
+	s.recordTreeGrowth(entryID+1, 1)
 
 	// Generate receipt using the entryID (which is treeSize before increment)
-	receipt, err := s.GetReceipt(entryID)
+	receipt, err := s.GetReceipt(ctx, entryID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate receipt: %w", err)
 	}
@@ -209,36 +445,148 @@ func (s *TransparencyService) RegisterStatement(req *RegisterStatementRequest) (
 	}, nil
 }
 
-// GetReceipt retrieves a receipt for a registered statement
-// Implements draft-ietf-cose-merkle-tree-proofs with inclusion proof and signed tree head
-// The receipt is computed dynamically from the current tree state
-func (s *TransparencyService) GetReceipt(entryID int64) ([]byte, error) {
-	// Get current tree size
+// appendEntry appends a statement to its entry bundle and its leaf to the tree,
+// inserts its metadata and advances current_tree_size, returning the entry ID
+// The metadata is committed only once the tree append succeeds, and the tree is
+// truncated back if it cannot be committed, so the two never diverge
+func (s *TransparencyService) appendEntry(stmt *database.Statement, statement []byte) (int64, error) {
+	s.treeMu.Lock()
+	defer s.treeMu.Unlock()
+
 	treeSize, err := database.GetCurrentTreeSize(s.db)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tree size: %w", err)
+		return 0, fmt.Errorf("failed to get tree size: %w", err)
+	}
+	if s.tree.Size() != treeSize {
+		return 0, fmt.Errorf("tree state holds %d leaves but current_tree_size is %d", s.tree.Size(), treeSize)
 	}
 
-	// Verify entry ID is valid (within tree bounds)
-	if entryID >= treeSize {
-		return nil, fmt.Errorf("entry ID %d not found in tree of size %d", entryID, treeSize)
+	// The entry takes the next leaf, so its tile coordinates are known up front
+	stmt.TreeSizeAtRegistration = treeSize
+	stmt.EntryTileKey = merkle.EntryTileIndexToPath(merkle.EntryIDToTileIndex(treeSize), nil)
+	stmt.EntryTileOffset = merkle.EntryIDToTileOffset(treeSize)
+
+	appending := false
+	_, err = database.AppendStatement(s.db, *stmt, func() error {
+		// Append to the entry bundle, hash tile and compact range
+		appending = true
+		if _, err := s.tree.AppendEntry(statement); err != nil {
+			return fmt.Errorf("failed to append to tree: %w", err)
+		}
+		return nil
+	})
+	if err != nil && appending {
+		// Undo the append, or the partial tile writes of a failed one
+		if truncateErr := s.tree.Truncate(treeSize); truncateErr != nil {
+			return 0, fmt.Errorf("%w (and failed to truncate the tree back to %d: %v)", err, treeSize, truncateErr)
+		}
+	}
+	if err != nil {
+		return 0, err
 	}
 
-	// Compute Merkle root using tessera library
-	rootHash, err := merkle.ComputeTreeRoot(s.storage, treeSize)
+	return treeSize, nil
+}
+
+// treeHead returns the size and root hash of the published tree: the compact
+// range of a log, or the replicated checkpoint of a mirror
+func (s *TransparencyService) treeHead() (int64, [32]byte, error) {
+	if s.IsMirror() {
+		note, err := s.mirroredCheckpoint()
+		if err != nil || note == "" {
+			return 0, [32]byte{}, err
+		}
+		checkpoint, err := merkle.DecodeCheckpoint(note)
+		if err != nil {
+			return 0, [32]byte{}, fmt.Errorf("failed to decode mirrored checkpoint: %w", err)
+		}
+		return checkpoint.TreeSize, checkpoint.RootHash, nil
+	}
+
+	s.treeMu.RLock()
+	defer s.treeMu.RUnlock()
+
+	treeSize, err := database.GetCurrentTreeSize(s.db)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compute merkle root: %w", err)
+		return 0, [32]byte{}, fmt.Errorf("failed to get tree size: %w", err)
+	}
+	if treeSize == 0 {
+		return 0, [32]byte{}, nil
+	}
+	if s.tree.Size() != treeSize {
+		return 0, [32]byte{}, fmt.Errorf("tree state holds %d leaves but current_tree_size is %d", s.tree.Size(), treeSize)
+	}
+
+	rootHash, err := s.tree.Root()
+	if err != nil {
+		return 0, [32]byte{}, err
+	}
+	return treeSize, rootHash, nil
+}
+
+// ReceiptOptions selects the tree a receipt proves inclusion in
+type ReceiptOptions struct {
+	TreeSize int64 // Tree size of a published checkpoint (0 for the current tree)
+	Refresh  bool  // Issue a receipt against the current tree, leaving the stored one in place
+}
+
+// GetReceipt retrieves a receipt for a registered statement
+// Implements draft-ietf-cose-merkle-tree-proofs with inclusion proof and signed tree head
+// A log issues the receipt once, when the entry is integrated, and serves it from storage
+func (s *TransparencyService) GetReceipt(ctx context.Context, entryID int64) ([]byte, error) {
+	return s.GetReceiptWithOptions(ctx, entryID, ReceiptOptions{})
+}
+
+// GetReceiptWithOptions retrieves a receipt for a registered statement, proving
+// inclusion in the tree of a published checkpoint when opts.TreeSize is set so
+// the receipt matches a checkpoint an auditor has already pinned
+func (s *TransparencyService) GetReceiptWithOptions(ctx context.Context, entryID int64, opts ReceiptOptions) ([]byte, error) {
+	if s.IsMirror() {
+		return s.mirroredReceipt(entryID, opts)
+	}
+
+	// A log keeps the receipt of each entry; pinned tree sizes and refreshes
+	// issue one per request and never replace the stored receipt
+	stored := opts.TreeSize == 0 && !opts.Refresh
+	if stored {
+		receipt, err := s.storedReceipt(ctx, entryID)
+		if err != nil || receipt != nil {
+			return receipt, err
+		}
+	}
+
+	// Get the tree size and root from the compact range or the checkpoint
+	start := time.Now()
+	treeSize, rootHash, err := s.treeHead()
+	if err == nil && opts.TreeSize > 0 {
+		treeSize, rootHash, err = s.publishedHead(opts.TreeSize, treeSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.observeProof("root", start)
+
+	// Verify entry ID is valid (within tree bounds)
+	if entryID < 0 || entryID >= treeSize {
+		return nil, NewNotFoundError(fmt.Sprintf("entry %d not found in tree of size %d", entryID, treeSize), nil)
 	}
 
 	// Generate inclusion proof using tessera library
+	start = time.Now()
 	inclusionProof, err := merkle.GenerateInclusionProof(s.storage, entryID, treeSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate inclusion proof: %w", err)
 	}
+	s.observeProof("inclusion", start)
 
-	// Build CWT claims with issuer
-	cwtClaims := cose.CWTClaimsSet{
-		cose.CWTClaimIss: s.config.Issuer, // Issuer URL
+	// Bind the receipt to the statement registered at the entry
+	stmt, err := s.entryStatement(entryID)
+	if err != nil {
+		return nil, err
+	}
+	cwtClaims, err := s.receiptClaims(entryID, stmt)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build protected headers: kid (4), alg (1), vds (395), CWT claims (15)
@@ -247,13 +595,7 @@ func (s *TransparencyService) GetReceipt(entryID int64) ([]byte, error) {
 		cose.HeaderLabelKid:                    s.receiptSigningKeyIdentifier, // kid: parsed from key file
 		cose.HeaderLabelAlg:                    int64(-7),                      // alg: ES256
 		cose.HeaderLabelVerifiableDataStructure: int64(1),                       // vds: RFC 6962 SHA-256 tree algorithm
-		cose.HeaderLabelCWTClaims:              cwtClaims,                      // CWT claims with issuer
-	}
-
-	// Encode protected headers using cbor
-	protectedBytes, err := cbor.Marshal(protectedHeaders)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode protected headers: %w", err)
+		cose.HeaderLabelCWTClaims:              cwtClaims,                      // CWT claims binding the entry
 	}
 
 	// Build inclusion-path as array of hashes (initialize as empty array, not nil)
@@ -269,8 +611,8 @@ func (s *TransparencyService) GetReceipt(entryID int64) ([]byte, error) {
 		inclusionPath,            // inclusion-path: array of hashes
 	}
 
-	// CBOR encode the entire inclusion proof array
-	inclusionProofCBOR, err := cbor.Marshal(inclusionProofArray)
+	// CBOR encode the entire inclusion proof array (core deterministic, as the headers)
+	inclusionProofCBOR, err := cose.Marshal(inclusionProofArray)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode inclusion proof: %w", err)
 	}
@@ -283,41 +625,19 @@ func (s *TransparencyService) GetReceipt(entryID int64) ([]byte, error) {
 		},
 	}
 
-	// Payload is the Merkle tree root hash
-	payload := rootHash[:]
-
-	// Create Sig_structure for signing (same structure as CreateCoseSign1)
-	sigStructure := []interface{}{
-		"Signature1",
-		protectedBytes,
-		[]byte{}, // empty external AAD
-		payload,
-	}
-
-	toBeSigned, err := cbor.Marshal(sigStructure)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode Sig_structure: %w", err)
-	}
-
 	// Sign using ES256 signer
 	signer, err := cose.NewES256Signer(s.privateKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create signer: %w", err)
 	}
 
-	signature, err := signer.Sign(toBeSigned)
+	// Sign the Merkle tree root hash with a detached payload
+	// The payload (Merkle root) can be reconstructed from the inclusion proof
+	receipt, err := cose.CreateCoseSign1(protectedHeaders, rootHash[:], signer, cose.CoseSign1Options{Detached: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign receipt: %w", err)
 	}
-
-	// Build COSE Sign1 receipt with detached payload (nil)
-	// The payload (Merkle root) can be reconstructed from the inclusion proof
-	receipt := &cose.CoseSign1{
-		Protected:   protectedBytes,
-		Unprotected: unprotectedHeaders,
-		Payload:     nil, // Detached - reconstructed from inclusion proof
-		Signature:   signature,
-	}
+	receipt.Unprotected = unprotectedHeaders
 
 	// Encode as CBOR with COSE_Sign1 tag (18)
 	receiptBytes, err := cose.EncodeCoseSign1(receipt)
@@ -325,26 +645,70 @@ func (s *TransparencyService) GetReceipt(entryID int64) ([]byte, error) {
 		return nil, fmt.Errorf("failed to encode receipt: %w", err)
 	}
 
+	logging.FromContext(ctx).Debug("receipt issued",
+		"entry_id", entryID,
+		"tree_size", treeSize,
+		"kid", hex.EncodeToString(s.receiptSigningKeyIdentifier),
+	)
+
+	if stored && stmt != nil {
+		if err := s.storeReceipt(stmt, entryID, treeSize, receiptBytes); err != nil {
+			return nil, err
+		}
+	}
+
 	return receiptBytes, nil
 }
 
-// GetCheckpoint returns the current signed tree head
-func (s *TransparencyService) GetCheckpoint() (string, error) {
-	// Get current tree size
-	treeSize, err := database.GetCurrentTreeSize(s.db)
-	if err != nil {
-		return "", fmt.Errorf("failed to get tree size: %w", err)
+// publishedHead returns the size and root hash of the checkpoint published at
+// treeSize: recorded in tree_state by a log, or replicated by a mirror
+func (s *TransparencyService) publishedHead(treeSize, currentSize int64) (int64, [32]byte, error) {
+	if treeSize > currentSize {
+		return 0, [32]byte{}, NewNotFoundError(fmt.Sprintf("tree size %d exceeds current tree size %d", treeSize, currentSize), nil)
 	}
 
-	// Compute tree root
-	var rootHash [32]byte
-	if treeSize > 0 {
-		rootHash, err = s.computeMerkleRoot(treeSize)
+	var note string
+	if s.IsMirror() {
+		data, err := s.storage.Get(merkle.CheckpointHistoryPath(treeSize))
+		if err != nil {
+			return 0, [32]byte{}, fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+		note = string(data)
+	} else {
+		state, err := database.GetTreeState(s.db, treeSize)
 		if err != nil {
-			return "", fmt.Errorf("failed to compute merkle root: %w", err)
+			return 0, [32]byte{}, err
 		}
+		if state != nil {
+			note = state.CheckpointSignedNote
+		}
+	}
+	if note == "" {
+		return 0, [32]byte{}, NewNotFoundError(fmt.Sprintf("no checkpoint was published at tree size %d", treeSize), nil)
+	}
+
+	checkpoint, err := merkle.DecodeCheckpoint(note)
+	if err != nil {
+		return 0, [32]byte{}, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return checkpoint.TreeSize, checkpoint.RootHash, nil
+}
+
+// GetCheckpoint returns the current signed tree head
+// A mirror returns the checkpoint it replicated, signed by the mirrored log
+func (s *TransparencyService) GetCheckpoint() (string, error) {
+	if s.IsMirror() {
+		return s.mirroredCheckpoint()
 	}
 
+	// Get current tree size and root from the compact range
+	start := time.Now()
+	treeSize, rootHash, err := s.treeHead()
+	if err != nil {
+		return "", err
+	}
+	s.observeProof("root", start)
+
 	// Create checkpoint
 	checkpoint, err := merkle.CreateCheckpoint(
 		treeSize,
@@ -361,8 +725,9 @@ func (s *TransparencyService) GetCheckpoint() (string, error) {
 }
 
 // GetSCITTConfiguration returns service configuration
+// A sharded service also lists its shards so receipts from any shard can be verified
 func (s *TransparencyService) GetSCITTConfiguration() map[string]interface{} {
-	return map[string]interface{}{
+	configuration := map[string]interface{}{
 		"issuer": s.config.Issuer,
 		"supported_algorithms": []string{
 			"ES256",
@@ -371,9 +736,14 @@ func (s *TransparencyService) GetSCITTConfiguration() map[string]interface{} {
 			"SHA-256",
 		},
 		"registration_policy": map[string]interface{}{
-			"type": "open",
+			"type":             "open",
+			"allow_duplicates": s.config.Registration.AllowDuplicates,
 		},
 	}
+	if s.shards != nil {
+		configuration["sharding"] = s.shardingConfiguration()
+	}
+	return configuration
 }
 
 // GetSCITTKeys returns service verification keys as COSE Key Set (CBOR)
@@ -388,213 +758,261 @@ func (s *TransparencyService) GetSCITTKeys() ([]byte, error) {
 	return cborData, nil
 }
 
-// loadPrivateKey loads a private key from PEM or CBOR file
-// Supports both .pem and .cbor file extensions
-func loadPrivateKey(path string) (*ecdsa.PrivateKey, error) {
-	keyData, err := os.ReadFile(path)
+// AuthenticateAPIKey verifies a client API key issued with "scitt service apikey create"
+func (s *TransparencyService) AuthenticateAPIKey(token string) (*auth.Principal, error) {
+	principal, err := auth.AuthenticateAPIKey(s.db, token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read private key file: %w", err)
-	}
-
-	// Try CBOR format first (if file extension is .cbor)
-	if len(path) > 5 && path[len(path)-5:] == ".cbor" {
-		privateKey, err := cose.ImportPrivateKeyFromCOSECBOR(keyData)
-		if err != nil {
-			return nil, fmt.Errorf("failed to import CBOR private key: %w", err)
+		if errors.Is(err, auth.ErrInvalidAPIKey) || errors.Is(err, auth.ErrAPIKeyRevoked) || errors.Is(err, auth.ErrAPIKeyExpired) {
+			return nil, NewUnauthorizedError(err.Error(), nil)
 		}
-		return privateKey, nil
+		return nil, NewInternalError("failed to verify API key", err)
 	}
+	return principal, nil
+}
 
-	// Fall back to PEM format
-	privateKey, err := cose.ImportPrivateKeyFromPEM(string(keyData))
+// FindStatementsByArtifactDigest returns every registered statement about an artifact
+// digest is the raw artifact hash computed with the COSE hash algorithm hashAlg
+func (s *TransparencyService) FindStatementsByArtifactDigest(hashAlg int, digest []byte) ([]database.Statement, error) {
+	statements, err := database.FindStatementsByPayloadHash(s.db, hashAlg, hex.EncodeToString(digest))
 	if err != nil {
-		return nil, fmt.Errorf("failed to import PEM private key: %w", err)
+		return nil, fmt.Errorf("failed to find statements by artifact digest: %w", err)
 	}
+	return statements, nil
+}
 
-	return privateKey, nil
+// ArtifactEntry is a registered statement about an artifact together with its receipt
+type ArtifactEntry struct {
+	EntryID   int64
+	Shard     string // Empty unless sharded
+	Statement database.Statement
+	Receipt   []byte
 }
 
-// loadPublicKey loads a public key from JWK or CBOR file
-// Supports both .jwk/.json and .cbor file extensions
-func loadPublicKey(path string) (*ecdsa.PublicKey, error) {
-	keyData, err := os.ReadFile(path)
+// LookupArtifact returns every log entry about an artifact digest with a receipt for each
+// A sharded service searches every shard, oldest first
+func (s *TransparencyService) LookupArtifact(ctx context.Context, hashAlg int, digest []byte) ([]ArtifactEntry, error) {
+	if s.shards != nil {
+		var entries []ArtifactEntry
+		for _, log := range s.shardLogs() {
+			found, err := log.LookupArtifact(ctx, hashAlg, digest)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range found {
+				entry.Shard = log.shardName
+				entries = append(entries, entry)
+			}
+		}
+		return entries, nil
+	}
+
+	statements, err := s.FindStatementsByArtifactDigest(hashAlg, digest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read public key file: %w", err)
+		return nil, err
 	}
 
-	// Try CBOR format first (if file extension is .cbor)
-	if len(path) > 5 && path[len(path)-5:] == ".cbor" {
-		publicKey, err := cose.ImportPublicKeyFromCOSECBOR(keyData)
+	entries := make([]ArtifactEntry, 0, len(statements))
+	for _, stmt := range statements {
+		entryID, err := entryIDFromStatement(&stmt)
 		if err != nil {
-			return nil, fmt.Errorf("failed to import CBOR public key: %w", err)
+			return nil, err
 		}
-		return publicKey, nil
-	}
 
-	// Fall back to JWK format
-	jwk, err := cose.UnmarshalJWK(keyData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JWK: %w", err)
-	}
+		receipt, err := s.GetReceipt(ctx, entryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get receipt for entry %d: %w", entryID, err)
+		}
 
-	publicKey, err := cose.ImportPublicKeyFromJWK(jwk)
-	if err != nil {
-		return nil, fmt.Errorf("failed to import JWK public key: %w", err)
+		entries = append(entries, ArtifactEntry{
+			EntryID:   entryID,
+			Statement: stmt,
+			Receipt:   receipt,
+		})
 	}
 
-	return publicKey, nil
+	return entries, nil
 }
 
-// computeMerkleRoot computes the RFC 6962 merkle tree root from statement hashes
-func (s *TransparencyService) computeMerkleRoot(treeSize int64) ([32]byte, error) {
-	if treeSize == 0 {
-		return [32]byte{}, fmt.Errorf("cannot compute root of empty tree")
+// LogEntry is a registered statement with its 0-based log entry ID
+type LogEntry struct {
+	EntryID   int64
+	Shard     string // Empty unless sharded
+	Statement database.Statement
+}
+
+// ListEntries returns the entries registered for a statement issuer and/or subject,
+// ordered by entry ID (and by shard, oldest first, when sharded)
+func (s *TransparencyService) ListEntries(ctx context.Context, filters database.StatementQueryFilters) ([]LogEntry, error) {
+	if s.shards != nil {
+		var entries []LogEntry
+		for _, log := range s.shardLogs() {
+			found, err := log.ListEntries(ctx, filters)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range found {
+				entry.Shard = log.shardName
+				entries = append(entries, entry)
+			}
+		}
+		return entries, nil
 	}
 
-	// Query all statement hashes in order
-	rows, err := s.db.Query(`
-		SELECT statement_hash
-		FROM statements
-		WHERE entry_id <= ?
-		ORDER BY entry_id ASC
-	`, treeSize)
+	statements, err := database.FindStatementsBy(s.db, filters)
 	if err != nil {
-		return [32]byte{}, fmt.Errorf("failed to query statement hashes: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	// Collect leaf hashes
-	var leafHashes [][32]byte
-	for rows.Next() {
-		var hashHex string
-		if err := rows.Scan(&hashHex); err != nil {
-			return [32]byte{}, fmt.Errorf("failed to scan statement hash: %w", err)
-		}
-
-		// Decode hex hash
-		hashBytes, err := hex.DecodeString(hashHex)
+	entries := make([]LogEntry, 0, len(statements))
+	for _, stmt := range statements {
+		entryID, err := entryIDFromStatement(&stmt)
 		if err != nil {
-			return [32]byte{}, fmt.Errorf("invalid hash hex: %w", err)
+			return nil, err
 		}
+		entries = append(entries, LogEntry{EntryID: entryID, Statement: stmt})
+	}
 
-		if len(hashBytes) != 32 {
-			return [32]byte{}, fmt.Errorf("invalid hash length: %d", len(hashBytes))
-		}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].EntryID < entries[j].EntryID })
+	return entries, nil
+}
 
-		var hash [32]byte
-		copy(hash[:], hashBytes)
-		leafHashes = append(leafHashes, hash)
-	}
+// statementMetadata extracts the metadata indexed for a statement from its
+// protected headers; the log position fields are left for the caller
+func statementMetadata(coseSign1 *cose.CoseSign1, headers cose.Headers) (*database.Statement, error) {
+	stmt := &database.Statement{}
 
-	if err := rows.Err(); err != nil {
-		return [32]byte{}, fmt.Errorf("error iterating rows: %w", err)
+	// Issuer and subject come from the CWT claims if present
+	if claims, ok := headers.CWTClaims(); ok {
+		stmt.Iss, _ = claims.Iss()
+		subject, _ := claims.Sub()
+		stmt.Sub = optionalString(subject)
 	}
 
-	if int64(len(leafHashes)) != treeSize {
-		return [32]byte{}, fmt.Errorf("expected %d leaves, got %d", treeSize, len(leafHashes))
-	}
+	contentType, _ := headers.ContentType()
+	typ, _ := headers.Typ()
+	stmt.Cty = optionalString(contentType)
+	stmt.Typ = optionalString(typ)
 
-	// Compute merkle root using RFC 6962 algorithm
-	if len(leafHashes) == 1 {
-		// Single leaf: MTH({d[0]}) = SHA-256(0x00 || d[0])
-		return hashLeaf(leafHashes[0]), nil
+	// Artifact digest and hash envelope parameters (labels 258-260)
+	payload, err := extractPayloadMetadata(coseSign1, headers)
+	if err != nil {
+		return nil, err
 	}
+	stmt.PayloadHashAlg = payload.PayloadHashAlg
+	stmt.PayloadHash = hex.EncodeToString(payload.PayloadHash)
+	stmt.PreimageContentType = optionalString(payload.PreimageContentType)
+	stmt.PayloadLocation = optionalString(payload.PayloadLocation)
 
-	return computeSubtreeHash(leafHashes), nil
+	return stmt, nil
 }
 
-// computeSubtreeHash computes the RFC 6962 merkle tree hash of a subtree
-// RFC 6962: MTH(D[n]) = SHA-256(0x01 || MTH(D[0:k]) || MTH(D[k:n]))
-// where k is the largest power of 2 less than n
-func computeSubtreeHash(leaves [][32]byte) [32]byte {
-	n := len(leaves)
-
-	if n == 0 {
-		panic("cannot compute hash of empty subtree")
+// extractPayloadMetadata returns the artifact digest described by a statement
+//
+// For hash envelope statements (label 258 present) the payload is the artifact
+// hash and the envelope parameters are returned as-is. For other statements
+// with an attached payload, the SHA-256 of the payload is used so the payload
+// itself can be looked up by digest. Detached non-envelope payloads have no
+// known digest.
+func extractPayloadMetadata(coseSign1 *cose.CoseSign1, headers cose.Headers) (*cose.HashEnvelope, error) {
+	if _, ok := headers.Get(cose.HeaderLabelPayloadHashAlg); ok {
+		params, err := cose.ExtractHashEnvelopeParams(coseSign1)
+		if err != nil {
+			return nil, NewInvalidStatementError("invalid hash envelope", err)
+		}
+		return params, nil
 	}
 
-	if n == 1 {
-		// Single leaf
-		return hashLeaf(leaves[0])
+	envelope := &cose.HashEnvelope{
+		PayloadHashAlg: cose.HashAlgorithmSHA256,
+	}
+	if coseSign1.Payload != nil {
+		digest := sha256.Sum256(coseSign1.Payload)
+		envelope.PayloadHash = digest[:]
 	}
 
-	// Find k: largest power of 2 less than n
-	k := largestPowerOfTwoLessThan(n)
-
-	// Split into left and right subtrees
-	left := leaves[:k]
-	right := leaves[k:]
+	return envelope, nil
+}
 
-	leftHash := computeSubtreeHash(left)
+// headerKid returns the key identifier (label 4) of a statement as hex, or empty if absent
+func headerKid(headers cose.Headers) string {
+	kid, ok := headers.Kid()
+	if !ok {
+		return ""
+	}
+	return hex.EncodeToString(kid)
+}
 
-	if len(right) == 0 {
-		return leftHash
+// optionalString converts an empty string to a nil pointer for nullable columns
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
 	}
+	return &value
+}
 
-	rightHash := computeSubtreeHash(right)
+// entryIDFromStatement derives the log entry ID from a statement's entry tile coordinates
+func entryIDFromStatement(stmt *database.Statement) (int64, error) {
+	parsed, err := merkle.ParseEntryTilePath(stmt.EntryTileKey)
+	if err != nil {
+		return 0, fmt.Errorf("invalid entry tile key for statement %s: %w", stmt.StatementHash, err)
+	}
 
-	// Hash the two subtrees together with node prefix
-	return hashNode(leftHash, rightHash)
+	return merkle.TileCoordinatesToEntryID(parsed.Index, stmt.EntryTileOffset), nil
 }
 
-// hashLeaf hashes a leaf with RFC 6962 prefix (0x00)
-func hashLeaf(leaf [32]byte) [32]byte {
-	h := sha256.New()
-	h.Write([]byte{0x00}) // RFC 6962 leaf prefix
-	h.Write(leaf[:])
-	var result [32]byte
-	copy(result[:], h.Sum(nil))
-	return result
-}
+// loadPrivateKey loads a private key from PEM or CBOR file
+// Supports both .pem and .cbor file extensions
+func loadPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	keyData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
 
-// hashNode hashes two child nodes with RFC 6962 prefix (0x01)
-func hashNode(left, right [32]byte) [32]byte {
-	h := sha256.New()
-	h.Write([]byte{0x01}) // RFC 6962 node prefix
-	h.Write(left[:])
-	h.Write(right[:])
-	var result [32]byte
-	copy(result[:], h.Sum(nil))
-	return result
-}
+	// Try CBOR format first (if file extension is .cbor)
+	if len(path) > 5 && path[len(path)-5:] == ".cbor" {
+		privateKey, err := cose.ImportPrivateKeyFromCOSECBOR(keyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import CBOR private key: %w", err)
+		}
+		return privateKey, nil
+	}
 
-// largestPowerOfTwoLessThan finds the largest power of 2 strictly less than n
-func largestPowerOfTwoLessThan(n int) int {
-	k := 1
-	for k*2 < n {
-		k *= 2
+	// Fall back to PEM format
+	privateKey, err := cose.ImportPrivateKeyFromPEM(string(keyData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to import PEM private key: %w", err)
 	}
-	return k
-}
 
-// appendToEntryTile appends a leaf to an entry tile (tessera-style)
-// This matches the tile storage format expected by the merkle proof library
-func appendToEntryTile(store storage.Storage, entryID int64, leafHash []byte) error {
-	tileIndex := merkle.EntryIDToTileIndex(entryID)
-	tilePath := merkle.EntryTileIndexToPath(tileIndex, nil)
+	return privateKey, nil
+}
 
-	// Read existing tile (if any)
-	existingTile, err := store.Get(tilePath)
+// loadPublicKey loads a public key from JWK or CBOR file
+// Supports both .jwk/.json and .cbor file extensions
+func loadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	keyData, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to get existing tile: %w", err)
+		return nil, fmt.Errorf("failed to read public key file: %w", err)
 	}
 
-	var currentSize int
-	if existingTile != nil {
-		currentSize = len(existingTile) / 32 // 32 bytes per hash
+	// Try CBOR format first (if file extension is .cbor)
+	if len(path) > 5 && path[len(path)-5:] == ".cbor" {
+		publicKey, err := cose.ImportPublicKeyFromCOSECBOR(keyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import CBOR public key: %w", err)
+		}
+		return publicKey, nil
 	}
 
-	// Append new leaf
-	newTile := make([]byte, (currentSize+1)*32)
-	if existingTile != nil {
-		copy(newTile, existingTile)
+	// Fall back to JWK format
+	jwk, err := cose.UnmarshalJWK(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JWK: %w", err)
 	}
-	copy(newTile[currentSize*32:], leafHash)
 
-	// Write updated tile
-	if err := store.Put(tilePath, newTile); err != nil {
-		return fmt.Errorf("failed to put tile: %w", err)
+	publicKey, err := cose.ImportPublicKeyFromJWK(jwk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import JWK public key: %w", err)
 	}
 
-	return nil
+	return publicKey, nil
 }
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+)
+
+// Shard statuses reported in the service configuration
+const (
+	ShardStatusActive = "active"
+	ShardStatusFrozen = "frozen"
+)
+
+// errShardFrozen is the cause of a registration rejected by a frozen shard
+var errShardFrozen = errors.New("shard is frozen")
+
+// sharding holds the logs of a temporally sharded service
+// Each shard is a log with its own origin, database and storage prefix; the
+// shard registry in the service database records which shards are frozen
+type sharding struct {
+	interval string
+
+	mu       sync.RWMutex
+	logs     map[string]*TransparencyService
+	registry []database.Shard // oldest first
+	active   *TransparencyService
+}
+
+// ShardName returns the name of the shard covering t for an interval:
+// "2025" for yearly shards and "2025-06" for monthly shards (UTC)
+func ShardName(interval string, t time.Time) string {
+	if interval == config.ShardIntervalMonthly {
+		return t.UTC().Format("2006-01")
+	}
+	return t.UTC().Format("2006")
+}
+
+// ShardOrigin returns the issuer and checkpoint origin of a shard
+func ShardOrigin(issuer, name string) string {
+	return strings.TrimSuffix(issuer, "/") + "/shards/" + name
+}
+
+// shardDatabasePath returns the database file of a shard next to the service database
+func shardDatabasePath(path, name string) string {
+	if path == ":memory:" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + name + ext
+}
+
+// shardStoragePrefix returns the storage prefix of a shard's tiles, statements and checkpoints
+func shardStoragePrefix(name string) string {
+	return "shards/" + name + "/"
+}
+
+// openShards opens every registered shard and rotates to the shard covering now
+func (s *TransparencyService) openShards(now time.Time) error {
+	treeSize, err := database.GetCurrentTreeSize(s.db)
+	if err != nil {
+		return fmt.Errorf("failed to get tree size: %w", err)
+	}
+	if treeSize > 0 {
+		return fmt.Errorf("log already has %d entries and cannot be sharded", treeSize)
+	}
+
+	registry, err := database.ListShards(s.db)
+	if err != nil {
+		return err
+	}
+
+	s.shards = &sharding{
+		interval: s.config.Sharding.Interval,
+		logs:     make(map[string]*TransparencyService),
+		registry: registry,
+	}
+	for _, shard := range registry {
+		log, err := s.openShard(shard.Name, shard.Frozen())
+		if err != nil {
+			return err
+		}
+		s.shards.logs[shard.Name] = log
+		if !shard.Frozen() {
+			s.shards.active = log
+		}
+	}
+
+	return s.RotateShards(now)
+}
+
+// openShard opens the log of one shard with a copy of the service configuration
+func (s *TransparencyService) openShard(name string, frozen bool) (*TransparencyService, error) {
+	cfg := *s.config
+	cfg.Issuer = ShardOrigin(s.config.Issuer, name)
+	cfg.Database.Path = shardDatabasePath(s.config.Database.Path, name)
+	cfg.Sharding = nil
+
+	db, err := database.OpenDatabase(database.DatabaseOptions{
+		Path:      cfg.Database.Path,
+		EnableWAL: cfg.Database.EnableWAL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database of shard %s: %w", name, err)
+	}
+
+	log := &TransparencyService{
+		config:                      &cfg,
+		db:                          db,
+		storage:                     storage.WithPrefix(s.storage, shardStoragePrefix(name)),
+		privateKey:                  s.privateKey,
+		publicKey:                   s.publicKey,
+		receiptSigningKeyIdentifier: s.receiptSigningKeyIdentifier,
+		shardName:                   name,
+		frozen:                      frozen,
+	}
+	if err := log.startLog(); err != nil {
+		log.Close()
+		return nil, fmt.Errorf("failed to start shard %s: %w", name, err)
+	}
+
+	return log, nil
+}
+
+// closeShards closes the log of every shard
+func (s *TransparencyService) closeShards() {
+	if s.shards == nil {
+		return
+	}
+	s.shards.mu.Lock()
+	defer s.shards.mu.Unlock()
+
+	for _, log := range s.shards.logs {
+		log.Close()
+	}
+	s.shards.logs = nil
+	s.shards.active = nil
+}
+
+// IsSharded reports whether the service splits its log into temporal shards
+func (s *TransparencyService) IsSharded() bool {
+	return s.shards != nil
+}
+
+// RotateShards freezes the active shard and opens a new one when now falls in a
+// later shard interval; the frozen shard's final checkpoint is published and
+// recorded in the shard registry. Shards never rotate backwards.
+func (s *TransparencyService) RotateShards(now time.Time) error {
+	if s.shards == nil {
+		return nil
+	}
+	name := ShardName(s.shards.interval, now)
+
+	s.shards.mu.Lock()
+	defer s.shards.mu.Unlock()
+
+	active := s.shards.active
+	if active != nil && name <= active.shardName {
+		return nil
+	}
+	if _, exists := s.shards.logs[name]; exists {
+		return fmt.Errorf("shard %s is already frozen", name)
+	}
+	defer s.reloadShardRegistry()
+
+	// Open the new shard first so a failure leaves the active shard in place
+	log, err := s.openShard(name, false)
+	if err != nil {
+		return err
+	}
+	if active != nil {
+		if err := s.freezeShard(active); err != nil {
+			log.Close()
+			return err
+		}
+		s.shards.active = nil
+	}
+	if err := database.InsertShard(s.db, name, ShardOrigin(s.config.Issuer, name)); err != nil {
+		log.Close()
+		return err
+	}
+	s.shards.logs[name] = log
+	s.shards.active = log
+
+	slog.Info("shard opened", "shard", name, "origin", log.config.Issuer)
+	return nil
+}
+
+// reloadShardRegistry refreshes the shard registry listed in the service configuration
+// The caller must hold s.shards.mu
+func (s *TransparencyService) reloadShardRegistry() {
+	registry, err := database.ListShards(s.db)
+	if err != nil {
+		slog.Warn("failed to reload shard registry", "error", err)
+		return
+	}
+	s.shards.registry = registry
+}
+
+// freezeShard publishes the final checkpoint of a shard and makes it read-only
+func (s *TransparencyService) freezeShard(log *TransparencyService) error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	note, err := log.GetPublishedCheckpoint(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to publish final checkpoint of shard %s: %w", log.shardName, err)
+	}
+	checkpoint, err := merkle.DecodeCheckpoint(note)
+	if err != nil {
+		return fmt.Errorf("failed to decode final checkpoint of shard %s: %w", log.shardName, err)
+	}
+
+	if err := database.FreezeShard(s.db, log.shardName, checkpoint.TreeSize, note); err != nil {
+		return err
+	}
+	log.frozen = true
+
+	slog.Info("shard frozen", "shard", log.shardName, "tree_size", checkpoint.TreeSize)
+	return nil
+}
+
+// ActiveLog returns the log that accepts registrations: the active shard of a
+// sharded service, rotating first if its interval has ended, or the service itself
+func (s *TransparencyService) ActiveLog() (*TransparencyService, error) {
+	if s.shards == nil {
+		return s, nil
+	}
+
+	s.shards.mu.RLock()
+	active := s.shards.active
+	s.shards.mu.RUnlock()
+
+	if active == nil || ShardName(s.shards.interval, time.Now()) > active.shardName {
+		if err := s.RotateShards(time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to rotate shards: %w", err)
+		}
+		s.shards.mu.RLock()
+		active = s.shards.active
+		s.shards.mu.RUnlock()
+	}
+
+	return active, nil
+}
+
+// Shard returns the log of a shard, active or frozen
+func (s *TransparencyService) Shard(name string) (*TransparencyService, error) {
+	if s.shards != nil {
+		s.shards.mu.RLock()
+		log, ok := s.shards.logs[name]
+		s.shards.mu.RUnlock()
+		if ok {
+			return log, nil
+		}
+	}
+	return nil, NewNotFoundError(fmt.Sprintf("shard %s not found", name), nil)
+}
+
+// ShardName returns the name of the shard served by this log (empty unless sharded)
+func (s *TransparencyService) ShardName() string {
+	return s.shardName
+}
+
+// shardLogs returns the log of every shard, oldest first
+func (s *TransparencyService) shardLogs() []*TransparencyService {
+	s.shards.mu.RLock()
+	defer s.shards.mu.RUnlock()
+
+	logs := make([]*TransparencyService, 0, len(s.shards.registry))
+	for _, shard := range s.shards.registry {
+		if log, ok := s.shards.logs[shard.Name]; ok {
+			logs = append(logs, log)
+		}
+	}
+	return logs
+}
+
+// registerInActiveShard registers a statement in the active shard, retrying
+// once in the new shard if the active shard was frozen while registering
+func (s *TransparencyService) registerInActiveShard(ctx context.Context, req *RegisterStatementRequest, trace *registrationLog) (*RegisterStatementResponse, error) {
+	for attempt := 0; ; attempt++ {
+		log, err := s.ActiveLog()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := log.registerStatement(ctx, req, trace)
+		if errors.Is(err, errShardFrozen) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		trace.with("shard", log.shardName)
+		resp.Shard = log.shardName
+		if !resp.AlreadyRegistered {
+			log.notifyWitnesses()
+		}
+		return resp, nil
+	}
+}
+
+// shardingConfiguration describes the shards for the service configuration
+func (s *TransparencyService) shardingConfiguration() map[string]interface{} {
+	s.shards.mu.RLock()
+	defer s.shards.mu.RUnlock()
+
+	shards := make([]map[string]interface{}, 0, len(s.shards.registry))
+	for _, shard := range s.shards.registry {
+		entry := map[string]interface{}{
+			"name":       shard.Name,
+			"origin":     shard.Origin,
+			"status":     ShardStatusActive,
+			"created_at": shard.CreatedAt,
+		}
+		if shard.Frozen() {
+			entry["status"] = ShardStatusFrozen
+			entry["frozen_at"] = *shard.FrozenAt
+			entry["final_tree_size"] = *shard.FinalTreeSize
+			entry["final_checkpoint"] = *shard.FinalCheckpoint
+		}
+		shards = append(shards, entry)
+	}
+
+	configuration := map[string]interface{}{
+		"interval": s.shards.interval,
+		"shards":   shards,
+	}
+	if s.shards.active != nil {
+		configuration["active_shard"] = s.shards.active.shardName
+	}
+	return configuration
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/witness"
+)
+
+// defaultWitnessTimeout bounds a submission to one witness when none is configured
+const defaultWitnessTimeout = 10 * time.Second
+
+// witnessing submits checkpoints to the configured witnesses in the background
+type witnessing struct {
+	clients []*witness.Client
+	timeout time.Duration
+
+	// mu serializes cosigning rounds and guards sizes
+	mu sync.Mutex
+	// sizes is the last tree size each witness is known to have cosigned
+	sizes map[string]int64
+
+	trigger chan struct{}
+	stop    context.CancelFunc
+	done    chan struct{}
+}
+
+// newWitnessing creates clients for the configured witnesses
+func newWitnessing(cfg *config.WitnessingConfig) (*witnessing, error) {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultWitnessTimeout
+	}
+
+	w := &witnessing{
+		timeout: timeout,
+		sizes:   make(map[string]int64),
+		trigger: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	httpClient := &http.Client{Timeout: timeout}
+	for _, wc := range cfg.Witnesses {
+		verifier, err := witness.ParseVerifierKey(wc.VerifierKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure witness %s: %w", wc.URL, err)
+		}
+		w.clients = append(w.clients, witness.NewClient(wc.URL, verifier, httpClient))
+	}
+
+	return w, nil
+}
+
+// startWitnessing runs the background cosigning loop until Close
+func (s *TransparencyService) startWitnessing() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.witnesses.stop = cancel
+
+	// Cosign the current tree head on startup, then after every registration
+	s.notifyWitnesses()
+
+	go func() {
+		defer close(s.witnesses.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.witnesses.trigger:
+				if _, err := s.CosignCheckpoint(ctx); err != nil && ctx.Err() == nil {
+					slog.Warn("checkpoint cosigning failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// stopWitnessing stops the background cosigning loop and waits for it to exit
+func (s *TransparencyService) stopWitnessing() {
+	if s.witnesses == nil || s.witnesses.stop == nil {
+		return
+	}
+	s.witnesses.stop()
+	<-s.witnesses.done
+}
+
+// notifyWitnesses schedules a cosigning round without blocking the caller
+func (s *TransparencyService) notifyWitnesses() {
+	if s.witnesses == nil {
+		return
+	}
+	select {
+	case s.witnesses.trigger <- struct{}{}:
+	default:
+		// A round is already pending and will pick up the latest tree head
+	}
+}
+
+// CosignCheckpoint signs a checkpoint for the current tree, submits it with a
+// consistency proof to every configured witness and records the checkpoint
+// with the cosignatures collected in tree_state
+// Witnesses that fail are logged and skipped; nil is returned for an empty tree
+func (s *TransparencyService) CosignCheckpoint(ctx context.Context) (*database.TreeState, error) {
+	if s.witnesses == nil {
+		return nil, fmt.Errorf("no witnesses configured")
+	}
+
+	s.witnesses.mu.Lock()
+	defer s.witnesses.mu.Unlock()
+
+	note, err := s.GetCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	checkpoint, err := merkle.DecodeCheckpoint(note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	if checkpoint.TreeSize == 0 {
+		return nil, nil
+	}
+
+	var cosignatures []string
+	for _, client := range s.witnesses.clients {
+		line, err := s.submitToWitness(ctx, client, note, checkpoint.TreeSize)
+		if err != nil {
+			witnessCosignaturesTotal.WithLabelValues(client.Name(), "failed", s.config.Name).Inc()
+			slog.Warn("witness did not cosign checkpoint",
+				"witness", client.Name(),
+				"tree_size", checkpoint.TreeSize,
+				"error", err,
+			)
+			continue
+		}
+		witnessCosignaturesTotal.WithLabelValues(client.Name(), "cosigned", s.config.Name).Inc()
+		cosignatures = append(cosignatures, line)
+	}
+
+	state := database.TreeState{
+		TreeSize:             checkpoint.TreeSize,
+		RootHash:             hex.EncodeToString(checkpoint.RootHash[:]),
+		CheckpointStorageKey: merkle.CheckpointHistoryPath(checkpoint.TreeSize),
+		CheckpointSignedNote: witness.AppendCosignatures(note, cosignatures...),
+	}
+
+	// Keep a checkpoint already recorded at this size if it has more cosignatures
+	if existing, err := database.GetTreeState(s.db, state.TreeSize); err != nil {
+		return nil, err
+	} else if existing != nil && countSignatures(existing.CheckpointSignedNote) > countSignatures(state.CheckpointSignedNote) {
+		return existing, nil
+	}
+
+	if err := database.SaveTreeState(s.db, state); err != nil {
+		return nil, err
+	}
+	if err := s.storeCheckpoint(state); err != nil {
+		return nil, err
+	}
+
+	slog.Info("checkpoint cosigned",
+		"tree_size", state.TreeSize,
+		"cosignatures", len(cosignatures),
+		"witnesses", len(s.witnesses.clients),
+	)
+
+	return &state, nil
+}
+
+// submitToWitness sends a checkpoint to one witness, retrying once from the
+// witness's latest size when it reports a conflict
+func (s *TransparencyService) submitToWitness(ctx context.Context, client *witness.Client, note string, treeSize int64) (string, error) {
+	oldSize := s.witnesses.sizes[client.Name()]
+
+	for attempt := 0; ; attempt++ {
+		if oldSize > treeSize {
+			return "", fmt.Errorf("witness has cosigned size %d, larger than the log's %d", oldSize, treeSize)
+		}
+
+		proof, err := merkle.GenerateConsistencyProof(s.storage, oldSize, treeSize)
+		if err != nil {
+			return "", err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, s.witnesses.timeout)
+		line, err := client.AddCheckpoint(ctx, &witness.AddCheckpointRequest{
+			OldSize:    oldSize,
+			Proof:      proof.Proof,
+			Checkpoint: note,
+		})
+		cancel()
+
+		var conflict *witness.ConflictError
+		if errors.As(err, &conflict) && attempt == 0 && conflict.Size != oldSize {
+			oldSize = conflict.Size
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		s.witnesses.sizes[client.Name()] = treeSize
+		return line, nil
+	}
+}
+
+// countSignatures returns the number of signature lines in a signed note
+func countSignatures(note string) int {
+	_, signatures, err := witness.SplitNote(note)
+	if err != nil {
+		return 0
+	}
+	return len(signatures)
+}
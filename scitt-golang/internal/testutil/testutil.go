@@ -0,0 +1,82 @@
+// Package testutil provides fixtures shared by the transparency service tests
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+)
+
+// Keys is a service key pair written to disk as COSE keys, so several
+// services can share it
+type Keys struct {
+	PrivatePath string
+	PublicPath  string
+	Public      *ecdsa.PublicKey
+}
+
+// NewKeys generates an ES256 service key pair in a temporary directory
+func NewKeys(t testing.TB) *Keys {
+	t.Helper()
+
+	keyPair, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	privateKeyCBOR, err := cose.ExportPrivateKeyToCOSECBOR(keyPair.Private)
+	if err != nil {
+		t.Fatalf("failed to export private key: %v", err)
+	}
+	publicKeyCBOR, err := cose.ExportPublicKeyToCOSECBOR(keyPair.Public)
+	if err != nil {
+		t.Fatalf("failed to export public key: %v", err)
+	}
+
+	dir := t.TempDir()
+	keys := &Keys{
+		PrivatePath: filepath.Join(dir, "service-key.cbor"),
+		PublicPath:  filepath.Join(dir, "service-key-pub.cbor"),
+		Public:      keyPair.Public,
+	}
+	if err := os.WriteFile(keys.PrivatePath, privateKeyCBOR, 0600); err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+	if err := os.WriteFile(keys.PublicPath, publicKeyCBOR, 0644); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	return keys
+}
+
+// NewConfig returns the configuration of a service signing with keys, with a
+// database in a temporary directory, in-memory tile storage and a fresh API key
+func NewConfig(t testing.TB, keys *Keys) *config.Config {
+	t.Helper()
+
+	apiKey, err := config.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("failed to generate API key: %v", err)
+	}
+
+	return &config.Config{
+		Issuer: "https://test.example.com",
+		Database: config.DatabaseConfig{
+			Path:      filepath.Join(t.TempDir(), "test.db"),
+			EnableWAL: true,
+		},
+		Storage: config.StorageConfig{
+			Type: "memory",
+		},
+		Keys: config.KeysConfig{
+			Private: keys.PrivatePath,
+			Public:  keys.PublicPath,
+		},
+		Server: config.ServerConfig{
+			Host:   "127.0.0.1",
+			APIKey: apiKey,
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package cose
+
+import (
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// encMode encodes the core deterministic encoding of RFC 8949 §4.2: preferred
+// (shortest) serialization, definite lengths and map keys sorted by their
+// encoded bytes, so the same value always encodes to the same bytes
+var encMode = func() cbor.EncMode {
+	mode, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(fmt.Sprintf("invalid CBOR encoding options: %v", err))
+	}
+	return mode
+}()
+
+// decMode decodes CBOR strictly, rejecting duplicate map keys and indefinite lengths
+var decMode = func() cbor.DecMode {
+	mode, err := cbor.DecOptions{
+		DupMapKey:   cbor.DupMapKeyEnforcedAPF,
+		IndefLength: cbor.IndefLengthForbidden,
+	}.DecMode()
+	if err != nil {
+		panic(fmt.Sprintf("invalid CBOR decoding options: %v", err))
+	}
+	return mode
+}()
+
+// Marshal encodes v as core deterministic CBOR (RFC 8949 §4.2)
+// Protected headers, Sig_structures, COSE_Keys and proofs are encoded with it
+func Marshal(v interface{}) ([]byte, error) {
+	return encMode.Marshal(v)
+}
+
+// Unmarshal decodes CBOR into v, rejecting duplicate map keys and indefinite lengths
+func Unmarshal(data []byte, v interface{}) error {
+	return decMode.Unmarshal(data, v)
+}
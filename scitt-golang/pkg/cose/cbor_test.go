@@ -0,0 +1,102 @@
+package cose_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+)
+
+func TestDeterministicEncoding(t *testing.T) {
+	t.Run("sorts map keys by their encoding", func(t *testing.T) {
+		encoded, err := cose.Marshal(map[interface{}]interface{}{
+			int64(4):  []byte{0x01},
+			"a":       int64(1),
+			int64(-1): int64(1000),
+			int64(1):  int64(-7),
+		})
+		if err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+
+		// {1: -7, 4: h'01', -1: 1000, "a": 1}, with the shortest integer forms
+		want := "a4" + "0126" + "044101" + "201903e8" + "616101"
+		if got := hex.EncodeToString(encoded); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	})
+
+	t.Run("signs the same headers to the same protected bytes", func(t *testing.T) {
+		keyPair, _ := cose.GenerateES256KeyPair()
+		signer, _ := cose.NewES256Signer(keyPair.Private)
+
+		var protected []byte
+		for i := 0; i < 20; i++ {
+			headers := cose.CreateProtectedHeaders(cose.ProtectedHeadersOptions{
+				Alg: cose.AlgorithmES256,
+				Kid: []byte("kid"),
+				Cty: "application/json",
+				Typ: "application/example+cose",
+				CWTClaims: cose.CreateCWTClaims(cose.CWTClaimsOptions{
+					Iss: "https://issuer.example.com",
+					Sub: "artifact",
+					Iat: 1700000000,
+				}),
+			})
+			signed, err := cose.CreateCoseSign1(headers, []byte("payload"), signer, cose.CoseSign1Options{})
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+			if protected != nil && !bytes.Equal(signed.Protected, protected) {
+				t.Fatalf("protected headers differ between signings: %x and %x", protected, signed.Protected)
+			}
+			protected = signed.Protected
+		}
+	})
+
+	t.Run("exports COSE key sets in deterministic encoding", func(t *testing.T) {
+		keyPair, _ := cose.GenerateES256KeyPair()
+		keySet, err := cose.ExportCOSEKeySetToCBOR([]*ecdsa.PublicKey{keyPair.Public})
+		if err != nil {
+			t.Fatalf("failed to export key set: %v", err)
+		}
+
+		var decoded interface{}
+		if err := cose.Unmarshal(keySet, &decoded); err != nil {
+			t.Fatalf("failed to decode key set: %v", err)
+		}
+		reencoded, err := cose.Marshal(decoded)
+		if err != nil {
+			t.Fatalf("failed to encode key set: %v", err)
+		}
+		if !bytes.Equal(reencoded, keySet) {
+			t.Errorf("key set is not in deterministic encoding: %x", keySet)
+		}
+	})
+}
+
+func TestStrictDecoding(t *testing.T) {
+	for name, data := range map[string]string{
+		"duplicate map keys":     "a2" + "0126" + "0127",
+		"indefinite length map":  "bf" + "0126" + "ff",
+		"indefinite length list": "9f" + "01" + "ff",
+		"indefinite byte string": "5f" + "4101" + "ff",
+	} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			encoded, _ := hex.DecodeString(data)
+			var decoded interface{}
+			if err := cose.Unmarshal(encoded, &decoded); err == nil {
+				t.Errorf("expected an error decoding %s", data)
+			}
+		})
+	}
+
+	t.Run("rejects protected headers with duplicate labels", func(t *testing.T) {
+		protected, _ := hex.DecodeString("a2" + "0126" + "0127")
+		if _, err := cose.GetProtectedHeaders(&cose.CoseSign1{Protected: protected}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
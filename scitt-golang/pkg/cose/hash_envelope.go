@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 )
 
 // Hash Algorithm Constants (for COSE Hash Envelope)
@@ -239,15 +240,13 @@ func ExtractHashEnvelopeParams(coseSign1 *CoseSign1) (*HashEnvelope, error) {
 	}
 
 	// Extract payload_hash_alg (label 258) - required
-	payloadHashAlgKey := uint64(HeaderLabelPayloadHashAlg)
-	payloadHashAlgVal, ok := headers[payloadHashAlgKey]
-	if !ok {
+	if _, ok := headers.Get(HeaderLabelPayloadHashAlg); !ok {
 		return nil, fmt.Errorf("missing payload_hash_alg (label 258) in protected headers")
 	}
 
-	payloadHashAlg, ok := payloadHashAlgVal.(int64)
+	payloadHashAlg, ok := headers.PayloadHashAlg()
 	if !ok {
-		return nil, fmt.Errorf("invalid payload_hash_alg type: expected int64")
+		return nil, fmt.Errorf("invalid payload_hash_alg type: expected an integer")
 	}
 
 	// Check payload exists
@@ -256,19 +255,8 @@ func ExtractHashEnvelopeParams(coseSign1 *CoseSign1) (*HashEnvelope, error) {
 	}
 
 	// Extract optional parameters
-	var preimageContentType, payloadLocation string
-
-	if val, ok := headers[uint64(HeaderLabelPayloadPreimageContentType)]; ok {
-		if str, ok := val.(string); ok {
-			preimageContentType = str
-		}
-	}
-
-	if val, ok := headers[uint64(HeaderLabelPayloadLocation)]; ok {
-		if str, ok := val.(string); ok {
-			payloadLocation = str
-		}
-	}
+	preimageContentType, _ := headers.PayloadPreimageContentType()
+	payloadLocation, _ := headers.PayloadLocation()
 
 	return &HashEnvelope{
 		PayloadHash:         coseSign1.Payload,
@@ -278,6 +266,44 @@ func ExtractHashEnvelopeParams(coseSign1 *CoseSign1) (*HashEnvelope, error) {
 	}, nil
 }
 
+// ParseHashAlgorithm parses a hash algorithm name (e.g. "sha-256") or
+// COSE algorithm identifier (e.g. "-16") into a COSE hash algorithm
+func ParseHashAlgorithm(name string) (int, error) {
+	switch strings.ToLower(name) {
+	case "sha-256", "sha256", "-16":
+		return HashAlgorithmSHA256, nil
+	case "sha-384", "sha384", "-43":
+		return HashAlgorithmSHA384, nil
+	case "sha-512", "sha512", "-44":
+		return HashAlgorithmSHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported hash algorithm: %s", name)
+	}
+}
+
+// HashAlgorithmName returns the canonical name of a COSE hash algorithm
+func HashAlgorithmName(algorithm int) string {
+	switch algorithm {
+	case HashAlgorithmSHA256:
+		return "sha-256"
+	case HashAlgorithmSHA384:
+		return "sha-384"
+	case HashAlgorithmSHA512:
+		return "sha-512"
+	default:
+		return fmt.Sprintf("%d", algorithm)
+	}
+}
+
+// HashAlgorithmSize returns the digest size in bytes of a COSE hash algorithm
+func HashAlgorithmSize(algorithm int) (int, error) {
+	hashAlg, err := getCryptoHashAlgorithm(algorithm)
+	if err != nil {
+		return 0, err
+	}
+	return hashAlg.Size(), nil
+}
+
 // getCryptoHashAlgorithm converts COSE hash algorithm to crypto.Hash
 func getCryptoHashAlgorithm(algorithm int) (crypto.Hash, error) {
 	switch algorithm {
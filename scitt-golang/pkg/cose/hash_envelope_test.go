@@ -324,7 +324,7 @@ func TestSignHashEnvelope(t *testing.T) {
 			t.Fatalf("failed to get headers: %v", err)
 		}
 
-		if _, ok := headers[uint64(cose.HeaderLabelCWTClaims)]; !ok {
+		if _, ok := headers.CWTClaims(); !ok {
 			t.Error("CWT claims should be in protected headers")
 		}
 	})
@@ -529,3 +529,45 @@ func TestExtractHashEnvelopeParams(t *testing.T) {
 		}
 	})
 }
+
+func TestParseHashAlgorithm(t *testing.T) {
+	t.Run("parses names and identifiers", func(t *testing.T) {
+		cases := map[string]int{
+			"sha-256": cose.HashAlgorithmSHA256,
+			"SHA-256": cose.HashAlgorithmSHA256,
+			"-16":     cose.HashAlgorithmSHA256,
+			"sha384":  cose.HashAlgorithmSHA384,
+			"sha-512": cose.HashAlgorithmSHA512,
+		}
+
+		for name, expected := range cases {
+			alg, err := cose.ParseHashAlgorithm(name)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", name, err)
+				continue
+			}
+			if alg != expected {
+				t.Errorf("%s: expected %d, got %d", name, expected, alg)
+			}
+			if cose.HashAlgorithmName(alg) == "" {
+				t.Errorf("%s: expected canonical name", name)
+			}
+		}
+	})
+
+	t.Run("rejects unknown algorithms", func(t *testing.T) {
+		if _, err := cose.ParseHashAlgorithm("md5"); err == nil {
+			t.Error("expected error for unsupported algorithm")
+		}
+	})
+
+	t.Run("reports digest sizes", func(t *testing.T) {
+		size, err := cose.HashAlgorithmSize(cose.HashAlgorithmSHA384)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if size != 48 {
+			t.Errorf("expected 48 bytes, got %d", size)
+		}
+	})
+}
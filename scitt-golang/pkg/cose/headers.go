@@ -0,0 +1,293 @@
+package cose
+
+import (
+	"fmt"
+	"math"
+)
+
+// Headers is a decoded COSE header map with normalized labels: integer labels
+// are int64, whichever integer type the CBOR decoder produced, and text labels
+// are strings. The accessors report false when a header is absent or does not
+// have the type RFC 9052 (or the defining specification) requires.
+type Headers map[interface{}]interface{}
+
+// understoodHeaders lists the header labels this package processes; a crit
+// header naming any other label is rejected
+var understoodHeaders = map[interface{}]bool{
+	int64(HeaderLabelAlg):                        true,
+	int64(HeaderLabelCrit):                       true,
+	int64(HeaderLabelContentType):                true,
+	int64(HeaderLabelKid):                        true,
+	int64(HeaderLabelCWTClaims):                  true,
+	int64(HeaderLabelTyp):                        true,
+	int64(HeaderLabelX5Chain):                    true,
+	int64(HeaderLabelPayloadHashAlg):             true,
+	int64(HeaderLabelPayloadPreimageContentType): true,
+	int64(HeaderLabelPayloadLocation):            true,
+	int64(HeaderLabelReceipts):                   true,
+	int64(HeaderLabelVerifiableDataStructure):    true,
+	int64(HeaderLabelVerifiableDataProof):        true,
+}
+
+// NewHeaders normalizes the labels of a decoded header map
+func NewHeaders(m map[interface{}]interface{}) (Headers, error) {
+	headers := make(Headers, len(m))
+	for key, value := range m {
+		label, err := normalizeLabel(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := headers[label]; ok {
+			return nil, fmt.Errorf("duplicate header label %v", label)
+		}
+		headers[label] = value
+	}
+	return headers, nil
+}
+
+// DecodeHeaders decodes an encoded header map, such as the protected header
+// bytes of a COSE structure; empty bytes decode to no headers (RFC 9052 §3)
+func DecodeHeaders(data []byte) (Headers, error) {
+	if len(data) == 0 {
+		return Headers{}, nil
+	}
+	var m map[interface{}]interface{}
+	if err := Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return NewHeaders(m)
+}
+
+// GetUnprotectedHeaders returns the unprotected headers of a COSE Sign1 with normalized labels
+func GetUnprotectedHeaders(coseSign1 *CoseSign1) (Headers, error) {
+	headers, err := NewHeaders(coseSign1.Unprotected)
+	if err != nil {
+		return nil, fmt.Errorf("invalid unprotected headers: %w", err)
+	}
+	return headers, nil
+}
+
+// Get returns the value of a header label
+func (h Headers) Get(label int64) (interface{}, bool) {
+	value, ok := h[label]
+	return value, ok
+}
+
+// Int returns an integer header value
+func (h Headers) Int(label int64) (int64, bool) {
+	return ToInt64(h[label])
+}
+
+// Text returns a text string header value
+func (h Headers) Text(label int64) (string, bool) {
+	value, ok := h[label].(string)
+	return value, ok
+}
+
+// Bytes returns a byte string header value
+func (h Headers) Bytes(label int64) ([]byte, bool) {
+	value, ok := h[label].([]byte)
+	return value, ok
+}
+
+// Map returns a map header value with normalized labels
+func (h Headers) Map(label int64) (Headers, bool) {
+	value, ok := h[label].(map[interface{}]interface{})
+	if !ok {
+		return nil, false
+	}
+	headers, err := NewHeaders(value)
+	if err != nil {
+		return nil, false
+	}
+	return headers, true
+}
+
+// Alg returns the algorithm identifier (label 1)
+func (h Headers) Alg() (int64, bool) {
+	return h.Int(HeaderLabelAlg)
+}
+
+// Crit returns the labels of the critical headers (label 2)
+func (h Headers) Crit() ([]interface{}, bool) {
+	values, ok := h[int64(HeaderLabelCrit)].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	labels := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		label, err := normalizeLabel(value)
+		if err != nil {
+			return nil, false
+		}
+		labels = append(labels, label)
+	}
+	return labels, true
+}
+
+// ContentType returns the content type (label 3) when given as a media type
+func (h Headers) ContentType() (string, bool) {
+	return h.Text(HeaderLabelContentType)
+}
+
+// Kid returns the key identifier (label 4); text identifiers are returned as their bytes
+func (h Headers) Kid() ([]byte, bool) {
+	switch kid := h[int64(HeaderLabelKid)].(type) {
+	case []byte:
+		return kid, true
+	case string:
+		return []byte(kid), true
+	default:
+		return nil, false
+	}
+}
+
+// Typ returns the media type of the COSE object (label 16)
+func (h Headers) Typ() (string, bool) {
+	return h.Text(HeaderLabelTyp)
+}
+
+// CWTClaims returns the CWT claims set (label 15) with normalized claim keys
+func (h Headers) CWTClaims() (CWTClaimsSet, bool) {
+	claims, ok := h.Map(HeaderLabelCWTClaims)
+	return CWTClaimsSet(claims), ok
+}
+
+// X5Chain returns the X.509 certificate chain (label 33), leaf first
+func (h Headers) X5Chain() ([][]byte, bool) {
+	return h.byteStrings(HeaderLabelX5Chain)
+}
+
+// PayloadHashAlg returns the hash envelope payload hash algorithm (label 258)
+func (h Headers) PayloadHashAlg() (int64, bool) {
+	return h.Int(HeaderLabelPayloadHashAlg)
+}
+
+// PayloadPreimageContentType returns the content type of the hashed payload (label 259)
+func (h Headers) PayloadPreimageContentType() (string, bool) {
+	return h.Text(HeaderLabelPayloadPreimageContentType)
+}
+
+// PayloadLocation returns the location of the hashed payload (label 260)
+func (h Headers) PayloadLocation() (string, bool) {
+	return h.Text(HeaderLabelPayloadLocation)
+}
+
+// Receipts returns the encoded receipts (label 394)
+func (h Headers) Receipts() ([][]byte, bool) {
+	values, ok := h[int64(HeaderLabelReceipts)].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	return byteStringArray(values)
+}
+
+// VerifiableDataStructure returns the verifiable data structure algorithm (label 395)
+func (h Headers) VerifiableDataStructure() (int64, bool) {
+	return h.Int(HeaderLabelVerifiableDataStructure)
+}
+
+// VerifiableDataProofs returns the verifiable data proofs map (label 396)
+// Inclusion proofs are at key -1 and consistency proofs at key -2
+func (h Headers) VerifiableDataProofs() (Headers, bool) {
+	return h.Map(HeaderLabelVerifiableDataProof)
+}
+
+// CheckCritical enforces the crit header (RFC 9052 §3.1) of protected headers:
+// it must be a non-empty array of labels present in the protected headers, and
+// each label must be one this package processes
+func (h Headers) CheckCritical() error {
+	if _, ok := h[int64(HeaderLabelCrit)]; !ok {
+		return nil
+	}
+	labels, ok := h.Crit()
+	if !ok || len(labels) == 0 {
+		return fmt.Errorf("crit header must be a non-empty array of header labels")
+	}
+	for _, label := range labels {
+		if _, ok := h[label]; !ok {
+			return fmt.Errorf("critical header %v is not in the protected headers", label)
+		}
+		if !understoodHeaders[label] {
+			return fmt.Errorf("critical header %v is not supported", label)
+		}
+	}
+	return nil
+}
+
+// byteStrings returns a header holding a byte string or an array of byte strings
+func (h Headers) byteStrings(label int64) ([][]byte, bool) {
+	switch value := h[label].(type) {
+	case []byte:
+		return [][]byte{value}, true
+	case []interface{}:
+		return byteStringArray(value)
+	default:
+		return nil, false
+	}
+}
+
+// Iss returns the issuer claim of a decoded claims set
+func (c CWTClaimsSet) Iss() (string, bool) {
+	return Headers(c).Text(CWTClaimIss)
+}
+
+// Sub returns the subject claim of a decoded claims set
+func (c CWTClaimsSet) Sub() (string, bool) {
+	return Headers(c).Text(CWTClaimSub)
+}
+
+// Iat returns the issued at claim of a decoded claims set, in seconds since the epoch
+func (c CWTClaimsSet) Iat() (int64, bool) {
+	return Headers(c).Int(CWTClaimIat)
+}
+
+// Cti returns the CWT ID claim of a decoded claims set
+func (c CWTClaimsSet) Cti() ([]byte, bool) {
+	return Headers(c).Bytes(CWTClaimCti)
+}
+
+// normalizeLabel converts a decoded header label to int64 or string
+func normalizeLabel(key interface{}) (interface{}, error) {
+	if label, ok := key.(string); ok {
+		return label, nil
+	}
+	if label, ok := ToInt64(key); ok {
+		return label, nil
+	}
+	return nil, fmt.Errorf("invalid header label %v: expected an integer or text string", key)
+}
+
+// ToInt64 converts a decoded CBOR integer to int64, rejecting values that overflow it
+func ToInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// byteStringArray converts a decoded array of byte strings
+func byteStringArray(values []interface{}) ([][]byte, bool) {
+	result := make([][]byte, 0, len(values))
+	for _, value := range values {
+		b, ok := value.([]byte)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, b)
+	}
+	return result, true
+}
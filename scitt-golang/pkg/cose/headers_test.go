@@ -0,0 +1,226 @@
+package cose_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+)
+
+func TestHeaders(t *testing.T) {
+	t.Run("normalizes integer labels to int64", func(t *testing.T) {
+		headers, err := cose.NewHeaders(map[interface{}]interface{}{
+			uint64(cose.HeaderLabelAlg): int64(cose.AlgorithmES256),
+			int(cose.HeaderLabelKid):    []byte("kid"),
+			int64(-70000):               "negative",
+			"text":                      "label",
+		})
+		if err != nil {
+			t.Fatalf("failed to normalize headers: %v", err)
+		}
+
+		for _, label := range []interface{}{int64(cose.HeaderLabelAlg), int64(cose.HeaderLabelKid), int64(-70000), "text"} {
+			if _, ok := headers[label]; !ok {
+				t.Errorf("label %v (%T) not found in %v", label, label, headers)
+			}
+		}
+	})
+
+	t.Run("rejects invalid and duplicate labels", func(t *testing.T) {
+		if _, err := cose.NewHeaders(map[interface{}]interface{}{true: 1}); err == nil {
+			t.Error("expected an error for a boolean label")
+		}
+		if _, err := cose.NewHeaders(map[interface{}]interface{}{uint64(1): -7, int64(1): -7}); err == nil {
+			t.Error("expected an error for a label given twice")
+		}
+	})
+
+	t.Run("rejects integers beyond int64", func(t *testing.T) {
+		if n, ok := cose.ToInt64(uint64(1) << 63); ok {
+			t.Errorf("expected overflow to be rejected, got %d", n)
+		}
+		if n, ok := cose.ToInt64(uint64(42)); !ok || n != 42 {
+			t.Errorf("expected 42, got %d", n)
+		}
+	})
+
+	t.Run("reads typed header values", func(t *testing.T) {
+		protected, err := cose.Marshal(map[interface{}]interface{}{
+			cose.HeaderLabelAlg:                        cose.AlgorithmES256,
+			cose.HeaderLabelContentType:                "application/json",
+			cose.HeaderLabelKid:                        []byte("kid"),
+			cose.HeaderLabelTyp:                        "application/example+cose",
+			cose.HeaderLabelX5Chain:                    []byte("leaf"),
+			cose.HeaderLabelPayloadHashAlg:             cose.HashAlgorithmSHA256,
+			cose.HeaderLabelPayloadPreimageContentType: "text/plain",
+			cose.HeaderLabelPayloadLocation:            "https://example.com/artifact",
+			cose.HeaderLabelReceipts:                   []interface{}{[]byte("receipt")},
+			cose.HeaderLabelVerifiableDataStructure:    1,
+			cose.HeaderLabelVerifiableDataProof:        map[interface{}]interface{}{-1: []byte("proof")},
+			cose.HeaderLabelCWTClaims: cose.CreateCWTClaims(cose.CWTClaimsOptions{
+				Iss: "https://issuer.example.com",
+				Sub: "artifact",
+				Iat: 1700000000,
+				Cti: []byte("cti"),
+			}),
+		})
+		if err != nil {
+			t.Fatalf("failed to encode headers: %v", err)
+		}
+		headers, err := cose.DecodeHeaders(protected)
+		if err != nil {
+			t.Fatalf("failed to decode headers: %v", err)
+		}
+
+		if alg, ok := headers.Alg(); !ok || alg != cose.AlgorithmES256 {
+			t.Errorf("unexpected alg: %v", alg)
+		}
+		if cty, ok := headers.ContentType(); !ok || cty != "application/json" {
+			t.Errorf("unexpected cty: %v", cty)
+		}
+		if kid, ok := headers.Kid(); !ok || string(kid) != "kid" {
+			t.Errorf("unexpected kid: %v", kid)
+		}
+		if typ, ok := headers.Typ(); !ok || typ != "application/example+cose" {
+			t.Errorf("unexpected typ: %v", typ)
+		}
+		if chain, ok := headers.X5Chain(); !ok || len(chain) != 1 || string(chain[0]) != "leaf" {
+			t.Errorf("unexpected x5chain: %v", chain)
+		}
+		if hashAlg, ok := headers.PayloadHashAlg(); !ok || hashAlg != cose.HashAlgorithmSHA256 {
+			t.Errorf("unexpected payload hash alg: %v", hashAlg)
+		}
+		if cty, ok := headers.PayloadPreimageContentType(); !ok || cty != "text/plain" {
+			t.Errorf("unexpected preimage content type: %v", cty)
+		}
+		if location, ok := headers.PayloadLocation(); !ok || location != "https://example.com/artifact" {
+			t.Errorf("unexpected payload location: %v", location)
+		}
+		if receipts, ok := headers.Receipts(); !ok || len(receipts) != 1 || string(receipts[0]) != "receipt" {
+			t.Errorf("unexpected receipts: %v", receipts)
+		}
+		if vds, ok := headers.VerifiableDataStructure(); !ok || vds != 1 {
+			t.Errorf("unexpected verifiable data structure: %v", vds)
+		}
+		vdp, ok := headers.VerifiableDataProofs()
+		if !ok {
+			t.Fatal("verifiable data proofs not found")
+		}
+		if proof, ok := vdp.Bytes(-1); !ok || string(proof) != "proof" {
+			t.Errorf("unexpected inclusion proof: %v", proof)
+		}
+
+		claims, ok := headers.CWTClaims()
+		if !ok {
+			t.Fatal("CWT claims not found")
+		}
+		if iss, ok := claims.Iss(); !ok || iss != "https://issuer.example.com" {
+			t.Errorf("unexpected iss: %v", iss)
+		}
+		if sub, ok := claims.Sub(); !ok || sub != "artifact" {
+			t.Errorf("unexpected sub: %v", sub)
+		}
+		if iat, ok := claims.Iat(); !ok || iat != 1700000000 {
+			t.Errorf("unexpected iat: %v", iat)
+		}
+		if cti, ok := claims.Cti(); !ok || !bytes.Equal(cti, []byte("cti")) {
+			t.Errorf("unexpected cti: %v", cti)
+		}
+	})
+
+	t.Run("reports headers of the wrong type as absent", func(t *testing.T) {
+		headers, _ := cose.NewHeaders(map[interface{}]interface{}{
+			uint64(cose.HeaderLabelAlg):            "ES256",
+			uint64(cose.HeaderLabelPayloadHashAlg): "sha-256",
+		})
+		if _, ok := headers.Alg(); ok {
+			t.Error("expected a text alg to be rejected")
+		}
+		if _, ok := headers.PayloadHashAlg(); ok {
+			t.Error("expected a text payload hash alg to be rejected")
+		}
+		if _, ok := headers.Get(cose.HeaderLabelAlg); !ok {
+			t.Error("expected the raw alg value to be present")
+		}
+	})
+
+	t.Run("decodes empty protected headers", func(t *testing.T) {
+		headers, err := cose.DecodeHeaders(nil)
+		if err != nil || len(headers) != 0 {
+			t.Errorf("expected no headers, got %v, %v", headers, err)
+		}
+	})
+}
+
+func TestCriticalHeaders(t *testing.T) {
+	keyPair, _ := cose.GenerateES256KeyPair()
+	signer, _ := cose.NewES256Signer(keyPair.Private)
+	verifier, _ := cose.NewES256Verifier(keyPair.Public)
+
+	sign := func(t *testing.T, extra map[interface{}]interface{}) *cose.CoseSign1 {
+		t.Helper()
+		headers := cose.CreateProtectedHeaders(cose.ProtectedHeadersOptions{Alg: cose.AlgorithmES256})
+		for label, value := range extra {
+			headers[label] = value
+		}
+		signed, err := cose.CreateCoseSign1(headers, []byte("payload"), signer, cose.CoseSign1Options{})
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		return signed
+	}
+
+	t.Run("accepts understood critical headers", func(t *testing.T) {
+		signed := sign(t, map[interface{}]interface{}{
+			cose.HeaderLabelCrit: []interface{}{cose.HeaderLabelTyp},
+			cose.HeaderLabelTyp:  "application/example+cose",
+		})
+		valid, err := cose.VerifyCoseSign1(signed, verifier, nil)
+		if err != nil || !valid {
+			t.Errorf("expected the signature to verify: %v", err)
+		}
+	})
+
+	for name, extra := range map[string]map[interface{}]interface{}{
+		"an unknown critical header": {
+			cose.HeaderLabelCrit: []interface{}{-70000},
+			-70000:               "must be understood",
+		},
+		"an unknown critical text label": {
+			cose.HeaderLabelCrit: []interface{}{"private"},
+			"private":            true,
+		},
+		"a critical header that is absent": {
+			cose.HeaderLabelCrit: []interface{}{cose.HeaderLabelTyp},
+		},
+		"an empty crit array": {
+			cose.HeaderLabelCrit: []interface{}{},
+		},
+		"a crit header that is not an array": {
+			cose.HeaderLabelCrit: cose.HeaderLabelTyp,
+		},
+	} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			signed := sign(t, extra)
+			headers, err := cose.GetProtectedHeaders(signed)
+			if err != nil {
+				t.Fatalf("failed to decode headers: %v", err)
+			}
+			if err := headers.CheckCritical(); err == nil {
+				t.Error("expected the crit header to be rejected")
+			}
+			if _, err := cose.VerifyCoseSign1(signed, verifier, nil); err == nil {
+				t.Error("expected verification to fail")
+			}
+		})
+	}
+
+	t.Run("rejects protected headers with labels that are not integers or text", func(t *testing.T) {
+		// {true: 1}
+		protected, _ := hex.DecodeString("a1" + "f5" + "01")
+		if _, err := cose.GetProtectedHeaders(&cose.CoseSign1{Protected: protected}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
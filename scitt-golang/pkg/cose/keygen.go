@@ -628,10 +628,32 @@ func ExportCOSEKeySetToCBOR(publicKeys []*ecdsa.PublicKey) ([]byte, error) {
 	}
 
 	// Marshal array of CBOR-encoded keys using fxamacker/cbor
-	cborData, err := cbor.Marshal(coseKeysCBOR)
+	cborData, err := Marshal(coseKeysCBOR)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal COSE key set to CBOR: %w", err)
 	}
 
 	return cborData, nil
 }
+
+// ImportCOSEKeySetFromCBOR imports the public keys of a COSE Key Set (array of COSE_Keys)
+func ImportCOSEKeySetFromCBOR(cborData []byte) ([]*ecdsa.PublicKey, error) {
+	var coseKeysCBOR []cbor.RawMessage
+	if err := Unmarshal(cborData, &coseKeysCBOR); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal COSE key set: %w", err)
+	}
+	if len(coseKeysCBOR) == 0 {
+		return nil, errors.New("COSE key set is empty")
+	}
+
+	publicKeys := make([]*ecdsa.PublicKey, 0, len(coseKeysCBOR))
+	for _, keyCBOR := range coseKeysCBOR {
+		publicKey, err := ImportPublicKeyFromCOSECBOR(keyCBOR)
+		if err != nil {
+			return nil, err
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	return publicKeys, nil
+}
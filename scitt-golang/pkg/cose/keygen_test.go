@@ -2,6 +2,7 @@ package cose_test
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/elliptic"
 	"encoding/json"
 	"strings"
@@ -782,3 +783,35 @@ func TestImportPublicKeyFromCOSECBOR(t *testing.T) {
 		}
 	})
 }
+
+func TestImportCOSEKeySetFromCBOR(t *testing.T) {
+	first, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	second, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+
+	t.Run("round trips a key set", func(t *testing.T) {
+		cborData, err := cose.ExportCOSEKeySetToCBOR([]*ecdsa.PublicKey{first.Public, second.Public})
+		if err != nil {
+			t.Fatalf("failed to export key set: %v", err)
+		}
+
+		imported, err := cose.ImportCOSEKeySetFromCBOR(cborData)
+		if err != nil {
+			t.Fatalf("failed to import key set: %v", err)
+		}
+		if len(imported) != 2 || !imported[0].Equal(first.Public) || !imported[1].Equal(second.Public) {
+			t.Error("imported keys do not match the exported keys")
+		}
+	})
+
+	t.Run("rejects invalid CBOR data", func(t *testing.T) {
+		if _, err := cose.ImportCOSEKeySetFromCBOR([]byte{0xff, 0xff}); err == nil {
+			t.Error("expected error for invalid CBOR data")
+		}
+	})
+}
@@ -2,8 +2,6 @@ package cose
 
 import (
 	"fmt"
-
-	"github.com/fxamacker/cbor/v2"
 )
 
 // COSE Header Label constants (RFC 9052)
@@ -17,6 +15,7 @@ const (
 	HeaderLabelCounterSig     = 7  // Counter signature
 	HeaderLabelCWTClaims      = 15 // CWT Claims Set (RFC 9597)
 	HeaderLabelTyp            = 16 // Type (media type of content)
+	HeaderLabelX5Chain        = 33 // X.509 certificate chain (RFC 9360)
 	HeaderLabelIss            = 391 // Issuer (SCITT specific)
 	HeaderLabelSub            = 392 // Subject (SCITT specific)
 	HeaderLabelPayloadHashAlg = 258 // Hash algorithm for payload
@@ -173,8 +172,8 @@ func CreateCoseSign1(
 	signer Signer,
 	options CoseSign1Options,
 ) (*CoseSign1, error) {
-	// Encode protected headers to CBOR
-	protectedEncoded, err := cbor.Marshal(protectedHeaders)
+	// Encode protected headers to core deterministic CBOR
+	protectedEncoded, err := Marshal(protectedHeaders)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode protected headers: %w", err)
 	}
@@ -193,7 +192,7 @@ func CreateCoseSign1(
 		payload,
 	}
 
-	toBeSigned, err := cbor.Marshal(sigStructure)
+	toBeSigned, err := Marshal(sigStructure)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode Sig_structure: %w", err)
 	}
@@ -221,8 +220,9 @@ func CreateCoseSign1(
 // VerifyCoseSign1 verifies a COSE Sign1 signature
 //
 // This function:
-// 1. Reconstructs the Sig_structure from the COSE Sign1
-// 2. Verifies the signature using the provided verifier
+// 1. Rejects critical headers it does not understand
+// 2. Reconstructs the Sig_structure from the COSE Sign1
+// 3. Verifies the signature using the provided verifier
 //
 // Parameters:
 //   - coseSign1: COSE Sign1 structure to verify
@@ -236,6 +236,15 @@ func VerifyCoseSign1(
 	verifier Verifier,
 	externalPayload []byte,
 ) (bool, error) {
+	// Reject critical headers that cannot be processed
+	headers, err := GetProtectedHeaders(coseSign1)
+	if err != nil {
+		return false, err
+	}
+	if err := headers.CheckCritical(); err != nil {
+		return false, err
+	}
+
 	// Determine payload to verify
 	payload := coseSign1.Payload
 	if payload == nil {
@@ -253,7 +262,7 @@ func VerifyCoseSign1(
 		payload,
 	}
 
-	toBeSigned, err := cbor.Marshal(sigStructure)
+	toBeSigned, err := Marshal(sigStructure)
 	if err != nil {
 		return false, fmt.Errorf("failed to encode Sig_structure: %w", err)
 	}
@@ -263,9 +272,9 @@ func VerifyCoseSign1(
 }
 
 // GetProtectedHeaders decodes and returns the protected headers from COSE Sign1
-func GetProtectedHeaders(coseSign1 *CoseSign1) (ProtectedHeaders, error) {
-	var headers ProtectedHeaders
-	if err := cbor.Unmarshal(coseSign1.Protected, &headers); err != nil {
+func GetProtectedHeaders(coseSign1 *CoseSign1) (Headers, error) {
+	headers, err := DecodeHeaders(coseSign1.Protected)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode protected headers: %w", err)
 	}
 	return headers, nil
@@ -287,7 +296,7 @@ func EncodeCoseSign1(coseSign1 *CoseSign1) ([]byte, error) {
 		coseSign1.Signature,
 	}
 
-	encoded, err := cbor.Marshal(coseArray)
+	encoded, err := Marshal(coseArray)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode COSE Sign1: %w", err)
 	}
@@ -302,7 +311,7 @@ func EncodeCoseSign1(coseSign1 *CoseSign1) ([]byte, error) {
 func DecodeCoseSign1(encoded []byte) (*CoseSign1, error) {
 	// Try to decode as array first
 	var coseArray []interface{}
-	if err := cbor.Unmarshal(encoded, &coseArray); err != nil {
+	if err := Unmarshal(encoded, &coseArray); err != nil {
 		return nil, fmt.Errorf("failed to decode COSE Sign1: %w", err)
 	}
 
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// APIKey represents a stored client credential
+// Only a salted hash of the secret is stored; the secret itself is never persisted
+type APIKey struct {
+	KeyID          string     `json:"key_id"`
+	Name           string     `json:"name"`
+	Salt           string     `json:"-"`
+	KeyHash        string     `json:"-"`
+	Scopes         []string   `json:"scopes"`
+	AllowedIssuers []string   `json:"allowed_issuers,omitempty"` // Empty allows any issuer
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+}
+
+// InsertAPIKey stores a new API key
+func InsertAPIKey(db *sql.DB, key APIKey) error {
+	defer observeQuery("insert_api_key")()
+
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to encode scopes: %w", err)
+	}
+
+	var allowedIssuers *string
+	if len(key.AllowedIssuers) > 0 {
+		encoded, err := json.Marshal(key.AllowedIssuers)
+		if err != nil {
+			return fmt.Errorf("failed to encode allowed issuers: %w", err)
+		}
+		value := string(encoded)
+		allowedIssuers = &value
+	}
+
+	var expiresAt *time.Time
+	if key.ExpiresAt != nil {
+		utc := key.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO api_keys (
+			key_id, name, salt, key_hash, scopes, allowed_issuers, expires_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, key.KeyID, key.Name, key.Salt, key.KeyHash, string(scopes), allowedIssuers, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKey retrieves an API key by its key ID
+// Returns nil if the key does not exist
+func GetAPIKey(db *sql.DB, keyID string) (*APIKey, error) {
+	defer observeQuery("get_api_key")()
+
+	row := db.QueryRow(`
+		SELECT key_id, name, salt, key_hash, scopes, allowed_issuers,
+		       created_at, expires_at, revoked_at
+		FROM api_keys WHERE key_id = ?
+	`, keyID)
+
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys returns all API keys, including revoked and expired keys
+func ListAPIKeys(db *sql.DB) ([]APIKey, error) {
+	defer observeQuery("list_api_keys")()
+
+	rows, err := db.Query(`
+		SELECT key_id, name, salt, key_hash, scopes, allowed_issuers,
+		       created_at, expires_at, revoked_at
+		FROM api_keys ORDER BY created_at ASC, key_id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api key rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks an API key as revoked
+func RevokeAPIKey(db *sql.DB, keyID string) error {
+	defer observeQuery("revoke_api_key")()
+
+	result, err := db.Exec(`
+		UPDATE api_keys SET revoked_at = ?
+		WHERE key_id = ? AND revoked_at IS NULL
+	`, time.Now().UTC(), keyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("api key not found or already revoked: %s", keyID)
+	}
+
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAPIKey scans a single api_keys row
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	var key APIKey
+	var scopes string
+	var allowedIssuers sql.NullString
+	var expiresAt, revokedAt sql.NullTime
+
+	if err := row.Scan(
+		&key.KeyID,
+		&key.Name,
+		&key.Salt,
+		&key.KeyHash,
+		&scopes,
+		&allowedIssuers,
+		&key.CreatedAt,
+		&expiresAt,
+		&revokedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("invalid scopes for api key %s: %w", key.KeyID, err)
+	}
+
+	if allowedIssuers.Valid {
+		if err := json.Unmarshal([]byte(allowedIssuers.String), &key.AllowedIssuers); err != nil {
+			return nil, fmt.Errorf("invalid allowed issuers for api key %s: %w", key.KeyID, err)
+		}
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,115 @@
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+)
+
+func TestAPIKeys(t *testing.T) {
+	t.Run("inserts and retrieves api key", func(t *testing.T) {
+		db, err := database.OpenDatabase(database.DatabaseOptions{
+			Path: filepath.Join(t.TempDir(), "test.db"),
+		})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		key := database.APIKey{
+			KeyID:          "key1",
+			Name:           "Acme CI",
+			Salt:           "salt",
+			KeyHash:        "hash",
+			Scopes:         []string{"register", "read"},
+			AllowedIssuers: []string{"https://acme.example"},
+			ExpiresAt:      &expiresAt,
+		}
+
+		if err := database.InsertAPIKey(db, key); err != nil {
+			t.Fatalf("failed to insert api key: %v", err)
+		}
+
+		retrieved, err := database.GetAPIKey(db, "key1")
+		if err != nil {
+			t.Fatalf("failed to get api key: %v", err)
+		}
+		if retrieved == nil {
+			t.Fatal("expected api key")
+		}
+
+		if retrieved.Name != "Acme CI" || retrieved.KeyHash != "hash" {
+			t.Errorf("unexpected api key: %+v", retrieved)
+		}
+		if len(retrieved.Scopes) != 2 || retrieved.Scopes[1] != "read" {
+			t.Errorf("unexpected scopes: %v", retrieved.Scopes)
+		}
+		if len(retrieved.AllowedIssuers) != 1 || retrieved.AllowedIssuers[0] != "https://acme.example" {
+			t.Errorf("unexpected allowed issuers: %v", retrieved.AllowedIssuers)
+		}
+		if retrieved.ExpiresAt == nil || !retrieved.ExpiresAt.Equal(expiresAt) {
+			t.Errorf("expected expiry %s, got %v", expiresAt, retrieved.ExpiresAt)
+		}
+		if retrieved.RevokedAt != nil {
+			t.Error("expected key not to be revoked")
+		}
+	})
+
+	t.Run("returns nil for unknown key", func(t *testing.T) {
+		db, err := database.OpenDatabase(database.DatabaseOptions{
+			Path: filepath.Join(t.TempDir(), "test.db"),
+		})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+
+		key, err := database.GetAPIKey(db, "missing")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if key != nil {
+			t.Error("expected nil for unknown key")
+		}
+	})
+
+	t.Run("lists and revokes api keys", func(t *testing.T) {
+		db, err := database.OpenDatabase(database.DatabaseOptions{
+			Path: filepath.Join(t.TempDir(), "test.db"),
+		})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+
+		for _, id := range []string{"key1", "key2"} {
+			if err := database.InsertAPIKey(db, database.APIKey{
+				KeyID: id, Name: id, Salt: "salt", KeyHash: "hash", Scopes: []string{"register"},
+			}); err != nil {
+				t.Fatalf("failed to insert api key: %v", err)
+			}
+		}
+
+		if err := database.RevokeAPIKey(db, "key2"); err != nil {
+			t.Fatalf("failed to revoke api key: %v", err)
+		}
+
+		if err := database.RevokeAPIKey(db, "key2"); err == nil {
+			t.Error("expected error revoking an already revoked key")
+		}
+
+		keys, err := database.ListAPIKeys(db)
+		if err != nil {
+			t.Fatalf("failed to list api keys: %v", err)
+		}
+		if len(keys) != 2 {
+			t.Fatalf("expected 2 keys, got %d", len(keys))
+		}
+		if keys[0].RevokedAt != nil || keys[1].RevokedAt == nil {
+			t.Errorf("expected only key2 to be revoked")
+		}
+	})
+}
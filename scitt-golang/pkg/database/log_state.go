@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"time"
 )
 
 // TreeState represents the state of the Merkle tree at a specific size
@@ -16,6 +17,8 @@ type TreeState struct {
 
 // GetCurrentTreeSize returns the current size of the Merkle tree
 func GetCurrentTreeSize(db *sql.DB) (int64, error) {
+	defer observeQuery("get_current_tree_size")()
+
 	var treeSize int64
 	err := db.QueryRow("SELECT tree_size FROM current_tree_size WHERE id = 1").Scan(&treeSize)
 	if err != nil {
@@ -27,8 +30,22 @@ func GetCurrentTreeSize(db *sql.DB) (int64, error) {
 	return treeSize, nil
 }
 
+// GetCurrentTreeSizeUpdatedAt returns when the current tree size was last changed
+func GetCurrentTreeSizeUpdatedAt(db *sql.DB) (time.Time, error) {
+	defer observeQuery("get_current_tree_size_updated_at")()
+
+	var updatedAt sql.NullTime
+	err := db.QueryRow("SELECT last_updated FROM current_tree_size WHERE id = 1").Scan(&updatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return time.Time{}, fmt.Errorf("failed to get tree size update time: %w", err)
+	}
+	return updatedAt.Time, nil
+}
+
 // UpdateTreeSize updates the current tree size
 func UpdateTreeSize(db *sql.DB, newSize int64) error {
+	defer observeQuery("update_tree_size")()
+
 	_, err := db.Exec(`
 		UPDATE current_tree_size
 		SET tree_size = ?, last_updated = CURRENT_TIMESTAMP
@@ -49,6 +66,8 @@ func SetCurrentTreeSize(db *sql.DB, newSize int64) error {
 
 // RecordTreeState records the tree state at a specific size (for checkpoints)
 func RecordTreeState(db *sql.DB, state TreeState) error {
+	defer observeQuery("record_tree_state")()
+
 	_, err := db.Exec(`
 		INSERT INTO tree_state (
 			tree_size, root_hash, checkpoint_storage_key, checkpoint_signed_note
@@ -62,8 +81,33 @@ func RecordTreeState(db *sql.DB, state TreeState) error {
 	return nil
 }
 
+// SaveTreeState records the tree state at a specific size, replacing any
+// checkpoint previously recorded for that size (e.g. with fewer cosignatures)
+func SaveTreeState(db *sql.DB, state TreeState) error {
+	defer observeQuery("save_tree_state")()
+
+	_, err := db.Exec(`
+		INSERT INTO tree_state (
+			tree_size, root_hash, checkpoint_storage_key, checkpoint_signed_note
+		) VALUES (?, ?, ?, ?)
+		ON CONFLICT(tree_size) DO UPDATE SET
+			root_hash = excluded.root_hash,
+			checkpoint_storage_key = excluded.checkpoint_storage_key,
+			checkpoint_signed_note = excluded.checkpoint_signed_note,
+			updated_at = CURRENT_TIMESTAMP
+	`, state.TreeSize, state.RootHash, state.CheckpointStorageKey, state.CheckpointSignedNote)
+
+	if err != nil {
+		return fmt.Errorf("failed to save tree state: %w", err)
+	}
+
+	return nil
+}
+
 // GetTreeState retrieves the tree state for a specific size
 func GetTreeState(db *sql.DB, treeSize int64) (*TreeState, error) {
+	defer observeQuery("get_tree_state")()
+
 	var state TreeState
 	err := db.QueryRow(`
 		SELECT tree_size, root_hash, checkpoint_storage_key, checkpoint_signed_note, updated_at
@@ -89,6 +133,8 @@ func GetTreeState(db *sql.DB, treeSize int64) (*TreeState, error) {
 
 // GetTreeStateHistory returns historical tree states (most recent first)
 func GetTreeStateHistory(db *sql.DB, limit int) ([]TreeState, error) {
+	defer observeQuery("get_tree_state_history")()
+
 	query := "SELECT tree_size, root_hash, checkpoint_storage_key, checkpoint_signed_note, updated_at FROM tree_state ORDER BY tree_size DESC"
 
 	if limit > 0 {
@@ -125,6 +171,8 @@ func GetTreeStateHistory(db *sql.DB, limit int) ([]TreeState, error) {
 
 // GetLatestCheckpoint returns the most recent tree state (checkpoint)
 func GetLatestCheckpoint(db *sql.DB) (*TreeState, error) {
+	defer observeQuery("get_latest_checkpoint")()
+
 	var state TreeState
 	err := db.QueryRow(`
 		SELECT tree_size, root_hash, checkpoint_storage_key, checkpoint_signed_note, updated_at
@@ -211,6 +211,45 @@ func TestRecordTreeState(t *testing.T) {
 			}
 		}
 	})
+	t.Run("save replaces the checkpoint at the same size", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dbPath := filepath.Join(tmpDir, "test.db")
+
+		db, err := database.OpenDatabase(database.DatabaseOptions{
+			Path:      dbPath,
+			EnableWAL: false,
+		})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+
+		state := database.TreeState{
+			TreeSize:             100,
+			RootHash:             "abc123",
+			CheckpointStorageKey: "checkpoints/100",
+			CheckpointSignedNote: "signed note",
+		}
+		if err := database.RecordTreeState(db, state); err != nil {
+			t.Fatalf("failed to record tree state: %v", err)
+		}
+		if err := database.RecordTreeState(db, state); err == nil {
+			t.Error("recording the same size twice should fail")
+		}
+
+		state.CheckpointSignedNote = "signed note\n— witness cosignature"
+		if err := database.SaveTreeState(db, state); err != nil {
+			t.Fatalf("failed to save tree state: %v", err)
+		}
+
+		retrieved, err := database.GetTreeState(db, 100)
+		if err != nil {
+			t.Fatalf("failed to get tree state: %v", err)
+		}
+		if retrieved.CheckpointSignedNote != state.CheckpointSignedNote {
+			t.Errorf("expected updated signed note, got %q", retrieved.CheckpointSignedNote)
+		}
+	})
 }
 
 func TestGetTreeState(t *testing.T) {
@@ -0,0 +1,24 @@
+package database
+
+import (
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/metrics"
+)
+
+var queryDuration = metrics.NewHistogramVec(
+	"scitt_database_query_duration_seconds",
+	"Latency of SQLite queries by operation.",
+	metrics.DefaultBuckets,
+	"query",
+)
+
+// observeQuery starts timing a query; call the returned function when it completes
+//
+//	defer observeQuery("get_statement_by_hash")()
+func observeQuery(query string) func() {
+	start := time.Now()
+	return func() {
+		queryDuration.WithLabelValues(query).Observe(metrics.Since(start))
+	}
+}
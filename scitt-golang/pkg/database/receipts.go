@@ -0,0 +1,68 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Receipt points to a receipt issued for a statement and kept in storage
+type Receipt struct {
+	EntryID     int64  `json:"entry_id"`     // statements row the receipt was issued for
+	ReceiptHash string `json:"receipt_hash"` // Hex SHA-256 of the receipt bytes
+	StorageKey  string `json:"storage_key"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	TreeSize    int64  `json:"tree_size"`  // Tree size the receipt proves inclusion in
+	LeafIndex   int64  `json:"leaf_index"` // Index of the statement's leaf in the log
+}
+
+// SaveReceipt records the receipt of a statement, replacing any receipt
+// previously recorded for it (e.g. against a smaller tree)
+func SaveReceipt(db *sql.DB, receipt Receipt) error {
+	defer observeQuery("save_receipt")()
+
+	_, err := db.Exec(`
+		INSERT INTO receipts (
+			entry_id, receipt_hash, storage_key, tree_size, leaf_index
+		) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(entry_id) DO UPDATE SET
+			receipt_hash = excluded.receipt_hash,
+			storage_key = excluded.storage_key,
+			tree_size = excluded.tree_size,
+			leaf_index = excluded.leaf_index,
+			created_at = CURRENT_TIMESTAMP
+	`, receipt.EntryID, receipt.ReceiptHash, receipt.StorageKey, receipt.TreeSize, receipt.LeafIndex)
+
+	if err != nil {
+		return fmt.Errorf("failed to save receipt: %w", err)
+	}
+
+	return nil
+}
+
+// GetReceiptByLeafIndex retrieves the receipt recorded for the leaf at an index
+func GetReceiptByLeafIndex(db *sql.DB, leafIndex int64) (*Receipt, error) {
+	defer observeQuery("get_receipt_by_leaf_index")()
+
+	var receipt Receipt
+	err := db.QueryRow(`
+		SELECT entry_id, receipt_hash, storage_key, created_at, tree_size, leaf_index
+		FROM receipts
+		WHERE leaf_index = ?
+	`, leafIndex).Scan(
+		&receipt.EntryID,
+		&receipt.ReceiptHash,
+		&receipt.StorageKey,
+		&receipt.CreatedAt,
+		&receipt.TreeSize,
+		&receipt.LeafIndex,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+
+	return &receipt, nil
+}
@@ -0,0 +1,73 @@
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+)
+
+func TestReceipts(t *testing.T) {
+	db, err := database.OpenDatabase(database.DatabaseOptions{
+		Path: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.CloseDatabase(db)
+
+	rowID, err := database.InsertStatement(db, database.Statement{
+		StatementHash:          "hash123",
+		Iss:                    "https://issuer.example.com",
+		PayloadHashAlg:         -16,
+		PayloadHash:            "payload-hash",
+		TreeSizeAtRegistration: 5,
+		EntryTileKey:           "tile/entries/000",
+		EntryTileOffset:        5,
+	})
+	if err != nil {
+		t.Fatalf("failed to insert statement: %v", err)
+	}
+
+	t.Run("finds a statement by entry tile position", func(t *testing.T) {
+		stmt, err := database.GetStatementByEntryTile(db, "tile/entries/000", 5)
+		if err != nil || stmt == nil || stmt.EntryID != rowID {
+			t.Fatalf("expected statement %d, got %+v (%v)", rowID, stmt, err)
+		}
+		if stmt, err := database.GetStatementByEntryTile(db, "tile/entries/000", 6); err != nil || stmt != nil {
+			t.Errorf("expected no statement, got %+v (%v)", stmt, err)
+		}
+	})
+
+	t.Run("records and replaces a statement's receipt", func(t *testing.T) {
+		if record, err := database.GetReceiptByLeafIndex(db, 5); err != nil || record != nil {
+			t.Fatalf("expected no receipt, got %+v (%v)", record, err)
+		}
+
+		receipt := database.Receipt{EntryID: rowID, ReceiptHash: "aa", StorageKey: "receipts/5", TreeSize: 6, LeafIndex: 5}
+		if err := database.SaveReceipt(db, receipt); err != nil {
+			t.Fatalf("failed to save receipt: %v", err)
+		}
+		receipt.ReceiptHash, receipt.TreeSize = "bb", 9
+		if err := database.SaveReceipt(db, receipt); err != nil {
+			t.Fatalf("failed to replace receipt: %v", err)
+		}
+
+		record, err := database.GetReceiptByLeafIndex(db, 5)
+		if err != nil || record == nil {
+			t.Fatalf("expected a receipt: %v", err)
+		}
+		if record.ReceiptHash != "bb" || record.TreeSize != 9 || record.StorageKey != "receipts/5" {
+			t.Errorf("expected the replaced receipt, got %+v", record)
+		}
+	})
+
+	t.Run("drops receipts when statements are replaced", func(t *testing.T) {
+		if err := database.ReplaceStatements(db, nil, 0); err != nil {
+			t.Fatalf("failed to replace statements: %v", err)
+		}
+		if record, err := database.GetReceiptByLeafIndex(db, 5); err != nil || record != nil {
+			t.Errorf("expected no receipt, got %+v (%v)", record, err)
+		}
+	})
+}
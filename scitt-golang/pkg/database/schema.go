@@ -2,6 +2,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -65,18 +66,24 @@ func initializeSchema(db *sql.DB) error {
 		return fmt.Errorf("failed to create schema_version table: %w", err)
 	}
 
-	// Check if schema is already initialized
-	var currentVersion sql.NullString
-	err := db.QueryRow("SELECT version FROM schema_version ORDER BY applied_at DESC LIMIT 1").Scan(&currentVersion)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("failed to check schema version: %w", err)
+	// Check if the base schema is already initialized
+	initialized, err := hasSchemaVersion(db, "1.0.0")
+	if err != nil {
+		return err
 	}
 
-	if currentVersion.Valid && currentVersion.String == "1.0.0" {
-		// Schema already initialized
-		return nil
+	if !initialized {
+		if err := createBaseSchema(db); err != nil {
+			return err
+		}
 	}
 
+	// Apply incremental migrations on top of the base schema
+	return applyMigrations(db)
+}
+
+// createBaseSchema creates the version 1.0.0 tables, indexes, and initial data
+func createBaseSchema(db *sql.DB) error {
 	// Statements table: Metadata for registered signed statements
 	if _, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS statements (
@@ -246,6 +253,131 @@ func initializeSchema(db *sql.DB) error {
 	return nil
 }
 
+// schemaMigration is an incremental schema change applied after the base schema
+type schemaMigration struct {
+	version string
+	apply   func(tx *sql.Tx) error
+}
+
+// schemaMigrations lists migrations in the order they must be applied
+var schemaMigrations = []schemaMigration{
+	{version: "1.1.0", apply: migrateNonUniqueStatementHash},
+	{version: "1.2.0", apply: migratePayloadHashIndex},
+	{version: "1.3.0", apply: migrateAPIKeys},
+	{version: "1.4.0", apply: migrateShards},
+	{version: "1.5.0", apply: migrateReceiptIndexes},
+}
+
+// hasSchemaVersion reports whether a schema version has been recorded
+func hasSchemaVersion(db *sql.DB, version string) (bool, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_version WHERE version = ?", version).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check schema version: %w", err)
+	}
+	return count > 0, nil
+}
+
+// applyMigrations applies every migration that has not been recorded yet
+//
+// Each migration runs in its own transaction on a dedicated connection with
+// foreign keys disabled, so that tables can be rebuilt (SQLite cannot drop
+// constraints in place).
+func applyMigrations(db *sql.DB) error {
+	for _, migration := range schemaMigrations {
+		applied, err := hasSchemaVersion(db, migration.version)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		if err := applyMigration(db, migration); err != nil {
+			return fmt.Errorf("failed to apply schema migration %s: %w", migration.version, err)
+		}
+	}
+
+	return nil
+}
+
+// applyMigration runs a single migration and records its version
+func applyMigration(db *sql.DB, migration schemaMigration) error {
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := migration.apply(tx); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?)", migration.version); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateNonUniqueStatementHash drops the UNIQUE constraint on statements.statement_hash
+//
+// Duplicate detection is performed by the service before appending to the log,
+// and intentional duplicates (allowed by registration policy) need their own rows.
+func migrateNonUniqueStatementHash(tx *sql.Tx) error {
+	statements := []string{
+		`CREATE TABLE statements_new (
+			entry_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			statement_hash TEXT NOT NULL,
+
+			iss TEXT NOT NULL,
+			sub TEXT,
+			cty TEXT,
+			typ TEXT,
+
+			payload_hash_alg INTEGER NOT NULL,
+			payload_hash TEXT NOT NULL,
+			preimage_content_type TEXT,
+			payload_location TEXT,
+
+			registered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			tree_size_at_registration INTEGER NOT NULL,
+
+			entry_tile_key TEXT NOT NULL,
+			entry_tile_offset INTEGER NOT NULL
+		)`,
+		"INSERT INTO statements_new SELECT * FROM statements",
+		"DROP TABLE statements",
+		"ALTER TABLE statements_new RENAME TO statements",
+		"CREATE INDEX IF NOT EXISTS idx_statements_iss ON statements(iss)",
+		"CREATE INDEX IF NOT EXISTS idx_statements_sub ON statements(sub)",
+		"CREATE INDEX IF NOT EXISTS idx_statements_cty ON statements(cty)",
+		"CREATE INDEX IF NOT EXISTS idx_statements_typ ON statements(typ)",
+		"CREATE INDEX IF NOT EXISTS idx_statements_registered_at ON statements(registered_at)",
+		"CREATE INDEX IF NOT EXISTS idx_statements_hash ON statements(statement_hash)",
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("failed to rebuild statements table: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // enableWAL enables Write-Ahead Logging mode
 // Improves concurrent read/write performance
 func enableWAL(db *sql.DB) error {
@@ -264,7 +396,82 @@ func enableWAL(db *sql.DB) error {
 	return nil
 }
 
-// CloseDatabase closes the database connection
+// CloseDatabase flushes the write-ahead log and closes the database connection
 func CloseDatabase(db *sql.DB) error {
+	// Best effort: fold the WAL back into the main database file so the
+	// database is self-contained on disk after a clean shutdown
+	db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+
 	return db.Close()
 }
+
+// migratePayloadHashIndex indexes statements by artifact digest
+func migratePayloadHashIndex(tx *sql.Tx) error {
+	if _, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_statements_payload_hash ON statements(payload_hash, payload_hash_alg)"); err != nil {
+		return fmt.Errorf("failed to create payload hash index: %w", err)
+	}
+	return nil
+}
+
+// migrateAPIKeys adds per-client API keys and records the credential used for each entry
+func migrateAPIKeys(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			key_id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+
+			salt TEXT NOT NULL,
+			key_hash TEXT NOT NULL,
+
+			scopes TEXT NOT NULL,
+			allowed_issuers TEXT,
+
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP,
+			revoked_at TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create api_keys table: %w", err)
+	}
+
+	if _, err := tx.Exec("ALTER TABLE statements ADD COLUMN credential_id TEXT"); err != nil {
+		return fmt.Errorf("failed to add credential_id column: %w", err)
+	}
+
+	if _, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_statements_credential_id ON statements(credential_id)"); err != nil {
+		return fmt.Errorf("failed to create credential index: %w", err)
+	}
+
+	return nil
+}
+
+// migrateShards adds the registry of temporal log shards
+func migrateShards(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS shards (
+			name TEXT PRIMARY KEY,
+			origin TEXT NOT NULL,
+
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			frozen_at TIMESTAMP,
+			final_tree_size INTEGER,
+			final_checkpoint TEXT
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create shards table: %w", err)
+	}
+	return nil
+}
+
+// migrateReceiptIndexes indexes stored receipts and statements by leaf position
+func migrateReceiptIndexes(tx *sql.Tx) error {
+	for _, indexSQL := range []string{
+		"CREATE INDEX IF NOT EXISTS idx_receipts_leaf_index ON receipts(leaf_index)",
+		"CREATE INDEX IF NOT EXISTS idx_statements_entry_tile ON statements(entry_tile_key, entry_tile_offset)",
+	} {
+		if _, err := tx.Exec(indexSQL); err != nil {
+			return fmt.Errorf("failed to create receipt index: %w", err)
+		}
+	}
+	return nil
+}
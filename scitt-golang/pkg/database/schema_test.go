@@ -201,6 +201,85 @@ func TestOpenDatabase(t *testing.T) {
 	})
 }
 
+func TestSchemaMigrations(t *testing.T) {
+	t.Run("allows duplicate statement hashes", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dbPath := filepath.Join(tmpDir, "test.db")
+
+		db, err := database.OpenDatabase(database.DatabaseOptions{
+			Path:      dbPath,
+			EnableWAL: false,
+		})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+
+		statement := database.Statement{
+			StatementHash:          "duplicate-hash",
+			Iss:                    "https://issuer.example.com",
+			PayloadHashAlg:         -16,
+			PayloadHash:            "payload-hash",
+			TreeSizeAtRegistration: 0,
+			EntryTileKey:           "tile/entries/000",
+			EntryTileOffset:        0,
+		}
+
+		firstID, err := database.InsertStatement(db, statement)
+		if err != nil {
+			t.Fatalf("failed to insert first statement: %v", err)
+		}
+
+		statement.TreeSizeAtRegistration = 1
+		statement.EntryTileOffset = 1
+		if _, err := database.InsertStatement(db, statement); err != nil {
+			t.Fatalf("failed to insert duplicate statement: %v", err)
+		}
+
+		// Lookup by hash returns the earliest registration
+		found, err := database.GetStatementByHash(db, "duplicate-hash")
+		if err != nil {
+			t.Fatalf("failed to get statement by hash: %v", err)
+		}
+		if found == nil || found.EntryID != firstID {
+			t.Errorf("expected earliest entry %d, got %+v", firstID, found)
+		}
+	})
+
+	t.Run("records migration versions once", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dbPath := filepath.Join(tmpDir, "test.db")
+
+		for i := 0; i < 2; i++ {
+			db, err := database.OpenDatabase(database.DatabaseOptions{
+				Path:      dbPath,
+				EnableWAL: true,
+			})
+			if err != nil {
+				t.Fatalf("failed to open database (attempt %d): %v", i+1, err)
+			}
+			database.CloseDatabase(db)
+		}
+
+		db, err := database.OpenDatabase(database.DatabaseOptions{
+			Path:      dbPath,
+			EnableWAL: true,
+		})
+		if err != nil {
+			t.Fatalf("failed to reopen database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM schema_version WHERE version = '1.1.0'").Scan(&count); err != nil {
+			t.Fatalf("failed to query schema version: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("expected migration 1.1.0 recorded once, got %d", count)
+		}
+	})
+}
+
 func TestCloseDatabase(t *testing.T) {
 	t.Run("closes database connection", func(t *testing.T) {
 		tmpDir := t.TempDir()
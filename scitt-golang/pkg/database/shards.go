@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Shard is a temporal shard of a sharded log
+// A shard accepts registrations until it is frozen with a final checkpoint
+type Shard struct {
+	Name            string     `json:"name"`
+	Origin          string     `json:"origin"`
+	CreatedAt       time.Time  `json:"created_at"`
+	FrozenAt        *time.Time `json:"frozen_at,omitempty"`
+	FinalTreeSize   *int64     `json:"final_tree_size,omitempty"`
+	FinalCheckpoint *string    `json:"final_checkpoint,omitempty"`
+}
+
+// Frozen reports whether the shard has been frozen
+func (s *Shard) Frozen() bool {
+	return s.FrozenAt != nil
+}
+
+// InsertShard records a new active shard
+func InsertShard(db *sql.DB, name, origin string) error {
+	defer observeQuery("insert_shard")()
+
+	if _, err := db.Exec("INSERT INTO shards (name, origin) VALUES (?, ?)", name, origin); err != nil {
+		return fmt.Errorf("failed to insert shard: %w", err)
+	}
+	return nil
+}
+
+// ListShards returns every shard ordered by name, oldest first
+func ListShards(db *sql.DB) ([]Shard, error) {
+	defer observeQuery("list_shards")()
+
+	rows, err := db.Query(`
+		SELECT name, origin, created_at, frozen_at, final_tree_size, final_checkpoint
+		FROM shards ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shards: %w", err)
+	}
+	defer rows.Close()
+
+	var shards []Shard
+	for rows.Next() {
+		var shard Shard
+		var frozenAt sql.NullTime
+		var finalTreeSize sql.NullInt64
+		var finalCheckpoint sql.NullString
+		if err := rows.Scan(&shard.Name, &shard.Origin, &shard.CreatedAt, &frozenAt, &finalTreeSize, &finalCheckpoint); err != nil {
+			return nil, fmt.Errorf("failed to scan shard: %w", err)
+		}
+		if frozenAt.Valid {
+			shard.FrozenAt = &frozenAt.Time
+		}
+		if finalTreeSize.Valid {
+			shard.FinalTreeSize = &finalTreeSize.Int64
+		}
+		if finalCheckpoint.Valid {
+			shard.FinalCheckpoint = &finalCheckpoint.String
+		}
+		shards = append(shards, shard)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list shards: %w", err)
+	}
+
+	return shards, nil
+}
+
+// FreezeShard marks a shard read-only and records its final signed checkpoint
+func FreezeShard(db *sql.DB, name string, finalTreeSize int64, finalCheckpoint string) error {
+	defer observeQuery("freeze_shard")()
+
+	result, err := db.Exec(`
+		UPDATE shards SET frozen_at = ?, final_tree_size = ?, final_checkpoint = ?
+		WHERE name = ? AND frozen_at IS NULL
+	`, time.Now().UTC(), finalTreeSize, finalCheckpoint, name)
+	if err != nil {
+		return fmt.Errorf("failed to freeze shard: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to freeze shard: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("shard %s does not exist or is already frozen", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
+)
+
+func TestShards(t *testing.T) {
+	db, err := database.OpenDatabase(database.DatabaseOptions{
+		Path: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.CloseDatabase(db)
+
+	t.Run("lists shards oldest first", func(t *testing.T) {
+		if err := database.InsertShard(db, "2026", "https://ts.example/shards/2026"); err != nil {
+			t.Fatalf("failed to insert shard: %v", err)
+		}
+		if err := database.InsertShard(db, "2025", "https://ts.example/shards/2025"); err != nil {
+			t.Fatalf("failed to insert shard: %v", err)
+		}
+
+		shards, err := database.ListShards(db)
+		if err != nil {
+			t.Fatalf("failed to list shards: %v", err)
+		}
+		if len(shards) != 2 || shards[0].Name != "2025" || shards[1].Name != "2026" {
+			t.Fatalf("expected shards 2025 and 2026, got %+v", shards)
+		}
+		if shards[0].Frozen() || shards[0].Origin != "https://ts.example/shards/2025" {
+			t.Errorf("expected active shard with origin, got %+v", shards[0])
+		}
+	})
+
+	t.Run("rejects a duplicate shard", func(t *testing.T) {
+		if err := database.InsertShard(db, "2025", "https://ts.example/shards/2025"); err == nil {
+			t.Error("expected error inserting an existing shard")
+		}
+	})
+
+	t.Run("freezes a shard once", func(t *testing.T) {
+		if err := database.FreezeShard(db, "2025", 3, "final checkpoint"); err != nil {
+			t.Fatalf("failed to freeze shard: %v", err)
+		}
+		if err := database.FreezeShard(db, "2025", 3, "final checkpoint"); err == nil {
+			t.Error("expected error freezing a frozen shard")
+		}
+		if err := database.FreezeShard(db, "2024", 0, ""); err == nil {
+			t.Error("expected error freezing an unknown shard")
+		}
+
+		shards, err := database.ListShards(db)
+		if err != nil {
+			t.Fatalf("failed to list shards: %v", err)
+		}
+		frozen := shards[0]
+		if !frozen.Frozen() || *frozen.FinalTreeSize != 3 || *frozen.FinalCheckpoint != "final checkpoint" {
+			t.Errorf("expected frozen shard with final checkpoint, got %+v", frozen)
+		}
+		if shards[1].Frozen() {
+			t.Error("expected shard 2026 to remain active")
+		}
+	})
+}
@@ -23,6 +23,7 @@ type Statement struct {
 	TreeSizeAtRegistration  int64   `json:"tree_size_at_registration"`
 	EntryTileKey            string  `json:"entry_tile_key"`
 	EntryTileOffset         int     `json:"entry_tile_offset"`
+	CredentialID            *string `json:"credential_id,omitempty"` // Credential that submitted the entry
 }
 
 // StatementQueryFilters holds filters for querying statements
@@ -31,40 +32,81 @@ type StatementQueryFilters struct {
 	Sub              *string
 	Cty              *string
 	Typ              *string
+	PayloadHashAlg   *int
+	PayloadHash      *string
 	RegisteredAfter  *string
 	RegisteredBefore *string
 }
 
+// insertStatementColumns inserts one statements row, up to the registered_at value
+const insertStatementColumns = `
+	INSERT INTO statements (
+		statement_hash, iss, sub, cty, typ,
+		payload_hash_alg, payload_hash,
+		preimage_content_type, payload_location,
+		tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		credential_id, registered_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `
+
+// insertStatementSQL inserts a statement registered now unless RegisteredAt is set
+const insertStatementSQL = insertStatementColumns + `COALESCE(?, CURRENT_TIMESTAMP))`
+
+// restoreStatementSQL inserts a statement keeping its RegisteredAt, NULL when unknown
+const restoreStatementSQL = insertStatementColumns + `?)`
+
+// insertArgs returns the insertStatementSQL arguments for a statement
+func (s Statement) insertArgs() []interface{} {
+	return []interface{}{
+		s.StatementHash,
+		s.Iss,
+		s.Sub,
+		s.Cty,
+		s.Typ,
+		s.PayloadHashAlg,
+		s.PayloadHash,
+		s.PreimageContentType,
+		s.PayloadLocation,
+		s.TreeSizeAtRegistration,
+		s.EntryTileKey,
+		s.EntryTileOffset,
+		s.CredentialID,
+		registeredAtArg(s.RegisteredAt),
+	}
+}
+
+// registeredAtArg binds an empty registration time as NULL
+func registeredAtArg(registeredAt string) interface{} {
+	if registeredAt == "" {
+		return nil
+	}
+	return registeredAt
+}
+
+// optionalString scans a nullable column into a string, leaving it empty for NULL
+type optionalString struct{ dest *string }
+
+// Scan implements sql.Scanner
+func (o optionalString) Scan(value interface{}) error {
+	var s sql.NullString
+	if err := s.Scan(value); err != nil {
+		return err
+	}
+	*o.dest = s.String
+	return nil
+}
+
 // InsertStatement inserts a new statement into the database
 // Returns the auto-generated entry ID
 func InsertStatement(db *sql.DB, statement Statement) (int64, error) {
-	stmt, err := db.Prepare(`
-		INSERT INTO statements (
-			statement_hash, iss, sub, cty, typ,
-			payload_hash_alg, payload_hash,
-			preimage_content_type, payload_location,
-			tree_size_at_registration, entry_tile_key, entry_tile_offset
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`)
+	defer observeQuery("insert_statement")()
+
+	stmt, err := db.Prepare(insertStatementSQL)
 	if err != nil {
 		return 0, fmt.Errorf("failed to prepare insert statement: %w", err)
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(
-		statement.StatementHash,
-		statement.Iss,
-		statement.Sub,
-		statement.Cty,
-		statement.Typ,
-		statement.PayloadHashAlg,
-		statement.PayloadHash,
-		statement.PreimageContentType,
-		statement.PayloadLocation,
-		statement.TreeSizeAtRegistration,
-		statement.EntryTileKey,
-		statement.EntryTileOffset,
-	)
+	result, err := stmt.Exec(statement.insertArgs()...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert statement: %w", err)
 	}
@@ -77,12 +119,109 @@ func InsertStatement(db *sql.DB, statement Statement) (int64, error) {
 	return entryID, nil
 }
 
+// AppendStatement inserts a statement registered at its TreeSizeAtRegistration
+// and advances current_tree_size from that size by one, in one transaction
+// appendLeaf runs before the commit, so the row and the size are only
+// committed if it succeeds; the caller undoes appendLeaf if the commit fails
+// Returns the auto-generated entry ID
+func AppendStatement(db *sql.DB, statement Statement, appendLeaf func() error) (int64, error) {
+	defer observeQuery("insert_statement")()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(insertStatementSQL, statement.insertArgs()...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert statement: %w", err)
+	}
+	entryID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	result, err = tx.Exec(`
+		UPDATE current_tree_size
+		SET tree_size = tree_size + 1, last_updated = CURRENT_TIMESTAMP
+		WHERE id = 1 AND tree_size = ?
+	`, statement.TreeSizeAtRegistration)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update tree size: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated != 1 {
+		return 0, fmt.Errorf("current_tree_size is no longer %d", statement.TreeSizeAtRegistration)
+	}
+
+	if err := appendLeaf(); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit statement: %w", err)
+	}
+	return entryID, nil
+}
+
+// ReplaceStatements replaces every statements row and the current tree size in
+// one transaction, renumbering entry IDs from 1 in the order given
+// Each statement keeps its RegisteredAt; an empty one is recorded as unknown
+// Stored receipts reference the old rows and are dropped
+func ReplaceStatements(db *sql.DB, statements []Statement, treeSize int64) error {
+	defer observeQuery("replace_statements")()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM receipts",
+		"DELETE FROM statements",
+		"DELETE FROM sqlite_sequence WHERE name = 'statements'",
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("failed to clear statements: %w", err)
+		}
+	}
+
+	stmt, err := tx.Prepare(restoreStatementSQL)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, statement := range statements {
+		if _, err := stmt.Exec(statement.insertArgs()...); err != nil {
+			return fmt.Errorf("failed to insert statement: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE current_tree_size
+		SET tree_size = ?, last_updated = CURRENT_TIMESTAMP
+		WHERE id = 1
+	`, treeSize); err != nil {
+		return fmt.Errorf("failed to update tree size: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit statements: %w", err)
+	}
+	return nil
+}
+
 // FindStatementsByIssuer finds all statements by issuer URL
 func FindStatementsByIssuer(db *sql.DB, iss string) ([]Statement, error) {
+	defer observeQuery("find_statements_by_issuer")()
+
 	rows, err := db.Query(`
 		SELECT entry_id, statement_hash, iss, sub, cty, typ,
 		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
-		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
 		FROM statements WHERE iss = ? ORDER BY registered_at DESC
 	`, iss)
 	if err != nil {
@@ -95,10 +234,13 @@ func FindStatementsByIssuer(db *sql.DB, iss string) ([]Statement, error) {
 
 // FindStatementsBySubject finds all statements by subject
 func FindStatementsBySubject(db *sql.DB, sub string) ([]Statement, error) {
+	defer observeQuery("find_statements_by_subject")()
+
 	rows, err := db.Query(`
 		SELECT entry_id, statement_hash, iss, sub, cty, typ,
 		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
-		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
 		FROM statements WHERE sub = ? ORDER BY registered_at DESC
 	`, sub)
 	if err != nil {
@@ -111,10 +253,13 @@ func FindStatementsBySubject(db *sql.DB, sub string) ([]Statement, error) {
 
 // FindStatementsByContentType finds all statements by content type
 func FindStatementsByContentType(db *sql.DB, cty string) ([]Statement, error) {
+	defer observeQuery("find_statements_by_content_type")()
+
 	rows, err := db.Query(`
 		SELECT entry_id, statement_hash, iss, sub, cty, typ,
 		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
-		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
 		FROM statements WHERE cty = ? ORDER BY registered_at DESC
 	`, cty)
 	if err != nil {
@@ -127,10 +272,13 @@ func FindStatementsByContentType(db *sql.DB, cty string) ([]Statement, error) {
 
 // FindStatementsByType finds all statements by type
 func FindStatementsByType(db *sql.DB, typ string) ([]Statement, error) {
+	defer observeQuery("find_statements_by_type")()
+
 	rows, err := db.Query(`
 		SELECT entry_id, statement_hash, iss, sub, cty, typ,
 		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
-		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
 		FROM statements WHERE typ = ? ORDER BY registered_at DESC
 	`, typ)
 	if err != nil {
@@ -141,12 +289,37 @@ func FindStatementsByType(db *sql.DB, typ string) ([]Statement, error) {
 	return scanStatements(rows)
 }
 
+// FindStatementsByPayloadHash finds all statements about an artifact digest
+// payloadHash is the hex-encoded digest and payloadHashAlg its COSE algorithm identifier
+func FindStatementsByPayloadHash(db *sql.DB, payloadHashAlg int, payloadHash string) ([]Statement, error) {
+	defer observeQuery("find_statements_by_payload_hash")()
+
+	rows, err := db.Query(`
+		SELECT entry_id, statement_hash, iss, sub, cty, typ,
+		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
+		FROM statements
+		WHERE payload_hash = ? AND payload_hash_alg = ?
+		ORDER BY registered_at DESC
+	`, payloadHash, payloadHashAlg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statements by payload hash: %w", err)
+	}
+	defer rows.Close()
+
+	return scanStatements(rows)
+}
+
 // FindStatementsByDateRange finds statements within a date range
 func FindStatementsByDateRange(db *sql.DB, startDate, endDate string) ([]Statement, error) {
+	defer observeQuery("find_statements_by_date_range")()
+
 	rows, err := db.Query(`
 		SELECT entry_id, statement_hash, iss, sub, cty, typ,
 		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
-		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
 		FROM statements
 		WHERE registered_at BETWEEN ? AND ?
 		ORDER BY registered_at DESC
@@ -161,6 +334,8 @@ func FindStatementsByDateRange(db *sql.DB, startDate, endDate string) ([]Stateme
 
 // FindStatementsBy finds statements using combined filters
 func FindStatementsBy(db *sql.DB, filters StatementQueryFilters) ([]Statement, error) {
+	defer observeQuery("find_statements_by")()
+
 	var conditions []string
 	var params []interface{}
 
@@ -184,6 +359,16 @@ func FindStatementsBy(db *sql.DB, filters StatementQueryFilters) ([]Statement, e
 		params = append(params, *filters.Typ)
 	}
 
+	if filters.PayloadHashAlg != nil {
+		conditions = append(conditions, "payload_hash_alg = ?")
+		params = append(params, *filters.PayloadHashAlg)
+	}
+
+	if filters.PayloadHash != nil {
+		conditions = append(conditions, "payload_hash = ?")
+		params = append(params, *filters.PayloadHash)
+	}
+
 	if filters.RegisteredAfter != nil {
 		conditions = append(conditions, "registered_at >= ?")
 		params = append(params, *filters.RegisteredAfter)
@@ -197,7 +382,8 @@ func FindStatementsBy(db *sql.DB, filters StatementQueryFilters) ([]Statement, e
 	query := `
 		SELECT entry_id, statement_hash, iss, sub, cty, typ,
 		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
-		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
 		FROM statements
 	`
 
@@ -218,11 +404,14 @@ func FindStatementsBy(db *sql.DB, filters StatementQueryFilters) ([]Statement, e
 
 // GetStatementByEntryID retrieves a statement by its entry ID
 func GetStatementByEntryID(db *sql.DB, entryID int64) (*Statement, error) {
+	defer observeQuery("get_statement_by_entry_id")()
+
 	var stmt Statement
 	err := db.QueryRow(`
 		SELECT entry_id, statement_hash, iss, sub, cty, typ,
 		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
-		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
 		FROM statements WHERE entry_id = ?
 	`, entryID).Scan(
 		&stmt.EntryID,
@@ -235,10 +424,11 @@ func GetStatementByEntryID(db *sql.DB, entryID int64) (*Statement, error) {
 		&stmt.PayloadHash,
 		&stmt.PreimageContentType,
 		&stmt.PayloadLocation,
-		&stmt.RegisteredAt,
+		optionalString{&stmt.RegisteredAt},
 		&stmt.TreeSizeAtRegistration,
 		&stmt.EntryTileKey,
 		&stmt.EntryTileOffset,
+		&stmt.CredentialID,
 	)
 
 	if err != nil {
@@ -252,13 +442,18 @@ func GetStatementByEntryID(db *sql.DB, entryID int64) (*Statement, error) {
 }
 
 // GetStatementByHash retrieves a statement by its hash
+// If the statement was registered more than once, the earliest entry is returned
 func GetStatementByHash(db *sql.DB, hash string) (*Statement, error) {
+	defer observeQuery("get_statement_by_hash")()
+
 	var stmt Statement
 	err := db.QueryRow(`
 		SELECT entry_id, statement_hash, iss, sub, cty, typ,
 		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
-		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
 		FROM statements WHERE statement_hash = ?
+		ORDER BY entry_id ASC LIMIT 1
 	`, hash).Scan(
 		&stmt.EntryID,
 		&stmt.StatementHash,
@@ -270,10 +465,11 @@ func GetStatementByHash(db *sql.DB, hash string) (*Statement, error) {
 		&stmt.PayloadHash,
 		&stmt.PreimageContentType,
 		&stmt.PayloadLocation,
-		&stmt.RegisteredAt,
+		optionalString{&stmt.RegisteredAt},
 		&stmt.TreeSizeAtRegistration,
 		&stmt.EntryTileKey,
 		&stmt.EntryTileOffset,
+		&stmt.CredentialID,
 	)
 
 	if err != nil {
@@ -286,8 +482,34 @@ func GetStatementByHash(db *sql.DB, hash string) (*Statement, error) {
 	return &stmt, nil
 }
 
+// GetStatementByEntryTile retrieves the statement stored at an offset of an entry tile
+func GetStatementByEntryTile(db *sql.DB, entryTileKey string, entryTileOffset int) (*Statement, error) {
+	defer observeQuery("get_statement_by_entry_tile")()
+
+	rows, err := db.Query(`
+		SELECT entry_id, statement_hash, iss, sub, cty, typ,
+		       payload_hash_alg, payload_hash, preimage_content_type, payload_location,
+		       registered_at, tree_size_at_registration, entry_tile_key, entry_tile_offset,
+		       credential_id
+		FROM statements WHERE entry_tile_key = ? AND entry_tile_offset = ?
+		ORDER BY entry_id ASC LIMIT 1
+	`, entryTileKey, entryTileOffset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statement by entry tile: %w", err)
+	}
+	defer rows.Close()
+
+	statements, err := scanStatements(rows)
+	if err != nil || len(statements) == 0 {
+		return nil, err
+	}
+	return &statements[0], nil
+}
+
 // SaveStatement stores the raw COSE Sign1 bytes in the database
 func SaveStatement(db *sql.DB, entryID string, statementBytes []byte, leafHash []byte, leafIndex int64) error {
+	defer observeQuery("save_statement")()
+
 	// Create statement_blobs table if it doesn't exist
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS statement_blobs (
@@ -324,6 +546,8 @@ func SaveStatement(db *sql.DB, entryID string, statementBytes []byte, leafHash [
 
 // GetStatementBlob retrieves the raw COSE Sign1 bytes by entry ID
 func GetStatementBlob(db *sql.DB, entryID string) ([]byte, error) {
+	defer observeQuery("get_statement_blob")()
+
 	var data []byte
 	err := db.QueryRow(`
 		SELECT data FROM statement_blobs WHERE entry_id = ?
@@ -356,10 +580,11 @@ func scanStatements(rows *sql.Rows) ([]Statement, error) {
 			&stmt.PayloadHash,
 			&stmt.PreimageContentType,
 			&stmt.PayloadLocation,
-			&stmt.RegisteredAt,
+			optionalString{&stmt.RegisteredAt},
 			&stmt.TreeSizeAtRegistration,
 			&stmt.EntryTileKey,
 			&stmt.EntryTileOffset,
+			&stmt.CredentialID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan statement: %w", err)
@@ -376,13 +601,15 @@ func scanStatements(rows *sql.Rows) ([]Statement, error) {
 
 // FindStatementByEntryID finds a statement by entry ID
 func FindStatementByEntryID(db *sql.DB, entryID int64) (*Statement, error) {
+	defer observeQuery("find_statement_by_entry_id")()
+
 	var stmt Statement
 	err := db.QueryRow(`
 		SELECT entry_id, statement_hash, iss, sub, cty, typ,
 			   payload_hash_alg, payload_hash,
 			   preimage_content_type, payload_location,
 			   registered_at, tree_size_at_registration,
-			   entry_tile_key, entry_tile_offset
+			   entry_tile_key, entry_tile_offset, credential_id
 		FROM statements
 		WHERE entry_id = ?
 	`, entryID).Scan(
@@ -396,10 +623,11 @@ func FindStatementByEntryID(db *sql.DB, entryID int64) (*Statement, error) {
 		&stmt.PayloadHash,
 		&stmt.PreimageContentType,
 		&stmt.PayloadLocation,
-		&stmt.RegisteredAt,
+		optionalString{&stmt.RegisteredAt},
 		&stmt.TreeSizeAtRegistration,
 		&stmt.EntryTileKey,
 		&stmt.EntryTileOffset,
+		&stmt.CredentialID,
 	)
 
 	if err != nil {
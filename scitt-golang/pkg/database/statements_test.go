@@ -1,6 +1,8 @@
 package database_test
 
 import (
+	"database/sql"
+	"errors"
 	"path/filepath"
 	"testing"
 
@@ -281,6 +283,53 @@ func TestFindStatementsByType(t *testing.T) {
 	})
 }
 
+func TestFindStatementsByPayloadHash(t *testing.T) {
+	t.Run("finds statements by artifact digest", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dbPath := filepath.Join(tmpDir, "test.db")
+
+		db, err := database.OpenDatabase(database.DatabaseOptions{
+			Path:      dbPath,
+			EnableWAL: false,
+		})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+
+		statements := []database.Statement{
+			{StatementHash: "sbom", PayloadHashAlg: -16, PayloadHash: "aaaa"},
+			{StatementHash: "provenance", PayloadHashAlg: -16, PayloadHash: "aaaa"},
+			{StatementHash: "other-artifact", PayloadHashAlg: -16, PayloadHash: "bbbb"},
+			{StatementHash: "other-alg", PayloadHashAlg: -43, PayloadHash: "aaaa"},
+		}
+		for i, statement := range statements {
+			statement.Iss = "https://issuer.example.com"
+			statement.TreeSizeAtRegistration = int64(i)
+			statement.EntryTileKey = "tile/entries/000"
+			statement.EntryTileOffset = i
+			if _, err := database.InsertStatement(db, statement); err != nil {
+				t.Fatalf("failed to insert statement: %v", err)
+			}
+		}
+
+		results, err := database.FindStatementsByPayloadHash(db, -16, "aaaa")
+		if err != nil {
+			t.Fatalf("failed to find statements: %v", err)
+		}
+
+		if len(results) != 2 {
+			t.Fatalf("expected 2 statements, got %d", len(results))
+		}
+
+		for _, result := range results {
+			if result.PayloadHash != "aaaa" || result.PayloadHashAlg != -16 {
+				t.Errorf("unexpected statement %s with digest %d/%s", result.StatementHash, result.PayloadHashAlg, result.PayloadHash)
+			}
+		}
+	})
+}
+
 func TestFindStatementsBy(t *testing.T) {
 	t.Run("returns all statements with no filters", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -519,6 +568,109 @@ func TestGetStatementByHash(t *testing.T) {
 	})
 }
 
+func TestReplaceStatements(t *testing.T) {
+	t.Run("replaces rows and renumbers entry IDs", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dbPath := filepath.Join(tmpDir, "test.db")
+
+		db, err := database.OpenDatabase(database.DatabaseOptions{
+			Path:      dbPath,
+			EnableWAL: false,
+		})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer database.CloseDatabase(db)
+
+		for _, hash := range []string{"stale-1", "stale-2", "stale-3"} {
+			if _, err := database.InsertStatement(db, database.Statement{StatementHash: hash, Iss: "old", EntryTileKey: "tile/entries/000"}); err != nil {
+				t.Fatalf("failed to insert statement: %v", err)
+			}
+		}
+
+		rebuilt := []database.Statement{
+			{StatementHash: "hash-0", Iss: "https://issuer.example.com", EntryTileKey: "tile/entries/000", EntryTileOffset: 0, RegisteredAt: "2020-01-02T03:04:05Z"},
+			{StatementHash: "hash-1", Iss: "https://issuer.example.com", EntryTileKey: "tile/entries/000", EntryTileOffset: 1, TreeSizeAtRegistration: 1},
+		}
+		if err := database.ReplaceStatements(db, rebuilt, 2); err != nil {
+			t.Fatalf("failed to replace statements: %v", err)
+		}
+
+		if stale, _ := database.GetStatementByHash(db, "stale-1"); stale != nil {
+			t.Error("expected old rows to be removed")
+		}
+		first, err := database.GetStatementByEntryID(db, 1)
+		if err != nil || first == nil || first.StatementHash != "hash-0" {
+			t.Errorf("expected hash-0 at entry ID 1, got %+v (%v)", first, err)
+		}
+		if first != nil && first.RegisteredAt != "2020-01-02T03:04:05Z" {
+			t.Errorf("expected the registration time to be kept, got %q", first.RegisteredAt)
+		}
+		if second, _ := database.GetStatementByEntryID(db, 2); second == nil || second.RegisteredAt != "" {
+			t.Errorf("expected an unknown registration time to stay empty, got %+v", second)
+		}
+		if size, _ := database.GetCurrentTreeSize(db); size != 2 {
+			t.Errorf("expected tree size 2, got %d", size)
+		}
+	})
+}
+
+func TestAppendStatement(t *testing.T) {
+	openDB := func(t *testing.T) *sql.DB {
+		t.Helper()
+		db, err := database.OpenDatabase(database.DatabaseOptions{Path: filepath.Join(t.TempDir(), "test.db")})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		t.Cleanup(func() { database.CloseDatabase(db) })
+		return db
+	}
+
+	t.Run("inserts the statement and advances the tree size", func(t *testing.T) {
+		db := openDB(t)
+		statement := database.Statement{StatementHash: "hash-0", Iss: "https://issuer.example.com", EntryTileKey: "tile/entries/000"}
+
+		entryID, err := database.AppendStatement(db, statement, func() error { return nil })
+		if err != nil {
+			t.Fatalf("failed to append statement: %v", err)
+		}
+		if stored, _ := database.GetStatementByEntryID(db, entryID); stored == nil || stored.StatementHash != "hash-0" {
+			t.Errorf("expected hash-0 at entry ID %d, got %+v", entryID, stored)
+		}
+		if size, _ := database.GetCurrentTreeSize(db); size != 1 {
+			t.Errorf("expected tree size 1, got %d", size)
+		}
+	})
+
+	t.Run("rolls back when the leaf cannot be appended", func(t *testing.T) {
+		db := openDB(t)
+		statement := database.Statement{StatementHash: "hash-0", Iss: "https://issuer.example.com", EntryTileKey: "tile/entries/000"}
+
+		if _, err := database.AppendStatement(db, statement, func() error { return errors.New("append failed") }); err == nil {
+			t.Fatal("expected an error")
+		}
+		if stored, _ := database.GetStatementByHash(db, "hash-0"); stored != nil {
+			t.Error("expected the statement not to be inserted")
+		}
+		if size, _ := database.GetCurrentTreeSize(db); size != 0 {
+			t.Errorf("expected tree size 0, got %d", size)
+		}
+	})
+
+	t.Run("rejects a statement registered at a stale tree size", func(t *testing.T) {
+		db := openDB(t)
+		statement := database.Statement{StatementHash: "hash-0", Iss: "https://issuer.example.com", TreeSizeAtRegistration: 3}
+
+		called := false
+		if _, err := database.AppendStatement(db, statement, func() error { called = true; return nil }); err == nil {
+			t.Fatal("expected an error")
+		}
+		if called {
+			t.Error("expected the leaf not to be appended")
+		}
+	})
+}
+
 func TestSaveAndGetStatementBlob(t *testing.T) {
 	t.Run("saves and retrieves statement blob", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -0,0 +1,56 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MaxEntrySize is the largest entry an entry bundle can hold (uint16 length prefix)
+const MaxEntrySize = 1<<16 - 1
+
+// HashTileIndexToPath generates the path of a level 0 hash tile, which holds
+// the leaves whose entries are in the entry bundle of the same index
+func HashTileIndexToPath(index int64, width *int) string {
+	return TileIndexToPath(0, index, width)
+}
+
+// AppendEntryBundle appends a length-prefixed entry to an entry bundle
+func AppendEntryBundle(bundle, entry []byte) ([]byte, error) {
+	if len(entry) > MaxEntrySize {
+		return nil, fmt.Errorf("entry of %d bytes exceeds the %d byte limit of entry bundles", len(entry), MaxEntrySize)
+	}
+	bundle = binary.BigEndian.AppendUint16(bundle, uint16(len(entry)))
+	return append(bundle, entry...), nil
+}
+
+// EncodeEntryBundle encodes entries as an entry bundle
+func EncodeEntryBundle(entries [][]byte) ([]byte, error) {
+	var bundle []byte
+	for _, entry := range entries {
+		var err error
+		if bundle, err = AppendEntryBundle(bundle, entry); err != nil {
+			return nil, err
+		}
+	}
+	return bundle, nil
+}
+
+// DecodeEntryBundle splits an entry bundle into its entries
+func DecodeEntryBundle(data []byte) ([][]byte, error) {
+	var entries [][]byte
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("entry bundle ends inside the length of entry %d", len(entries))
+		}
+		size := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+size {
+			return nil, fmt.Errorf("entry bundle ends inside entry %d", len(entries))
+		}
+		entries = append(entries, data[2:2+size])
+		data = data[2+size:]
+	}
+	if len(entries) > TileSize {
+		return nil, fmt.Errorf("entry bundle holds %d entries, more than %d", len(entries), TileSize)
+	}
+	return entries, nil
+}
@@ -0,0 +1,146 @@
+package merkle_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+)
+
+func TestEntryBundles(t *testing.T) {
+	t.Run("round-trips length-prefixed entries", func(t *testing.T) {
+		entries := [][]byte{[]byte("first"), {}, bytes.Repeat([]byte{0xab}, 300)}
+
+		bundle, err := merkle.EncodeEntryBundle(entries)
+		if err != nil {
+			t.Fatalf("failed to encode bundle: %v", err)
+		}
+		if !bytes.Equal(bundle[:7], []byte{0x00, 0x05, 'f', 'i', 'r', 's', 't'}) {
+			t.Errorf("unexpected bundle prefix %x", bundle[:7])
+		}
+
+		decoded, err := merkle.DecodeEntryBundle(bundle)
+		if err != nil {
+			t.Fatalf("failed to decode bundle: %v", err)
+		}
+		if len(decoded) != len(entries) {
+			t.Fatalf("expected %d entries, got %d", len(entries), len(decoded))
+		}
+		for i := range entries {
+			if !bytes.Equal(decoded[i], entries[i]) {
+				t.Errorf("entry %d differs", i)
+			}
+		}
+	})
+
+	t.Run("rejects truncated bundles", func(t *testing.T) {
+		bundle, _ := merkle.EncodeEntryBundle([][]byte{[]byte("entry")})
+
+		for _, cut := range []int{1, 4} {
+			if _, err := merkle.DecodeEntryBundle(bundle[:cut]); err == nil {
+				t.Errorf("expected an error for a bundle cut at %d bytes", cut)
+			}
+		}
+	})
+
+	t.Run("rejects entries over the size limit", func(t *testing.T) {
+		if _, err := merkle.AppendEntryBundle(nil, make([]byte, merkle.MaxEntrySize+1)); err == nil {
+			t.Error("expected an error")
+		}
+		if _, err := merkle.AppendEntryBundle(nil, make([]byte, merkle.MaxEntrySize)); err != nil {
+			t.Errorf("expected the largest entry to fit: %v", err)
+		}
+	})
+}
+
+func TestTileLogEntries(t *testing.T) {
+	appendEntries := func(t *testing.T, tl *merkle.TileLog, store storage.Storage, n int) [][]byte {
+		t.Helper()
+		var entries [][]byte
+		for i := 0; i < n; i++ {
+			entry := []byte(fmt.Sprintf("statement %d", i))
+			hash := sha256.Sum256(entry)
+			if err := store.Put(merkle.StatementPath(hash[:]), entry); err != nil {
+				t.Fatalf("failed to store statement: %v", err)
+			}
+			if _, err := tl.AppendEntry(entry); err != nil {
+				t.Fatalf("failed to append entry %d: %v", i, err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	t.Run("writes entry bundles beside hash tiles", func(t *testing.T) {
+		store := storage.NewMemoryStorage()
+		tl := merkle.NewTileLog(store)
+		entries := appendEntries(t, tl, store, merkle.TileSize+3)
+
+		for index := int64(0); index < 2; index++ {
+			data, _ := store.Get(merkle.EntryTileIndexToPath(index, nil))
+			bundle, err := merkle.DecodeEntryBundle(data)
+			if err != nil {
+				t.Fatalf("failed to decode bundle %d: %v", index, err)
+			}
+			tile, _ := store.Get(merkle.HashTileIndexToPath(index, nil))
+			if len(tile) != len(bundle)*merkle.HashSize {
+				t.Fatalf("bundle %d holds %d entries but its hash tile %d bytes", index, len(bundle), len(tile))
+			}
+
+			// Leaves are recomputed from the entries alone
+			for offset, entry := range bundle {
+				hash := sha256.Sum256(entry)
+				if !bytes.Equal(hash[:], tile[offset*merkle.HashSize:(offset+1)*merkle.HashSize]) {
+					t.Errorf("entry %d of bundle %d does not hash to its leaf", offset, index)
+				}
+			}
+		}
+
+		entry, err := tl.GetEntry(merkle.TileSize + 1)
+		if err != nil {
+			t.Fatalf("failed to get entry: %v", err)
+		}
+		if !bytes.Equal(entry, entries[merkle.TileSize+1]) {
+			t.Error("unexpected entry")
+		}
+		if _, err := tl.GetEntry(merkle.TileSize + 3); err == nil {
+			t.Error("expected an error beyond the tree")
+		}
+	})
+
+	t.Run("rebuilds entry bundles from statements", func(t *testing.T) {
+		store := storage.NewMemoryStorage()
+		tl := merkle.NewTileLog(store)
+		appendEntries(t, tl, store, 5)
+
+		want, _ := store.Get(merkle.EntryTileIndexToPath(0, nil))
+		if err := store.Delete(merkle.EntryTileIndexToPath(0, nil)); err != nil {
+			t.Fatalf("failed to delete bundle: %v", err)
+		}
+		if err := tl.RebuildBundles(); err != nil {
+			t.Fatalf("failed to rebuild bundles: %v", err)
+		}
+		if got, _ := store.Get(merkle.EntryTileIndexToPath(0, nil)); !bytes.Equal(got, want) {
+			t.Error("rebuilt bundle differs")
+		}
+	})
+
+	t.Run("refuses to append after an entry beyond the tree", func(t *testing.T) {
+		store := storage.NewMemoryStorage()
+		tl := merkle.NewTileLog(store)
+		appendEntries(t, tl, store, 2)
+
+		data, _ := store.Get(merkle.EntryTileIndexToPath(0, nil))
+		data, _ = merkle.AppendEntryBundle(data, []byte("orphan"))
+		if err := store.Put(merkle.EntryTileIndexToPath(0, nil), data); err != nil {
+			t.Fatalf("failed to write bundle: %v", err)
+		}
+
+		if _, err := tl.AppendEntry([]byte("next")); err == nil {
+			t.Error("expected an error appending after an orphan entry")
+		}
+	})
+}
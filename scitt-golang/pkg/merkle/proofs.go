@@ -419,14 +419,14 @@ func getLeafFromStorage(store storage.Storage, entryID int64) ([HashSize]byte, e
 	tileIndex := EntryIDToTileIndex(entryID)
 	tileOffset := EntryIDToTileOffset(entryID)
 
-	tilePath := EntryTileIndexToPath(tileIndex, nil)
+	tilePath := HashTileIndexToPath(tileIndex, nil)
 	tileData, err := store.Get(tilePath)
 	if err != nil {
 		return [HashSize]byte{}, fmt.Errorf("failed to get tile: %w", err)
 	}
 
 	if tileData == nil {
-		return [HashSize]byte{}, fmt.Errorf("entry tile not found: %s", tilePath)
+		return [HashSize]byte{}, fmt.Errorf("hash tile not found: %s", tilePath)
 	}
 
 	start := tileOffset * HashSize
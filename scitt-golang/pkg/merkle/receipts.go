@@ -0,0 +1,153 @@
+package merkle
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+)
+
+// ReceiptInclusionProof extracts the inclusion proof from a receipt's
+// verifiable-data-proofs header (label 396, key -1: [tree-size, leaf-index, inclusion-path])
+func ReceiptInclusionProof(receipt *cose.CoseSign1) (*InclusionProof, error) {
+	unprotected, err := cose.GetUnprotectedHeaders(receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	vdp, ok := unprotected.VerifiableDataProofs()
+	if !ok {
+		return nil, fmt.Errorf("verifiable data proof not found in unprotected headers")
+	}
+
+	inclusionProofCBOR, ok := vdp.Bytes(-1)
+	if !ok {
+		return nil, fmt.Errorf("inclusion proof not found in verifiable data proof")
+	}
+
+	var inclusionProofArray []interface{}
+	if err := cose.Unmarshal(inclusionProofCBOR, &inclusionProofArray); err != nil {
+		return nil, fmt.Errorf("failed to decode inclusion proof: %w", err)
+	}
+
+	if len(inclusionProofArray) != 3 {
+		return nil, fmt.Errorf("invalid inclusion proof structure: expected 3 elements, got %d", len(inclusionProofArray))
+	}
+
+	treeSize, ok := cose.ToInt64(inclusionProofArray[0])
+	if !ok {
+		return nil, fmt.Errorf("tree size is not an integer")
+	}
+
+	leafIndex, ok := cose.ToInt64(inclusionProofArray[1])
+	if !ok {
+		return nil, fmt.Errorf("leaf index is not an integer")
+	}
+
+	inclusionPath, ok := inclusionProofArray[2].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("inclusion path is not an array")
+	}
+
+	auditPath := make([][HashSize]byte, 0, len(inclusionPath))
+	for i, hashInterface := range inclusionPath {
+		hashBytes, ok := hashInterface.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("hash at index %d is not bytes", i)
+		}
+		if len(hashBytes) != HashSize {
+			return nil, fmt.Errorf("hash at index %d has invalid length: %d", i, len(hashBytes))
+		}
+		var hash [HashSize]byte
+		copy(hash[:], hashBytes)
+		auditPath = append(auditPath, hash)
+	}
+
+	return &InclusionProof{
+		LeafIndex: leafIndex,
+		TreeSize:  treeSize,
+		AuditPath: auditPath,
+	}, nil
+}
+
+// VerifyReceipt reconstructs the Merkle root from a receipt's inclusion proof and
+// the leaf (the statement hash), and verifies the receipt signature over that root
+// Returns the inclusion proof and the root the receipt commits to
+func VerifyReceipt(receipt *cose.CoseSign1, leaf [HashSize]byte, publicKey *ecdsa.PublicKey) (*InclusionProof, [HashSize]byte, error) {
+	proof, err := ReceiptInclusionProof(receipt)
+	if err != nil {
+		return nil, [HashSize]byte{}, err
+	}
+
+	root := ReconstructRootFromInclusionProof(leaf, proof)
+
+	verifier, err := cose.NewES256Verifier(publicKey)
+	if err != nil {
+		return nil, [HashSize]byte{}, fmt.Errorf("failed to create verifier: %w", err)
+	}
+
+	valid, err := cose.VerifyCoseSign1(receipt, verifier, root[:])
+	if err != nil {
+		return nil, [HashSize]byte{}, fmt.Errorf("failed to verify receipt signature: %w", err)
+	}
+	if !valid {
+		return nil, [HashSize]byte{}, fmt.Errorf("receipt signature is invalid")
+	}
+
+	return proof, root, nil
+}
+
+// ReceiptClaims holds the CWT claims (label 15) of a receipt's protected header
+type ReceiptClaims struct {
+	Iss   string
+	Sub   string
+	Iat   *time.Time                  // Registration time of the entry (nil if absent)
+	Cti   []byte                      // Receipt identifier (nil if absent)
+	Extra map[interface{}]interface{} // Other claims, with integer labels as int64
+}
+
+// GetReceiptClaims extracts the CWT claims from a receipt's protected header
+func GetReceiptClaims(receipt *cose.CoseSign1) (*ReceiptClaims, error) {
+	headers, err := cose.GetProtectedHeaders(receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	claimsSet, ok := headers.CWTClaims()
+	if !ok {
+		return nil, fmt.Errorf("CWT claims not found in receipt protected headers")
+	}
+
+	claims := &ReceiptClaims{Extra: make(map[interface{}]interface{})}
+	for label, value := range claimsSet {
+		switch label {
+		case int64(cose.CWTClaimIss):
+			if claims.Iss, ok = value.(string); !ok {
+				return nil, fmt.Errorf("iss claim is not a string")
+			}
+		case int64(cose.CWTClaimSub):
+			if claims.Sub, ok = value.(string); !ok {
+				return nil, fmt.Errorf("sub claim is not a string")
+			}
+		case int64(cose.CWTClaimIat):
+			seconds, ok := claimsSet.Iat()
+			if !ok {
+				return nil, fmt.Errorf("iat claim is not an integer")
+			}
+			iat := time.Unix(seconds, 0).UTC()
+			claims.Iat = &iat
+		case int64(cose.CWTClaimCti):
+			if claims.Cti, ok = value.([]byte); !ok {
+				return nil, fmt.Errorf("cti claim is not a byte string")
+			}
+		default:
+			claims.Extra[label] = value
+		}
+	}
+
+	if claims.Iss == "" {
+		return nil, fmt.Errorf("issuer (iss) not found in CWT claims")
+	}
+	return claims, nil
+}
@@ -15,6 +15,28 @@ const (
 	FullTileBytes  = TileSize * HashSize // 8192 bytes
 )
 
+// Storage keys outside the tile hierarchy
+const (
+	CheckpointPath = "checkpoint"  // Latest checkpoint (signed note)
+	TreeStatePath  = ".tree-state" // Compact range of the tile log
+)
+
+// CheckpointHistoryPath returns the storage key of the checkpoint recorded at a tree size
+func CheckpointHistoryPath(treeSize int64) string {
+	return fmt.Sprintf("checkpoints/%d", treeSize)
+}
+
+// StatementPath returns the content-addressed storage key of a statement's COSE
+// Sign1 bytes, fanned out by the first byte of its SHA-256 hash (the leaf hash)
+func StatementPath(hash []byte) string {
+	return fmt.Sprintf("statements/%x/%x", hash[:1], hash)
+}
+
+// ReceiptPath returns the storage key of the receipt issued for an entry
+func ReceiptPath(entryID int64) string {
+	return fmt.Sprintf("receipts/%d", entryID)
+}
+
 // ParsedTilePath represents components of a parsed tile path
 type ParsedTilePath struct {
 	Level     int
@@ -219,6 +219,17 @@ func TestTileCoordinatesToEntryID(t *testing.T) {
 	}
 }
 
+func TestStatementPath(t *testing.T) {
+	hash := make([]byte, merkle.HashSize)
+	hash[0] = 0xab
+	hash[31] = 0x01
+
+	expected := "statements/ab/ab00000000000000000000000000000000000000000000000000000000000001"
+	if path := merkle.StatementPath(hash); path != expected {
+		t.Errorf("expected %s, got %s", expected, path)
+	}
+}
+
 func TestRoundTrip(t *testing.T) {
 	t.Run("tile path round trip", func(t *testing.T) {
 		testIndices := []int64{0, 1, 42, 255, 256, 1234, 65536, 1234067}
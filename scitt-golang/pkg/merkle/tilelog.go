@@ -1,6 +1,8 @@
 package merkle
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 
@@ -12,10 +14,11 @@ import (
 // TileLog represents a tile-based Merkle tree
 // Uses Tessera's compact range for efficient RFC 6962 Merkle tree operations
 type TileLog struct {
-	storage storage.Storage
-	size    int64
-	rf      *compact.RangeFactory
-	cr      *compact.Range
+	storage  storage.Storage
+	size     int64
+	rf       *compact.RangeFactory
+	cr       *compact.Range
+	hashOnly bool // Entries are not written to entry bundles
 }
 
 // TileLogState represents the persistent state of the tree
@@ -23,6 +26,10 @@ type TileLogState struct {
 	Size   int64    `json:"size"`
 	Root   []byte   `json:"root,omitempty"`
 	Hashes [][]byte `json:"hashes,omitempty"` // Compact range hashes
+
+	// HashOnly is set for logs upgraded from before entry bundles whose
+	// statements were not kept; only the hash tiles are written
+	HashOnly bool `json:"hash_only,omitempty"`
 }
 
 // NewTileLog creates a new tile log with the given storage
@@ -40,7 +47,7 @@ func NewTileLog(storage storage.Storage) *TileLog {
 
 // Load loads the tree state from storage
 func (tl *TileLog) Load() error {
-	stateData, err := tl.storage.Get(".tree-state")
+	stateData, err := tl.storage.Get(TreeStatePath)
 	if err != nil {
 		return fmt.Errorf("failed to get tree state: %w", err)
 	}
@@ -58,6 +65,7 @@ func (tl *TileLog) Load() error {
 	}
 
 	tl.size = state.Size
+	tl.hashOnly = state.HashOnly
 
 	// Restore compact range from saved hashes
 	if state.Size > 0 && len(state.Hashes) > 0 {
@@ -91,9 +99,10 @@ func (tl *TileLog) saveState() error {
 	}
 
 	state := TileLogState{
-		Size:   tl.size,
-		Root:   root,
-		Hashes: hashes,
+		Size:     tl.size,
+		Root:     root,
+		Hashes:   hashes,
+		HashOnly: tl.hashOnly,
 	}
 
 	stateData, err := json.Marshal(state)
@@ -101,7 +110,7 @@ func (tl *TileLog) saveState() error {
 		return fmt.Errorf("failed to marshal tree state: %w", err)
 	}
 
-	if err := tl.storage.Put(".tree-state", stateData); err != nil {
+	if err := tl.storage.Put(TreeStatePath, stateData); err != nil {
 		return fmt.Errorf("failed to put tree state: %w", err)
 	}
 
@@ -112,13 +121,39 @@ func (tl *TileLog) saveState() error {
 // Returns the entry ID of the appended leaf
 // The leaf should be the raw record hash (e.g., SHA-256 of statement)
 // This function will apply the RFC 6962 leaf prefix (0x00) for tree computation
+// Only the hash tiles are written; logs of statements use AppendEntry
 func (tl *TileLog) Append(leaf [HashSize]byte) (int64, error) {
+	return tl.append(leaf, nil)
+}
+
+// AppendEntry appends an entry to its entry bundle and its SHA-256 hash as the
+// leaf, so the leaves can be recomputed from the entry bundles
+// Returns the entry ID of the appended entry
+// A hash-only log writes only the leaf
+func (tl *TileLog) AppendEntry(entry []byte) (int64, error) {
+	if len(entry) > MaxEntrySize {
+		return 0, fmt.Errorf("entry of %d bytes exceeds the %d byte limit of entry bundles", len(entry), MaxEntrySize)
+	}
+	if tl.hashOnly {
+		return tl.append(sha256.Sum256(entry), nil)
+	}
+	return tl.append(sha256.Sum256(entry), entry)
+}
+
+// append writes a leaf (and its entry, unless nil) to the tiles and compact range
+func (tl *TileLog) append(leaf [HashSize]byte, entry []byte) (int64, error) {
 	entryID := tl.size
 
-	// Store the RAW leaf hash in entry tile (without RFC 6962 prefix)
+	if entry != nil {
+		if err := tl.appendToEntryBundle(entryID, entry); err != nil {
+			return 0, fmt.Errorf("failed to append to entry bundle: %w", err)
+		}
+	}
+
+	// Store the RAW leaf hash in the hash tile (without RFC 6962 prefix)
 	// This preserves the original hash for retrieval
-	if err := tl.appendToEntryTile(entryID, leaf[:]); err != nil {
-		return 0, fmt.Errorf("failed to append to entry tile: %w", err)
+	if err := tl.appendToHashTile(entryID, leaf[:]); err != nil {
+		return 0, fmt.Errorf("failed to append to hash tile: %w", err)
 	}
 
 	// Apply RFC 6962 leaf hash prefix (0x00) for tree computation only
@@ -126,6 +161,7 @@ func (tl *TileLog) Append(leaf [HashSize]byte) (int64, error) {
 	leafHash := rfc6962.DefaultHasher.HashLeaf(leaf[:])
 
 	// Append to compact range for efficient tree computation
+	previous := tl.cr.Hashes()
 	if err := tl.cr.Append(leafHash, nil); err != nil {
 		return 0, fmt.Errorf("failed to append to compact range: %w", err)
 	}
@@ -135,14 +171,149 @@ func (tl *TileLog) Append(leaf [HashSize]byte) (int64, error) {
 
 	// Persist state
 	if err := tl.saveState(); err != nil {
-		// Roll back size increment on failure
+		// Roll back the size increment and compact range on failure
 		tl.size--
+		if cr, rangeErr := tl.rf.NewRange(0, uint64(tl.size), previous); rangeErr == nil {
+			tl.cr = cr
+		}
 		return 0, fmt.Errorf("failed to save state: %w", err)
 	}
 
 	return entryID, nil
 }
 
+// Rebuild recomputes the compact range from the first size leaves of the hash
+// tiles and persists it, for tiles written without a tree state
+func (tl *TileLog) Rebuild(size int64) error {
+	cr, err := tl.rangeFromTiles(size)
+	if err != nil {
+		return err
+	}
+
+	tl.size = size
+	tl.cr = cr
+	return tl.saveState()
+}
+
+// Truncate discards the leaves and entries from size on, including any written
+// beyond the tree by an interrupted append, and rebuilds the compact range
+// Used to undo an append whose registration could not be committed
+func (tl *TileLog) Truncate(size int64) error {
+	if size < 0 || size > tl.size {
+		return fmt.Errorf("cannot truncate a tree of size %d to %d", tl.size, size)
+	}
+
+	first := EntryIDToTileIndex(size)
+	for index := first; index <= EntryIDToTileIndex(tl.size); index++ {
+		keep := 0
+		if index == first {
+			keep = EntryIDToTileOffset(size)
+		}
+		if err := tl.truncateTiles(index, keep); err != nil {
+			return err
+		}
+	}
+
+	return tl.Rebuild(size)
+}
+
+// truncateTiles keeps the first keep leaves of a hash tile and entries of its
+// entry bundle, deleting them when none are kept
+func (tl *TileLog) truncateTiles(index int64, keep int) error {
+	tilePath := HashTileIndexToPath(index, nil)
+	tile, err := tl.storage.Get(tilePath)
+	if err != nil {
+		return fmt.Errorf("failed to get hash tile: %w", err)
+	}
+	if len(tile) > keep*HashSize {
+		if err := tl.putOrDelete(tilePath, tile[:keep*HashSize]); err != nil {
+			return fmt.Errorf("failed to truncate hash tile %s: %w", tilePath, err)
+		}
+	}
+
+	bundlePath := EntryTileIndexToPath(index, nil)
+	bundle, err := tl.storage.Get(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to get entry bundle: %w", err)
+	}
+	entries, err := DecodeEntryBundle(bundle)
+	if err != nil {
+		return fmt.Errorf("failed to decode entry bundle %s: %w", bundlePath, err)
+	}
+	if len(entries) > keep {
+		encoded, err := EncodeEntryBundle(entries[:keep])
+		if err != nil {
+			return err
+		}
+		if err := tl.putOrDelete(bundlePath, encoded); err != nil {
+			return fmt.Errorf("failed to truncate entry bundle %s: %w", bundlePath, err)
+		}
+	}
+
+	return nil
+}
+
+// putOrDelete writes data at key, or deletes the key when data is empty
+func (tl *TileLog) putOrDelete(key string, data []byte) error {
+	if len(data) == 0 {
+		return tl.storage.Delete(key)
+	}
+	return tl.storage.Put(key, data)
+}
+
+// Verify recomputes the compact range from the hash tiles and checks that it
+// matches the persisted tree state
+func (tl *TileLog) Verify() error {
+	cr, err := tl.rangeFromTiles(tl.size)
+	if err != nil {
+		return err
+	}
+
+	want, have := cr.Hashes(), tl.cr.Hashes()
+	if len(want) != len(have) {
+		return fmt.Errorf("compact range does not match the hash tiles at size %d", tl.size)
+	}
+	for i := range want {
+		if !bytes.Equal(want[i], have[i]) {
+			return fmt.Errorf("compact range does not match the hash tiles at size %d", tl.size)
+		}
+	}
+
+	return nil
+}
+
+// rangeFromTiles builds the compact range of the first size leaves of the hash
+// tiles, reading each tile once
+func (tl *TileLog) rangeFromTiles(size int64) (*compact.Range, error) {
+	cr := tl.rf.NewEmptyRange(0)
+
+	var tile []byte
+	for entryID := int64(0); entryID < size; entryID++ {
+		offset := EntryIDToTileOffset(entryID)
+		if offset == 0 || tile == nil {
+			tilePath := HashTileIndexToPath(EntryIDToTileIndex(entryID), nil)
+			data, err := tl.storage.Get(tilePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get hash tile: %w", err)
+			}
+			if data == nil {
+				return nil, fmt.Errorf("hash tile not found: %s", tilePath)
+			}
+			tile = data
+		}
+
+		end := (offset + 1) * HashSize
+		if end > len(tile) {
+			return nil, fmt.Errorf("hash tiles end before tree size %d", size)
+		}
+		if err := cr.Append(rfc6962.DefaultHasher.HashLeaf(tile[end-HashSize:end]), nil); err != nil {
+			return nil, fmt.Errorf("failed to append to compact range: %w", err)
+		}
+	}
+
+	return cr, nil
+}
+
 // Size returns the current tree size (number of leaves)
 func (tl *TileLog) Size() int64 {
 	return tl.size
@@ -178,14 +349,14 @@ func (tl *TileLog) getLeafDirect(entryID int64) ([HashSize]byte, error) {
 	tileIndex := EntryIDToTileIndex(entryID)
 	tileOffset := EntryIDToTileOffset(entryID)
 
-	tilePath := EntryTileIndexToPath(tileIndex, nil)
+	tilePath := HashTileIndexToPath(tileIndex, nil)
 	tileData, err := tl.storage.Get(tilePath)
 	if err != nil {
-		return [HashSize]byte{}, fmt.Errorf("failed to get entry tile: %w", err)
+		return [HashSize]byte{}, fmt.Errorf("failed to get hash tile: %w", err)
 	}
 
 	if tileData == nil {
-		return [HashSize]byte{}, fmt.Errorf("entry tile not found: %s", tilePath)
+		return [HashSize]byte{}, fmt.Errorf("hash tile not found: %s", tilePath)
 	}
 
 	// Extract the specific hash from the tile
@@ -201,10 +372,134 @@ func (tl *TileLog) getLeafDirect(entryID int64) ([HashSize]byte, error) {
 	return leaf, nil
 }
 
-// appendToEntryTile appends a leaf to an entry tile
-func (tl *TileLog) appendToEntryTile(entryID int64, leafHash []byte) error {
+// GetEntry retrieves an entry from its entry bundle by entry ID
+func (tl *TileLog) GetEntry(entryID int64) ([]byte, error) {
+	if entryID >= tl.size {
+		return nil, fmt.Errorf("entry ID %d out of bounds (size: %d)", entryID, tl.size)
+	}
+
+	bundlePath := EntryTileIndexToPath(EntryIDToTileIndex(entryID), nil)
+	data, err := tl.storage.Get(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entry bundle: %w", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("entry bundle not found: %s", bundlePath)
+	}
+
+	entries, err := DecodeEntryBundle(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode entry bundle %s: %w", bundlePath, err)
+	}
+	offset := EntryIDToTileOffset(entryID)
+	if offset >= len(entries) {
+		return nil, fmt.Errorf("entry bundle %s holds %d entries, expected at least %d", bundlePath, len(entries), offset+1)
+	}
+	return entries[offset], nil
+}
+
+// HashOnly reports whether the log writes only hash tiles, without entry bundles
+func (tl *TileLog) HashOnly() bool {
+	return tl.hashOnly
+}
+
+// DropBundles makes the log hash-only and deletes its entry bundles, for logs
+// whose statements were not kept
+func (tl *TileLog) DropBundles() error {
+	tl.hashOnly = true
+	if err := tl.saveState(); err != nil {
+		return err
+	}
+
+	for index := int64(0); TileCoordinatesToEntryID(index, 0) < tl.size; index++ {
+		if err := tl.storage.Delete(EntryTileIndexToPath(index, nil)); err != nil {
+			return fmt.Errorf("failed to delete entry bundle: %w", err)
+		}
+	}
+	return nil
+}
+
+// MissingStatement returns the first entry whose statement is missing from
+// storage or does not hash to its leaf, or -1 when every statement is stored
+func (tl *TileLog) MissingStatement() (int64, error) {
+	for index := int64(0); TileCoordinatesToEntryID(index, 0) < tl.size; index++ {
+		tile, err := tl.storage.Get(HashTileIndexToPath(index, nil))
+		if err != nil {
+			return 0, fmt.Errorf("failed to get hash tile: %w", err)
+		}
+
+		width := tl.size - TileCoordinatesToEntryID(index, 0)
+		if width > TileSize {
+			width = TileSize
+		}
+		if int64(len(tile)) < width*HashSize {
+			return 0, fmt.Errorf("hash tiles end before tree size %d", tl.size)
+		}
+
+		for offset := 0; int64(offset) < width; offset++ {
+			leaf := tile[offset*HashSize : (offset+1)*HashSize]
+			entry, err := tl.storage.Get(StatementPath(leaf))
+			if err != nil {
+				return 0, fmt.Errorf("failed to get statement: %w", err)
+			}
+			if hash := sha256.Sum256(entry); entry == nil || !bytes.Equal(hash[:], leaf) {
+				return TileCoordinatesToEntryID(index, offset), nil
+			}
+		}
+	}
+	return -1, nil
+}
+
+// RebuildBundles rewrites the entry bundles of the tree from the
+// content-addressed statements its leaves commit to; a hash-only log writes
+// entry bundles again from then on
+func (tl *TileLog) RebuildBundles() error {
+	for index := int64(0); TileCoordinatesToEntryID(index, 0) < tl.size; index++ {
+		tilePath := HashTileIndexToPath(index, nil)
+		tile, err := tl.storage.Get(tilePath)
+		if err != nil {
+			return fmt.Errorf("failed to get hash tile: %w", err)
+		}
+
+		width := tl.size - TileCoordinatesToEntryID(index, 0)
+		if width > TileSize {
+			width = TileSize
+		}
+		if int64(len(tile)) < width*HashSize {
+			return fmt.Errorf("hash tiles end before tree size %d", tl.size)
+		}
+
+		var bundle []byte
+		for offset := 0; int64(offset) < width; offset++ {
+			leaf := tile[offset*HashSize : (offset+1)*HashSize]
+			entry, err := tl.storage.Get(StatementPath(leaf))
+			if err != nil {
+				return fmt.Errorf("failed to get statement: %w", err)
+			}
+			if hash := sha256.Sum256(entry); entry == nil || !bytes.Equal(hash[:], leaf) {
+				return fmt.Errorf("statement of entry %d is missing or does not hash to its leaf", TileCoordinatesToEntryID(index, offset))
+			}
+			if bundle, err = AppendEntryBundle(bundle, entry); err != nil {
+				return err
+			}
+		}
+
+		if err := tl.storage.Put(EntryTileIndexToPath(index, nil), bundle); err != nil {
+			return fmt.Errorf("failed to put entry bundle: %w", err)
+		}
+	}
+
+	if tl.hashOnly {
+		tl.hashOnly = false
+		return tl.saveState()
+	}
+	return nil
+}
+
+// appendToHashTile appends a leaf to a hash tile
+func (tl *TileLog) appendToHashTile(entryID int64, leafHash []byte) error {
 	tileIndex := EntryIDToTileIndex(entryID)
-	tilePath := EntryTileIndexToPath(tileIndex, nil)
+	tilePath := HashTileIndexToPath(tileIndex, nil)
 
 	// Read existing tile (if any)
 	existingTile, err := tl.storage.Get(tilePath)
@@ -212,17 +507,17 @@ func (tl *TileLog) appendToEntryTile(entryID int64, leafHash []byte) error {
 		return fmt.Errorf("failed to get existing tile: %w", err)
 	}
 
-	var currentSize int
-	if existingTile != nil {
-		currentSize = len(existingTile) / HashSize
+	// The tile must end exactly before the new leaf, so that a leaf left by an
+	// interrupted append is never mistaken for this entry
+	offset := EntryIDToTileOffset(entryID)
+	if len(existingTile) != offset*HashSize {
+		return fmt.Errorf("hash tile %s holds %d bytes, expected %d before entry %d", tilePath, len(existingTile), offset*HashSize, entryID)
 	}
 
 	// Append new leaf
-	newTile := make([]byte, (currentSize+1)*HashSize)
-	if existingTile != nil {
-		copy(newTile, existingTile)
-	}
-	copy(newTile[currentSize*HashSize:], leafHash)
+	newTile := make([]byte, (offset+1)*HashSize)
+	copy(newTile, existingTile)
+	copy(newTile[offset*HashSize:], leafHash)
 
 	// Write updated tile
 	if err := tl.storage.Put(tilePath, newTile); err != nil {
@@ -232,6 +527,35 @@ func (tl *TileLog) appendToEntryTile(entryID int64, leafHash []byte) error {
 	return nil
 }
 
+// appendToEntryBundle appends an entry to an entry bundle
+func (tl *TileLog) appendToEntryBundle(entryID int64, entry []byte) error {
+	bundlePath := EntryTileIndexToPath(EntryIDToTileIndex(entryID), nil)
+
+	existing, err := tl.storage.Get(bundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to get existing bundle: %w", err)
+	}
+
+	// As with hash tiles, the bundle must end exactly before the new entry
+	entries, err := DecodeEntryBundle(existing)
+	if err != nil {
+		return fmt.Errorf("failed to decode entry bundle %s: %w", bundlePath, err)
+	}
+	if offset := EntryIDToTileOffset(entryID); len(entries) != offset {
+		return fmt.Errorf("entry bundle %s holds %d entries, expected %d before entry %d", bundlePath, len(entries), offset, entryID)
+	}
+
+	bundle, err := AppendEntryBundle(existing, entry)
+	if err != nil {
+		return err
+	}
+	if err := tl.storage.Put(bundlePath, bundle); err != nil {
+		return fmt.Errorf("failed to put entry bundle: %w", err)
+	}
+
+	return nil
+}
+
 // RecordHash computes the hash of a record (leaf) with RFC 6962 prefix
 // This is a convenience function that wraps Tessera's HashLeaf
 func RecordHash(data []byte) [HashSize]byte {
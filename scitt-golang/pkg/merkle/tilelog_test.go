@@ -425,7 +425,235 @@ func TestTileLogPersistence(t *testing.T) {
 	})
 }
 
+func TestTileLogRebuildAndVerify(t *testing.T) {
+	appendLeaves := func(t *testing.T, tl *merkle.TileLog, n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if _, err := tl.Append(hashData([]byte{byte(i), byte(i >> 8)})); err != nil {
+				t.Fatalf("failed to append leaf %d: %v", i, err)
+			}
+		}
+	}
+
+	t.Run("rebuilds a lost tree state from the entry tiles", func(t *testing.T) {
+		store := storage.NewMemoryStorage()
+		tl := merkle.NewTileLog(store)
+		appendLeaves(t, tl, 300)
+		root, _ := tl.Root()
+
+		if err := store.Delete(merkle.TreeStatePath); err != nil {
+			t.Fatalf("failed to delete tree state: %v", err)
+		}
+
+		rebuilt := merkle.NewTileLog(store)
+		if err := rebuilt.Rebuild(300); err != nil {
+			t.Fatalf("failed to rebuild: %v", err)
+		}
+		if rebuiltRoot, _ := rebuilt.Root(); rebuiltRoot != root {
+			t.Error("expected the rebuilt root to match")
+		}
+
+		reloaded := merkle.NewTileLog(store)
+		if err := reloaded.Load(); err != nil {
+			t.Fatalf("failed to load: %v", err)
+		}
+		if reloaded.Size() != 300 {
+			t.Errorf("expected the rebuilt state to be persisted, got size %d", reloaded.Size())
+		}
+	})
+
+	t.Run("rejects a rebuild beyond the entry tiles", func(t *testing.T) {
+		tl := merkle.NewTileLog(storage.NewMemoryStorage())
+		appendLeaves(t, tl, 3)
+
+		if err := tl.Rebuild(4); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("verifies the tree state against the hash tiles", func(t *testing.T) {
+		store := storage.NewMemoryStorage()
+		tl := merkle.NewTileLog(store)
+		appendLeaves(t, tl, 5)
+
+		if err := tl.Verify(); err != nil {
+			t.Fatalf("expected the tree state to verify: %v", err)
+		}
+
+		tile, _ := store.Get(merkle.HashTileIndexToPath(0, nil))
+		tile[0] ^= 0xff
+		if err := store.Put(merkle.HashTileIndexToPath(0, nil), tile); err != nil {
+			t.Fatalf("failed to tamper with tile: %v", err)
+		}
+		if err := tl.Verify(); err == nil {
+			t.Error("expected a tampered tile to fail verification")
+		}
+	})
+
+	t.Run("refuses to append after a leaf beyond the tree", func(t *testing.T) {
+		store := storage.NewMemoryStorage()
+		tl := merkle.NewTileLog(store)
+		appendLeaves(t, tl, 2)
+
+		tile, _ := store.Get(merkle.HashTileIndexToPath(0, nil))
+		orphan := hashData([]byte("orphan"))
+		if err := store.Put(merkle.HashTileIndexToPath(0, nil), append(tile, orphan[:]...)); err != nil {
+			t.Fatalf("failed to write tile: %v", err)
+		}
+
+		if _, err := tl.Append(hashData([]byte("next"))); err == nil {
+			t.Error("expected an error appending after an orphan leaf")
+		}
+	})
+}
+
+func TestTileLogTruncate(t *testing.T) {
+	t.Run("discards entries from the truncated size", func(t *testing.T) {
+		store := storage.NewMemoryStorage()
+		tl := merkle.NewTileLog(store)
+		for i := 0; i < 3; i++ {
+			if _, err := tl.AppendEntry([]byte{byte(i)}); err != nil {
+				t.Fatalf("failed to append entry %d: %v", i, err)
+			}
+		}
+		root, _ := tl.Root()
+		if _, err := tl.AppendEntry([]byte("undone")); err != nil {
+			t.Fatalf("failed to append entry: %v", err)
+		}
+
+		if err := tl.Truncate(3); err != nil {
+			t.Fatalf("failed to truncate: %v", err)
+		}
+		if tl.Size() != 3 {
+			t.Errorf("expected size 3, got %d", tl.Size())
+		}
+		if truncatedRoot, _ := tl.Root(); truncatedRoot != root {
+			t.Error("expected the root of the truncated tree")
+		}
+		if err := tl.Verify(); err != nil {
+			t.Errorf("expected the tiles to match the tree state: %v", err)
+		}
+
+		// The next append takes the discarded entry's place
+		entryID, err := tl.AppendEntry([]byte("next"))
+		if err != nil || entryID != 3 {
+			t.Fatalf("expected to append entry 3, got %d (%v)", entryID, err)
+		}
+		if entry, _ := tl.GetEntry(3); string(entry) != "next" {
+			t.Errorf("expected the new entry, got %q", entry)
+		}
+	})
+
+	t.Run("removes a leaf written beyond the tree", func(t *testing.T) {
+		store := storage.NewMemoryStorage()
+		tl := merkle.NewTileLog(store)
+		if _, err := tl.AppendEntry([]byte("first")); err != nil {
+			t.Fatalf("failed to append entry: %v", err)
+		}
+
+		// An append interrupted after writing its entry bundle
+		bundlePath := merkle.EntryTileIndexToPath(0, nil)
+		bundle, _ := store.Get(bundlePath)
+		bundle, _ = merkle.AppendEntryBundle(bundle, []byte("orphan"))
+		store.Put(bundlePath, bundle)
+
+		if err := tl.Truncate(1); err != nil {
+			t.Fatalf("failed to truncate: %v", err)
+		}
+		if _, err := tl.AppendEntry([]byte("next")); err != nil {
+			t.Errorf("expected to append after truncating: %v", err)
+		}
+	})
+
+	t.Run("empties the tree", func(t *testing.T) {
+		store := storage.NewMemoryStorage()
+		tl := merkle.NewTileLog(store)
+		if _, err := tl.AppendEntry([]byte("only")); err != nil {
+			t.Fatalf("failed to append entry: %v", err)
+		}
+
+		if err := tl.Truncate(0); err != nil {
+			t.Fatalf("failed to truncate: %v", err)
+		}
+		if exists, _ := store.Exists(merkle.HashTileIndexToPath(0, nil)); exists {
+			t.Error("expected the empty hash tile to be deleted")
+		}
+	})
+
+	t.Run("rejects a size beyond the tree", func(t *testing.T) {
+		tl := merkle.NewTileLog(storage.NewMemoryStorage())
+		if err := tl.Truncate(1); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
 // Helper function to hash data
 func hashData(data []byte) [32]byte {
 	return sha256.Sum256(data)
 }
+
+func TestTileLogHashOnly(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	tl := merkle.NewTileLog(store)
+	var entries [][]byte
+	for i := 0; i < 3; i++ {
+		entry := []byte{byte(i)}
+		if _, err := tl.AppendEntry(entry); err != nil {
+			t.Fatalf("failed to append entry %d: %v", i, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	t.Run("drops entry bundles and appends only leaves", func(t *testing.T) {
+		if err := tl.DropBundles(); err != nil {
+			t.Fatalf("failed to drop bundles: %v", err)
+		}
+		if exists, _ := store.Exists(merkle.EntryTileIndexToPath(0, nil)); exists {
+			t.Error("expected the entry bundle deleted")
+		}
+		if _, err := tl.AppendEntry([]byte("leaf only")); err != nil {
+			t.Fatalf("failed to append entry: %v", err)
+		}
+		if exists, _ := store.Exists(merkle.EntryTileIndexToPath(0, nil)); exists {
+			t.Error("expected no entry bundle written by a hash-only log")
+		}
+		entries = append(entries, []byte("leaf only"))
+
+		reloaded := merkle.NewTileLog(store)
+		if err := reloaded.Load(); err != nil || !reloaded.HashOnly() {
+			t.Errorf("expected a hash-only tree state, got %v (%v)", reloaded.HashOnly(), err)
+		}
+	})
+
+	t.Run("finds the first missing statement", func(t *testing.T) {
+		for _, entry := range entries[1:] {
+			leaf := sha256.Sum256(entry)
+			store.Put(merkle.StatementPath(leaf[:]), entry)
+		}
+		if missing, err := tl.MissingStatement(); err != nil || missing != 0 {
+			t.Errorf("expected entry 0 missing, got %d (%v)", missing, err)
+		}
+
+		leaf := sha256.Sum256(entries[0])
+		store.Put(merkle.StatementPath(leaf[:]), entries[0])
+		if missing, err := tl.MissingStatement(); err != nil || missing != -1 {
+			t.Errorf("expected no missing statement, got %d (%v)", missing, err)
+		}
+	})
+
+	t.Run("writes entry bundles again once rebuilt", func(t *testing.T) {
+		if err := tl.RebuildBundles(); err != nil {
+			t.Fatalf("failed to rebuild bundles: %v", err)
+		}
+		if tl.HashOnly() {
+			t.Error("expected the log to write entry bundles again")
+		}
+		if _, err := tl.AppendEntry([]byte("bundled")); err != nil {
+			t.Fatalf("failed to append entry: %v", err)
+		}
+		if entry, err := tl.GetEntry(4); err != nil || string(entry) != "bundled" {
+			t.Errorf("expected the bundled entry, got %q (%v)", entry, err)
+		}
+	})
+}
@@ -0,0 +1,506 @@
+// Package metrics provides counters, gauges and histograms exposed in the
+// Prometheus text exposition format
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets are latency buckets in seconds suitable for request and query timings
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default is the registry used by the package-level constructors and served at /metrics
+var Default = NewRegistry()
+
+// collector is a metric family that can write itself in text format
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families by name
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]collector)}
+}
+
+// register adds a metric family, panicking on duplicate names like a programming error should
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.families[name]; exists {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", name))
+	}
+	r.families[name] = c
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := r.families
+	r.mu.Unlock()
+
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		families[name].write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler returns an HTTP handler serving the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// Since returns the seconds elapsed since start, for observing durations
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
+
+// family holds the children of a labelled metric
+type family[T any] struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	newChild   func() *T
+
+	mu        sync.Mutex
+	children  map[string]*T
+	labels    map[string][]string
+	omitEmpty map[string]bool
+}
+
+func newFamily[T any](name, help, kind string, labelNames []string, newChild func() *T) *family[T] {
+	return &family[T]{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		newChild:   newChild,
+		children:   make(map[string]*T),
+		labels:     make(map[string][]string),
+	}
+}
+
+// with returns the child for a set of label values, creating it on first use
+func (f *family[T]) with(values []string) *T {
+	if len(values) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labelNames), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	child, ok := f.children[key]
+	if !ok {
+		child = f.newChild()
+		f.children[key] = child
+		f.labels[key] = append([]string(nil), values...)
+	}
+	return child
+}
+
+// each visits children in a stable order
+func (f *family[T]) each(fn func(labels string, child *T)) {
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.children))
+	for key := range f.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	children := make([]*T, len(keys))
+	labels := make([]string, len(keys))
+	for i, key := range keys {
+		children[i] = f.children[key]
+		labels[i] = formatLabels(f.labelNames, f.labels[key], f.omitEmpty)
+	}
+	f.mu.Unlock()
+
+	for i := range keys {
+		fn(labels[i], children[i])
+	}
+}
+
+// OmitEmpty leaves the named labels out of series where their value is empty,
+// so that a label only some deployments set does not change the series of others
+func (f *family[T]) OmitEmpty(labelNames ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.omitEmpty == nil {
+		f.omitEmpty = make(map[string]bool)
+	}
+	for _, name := range labelNames {
+		f.omitEmpty[name] = true
+	}
+}
+
+func (f *family[T]) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	value atomicFloat
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() { c.value.add(1) }
+
+// Add adds a non-negative value to the counter
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.value.add(v)
+}
+
+// Value returns the current count
+func (c *Counter) Value() float64 { return c.value.load() }
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	*family[Counter]
+}
+
+// NewCounterVec creates and registers a counter family with the default registry
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labelNames...)
+}
+
+// NewCounterVec creates and registers a counter family
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	v := &CounterVec{newFamily(name, help, "counter", labelNames, func() *Counter { return &Counter{} })}
+	r.register(name, v)
+	return v
+}
+
+// WithLabelValues returns the counter for the given label values
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	return v.with(values)
+}
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.writeHeader(w)
+	v.each(func(labels string, c *Counter) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, labels, formatFloat(c.Value()))
+	})
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	value atomicFloat
+}
+
+// Set sets the gauge
+func (g *Gauge) Set(v float64) { g.value.store(v) }
+
+// Add adds v (which may be negative) to the gauge
+func (g *Gauge) Add(v float64) { g.value.add(v) }
+
+// Value returns the current value
+func (g *Gauge) Value() float64 { return g.value.load() }
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct {
+	*family[Gauge]
+}
+
+// NewGaugeVec creates and registers a gauge family with the default registry
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labelNames...)
+}
+
+// NewGaugeVec creates and registers a gauge family
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	v := &GaugeVec{newFamily(name, help, "gauge", labelNames, func() *Gauge { return &Gauge{} })}
+	r.register(name, v)
+	return v
+}
+
+// WithLabelValues returns the gauge for the given label values
+func (v *GaugeVec) WithLabelValues(values ...string) *Gauge {
+	return v.with(values)
+}
+
+func (v *GaugeVec) write(w *bufio.Writer) {
+	v.writeHeader(w)
+	v.each(func(labels string, g *Gauge) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, labels, formatFloat(g.Value()))
+	})
+}
+
+// GaugeFunc is a gauge whose value is computed when metrics are collected
+type GaugeFunc struct {
+	name string
+	help string
+
+	mu sync.Mutex
+	fn func() float64
+}
+
+// NewGaugeFunc creates and registers a computed gauge with the default registry
+func NewGaugeFunc(name, help string) *GaugeFunc {
+	return Default.NewGaugeFunc(name, help)
+}
+
+// NewGaugeFunc creates and registers a computed gauge
+// The gauge is omitted from the output until a function is set
+func (r *Registry) NewGaugeFunc(name, help string) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help}
+	r.register(name, g)
+	return g
+}
+
+// SetFunc sets the function computing the gauge value (nil removes it)
+func (g *GaugeFunc) SetFunc(fn func() float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.fn = fn
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.mu.Lock()
+	fn := g.fn
+	g.mu.Unlock()
+
+	if fn == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "# HELP %s %s\n", g.name, escapeHelp(g.help))
+	fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(fn()))
+}
+
+// GaugeFuncVec is a computed gauge partitioned by labels
+type GaugeFuncVec struct {
+	*family[gaugeFunc]
+}
+
+// gaugeFunc is the computed value of one GaugeFuncVec child
+type gaugeFunc struct {
+	mu sync.Mutex
+	fn func() float64
+}
+
+// NewGaugeFuncVec creates and registers a computed gauge family with the default registry
+func NewGaugeFuncVec(name, help string, labelNames ...string) *GaugeFuncVec {
+	return Default.NewGaugeFuncVec(name, help, labelNames...)
+}
+
+// NewGaugeFuncVec creates and registers a computed gauge family
+// Children are omitted from the output until a function is set
+func (r *Registry) NewGaugeFuncVec(name, help string, labelNames ...string) *GaugeFuncVec {
+	v := &GaugeFuncVec{newFamily(name, help, "gauge", labelNames, func() *gaugeFunc { return &gaugeFunc{} })}
+	r.register(name, v)
+	return v
+}
+
+// SetFunc sets the function computing the gauge for the given label values (nil removes it)
+func (v *GaugeFuncVec) SetFunc(fn func() float64, values ...string) {
+	child := v.with(values)
+	child.mu.Lock()
+	defer child.mu.Unlock()
+	child.fn = fn
+}
+
+func (v *GaugeFuncVec) write(w *bufio.Writer) {
+	headerWritten := false
+	v.each(func(labels string, g *gaugeFunc) {
+		g.mu.Lock()
+		fn := g.fn
+		g.mu.Unlock()
+
+		if fn == nil {
+			return
+		}
+		if !headerWritten {
+			v.writeHeader(w)
+			headerWritten = true
+		}
+		fmt.Fprintf(w, "%s%s %s\n", v.name, labels, formatFloat(fn()))
+	})
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	upperBounds []float64
+
+	mu     sync.Mutex
+	counts []uint64 // per bucket, non-cumulative; the last entry is +Inf
+	sum    float64
+	count  uint64
+}
+
+// Observe records a value
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upperBounds, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.sum += v
+	h.count++
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	*family[Histogram]
+}
+
+// NewHistogramVec creates and registers a histogram family with the default registry
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labelNames...)
+}
+
+// NewHistogramVec creates and registers a histogram family
+// Buckets are upper bounds in increasing order; +Inf is added automatically
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: buckets for %q must be sorted", name))
+	}
+
+	upperBounds := append([]float64(nil), buckets...)
+	v := &HistogramVec{newFamily(name, help, "histogram", labelNames, func() *Histogram {
+		return &Histogram{
+			upperBounds: upperBounds,
+			counts:      make([]uint64, len(upperBounds)+1),
+		}
+	})}
+	r.register(name, v)
+	return v
+}
+
+// WithLabelValues returns the histogram for the given label values
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return v.with(values)
+}
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.writeHeader(w)
+	v.each(func(labels string, h *Histogram) {
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+
+		var cumulative uint64
+		for i, bound := range h.upperBounds {
+			cumulative += counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, withLabel(labels, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, withLabel(labels, "le", "+Inf"), count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.name, labels, formatFloat(sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.name, labels, count)
+	})
+}
+
+// atomicFloat is a float64 updated with compare-and-swap
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) load() float64 { return math.Float64frombits(f.bits.Load()) }
+
+func (f *atomicFloat) store(v float64) { f.bits.Store(math.Float64bits(v)) }
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// formatLabels renders {name="value",...} (empty when there are no labels)
+// Labels in omitEmpty are left out when their value is empty
+func formatLabels(names, values []string, omitEmpty map[string]bool) string {
+	var b strings.Builder
+	for i, name := range names {
+		if values[i] == "" && omitEmpty[name] {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteByte('{')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(values[i]))
+		b.WriteByte('"')
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// withLabel appends one more label to a rendered label set
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + escapeLabelValue(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return strings.TrimSuffix(labels, "}") + "," + pair + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string { return labelValueEscaper.Replace(v) }
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(v string) string { return helpEscaper.Replace(v) }
+
+// formatFloat renders a sample value
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
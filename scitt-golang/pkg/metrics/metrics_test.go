@@ -0,0 +1,169 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/metrics"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	t.Run("writes counters with labels", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		requests := registry.NewCounterVec("test_requests_total", "Requests handled.", "route", "status")
+
+		requests.WithLabelValues("/entries", "201").Inc()
+		requests.WithLabelValues("/entries", "201").Add(2)
+		requests.WithLabelValues("/entries/{id}", "404").Inc()
+
+		output := writeText(t, registry)
+		for _, want := range []string{
+			"# HELP test_requests_total Requests handled.\n",
+			"# TYPE test_requests_total counter\n",
+			`test_requests_total{route="/entries",status="201"} 3` + "\n",
+			`test_requests_total{route="/entries/{id}",status="404"} 1` + "\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("output missing %q:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("writes cumulative histogram buckets", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		latency := registry.NewHistogramVec("test_duration_seconds", "Durations.", []float64{0.1, 1}, "op")
+
+		h := latency.WithLabelValues("get")
+		h.Observe(0.05)
+		h.Observe(0.1)
+		h.Observe(0.5)
+		h.Observe(3)
+
+		output := writeText(t, registry)
+		for _, want := range []string{
+			"# TYPE test_duration_seconds histogram\n",
+			`test_duration_seconds_bucket{op="get",le="0.1"} 2` + "\n",
+			`test_duration_seconds_bucket{op="get",le="1"} 3` + "\n",
+			`test_duration_seconds_bucket{op="get",le="+Inf"} 4` + "\n",
+			`test_duration_seconds_sum{op="get"} 3.65` + "\n",
+			`test_duration_seconds_count{op="get"} 4` + "\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("output missing %q:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("writes gauges and gauge funcs", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		size := registry.NewGaugeVec("test_tree_size", "Tree size.")
+		age := registry.NewGaugeFunc("test_age_seconds", "Age.")
+
+		size.WithLabelValues().Set(42)
+		if strings.Contains(writeText(t, registry), "test_age_seconds") {
+			t.Error("gauge func without a function should be omitted")
+		}
+
+		age.SetFunc(func() float64 { return 7.5 })
+		output := writeText(t, registry)
+		for _, want := range []string{"test_tree_size 42\n", "test_age_seconds 7.5\n"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("output missing %q:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("omits empty optional labels", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		requests := registry.NewCounterVec("test_log_requests_total", "Requests.", "route", "log")
+		requests.OmitEmpty("log")
+		size := registry.NewGaugeVec("test_log_tree_size", "Tree size.", "log")
+		size.OmitEmpty("log")
+
+		requests.WithLabelValues("/entries", "").Inc()
+		requests.WithLabelValues("/entries", "staging").Inc()
+		size.WithLabelValues("").Set(3)
+
+		output := writeText(t, registry)
+		for _, want := range []string{
+			`test_log_requests_total{route="/entries"} 1` + "\n",
+			`test_log_requests_total{route="/entries",log="staging"} 1` + "\n",
+			"test_log_tree_size 3\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("output missing %q:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("writes labelled gauge funcs", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		age := registry.NewGaugeFuncVec("test_log_age_seconds", "Age.", "log")
+
+		if strings.Contains(writeText(t, registry), "test_log_age_seconds") {
+			t.Error("gauge func family without functions should be omitted")
+		}
+
+		age.SetFunc(func() float64 { return 1 }, "production")
+		age.SetFunc(func() float64 { return 2 }, "staging")
+		output := writeText(t, registry)
+		for _, want := range []string{
+			"# TYPE test_log_age_seconds gauge\n",
+			`test_log_age_seconds{log="production"} 1` + "\n",
+			`test_log_age_seconds{log="staging"} 2` + "\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("output missing %q:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("escapes label values", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		registry.NewCounterVec("test_escaped_total", "Escaping.", "value").WithLabelValues("a\"b\\c\nd").Inc()
+
+		want := `test_escaped_total{value="a\"b\\c\nd"} 1`
+		if output := writeText(t, registry); !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	})
+
+	t.Run("rejects duplicate names", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		registry.NewCounterVec("test_duplicate_total", "First.")
+
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic on duplicate registration")
+			}
+		}()
+		registry.NewGaugeVec("test_duplicate_total", "Second.")
+	})
+}
+
+func TestRegistryHandler(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.NewCounterVec("test_handler_total", "Handler.").WithLabelValues().Inc()
+
+	w := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type: %s", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "test_handler_total 1\n") {
+		t.Errorf("unexpected body:\n%s", w.Body.String())
+	}
+}
+
+func writeText(t *testing.T, registry *metrics.Registry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := registry.WriteText(&buf); err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+	return buf.String()
+}
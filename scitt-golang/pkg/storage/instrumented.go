@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/metrics"
+)
+
+var (
+	operationDuration = metrics.NewHistogramVec(
+		"scitt_storage_operation_duration_seconds",
+		"Latency of storage operations by backend and operation.",
+		metrics.DefaultBuckets,
+		"backend", "operation",
+	)
+	operationErrors = metrics.NewCounterVec(
+		"scitt_storage_errors_total",
+		"Failed storage operations by backend and operation.",
+		"backend", "operation",
+	)
+)
+
+// InstrumentedStorage records latency and errors of every operation on a backend
+type InstrumentedStorage struct {
+	Storage
+	backend string
+}
+
+// Instrument wraps a storage backend so its operations are exported as metrics
+// backend names the implementation in metric labels (e.g. "local", "memory")
+func Instrument(store Storage, backend string) *InstrumentedStorage {
+	return &InstrumentedStorage{Storage: store, backend: backend}
+}
+
+// observe records the outcome of an operation started at start
+func (s *InstrumentedStorage) observe(operation string, start time.Time, err error) {
+	operationDuration.WithLabelValues(s.backend, operation).Observe(metrics.Since(start))
+	if err != nil {
+		operationErrors.WithLabelValues(s.backend, operation).Inc()
+	}
+}
+
+// Get retrieves data by key
+func (s *InstrumentedStorage) Get(key string) ([]byte, error) {
+	start := time.Now()
+	data, err := s.Storage.Get(key)
+	s.observe("get", start, err)
+	return data, err
+}
+
+// Put stores data at the specified key
+func (s *InstrumentedStorage) Put(key string, data []byte) error {
+	start := time.Now()
+	err := s.Storage.Put(key, data)
+	s.observe("put", start, err)
+	return err
+}
+
+// Delete removes data at the specified key
+func (s *InstrumentedStorage) Delete(key string) error {
+	start := time.Now()
+	err := s.Storage.Delete(key)
+	s.observe("delete", start, err)
+	return err
+}
+
+// Exists checks if a key exists
+func (s *InstrumentedStorage) Exists(key string) (bool, error) {
+	start := time.Now()
+	exists, err := s.Storage.Exists(key)
+	s.observe("exists", start, err)
+	return exists, err
+}
+
+// List returns all keys with the given prefix
+func (s *InstrumentedStorage) List(prefix string) ([]string, error) {
+	start := time.Now()
+	keys, err := s.Storage.List(prefix)
+	s.observe("list", start, err)
+	return keys, err
+}
+
+// String returns the wrapped backend description
+func (s *InstrumentedStorage) String() string {
+	if stringer, ok := s.Storage.(interface{ String() string }); ok {
+		return stringer.String()
+	}
+	return s.backend
+}
@@ -0,0 +1,48 @@
+package storage
+
+import "strings"
+
+// PrefixedStorage stores every key of a backend under a fixed prefix, so that
+// several logs can share one bucket or directory
+type PrefixedStorage struct {
+	Storage
+	prefix string
+}
+
+// WithPrefix wraps a storage backend so its keys are stored under prefix
+// The prefix should end with "/" (e.g. "shards/2025/")
+func WithPrefix(store Storage, prefix string) *PrefixedStorage {
+	return &PrefixedStorage{Storage: store, prefix: prefix}
+}
+
+// Get retrieves data by key
+func (s *PrefixedStorage) Get(key string) ([]byte, error) {
+	return s.Storage.Get(s.prefix + key)
+}
+
+// Put stores data at the specified key
+func (s *PrefixedStorage) Put(key string, data []byte) error {
+	return s.Storage.Put(s.prefix+key, data)
+}
+
+// Delete removes data at the specified key
+func (s *PrefixedStorage) Delete(key string) error {
+	return s.Storage.Delete(s.prefix + key)
+}
+
+// Exists checks if a key exists
+func (s *PrefixedStorage) Exists(key string) (bool, error) {
+	return s.Storage.Exists(s.prefix + key)
+}
+
+// List returns all keys with the given prefix, relative to the storage prefix
+func (s *PrefixedStorage) List(prefix string) ([]string, error) {
+	keys, err := s.Storage.List(s.prefix + prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, s.prefix)
+	}
+	return keys, nil
+}
@@ -0,0 +1,62 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+)
+
+// TestPrefixedStorage tests that prefixed keys are isolated from the rest of the backend
+func TestPrefixedStorage(t *testing.T) {
+	backend := storage.NewMemoryStorage()
+	store := storage.WithPrefix(backend, "shards/2025/")
+
+	if err := store.Put("checkpoint", []byte("shard")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	if err := backend.Put("checkpoint", []byte("root")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+
+	t.Run("stores keys under the prefix", func(t *testing.T) {
+		data, err := backend.Get("shards/2025/checkpoint")
+		if err != nil || string(data) != "shard" {
+			t.Errorf("expected prefixed key in backend, got %q (%v)", data, err)
+		}
+
+		data, err = store.Get("checkpoint")
+		if err != nil || string(data) != "shard" {
+			t.Errorf("expected shard checkpoint, got %q (%v)", data, err)
+		}
+
+		exists, err := store.Exists("checkpoint")
+		if err != nil || !exists {
+			t.Errorf("expected key to exist (%v)", err)
+		}
+	})
+
+	t.Run("lists keys relative to the prefix", func(t *testing.T) {
+		keys, err := store.List("")
+		if err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+		if len(keys) != 1 || keys[0] != "checkpoint" {
+			t.Errorf("expected [checkpoint], got %v", keys)
+		}
+	})
+
+	t.Run("deletes only the prefixed key", func(t *testing.T) {
+		if err := store.Delete("checkpoint"); err != nil {
+			t.Fatalf("delete failed: %v", err)
+		}
+
+		data, err := store.Get("checkpoint")
+		if err != nil || data != nil {
+			t.Errorf("expected shard checkpoint to be deleted, got %q (%v)", data, err)
+		}
+		data, err = backend.Get("checkpoint")
+		if err != nil || string(data) != "root" {
+			t.Errorf("expected root checkpoint to remain, got %q (%v)", data, err)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package witness
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxResponseBytes bounds witness responses
+const maxResponseBytes = 16 << 10
+
+// Client submits checkpoints to one witness and verifies its cosignatures
+type Client struct {
+	url        string
+	verifier   *Verifier
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the witness at baseURL whose cosignatures verify with verifier
+func NewClient(baseURL string, verifier *Verifier, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		url:        strings.TrimSuffix(baseURL, "/") + AddCheckpointPath,
+		verifier:   verifier,
+		httpClient: httpClient,
+	}
+}
+
+// Name returns the witness name
+func (c *Client) Name() string {
+	return c.verifier.Name()
+}
+
+// AddCheckpoint submits a checkpoint and returns the witness's verified cosignature line
+// A *ConflictError carries the witness's latest size when req.OldSize is stale
+func (c *Client) AddCheckpoint(ctx context.Context, req *AddCheckpointRequest) (string, error) {
+	body, _, err := SplitNote(req.Checkpoint)
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(req.Marshal()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create witness request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to contact witness %s: %w", c.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read witness response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == ContentTypeTreeSize {
+			size, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("witness %s returned invalid tree size: %w", c.Name(), err)
+			}
+			return "", &ConflictError{Size: size}
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("witness %s returned %d: %s", c.Name(), resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if _, err := c.verifier.Verify(body, line); err == nil {
+			return line, nil
+		}
+	}
+
+	return "", fmt.Errorf("witness %s returned no valid cosignature", c.Name())
+}
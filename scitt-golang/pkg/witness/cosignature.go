@@ -0,0 +1,272 @@
+// Package witness implements the C2SP tlog-witness protocol for checkpoints:
+// Ed25519 cosignatures (cosignature/v1), the add-checkpoint request format,
+// a client for submitting checkpoints and a witness HTTP handler
+package witness
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// algCosignatureV1 identifies Ed25519 cosignature/v1 keys in key hashes and encodings
+const algCosignatureV1 = 0x04
+
+// cosignatureSize is the length of an encoded cosignature: key hash, timestamp, signature
+const cosignatureSize = 4 + 8 + ed25519.SignatureSize
+
+// privateKeyPrefix starts an encoded witness signing key
+const privateKeyPrefix = "PRIVATE+KEY+"
+
+// Signer produces cosignatures over checkpoints with an Ed25519 witness key
+type Signer struct {
+	name    string
+	key     ed25519.PrivateKey
+	keyHash [4]byte
+}
+
+// Verifier checks cosignatures produced by one witness
+type Verifier struct {
+	name    string
+	key     ed25519.PublicKey
+	keyHash [4]byte
+}
+
+// GenerateKey creates a witness key pair, returning the encoded signing key and verifier key
+func GenerateKey(name string) (skey, vkey string, err error) {
+	if !validKeyName(name) {
+		return "", "", fmt.Errorf("invalid witness name: %q", name)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate witness key: %w", err)
+	}
+
+	keyHash := computeKeyHash(name, pub)
+	skey = fmt.Sprintf("%s%s+%s+%s", privateKeyPrefix, name, hex.EncodeToString(keyHash[:]),
+		base64.StdEncoding.EncodeToString(append([]byte{algCosignatureV1}, priv.Seed()...)))
+
+	return skey, FormatVerifierKey(name, pub), nil
+}
+
+// NewSigner parses an encoded witness signing key (PRIVATE+KEY+<name>+<hash>+<key>)
+func NewSigner(skey string) (*Signer, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(skey), privateKeyPrefix)
+	if !ok {
+		return nil, fmt.Errorf("invalid witness signing key: missing %s prefix", privateKeyPrefix)
+	}
+
+	name, keyHash, key, err := parseKey(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid witness signing key: %w", err)
+	}
+	if len(key) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid witness signing key: seed is %d bytes", len(key))
+	}
+
+	priv := ed25519.NewKeyFromSeed(key)
+	if computeKeyHash(name, priv.Public().(ed25519.PublicKey)) != keyHash {
+		return nil, fmt.Errorf("invalid witness signing key: key hash mismatch")
+	}
+
+	return &Signer{name: name, key: priv, keyHash: keyHash}, nil
+}
+
+// Name returns the witness name used in cosignature lines
+func (s *Signer) Name() string {
+	return s.name
+}
+
+// VerifierKey returns the encoded verifier key for this signer
+func (s *Signer) VerifierKey() string {
+	return FormatVerifierKey(s.name, s.key.Public().(ed25519.PublicKey))
+}
+
+// Cosign signs a checkpoint body at the given time and returns the cosignature line
+func (s *Signer) Cosign(body string, timestamp time.Time) string {
+	ts := uint64(timestamp.Unix())
+	signature := ed25519.Sign(s.key, cosignedMessage(body, ts))
+
+	encoded := make([]byte, 0, cosignatureSize)
+	encoded = append(encoded, s.keyHash[:]...)
+	encoded = binary.BigEndian.AppendUint64(encoded, ts)
+	encoded = append(encoded, signature...)
+
+	return fmt.Sprintf("— %s %s\n", s.name, base64.StdEncoding.EncodeToString(encoded))
+}
+
+// FormatVerifierKey encodes a witness public key as <name>+<hash>+<key>
+func FormatVerifierKey(name string, pub ed25519.PublicKey) string {
+	keyHash := computeKeyHash(name, pub)
+	return fmt.Sprintf("%s+%s+%s", name, hex.EncodeToString(keyHash[:]),
+		base64.StdEncoding.EncodeToString(append([]byte{algCosignatureV1}, pub...)))
+}
+
+// ParseVerifierKey parses an encoded witness verifier key (<name>+<hash>+<key>)
+func ParseVerifierKey(vkey string) (*Verifier, error) {
+	name, keyHash, key, err := parseKey(strings.TrimSpace(vkey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid witness verifier key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid witness verifier key: public key is %d bytes", len(key))
+	}
+
+	pub := ed25519.PublicKey(key)
+	if computeKeyHash(name, pub) != keyHash {
+		return nil, fmt.Errorf("invalid witness verifier key: key hash mismatch")
+	}
+
+	return &Verifier{name: name, key: pub, keyHash: keyHash}, nil
+}
+
+// Name returns the witness name expected in cosignature lines
+func (v *Verifier) Name() string {
+	return v.name
+}
+
+// Verify checks a cosignature line over a checkpoint body and returns the time it was made
+func (v *Verifier) Verify(body, line string) (time.Time, error) {
+	name, encoded, err := parseSignatureLine(line)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if name != v.name {
+		return time.Time{}, fmt.Errorf("cosignature is from %q, expected %q", name, v.name)
+	}
+	if len(encoded) != cosignatureSize || !bytes.Equal(encoded[:4], v.keyHash[:]) {
+		return time.Time{}, fmt.Errorf("cosignature is not from witness key %s", v.name)
+	}
+
+	ts := binary.BigEndian.Uint64(encoded[4:12])
+	if !ed25519.Verify(v.key, cosignedMessage(body, ts), encoded[12:]) {
+		return time.Time{}, fmt.Errorf("invalid cosignature from %s", v.name)
+	}
+
+	return time.Unix(int64(ts), 0), nil
+}
+
+// FindCosignature returns the first line in a signed note that verifies as this witness's cosignature
+func (v *Verifier) FindCosignature(note string) (string, time.Time, error) {
+	body, signatures, err := SplitNote(note)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	for _, line := range signatures {
+		if timestamp, err := v.Verify(body, line); err == nil {
+			return line, timestamp, nil
+		}
+	}
+	return "", time.Time{}, fmt.Errorf("no valid cosignature from %s", v.name)
+}
+
+// SplitNote splits a signed note into its body (including the final newline)
+// and its signature lines
+func SplitNote(note string) (string, []string, error) {
+	idx := strings.Index(note, "\n\n")
+	if idx < 0 {
+		return "", nil, fmt.Errorf("malformed signed note: missing blank line")
+	}
+
+	var signatures []string
+	for _, line := range strings.Split(strings.TrimRight(note[idx+2:], "\n"), "\n") {
+		if !strings.HasPrefix(line, "— ") {
+			return "", nil, fmt.Errorf("malformed signed note: invalid signature line")
+		}
+		signatures = append(signatures, line)
+	}
+
+	return note[:idx+1], signatures, nil
+}
+
+// AppendCosignatures adds cosignature lines to a signed note, skipping lines it already holds
+func AppendCosignatures(note string, lines ...string) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(note, "\n"))
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\n")
+		if line == "" || strings.Contains(note, line) {
+			continue
+		}
+		b.WriteString("\n")
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// cosignedMessage builds the cosignature/v1 signed message for a checkpoint body
+func cosignedMessage(body string, timestamp uint64) []byte {
+	return []byte(fmt.Sprintf("cosignature/v1\ntime %d\n%s", timestamp, body))
+}
+
+// computeKeyHash identifies a key by the first four bytes of SHA-256(name || "\n" || alg || key)
+func computeKeyHash(name string, pub ed25519.PublicKey) [4]byte {
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte{'\n', algCosignatureV1})
+	h.Write(pub)
+
+	var keyHash [4]byte
+	copy(keyHash[:], h.Sum(nil))
+	return keyHash
+}
+
+// parseKey parses <name>+<hash>+<base64(alg || key)>
+func parseKey(encoded string) (string, [4]byte, []byte, error) {
+	var keyHash [4]byte
+
+	name, rest, ok := strings.Cut(encoded, "+")
+	if !ok || !validKeyName(name) {
+		return "", keyHash, nil, fmt.Errorf("malformed key name")
+	}
+	hashHex, keyB64, ok := strings.Cut(rest, "+")
+	if !ok {
+		return "", keyHash, nil, fmt.Errorf("malformed key")
+	}
+
+	hashBytes, err := hex.DecodeString(hashHex)
+	if err != nil || len(hashBytes) != len(keyHash) {
+		return "", keyHash, nil, fmt.Errorf("malformed key hash")
+	}
+	copy(keyHash[:], hashBytes)
+
+	key, err := base64.StdEncoding.DecodeString(keyB64)
+	if err != nil || len(key) < 1 {
+		return "", keyHash, nil, fmt.Errorf("malformed key encoding")
+	}
+	if key[0] != algCosignatureV1 {
+		return "", keyHash, nil, fmt.Errorf("unsupported key algorithm 0x%02x", key[0])
+	}
+
+	return name, keyHash, key[1:], nil
+}
+
+// parseSignatureLine parses "— <name> <base64 signature>"
+func parseSignatureLine(line string) (string, []byte, error) {
+	rest, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "— ")
+	if !ok {
+		return "", nil, fmt.Errorf("malformed signature line")
+	}
+	name, sigB64, ok := strings.Cut(rest, " ")
+	if !ok {
+		return "", nil, fmt.Errorf("malformed signature line")
+	}
+	signature, err := base64.StdEncoding.DecodeString(sigB64)
+	if err != nil {
+		return "", nil, fmt.Errorf("malformed signature encoding: %w", err)
+	}
+	return name, signature, nil
+}
+
+// validKeyName reports whether name can appear in keys and signature lines
+func validKeyName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "+ \t\n")
+}
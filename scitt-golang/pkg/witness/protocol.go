@@ -0,0 +1,102 @@
+package witness
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+)
+
+// AddCheckpointPath is the witness endpoint checkpoints are submitted to
+const AddCheckpointPath = "/add-checkpoint"
+
+// ContentTypeTreeSize is the media type of a witness's latest size in a 409 response
+const ContentTypeTreeSize = "text/x.tlog.size"
+
+// maxProofHashes bounds the consistency proof a request may carry
+const maxProofHashes = 63
+
+// AddCheckpointRequest is a checkpoint submitted to a witness with a
+// consistency proof from the last size the witness cosigned
+type AddCheckpointRequest struct {
+	OldSize    int64
+	Proof      [][merkle.HashSize]byte
+	Checkpoint string // Signed note, as encoded by merkle.EncodeCheckpoint
+}
+
+// Marshal encodes the request body:
+//
+//	old <size>
+//	<base64 proof hash>...
+//
+//	<checkpoint>
+func (r *AddCheckpointRequest) Marshal() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "old %d\n", r.OldSize)
+	for _, hash := range r.Proof {
+		b.WriteString(base64.StdEncoding.EncodeToString(hash[:]))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(r.Checkpoint)
+	if !strings.HasSuffix(r.Checkpoint, "\n") {
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// ParseAddCheckpointRequest decodes an add-checkpoint request body
+func ParseAddCheckpointRequest(body []byte) (*AddCheckpointRequest, error) {
+	reader := bufio.NewReader(bytes.NewReader(body))
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("malformed request: missing old size line")
+	}
+	sizeStr, ok := strings.CutPrefix(strings.TrimSuffix(line, "\n"), "old ")
+	if !ok {
+		return nil, fmt.Errorf("malformed request: first line must be \"old <size>\"")
+	}
+	oldSize, err := strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil || oldSize < 0 {
+		return nil, fmt.Errorf("malformed request: invalid old size %q", sizeStr)
+	}
+
+	req := &AddCheckpointRequest{OldSize: oldSize}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("malformed request: missing blank line before checkpoint")
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			break
+		}
+		if len(req.Proof) == maxProofHashes {
+			return nil, fmt.Errorf("malformed request: consistency proof too long")
+		}
+
+		hash, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(hash) != merkle.HashSize {
+			return nil, fmt.Errorf("malformed request: invalid proof hash")
+		}
+		var h [merkle.HashSize]byte
+		copy(h[:], hash)
+		req.Proof = append(req.Proof, h)
+	}
+
+	rest := new(bytes.Buffer)
+	if _, err := rest.ReadFrom(reader); err != nil {
+		return nil, fmt.Errorf("malformed request: %w", err)
+	}
+	req.Checkpoint = rest.String()
+	if req.Checkpoint == "" {
+		return nil, fmt.Errorf("malformed request: missing checkpoint")
+	}
+
+	return req, nil
+}
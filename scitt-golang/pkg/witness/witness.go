@@ -0,0 +1,282 @@
+package witness
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+)
+
+// maxRequestBytes bounds add-checkpoint request bodies
+const maxRequestBytes = 64 << 10
+
+// Errors returned by Witness.AddCheckpoint, mapped to HTTP statuses by the handler
+var (
+	ErrUnknownLog       = errors.New("unknown log")
+	ErrInvalidSignature = errors.New("invalid checkpoint signature")
+	ErrInconsistent     = errors.New("consistency proof does not verify")
+	ErrBadRequest       = errors.New("bad request")
+)
+
+// ConflictError reports that the request's old size is not the witness's latest size
+// for the log (or that the checkpoint forks from it); the client should retry from Size
+type ConflictError struct {
+	Size int64
+}
+
+// Error implements error
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("witness latest size is %d", e.Size)
+}
+
+// LogState is the latest checkpoint a witness cosigned for one log
+type LogState struct {
+	TreeSize   int64  `json:"tree_size"`
+	RootHash   string `json:"root_hash"` // Hex-encoded
+	Checkpoint string `json:"checkpoint"`
+}
+
+// Config configures a witness
+type Config struct {
+	Signer *Signer
+	// Logs maps checkpoint origins (the issuer line) to their ES256 verification keys
+	Logs map[string]*ecdsa.PublicKey
+	// StatePath is the JSON file persisting the latest cosigned size per log
+	// (empty keeps state in memory only)
+	StatePath string
+}
+
+// Witness verifies and cosigns checkpoints for a set of logs, refusing any
+// checkpoint that is not consistent with the last one it cosigned
+type Witness struct {
+	signer    *Signer
+	logs      map[string]*ecdsa.PublicKey
+	statePath string
+
+	mu    sync.Mutex
+	state map[string]*LogState
+}
+
+// New creates a witness, loading any persisted state
+func New(cfg Config) (*Witness, error) {
+	if cfg.Signer == nil {
+		return nil, fmt.Errorf("witness signer is required")
+	}
+	if len(cfg.Logs) == 0 {
+		return nil, fmt.Errorf("at least one log is required")
+	}
+
+	w := &Witness{
+		signer:    cfg.Signer,
+		logs:      cfg.Logs,
+		statePath: cfg.StatePath,
+		state:     make(map[string]*LogState),
+	}
+
+	if cfg.StatePath != "" {
+		data, err := os.ReadFile(cfg.StatePath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read witness state: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &w.state); err != nil {
+				return nil, fmt.Errorf("failed to parse witness state: %w", err)
+			}
+		}
+	}
+
+	return w, nil
+}
+
+// State returns the latest cosigned checkpoint for a log (nil if none)
+func (w *Witness) State(origin string) *LogState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if state, ok := w.state[origin]; ok {
+		copied := *state
+		return &copied
+	}
+	return nil
+}
+
+// AddCheckpoint verifies a checkpoint against the log's key and the witness's
+// latest state, records it and returns the witness's cosignature line
+func (w *Witness) AddCheckpoint(req *AddCheckpointRequest) (string, error) {
+	checkpoint, err := merkle.DecodeCheckpoint(req.Checkpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+	body, _, err := SplitNote(req.Checkpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+
+	publicKey, ok := w.logs[checkpoint.Issuer]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownLog, checkpoint.Issuer)
+	}
+	valid, err := merkle.VerifyCheckpoint(checkpoint, publicKey)
+	if err != nil || !valid {
+		return "", ErrInvalidSignature
+	}
+
+	if req.OldSize > checkpoint.TreeSize {
+		return "", fmt.Errorf("%w: old size %d is greater than checkpoint size %d", ErrBadRequest, req.OldSize, checkpoint.TreeSize)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var latestSize int64
+	var latestRoot [merkle.HashSize]byte
+	if latest, ok := w.state[checkpoint.Issuer]; ok {
+		latestSize = latest.TreeSize
+		root, err := hex.DecodeString(latest.RootHash)
+		if err != nil || len(root) != merkle.HashSize {
+			return "", fmt.Errorf("corrupt witness state for %s", checkpoint.Issuer)
+		}
+		copy(latestRoot[:], root)
+	}
+
+	if req.OldSize != latestSize {
+		return "", &ConflictError{Size: latestSize}
+	}
+
+	switch {
+	case req.OldSize == 0:
+		if len(req.Proof) != 0 {
+			return "", fmt.Errorf("%w: proof must be empty for old size 0", ErrBadRequest)
+		}
+	case req.OldSize == checkpoint.TreeSize:
+		if len(req.Proof) != 0 {
+			return "", fmt.Errorf("%w: proof must be empty when sizes are equal", ErrBadRequest)
+		}
+		if checkpoint.RootHash != latestRoot {
+			return "", &ConflictError{Size: latestSize}
+		}
+	default:
+		proof := &merkle.ConsistencyProof{
+			OldSize: req.OldSize,
+			NewSize: checkpoint.TreeSize,
+			Proof:   req.Proof,
+		}
+		if !merkle.VerifyConsistencyProof(proof, latestRoot, checkpoint.RootHash) {
+			return "", ErrInconsistent
+		}
+	}
+
+	previous := w.state[checkpoint.Issuer]
+	w.state[checkpoint.Issuer] = &LogState{
+		TreeSize:   checkpoint.TreeSize,
+		RootHash:   hex.EncodeToString(checkpoint.RootHash[:]),
+		Checkpoint: req.Checkpoint,
+	}
+	if err := w.saveState(); err != nil {
+		if previous != nil {
+			w.state[checkpoint.Issuer] = previous
+		} else {
+			delete(w.state, checkpoint.Issuer)
+		}
+		return "", err
+	}
+
+	return w.signer.Cosign(body, time.Now()), nil
+}
+
+// saveState atomically writes the witness state file
+func (w *Witness) saveState() error {
+	if w.statePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(w.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode witness state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.statePath), ".witness-state-*")
+	if err != nil {
+		return fmt.Errorf("failed to write witness state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write witness state: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write witness state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write witness state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), w.statePath); err != nil {
+		return fmt.Errorf("failed to write witness state: %w", err)
+	}
+
+	return nil
+}
+
+// Handler returns the witness HTTP handler serving POST /add-checkpoint
+func (w *Witness) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(AddCheckpointPath, w.handleAddCheckpoint)
+	return mux
+}
+
+// handleAddCheckpoint implements the tlog-witness add-checkpoint endpoint
+func (w *Witness) handleAddCheckpoint(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(rw, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	req, err := ParseAddCheckpointRequest(body)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cosignature, err := w.AddCheckpoint(req)
+	if err != nil {
+		var conflict *ConflictError
+		switch {
+		case errors.As(err, &conflict):
+			rw.Header().Set("Content-Type", ContentTypeTreeSize)
+			rw.WriteHeader(http.StatusConflict)
+			fmt.Fprintf(rw, "%d\n", conflict.Size)
+		case errors.Is(err, ErrUnknownLog):
+			http.Error(rw, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrInvalidSignature):
+			http.Error(rw, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrInconsistent):
+			http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, ErrBadRequest):
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(rw, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(rw, cosignature)
+}
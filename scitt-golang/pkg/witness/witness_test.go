@@ -0,0 +1,346 @@
+package witness_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/merkle"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/storage"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/witness"
+)
+
+const testOrigin = "https://log.example.com"
+
+// testLog is a tile log that signs checkpoints with an ES256 key
+type testLog struct {
+	t      *testing.T
+	prefix string // distinguishes leaves of forked histories
+	store  storage.Storage
+	tl     *merkle.TileLog
+	key    *ecdsa.PrivateKey
+}
+
+func newTestLog(t *testing.T) *testLog {
+	t.Helper()
+	keyPair, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate log key: %v", err)
+	}
+	store := storage.NewMemoryStorage()
+	tl := merkle.NewTileLog(store)
+	if err := tl.Load(); err != nil {
+		t.Fatalf("failed to load tile log: %v", err)
+	}
+	return &testLog{t: t, store: store, tl: tl, key: keyPair.Private}
+}
+
+// grow appends n leaves and returns a signed checkpoint for the new size
+func (l *testLog) grow(n int) string {
+	l.t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := l.tl.Append(sha256.Sum256([]byte(fmt.Sprintf("%sentry %d", l.prefix, l.tl.Size())))); err != nil {
+			l.t.Fatalf("failed to append leaf: %v", err)
+		}
+	}
+	root, err := l.tl.Root()
+	if err != nil {
+		l.t.Fatalf("failed to compute root: %v", err)
+	}
+	checkpoint, err := merkle.CreateCheckpoint(l.tl.Size(), root, l.key, testOrigin)
+	if err != nil {
+		l.t.Fatalf("failed to create checkpoint: %v", err)
+	}
+	return merkle.EncodeCheckpoint(checkpoint)
+}
+
+// request builds an add-checkpoint request from oldSize to the current size
+func (l *testLog) request(oldSize int64, note string) *witness.AddCheckpointRequest {
+	l.t.Helper()
+	proof, err := merkle.GenerateConsistencyProof(l.store, oldSize, l.tl.Size())
+	if err != nil {
+		l.t.Fatalf("failed to generate consistency proof: %v", err)
+	}
+	return &witness.AddCheckpointRequest{OldSize: oldSize, Proof: proof.Proof, Checkpoint: note}
+}
+
+func newTestWitness(t *testing.T, log *testLog, statePath string) (*witness.Witness, *witness.Verifier) {
+	t.Helper()
+	skey, vkey, err := witness.GenerateKey("witness.example.com")
+	if err != nil {
+		t.Fatalf("failed to generate witness key: %v", err)
+	}
+	signer, err := witness.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("failed to parse witness key: %v", err)
+	}
+	verifier, err := witness.ParseVerifierKey(vkey)
+	if err != nil {
+		t.Fatalf("failed to parse verifier key: %v", err)
+	}
+	w, err := witness.New(witness.Config{
+		Signer:    signer,
+		Logs:      map[string]*ecdsa.PublicKey{testOrigin: &log.key.PublicKey},
+		StatePath: statePath,
+	})
+	if err != nil {
+		t.Fatalf("failed to create witness: %v", err)
+	}
+	return w, verifier
+}
+
+func TestCosignature(t *testing.T) {
+	skey, vkey, err := witness.GenerateKey("witness.example.com")
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, err := witness.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("failed to parse signing key: %v", err)
+	}
+	verifier, err := witness.ParseVerifierKey(vkey)
+	if err != nil {
+		t.Fatalf("failed to parse verifier key: %v", err)
+	}
+
+	body := "https://log.example.com\n5\nabcd\n1700000000000\n"
+	now := time.Unix(1700000000, 0)
+
+	t.Run("verifies its own cosignature", func(t *testing.T) {
+		if signer.VerifierKey() != vkey {
+			t.Errorf("verifier key mismatch: %s != %s", signer.VerifierKey(), vkey)
+		}
+		line := signer.Cosign(body, now)
+		if !strings.HasPrefix(line, "— witness.example.com ") {
+			t.Errorf("unexpected cosignature line: %q", line)
+		}
+		timestamp, err := verifier.Verify(body, line)
+		if err != nil {
+			t.Fatalf("cosignature should verify: %v", err)
+		}
+		if !timestamp.Equal(now) {
+			t.Errorf("expected timestamp %s, got %s", now, timestamp)
+		}
+	})
+
+	t.Run("rejects a cosignature over a different body", func(t *testing.T) {
+		line := signer.Cosign(body, now)
+		if _, err := verifier.Verify(strings.Replace(body, "5", "6", 1), line); err == nil {
+			t.Error("cosignature over a different body should not verify")
+		}
+	})
+
+	t.Run("rejects a cosignature from another key", func(t *testing.T) {
+		otherKey, _, _ := witness.GenerateKey("witness.example.com")
+		other, _ := witness.NewSigner(otherKey)
+		if _, err := verifier.Verify(body, other.Cosign(body, now)); err == nil {
+			t.Error("cosignature from another key should not verify")
+		}
+	})
+
+	t.Run("rejects malformed keys", func(t *testing.T) {
+		for _, key := range []string{"", "name", "name+zz+AAAA", strings.Replace(vkey, "witness.example.com", "other", 1)} {
+			if _, err := witness.ParseVerifierKey(key); err == nil {
+				t.Errorf("should reject verifier key %q", key)
+			}
+		}
+		if _, err := witness.NewSigner(vkey); err == nil {
+			t.Error("should reject a verifier key as signing key")
+		}
+	})
+
+	t.Run("appends and finds cosignatures in a signed note", func(t *testing.T) {
+		note := body + "\n— https://log.example.com c2ln"
+		line := signer.Cosign(body, now)
+
+		cosigned := witness.AppendCosignatures(note, line, line)
+		if strings.Count(cosigned, line) != 1 {
+			t.Errorf("cosignature should be appended once: %q", cosigned)
+		}
+
+		found, _, err := verifier.FindCosignature(cosigned)
+		if err != nil || found+"\n" != line {
+			t.Errorf("expected to find cosignature, got %q: %v", found, err)
+		}
+		if _, _, err := verifier.FindCosignature(note); err == nil {
+			t.Error("note without cosignature should not verify")
+		}
+	})
+}
+
+func TestAddCheckpointRequest(t *testing.T) {
+	t.Run("round trips", func(t *testing.T) {
+		req := &witness.AddCheckpointRequest{
+			OldSize:    3,
+			Proof:      [][merkle.HashSize]byte{sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b"))},
+			Checkpoint: "origin\n5\nabcd\n1\n\n— origin c2ln\n",
+		}
+		parsed, err := witness.ParseAddCheckpointRequest(req.Marshal())
+		if err != nil {
+			t.Fatalf("failed to parse request: %v", err)
+		}
+		if parsed.OldSize != 3 || len(parsed.Proof) != 2 || parsed.Proof[1] != req.Proof[1] || parsed.Checkpoint != req.Checkpoint {
+			t.Errorf("request did not round trip: %+v", parsed)
+		}
+	})
+
+	t.Run("rejects malformed requests", func(t *testing.T) {
+		for _, body := range []string{
+			"",
+			"new 3\n\ncheckpoint\n",
+			"old -1\n\ncheckpoint\n",
+			"old 3\nnot-base64!\n\ncheckpoint\n",
+			"old 3\n",
+			"old 3\n\n",
+		} {
+			if _, err := witness.ParseAddCheckpointRequest([]byte(body)); err == nil {
+				t.Errorf("should reject %q", body)
+			}
+		}
+	})
+}
+
+func TestWitness(t *testing.T) {
+	t.Run("cosigns consistent checkpoints", func(t *testing.T) {
+		log := newTestLog(t)
+		w, verifier := newTestWitness(t, log, "")
+
+		note := log.grow(3)
+		line, err := w.AddCheckpoint(log.request(0, note))
+		if err != nil {
+			t.Fatalf("failed to add first checkpoint: %v", err)
+		}
+		body, _, _ := witness.SplitNote(note)
+		if _, err := verifier.Verify(body, line); err != nil {
+			t.Errorf("cosignature should verify: %v", err)
+		}
+
+		note = log.grow(6)
+		if _, err := w.AddCheckpoint(log.request(3, note)); err != nil {
+			t.Fatalf("failed to add consistent checkpoint: %v", err)
+		}
+		if state := w.State(testOrigin); state == nil || state.TreeSize != 9 {
+			t.Errorf("expected state at size 9, got %+v", state)
+		}
+
+		// Re-submitting the same size is allowed
+		if _, err := w.AddCheckpoint(log.request(9, log.grow(0))); err != nil {
+			t.Errorf("failed to re-add checkpoint at the same size: %v", err)
+		}
+	})
+
+	t.Run("returns a conflict with its latest size for a stale old size", func(t *testing.T) {
+		log := newTestLog(t)
+		w, _ := newTestWitness(t, log, "")
+
+		if _, err := w.AddCheckpoint(log.request(0, log.grow(4))); err != nil {
+			t.Fatalf("failed to add checkpoint: %v", err)
+		}
+
+		_, err := w.AddCheckpoint(log.request(0, log.grow(1)))
+		var conflict *witness.ConflictError
+		if !errors.As(err, &conflict) || conflict.Size != 4 {
+			t.Errorf("expected conflict at size 4, got %v", err)
+		}
+	})
+
+	t.Run("rejects a fork", func(t *testing.T) {
+		log := newTestLog(t)
+		w, _ := newTestWitness(t, log, "")
+		if _, err := w.AddCheckpoint(log.request(0, log.grow(4))); err != nil {
+			t.Fatalf("failed to add checkpoint: %v", err)
+		}
+
+		// A different log history signed with the same key
+		fork := newTestLog(t)
+		fork.key = log.key
+		fork.prefix = "fork "
+		fork.grow(2)
+		forkNote := fork.grow(4)
+
+		_, err := w.AddCheckpoint(fork.request(4, forkNote))
+		if !errors.Is(err, witness.ErrInconsistent) {
+			t.Errorf("expected inconsistent proof, got %v", err)
+		}
+	})
+
+	t.Run("rejects unknown logs and bad signatures", func(t *testing.T) {
+		log := newTestLog(t)
+		w, _ := newTestWitness(t, log, "")
+
+		impostor := newTestLog(t)
+		if _, err := w.AddCheckpoint(impostor.request(0, impostor.grow(1))); !errors.Is(err, witness.ErrInvalidSignature) {
+			t.Errorf("expected invalid signature, got %v", err)
+		}
+
+		note := strings.Replace(log.grow(1), testOrigin, "https://other.example.com", -1)
+		if _, err := w.AddCheckpoint(log.request(0, note)); !errors.Is(err, witness.ErrUnknownLog) {
+			t.Errorf("expected unknown log, got %v", err)
+		}
+	})
+
+	t.Run("persists state across restarts", func(t *testing.T) {
+		log := newTestLog(t)
+		statePath := filepath.Join(t.TempDir(), "state.json")
+		w, _ := newTestWitness(t, log, statePath)
+		if _, err := w.AddCheckpoint(log.request(0, log.grow(5))); err != nil {
+			t.Fatalf("failed to add checkpoint: %v", err)
+		}
+
+		restarted, _ := newTestWitness(t, log, statePath)
+		if state := restarted.State(testOrigin); state == nil || state.TreeSize != 5 {
+			t.Errorf("expected persisted state at size 5, got %+v", state)
+		}
+	})
+}
+
+func TestClient(t *testing.T) {
+	log := newTestLog(t)
+	w, verifier := newTestWitness(t, log, "")
+	server := httptest.NewServer(w.Handler())
+	defer server.Close()
+
+	client := witness.NewClient(server.URL, verifier, server.Client())
+	ctx := context.Background()
+
+	t.Run("returns a verified cosignature", func(t *testing.T) {
+		note := log.grow(2)
+		line, err := client.AddCheckpoint(ctx, log.request(0, note))
+		if err != nil {
+			t.Fatalf("failed to add checkpoint: %v", err)
+		}
+		if _, _, err := verifier.FindCosignature(witness.AppendCosignatures(note, line)); err != nil {
+			t.Errorf("cosigned note should verify: %v", err)
+		}
+	})
+
+	t.Run("surfaces the witness's latest size on conflict", func(t *testing.T) {
+		_, err := client.AddCheckpoint(ctx, log.request(0, log.grow(1)))
+		var conflict *witness.ConflictError
+		if !errors.As(err, &conflict) || conflict.Size != 2 {
+			t.Fatalf("expected conflict at size 2, got %v", err)
+		}
+
+		if _, err := client.AddCheckpoint(ctx, log.request(conflict.Size, log.grow(0))); err != nil {
+			t.Errorf("retry from the witness's size should succeed: %v", err)
+		}
+	})
+
+	t.Run("rejects cosignatures from an unexpected key", func(t *testing.T) {
+		_, otherVkey, _ := witness.GenerateKey("witness.example.com")
+		other, _ := witness.ParseVerifierKey(otherVkey)
+		wrongClient := witness.NewClient(server.URL, other, server.Client())
+
+		if _, err := wrongClient.AddCheckpoint(ctx, log.request(3, log.grow(1))); err == nil {
+			t.Error("cosignature from an unexpected key should be rejected")
+		}
+	})
+}
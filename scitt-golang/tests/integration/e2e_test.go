@@ -2,18 +2,19 @@ package integration_test
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
-	"os"
-	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/config"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/server"
+	"github.com/tradeverifyd/transparency-service/scitt-golang/internal/testutil"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/cose"
 	"github.com/tradeverifyd/transparency-service/scitt-golang/pkg/database"
 )
@@ -21,11 +22,7 @@ import (
 // TestEndToEndFlow tests the complete transparency service workflow
 func TestEndToEndFlow(t *testing.T) {
 	// Setup test environment
-	tmpDir := t.TempDir()
-	cfg, apiKey, err := setupTestService(t, tmpDir)
-	if err != nil {
-		t.Fatalf("failed to setup test service: %v", err)
-	}
+	cfg, apiKey := setupTestService(t)
 
 	// Create server
 	srv, err := server.NewServer(cfg)
@@ -209,69 +206,84 @@ func TestEndToEndFlow(t *testing.T) {
 	})
 }
 
-// Helper functions
+// TestHashEnvelopeMetadata tests that hash envelope parameters are indexed on registration
+func TestHashEnvelopeMetadata(t *testing.T) {
+	cfg, apiKey := setupTestService(t)
 
-func setupTestService(t *testing.T, tmpDir string) (*config.Config, string, error) {
-	t.Helper()
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer srv.Close()
 
-	// Generate test keys
-	keyPair, err := cose.GenerateES256KeyPair()
+	artifact := []byte("release binary contents")
+	statement := createHashEnvelopeStatement(t, artifact)
+
+	req := httptest.NewRequest(http.MethodPost, "/entries", bytes.NewReader(statement))
+	req.Header.Set("Content-Type", "application/cose")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	db, err := database.OpenDatabase(database.DatabaseOptions{
+		Path:      cfg.Database.Path,
+		EnableWAL: cfg.Database.EnableWAL,
+	})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate key pair: %w", err)
+		t.Fatalf("failed to open database: %v", err)
 	}
+	defer database.CloseDatabase(db)
 
-	// Save private key as COSE CBOR (with kid set automatically)
-	privateKeyCBOR, err := cose.ExportPrivateKeyToCOSECBOR(keyPair.Private)
+	digest := sha256.Sum256(artifact)
+	statements, err := database.FindStatementsByPayloadHash(db, cose.HashAlgorithmSHA256, hex.EncodeToString(digest[:]))
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to export private key: %w", err)
+		t.Fatalf("failed to find statements by payload hash: %v", err)
 	}
-	privateKeyPath := filepath.Join(tmpDir, "service-key.cbor")
-	if err := os.WriteFile(privateKeyPath, privateKeyCBOR, 0600); err != nil {
-		return nil, "", fmt.Errorf("failed to write private key: %w", err)
+
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement for artifact digest, got %d", len(statements))
 	}
 
-	// Save public key as COSE CBOR (with kid set automatically)
-	publicKeyCBOR, err := cose.ExportPublicKeyToCOSECBOR(keyPair.Public)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to export public key: %w", err)
+	stmt := statements[0]
+	if stmt.Iss != "https://issuer.example.com" {
+		t.Errorf("expected iss https://issuer.example.com, got %q", stmt.Iss)
+	}
+	if stmt.Sub == nil || *stmt.Sub != "pkg:generic/release@1.0.0" {
+		t.Errorf("expected sub pkg:generic/release@1.0.0, got %v", stmt.Sub)
+	}
+	if stmt.Typ == nil || *stmt.Typ != "application/example+cose" {
+		t.Errorf("expected typ application/example+cose, got %v", stmt.Typ)
+	}
+	if stmt.PreimageContentType == nil || *stmt.PreimageContentType != "application/octet-stream" {
+		t.Errorf("expected preimage content type application/octet-stream, got %v", stmt.PreimageContentType)
 	}
-	publicKeyPath := filepath.Join(tmpDir, "service-key-pub.cbor")
-	if err := os.WriteFile(publicKeyPath, publicKeyCBOR, 0644); err != nil {
-		return nil, "", fmt.Errorf("failed to write public key: %w", err)
+	if stmt.PayloadLocation == nil || *stmt.PayloadLocation != "https://example.com/release.bin" {
+		t.Errorf("expected payload location https://example.com/release.bin, got %v", stmt.PayloadLocation)
 	}
 
-	// Generate API key for tests
-	apiKey, err := config.GenerateAPIKey()
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	// Registered with the service-wide API key from the definition file
+	if stmt.CredentialID == nil || *stmt.CredentialID != "config" {
+		t.Errorf("expected credential ID config, got %v", stmt.CredentialID)
 	}
+}
+
+// Helper functions
+
+func setupTestService(t *testing.T) (*config.Config, string) {
+	t.Helper()
 
-	// Create config
-	cfg := &config.Config{
-		Issuer: "https://integration-test.example.com",
-		Database: config.DatabaseConfig{
-			Path:      filepath.Join(tmpDir, "test.db"),
-			EnableWAL: true,
-		},
-		Storage: config.StorageConfig{
-			Type: "memory",
-		},
-		Keys: config.KeysConfig{
-			Private: privateKeyPath,
-			Public:  publicKeyPath,
-		},
-		Server: config.ServerConfig{
-			Host:   "127.0.0.1",
-			Port:   0,
-			APIKey: apiKey,
-			CORS: config.CORSConfig{
-				Enabled:        true,
-				AllowedOrigins: []string{"*"},
-			},
-		},
+	cfg := testutil.NewConfig(t, testutil.NewKeys(t))
+	cfg.Issuer = "https://integration-test.example.com"
+	cfg.Server.CORS = config.CORSConfig{
+		Enabled:        true,
+		AllowedOrigins: []string{"*"},
 	}
 
-	return cfg, apiKey, nil
+	return cfg, cfg.Server.APIKey
 }
 
 func createTestStatement(t *testing.T, subject string) []byte {
@@ -319,3 +331,43 @@ func createTestStatement(t *testing.T, subject string) []byte {
 
 	return statement
 }
+
+func createHashEnvelopeStatement(t *testing.T, artifact []byte) []byte {
+	t.Helper()
+
+	keyPair, err := cose.GenerateES256KeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+
+	signer, err := cose.NewES256Signer(keyPair.Private)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	digest := sha256.Sum256(artifact)
+
+	headers := cose.ProtectedHeaders{
+		cose.HeaderLabelAlg:                        cose.AlgorithmES256,
+		cose.HeaderLabelTyp:                        "application/example+cose",
+		cose.HeaderLabelPayloadHashAlg:             cose.HashAlgorithmSHA256,
+		cose.HeaderLabelPayloadPreimageContentType: "application/octet-stream",
+		cose.HeaderLabelPayloadLocation:            "https://example.com/release.bin",
+		cose.HeaderLabelCWTClaims: cose.CreateCWTClaims(cose.CWTClaimsOptions{
+			Iss: "https://issuer.example.com",
+			Sub: "pkg:generic/release@1.0.0",
+		}),
+	}
+
+	coseSign1Struct, err := cose.CreateCoseSign1(headers, digest[:], signer, cose.CoseSign1Options{})
+	if err != nil {
+		t.Fatalf("failed to create COSE Sign1: %v", err)
+	}
+
+	statement, err := cose.EncodeCoseSign1(coseSign1Struct)
+	if err != nil {
+		t.Fatalf("failed to encode COSE Sign1: %v", err)
+	}
+
+	return statement
+}